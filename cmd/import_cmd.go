@@ -44,9 +44,14 @@ var ImportCommand = cli.Command{
 		utils.ImportFileFlag,
 		utils.ImportEndHeightFlag,
 		utils.DataDirFlag,
+		utils.StoreBackendFlag,
 		utils.ConfigFlag,
 		utils.NetworkIdFlag,
 		utils.DisableEventLogFlag,
+		utils.EnableStateArchiveFlag,
+		utils.PruneBlocksFlag,
+		utils.EnableAddressIndexFlag,
+		utils.EnableTransferIndexFlag,
 	},
 	Description: "Note that import cmd doesn't support testmode",
 }
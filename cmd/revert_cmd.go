@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/ledger"
+	"github.com/urfave/cli"
+)
+
+var RevertCommand = cli.Command{
+	Name:      "revert",
+	Usage:     "Revert the ledger in DB to a given block height",
+	ArgsUsage: "",
+	Action:    revertBlocks,
+	Flags: []cli.Flag{
+		utils.RevertHeightFlag,
+		utils.DataDirFlag,
+		utils.StoreBackendFlag,
+		utils.ConfigFlag,
+		utils.NetworkIdFlag,
+		utils.DisableEventLogFlag,
+		utils.EnableStateArchiveFlag,
+	},
+	Description: "Note that node should be stopped before revert, and only the latest blocks can be reverted",
+}
+
+func revertBlocks(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	if !ctx.IsSet(utils.GetFlagName(utils.RevertHeightFlag)) {
+		PrintErrorMsg("Missing %s argument.", utils.RevertHeightFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	revertHeight := uint32(ctx.Uint(utils.GetFlagName(utils.RevertHeightFlag)))
+
+	cfg, err := SetOntologyConfig(ctx)
+	if err != nil {
+		PrintErrorMsg("SetOntologyConfig error:%s", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	ledger.DefLedger, err = ledger.NewLedger(dbDir, stateHashHeight)
+	if err != nil {
+		return fmt.Errorf("NewLedger error:%s", err)
+	}
+	defer ledger.DefLedger.Close()
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisConfig := config.DefConfig.Genesis
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, genesisConfig)
+	if err != nil {
+		return fmt.Errorf("BuildGenesisBlock error %s", err)
+	}
+	err = ledger.DefLedger.Init(bookKeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("init ledger error:%s", err)
+	}
+
+	currBlockHeight := ledger.DefLedger.GetCurrentBlockHeight()
+	if revertHeight >= currBlockHeight {
+		PrintWarnMsg("CurrentBlockHeight:%d lower than or equal to revert height:%d, No blocks to revert.", currBlockHeight, revertHeight)
+		return nil
+	}
+
+	PrintInfoMsg("Start revert blocks from height:%d to height:%d.", currBlockHeight, revertHeight)
+	err = ledger.DefLedger.RollbackTo(revertHeight)
+	if err != nil {
+		return fmt.Errorf("revert to height:%d error:%s", revertHeight, err)
+	}
+	PrintInfoMsg("Revert block completed, current block height:%d.", ledger.DefLedger.GetCurrentBlockHeight())
+	return nil
+}
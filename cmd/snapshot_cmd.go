@@ -0,0 +1,204 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/ledger"
+	"github.com/ontio/ontology/core/store"
+	"github.com/urfave/cli"
+)
+
+var SnapshotCommand = cli.Command{
+	Name:  "snapshot",
+	Usage: "Export or import state snapshot for fast node bootstrap",
+	Subcommands: []cli.Command{
+		{
+			Action:    exportSnapshot,
+			Name:      "export",
+			Usage:     "Export state snapshot of current block in DB to a file",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				utils.SnapshotFileFlag,
+				utils.DataDirFlag,
+				utils.StoreBackendFlag,
+				utils.EnableStateArchiveFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+			},
+			Description: "Note that node should be stopped before export snapshot",
+		},
+		{
+			Action:    importSnapshot,
+			Name:      "import",
+			Usage:     "Import state snapshot from a file to an empty DB",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				utils.SnapshotFileFlag,
+				utils.SnapshotHeightFlag,
+				utils.SnapshotBlockHashFlag,
+				utils.SnapshotStateRootFlag,
+				utils.DataDirFlag,
+				utils.StoreBackendFlag,
+				utils.EnableStateArchiveFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+			},
+			Description: "The snapshot file is checked against the height, block hash and state root printed by export " +
+				"on a trusted node. Blocks before snapshot height cannot be queried after import, except block headers",
+		},
+	},
+	Description: "",
+}
+
+func exportSnapshot(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	snapshotFile := ctx.String(utils.GetFlagName(utils.SnapshotFileFlag))
+	if snapshotFile == "" {
+		PrintErrorMsg("Missing %s argument.", utils.SnapshotFileFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	cfg, err := SetOntologyConfig(ctx)
+	if err != nil {
+		PrintErrorMsg("SetOntologyConfig error:%s", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	ledger.DefLedger, err = ledger.NewLedger(dbDir, stateHashHeight)
+	if err != nil {
+		return fmt.Errorf("NewLedger error:%s", err)
+	}
+	defer ledger.DefLedger.Close()
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return fmt.Errorf("BuildGenesisBlock error %s", err)
+	}
+	err = ledger.DefLedger.Init(bookKeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("init ledger error:%s", err)
+	}
+
+	sf, err := os.OpenFile(snapshotFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0664)
+	if err != nil {
+		return fmt.Errorf("open file:%s error:%s", snapshotFile, err)
+	}
+	defer sf.Close()
+	fWriter := bufio.NewWriter(sf)
+
+	PrintInfoMsg("Start export state snapshot.")
+	checkpoint, err := ledger.DefLedger.ExportStateSnapshot(fWriter)
+	if err != nil {
+		return fmt.Errorf("ExportStateSnapshot error:%s", err)
+	}
+	err = fWriter.Flush()
+	if err != nil {
+		return fmt.Errorf("export flush file error:%s", err)
+	}
+	PrintInfoMsg("Export state snapshot successfully.")
+	PrintInfoMsg("BlockHeight:%d", checkpoint.Height)
+	PrintInfoMsg("BlockHash:%s", checkpoint.BlockHash.ToHexString())
+	PrintInfoMsg("StateRoot:%s", checkpoint.StateRoot.ToHexString())
+	PrintInfoMsg("Snapshot file:%s", snapshotFile)
+	return nil
+}
+
+func importSnapshot(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	snapshotFile := ctx.String(utils.GetFlagName(utils.SnapshotFileFlag))
+	if snapshotFile == "" {
+		PrintErrorMsg("Missing %s argument.", utils.SnapshotFileFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	blockHash, err := common.Uint256FromHexString(ctx.String(utils.GetFlagName(utils.SnapshotBlockHashFlag)))
+	if err != nil {
+		PrintErrorMsg("Invalid %s argument:%s", utils.SnapshotBlockHashFlag.Name, err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	stateRoot, err := common.Uint256FromHexString(ctx.String(utils.GetFlagName(utils.SnapshotStateRootFlag)))
+	if err != nil {
+		PrintErrorMsg("Invalid %s argument:%s", utils.SnapshotStateRootFlag.Name, err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	checkpoint := &store.StateSnapshotCheckpoint{
+		Height:    uint32(ctx.Uint(utils.GetFlagName(utils.SnapshotHeightFlag))),
+		BlockHash: blockHash,
+		StateRoot: stateRoot,
+	}
+	cfg, err := SetOntologyConfig(ctx)
+	if err != nil {
+		PrintErrorMsg("SetOntologyConfig error:%s", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	ledger.DefLedger, err = ledger.NewLedger(dbDir, stateHashHeight)
+	if err != nil {
+		return fmt.Errorf("NewLedger error:%s", err)
+	}
+	defer ledger.DefLedger.Close()
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return fmt.Errorf("BuildGenesisBlock error %s", err)
+	}
+
+	sf, err := os.OpenFile(snapshotFile, os.O_RDONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("OpenFile error:%s", err)
+	}
+	defer sf.Close()
+
+	PrintInfoMsg("Start import state snapshot.")
+	err = ledger.DefLedger.ImportStateSnapshot(genesisBlock.Hash(), checkpoint, bufio.NewReader(sf))
+	if err != nil {
+		return fmt.Errorf("ImportStateSnapshot error:%s", err)
+	}
+	err = ledger.DefLedger.Init(bookKeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("init ledger error:%s", err)
+	}
+	PrintInfoMsg("Import state snapshot completed, current block height:%d.", checkpoint.Height)
+	return nil
+}
@@ -100,7 +100,12 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ConfigFlag,
 			utils.LogLevelFlag,
 			utils.DisableEventLogFlag,
+			utils.EnableStateArchiveFlag,
+			utils.PruneBlocksFlag,
+			utils.EnableAddressIndexFlag,
+			utils.EnableTransferIndexFlag,
 			utils.DataDirFlag,
+			utils.StoreBackendFlag,
 		},
 	},
 	{
@@ -137,10 +142,15 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "TXPOOL",
 		Flags: []cli.Flag{
 			utils.GasPriceFlag,
+			utils.GasPriceBumpFlag,
+			utils.TxPoolPayerQuotaFlag,
+			utils.TxPoolPeerQuotaFlag,
 			utils.GasLimitFlag,
 			utils.TxpoolPreExecDisableFlag,
 			utils.DisableSyncVerifyTxFlag,
 			utils.DisableBroadcastNetTxFlag,
+			utils.TxPoolJournalFlag,
+			utils.TxPoolRejournalFlag,
 		},
 	},
 	{
@@ -154,6 +164,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MaxConnInBoundFlag,
 			utils.MaxConnOutBoundFlag,
 			utils.MaxConnInBoundForSingleIPFlag,
+			utils.BanDurationFlag,
+			utils.EnableLinkEncryptionFlag,
 		},
 	},
 	{
@@ -163,6 +175,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCPortFlag,
 			utils.RPCLocalEnableFlag,
 			utils.RPCLocalProtFlag,
+			utils.RPCCompatibleDisabledFlag,
 		},
 	},
 	{
@@ -180,6 +193,13 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.WsPortFlag,
 		},
 	},
+	{
+		Name: "METRICS",
+		Flags: []cli.Flag{
+			utils.MetricsEnableFlag,
+			utils.MetricsPortFlag,
+		},
+	},
 	{
 		Name: "TEST MODE",
 		Flags: []cli.Flag{
@@ -251,6 +271,21 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ImportEndHeightFlag,
 		},
 	},
+	{
+		Name: "SNAPSHOT",
+		Flags: []cli.Flag{
+			utils.SnapshotFileFlag,
+			utils.SnapshotHeightFlag,
+			utils.SnapshotBlockHashFlag,
+			utils.SnapshotStateRootFlag,
+		},
+	},
+	{
+		Name: "REVERT",
+		Flags: []cli.Flag{
+			utils.RevertHeightFlag,
+		},
+	},
 	{
 		Name: "MISC",
 	},
@@ -28,6 +28,7 @@ import (
 
 const (
 	DEFAULT_EXPORT_FILE   = "./OntBlocks.dat"
+	DEFAULT_SNAPSHOT_FILE = "./OntState.snapshot"
 	DEFAULT_ABI_PATH      = "./abi"
 	DEFAULT_EXPORT_HEIGHT = 0
 	DEFAULT_WALLET_PATH   = "./wallet_data"
@@ -48,6 +49,27 @@ var (
 		Name:  "disable-event-log",
 		Usage: "Discard event log output by smart contract execution",
 	}
+	EnableStateArchiveFlag = cli.BoolFlag{
+		Name:  "enable-state-archive",
+		Usage: "Keep the history of contract storage to support state query at any past block height. Once enabled, the DB can only be opened with this flag",
+	}
+	PruneBlocksFlag = cli.UintFlag{
+		Name:  "prune-blocks",
+		Usage: "Only keep transactions and event logs of the latest `<number>` blocks. 0 means no pruning",
+	}
+	EnableAddressIndexFlag = cli.BoolFlag{
+		Name:  "enable-address-index",
+		Usage: "Index the transactions of address to support transaction history query by address",
+	}
+	EnableTransferIndexFlag = cli.BoolFlag{
+		Name:  "enable-transfer-index",
+		Usage: "Index the token transfer notifications to support balance change query by contract and address",
+	}
+	StoreBackendFlag = cli.StringFlag{
+		Name:  "store-backend",
+		Usage: "Storage engine `<name>` of ledger, leveldb, badger or memory. Must be the same as the one used to create the data directory",
+		Value: config.DEFAULT_STORE_BACKEND,
+	}
 	WalletFileFlag = cli.StringFlag{
 		Name:  "wallet,w",
 		Value: config.DEFAULT_WALLET_FILE_NAME,
@@ -63,6 +85,27 @@ var (
 		Usage: "Stop import block `<height>` of the import.",
 		Value: DEFAULT_EXPORT_HEIGHT,
 	}
+	SnapshotFileFlag = cli.StringFlag{
+		Name:  "snapshot-file",
+		Usage: "Path of state snapshot `<file>`",
+		Value: DEFAULT_SNAPSHOT_FILE,
+	}
+	SnapshotHeightFlag = cli.UintFlag{
+		Name:  "snapshot-height",
+		Usage: "Trusted block `<height>` of state snapshot.",
+	}
+	SnapshotBlockHashFlag = cli.StringFlag{
+		Name:  "snapshot-block-hash",
+		Usage: "Trusted block `<hash>` of state snapshot.",
+	}
+	SnapshotStateRootFlag = cli.StringFlag{
+		Name:  "snapshot-state-root",
+		Usage: "Trusted state `<root>` of state snapshot.",
+	}
+	RevertHeightFlag = cli.UintFlag{
+		Name:  "revert-height",
+		Usage: "Revert the ledger to block `<height>`. Blocks higher than it will be removed.",
+	}
 	DataDirFlag = cli.StringFlag{
 		Name:  "data-dir",
 		Usage: "Block data storage `<path>`",
@@ -89,6 +132,21 @@ var (
 		Usage: "Min gas price `<value>` of transaction to be accepted by tx pool.",
 		Value: config.DEFAULT_GAS_PRICE,
 	}
+	GasPriceBumpFlag = cli.Uint64Flag{
+		Name:  "gasprice-bump",
+		Usage: "Min gas price increase `<percentage>` for a transaction to replace the one with the same payer, nonce and payload in tx pool. 0 disables the replacement.",
+		Value: config.DEFAULT_GAS_PRICE_BUMP,
+	}
+	TxPoolPayerQuotaFlag = cli.UintFlag{
+		Name:  "txpool-payer-quota",
+		Usage: "Max `<number>` of transactions of a payer in tx pool. 0 means no limit",
+		Value: config.DEFAULT_TXPOOL_PAYER_QUOTA,
+	}
+	TxPoolPeerQuotaFlag = cli.UintFlag{
+		Name:  "txpool-peer-quota",
+		Usage: "Max `<number>` of transactions relayed by a peer in tx pool. 0 means no limit",
+		Value: config.DEFAULT_TXPOOL_PEER_QUOTA,
+	}
 
 	//Test Mode setting
 	EnableTestModeFlag = cli.BoolFlag{
@@ -141,6 +199,15 @@ var (
 		Usage: "Max connection `<number>` in bound for single ip",
 		Value: config.DEFAULT_MAX_CONN_IN_BOUND_FOR_SINGLE_IP,
 	}
+	BanDurationFlag = cli.UintFlag{
+		Name:  "ban-duration",
+		Usage: "Ban a misbehaving peer for `<seconds>`",
+		Value: config.DEFAULT_BAN_DURATION,
+	}
+	EnableLinkEncryptionFlag = cli.BoolFlag{
+		Name:  "enable-link-encryption",
+		Usage: "Start the encrypted handshake with outbound peers before the activation height",
+	}
 	// RPC settings
 	RPCDisabledFlag = cli.BoolFlag{
 		Name:  "disable-rpc",
@@ -160,6 +227,10 @@ var (
 		Usage: "Json rpc local server listening port `<number>`",
 		Value: config.DEFAULT_RPC_LOCAL_PORT,
 	}
+	RPCCompatibleDisabledFlag = cli.BoolFlag{
+		Name:  "disable-rpc-compatible",
+		Usage: "Respond json rpc with standard JSON-RPC 2.0 error object and ignore notification, instead of the compatible error and desc fields",
+	}
 
 	//Websocket setting
 	WsEnabledFlag = cli.BoolFlag{
@@ -172,6 +243,17 @@ var (
 		Value: config.DEFAULT_WS_PORT,
 	}
 
+	//Metrics setting
+	MetricsEnableFlag = cli.BoolFlag{
+		Name:  "metrics",
+		Usage: "Enable prometheus metrics server",
+	}
+	MetricsPortFlag = cli.UintFlag{
+		Name:  "metricsport",
+		Usage: "Metrics server listening port `<number>`",
+		Value: config.DEFAULT_METRICS_PORT,
+	}
+
 	//Restful setting
 	RestfulEnableFlag = cli.BoolFlag{
 		Name:  "rest",
@@ -480,6 +562,16 @@ var (
 		Name:  "disable-broadcast-net-tx",
 		Usage: "Disable broadcast tx from network in tx pool",
 	}
+	TxPoolJournalFlag = cli.StringFlag{
+		Name:  "txpool-journal",
+		Usage: "Journal `<file>` in the block data storage path to keep the transactions in tx pool across restarts. Empty to disable",
+		Value: config.DEFAULT_TXPOOL_JOURNAL,
+	}
+	TxPoolRejournalFlag = cli.UintFlag{
+		Name:  "txpool-rejournal",
+		Usage: "Time `<seconds>` interval to regenerate the tx pool journal",
+		Value: config.DEFAULT_TXPOOL_REJOURNAL,
+	}
 
 	NonOptionFlag = cli.StringFlag{
 		Name:  "option",
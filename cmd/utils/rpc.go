@@ -63,13 +63,21 @@ type JsonRpcRequest struct {
 	Params  []interface{} `json:"params"`
 }
 
-//JsonRpcResponse object response for JsonRpcRequest
+//JsonRpcResponse object response for JsonRpcRequest. Error is the error code in compatible mode,
+//or the JsonRpcError object otherwise
 type JsonRpcResponse struct {
-	Error  int64           `json:"error"`
+	Error  json.RawMessage `json:"error"`
 	Desc   string          `json:"desc"`
 	Result json.RawMessage `json:"result"`
 }
 
+//JsonRpcError object of JSON-RPC 2.0
+type JsonRpcError struct {
+	Code    int64           `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
 func sendRpcRequest(method string, params []interface{}) ([]byte, *OntologyError) {
 	rpcReq := &JsonRpcRequest{
 		Version: JSON_RPC_VERSION,
@@ -98,8 +106,28 @@ func sendRpcRequest(method string, params []interface{}) ([]byte, *OntologyError
 	if err != nil {
 		return nil, NewOntologyError(fmt.Errorf("json.Unmarshal JsonRpcResponse:%s error:%s", body, err))
 	}
-	if rpcRsp.Error != 0 {
-		return nil, NewOntologyError(fmt.Errorf("\n %s ", string(body)), rpcRsp.Error)
+	errCode, err := getRpcErrorCode(rpcRsp.Error)
+	if err != nil {
+		return nil, NewOntologyError(fmt.Errorf("json.Unmarshal JsonRpcResponse:%s error:%s", body, err))
+	}
+	if errCode != 0 {
+		return nil, NewOntologyError(fmt.Errorf("\n %s ", string(body)), errCode)
 	}
 	return rpcRsp.Result, nil
 }
+
+func getRpcErrorCode(data json.RawMessage) (int64, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return 0, nil
+	}
+	var errCode int64
+	if json.Unmarshal(data, &errCode) == nil {
+		return errCode, nil
+	}
+	rpcErr := &JsonRpcError{}
+	err := json.Unmarshal(data, rpcErr)
+	if err != nil {
+		return 0, err
+	}
+	return rpcErr.Code, nil
+}
@@ -57,10 +57,12 @@ const (
 	DEFAULT_RPC_LOCAL_PORT                  = uint(20337)
 	DEFAULT_REST_PORT                       = uint(20334)
 	DEFAULT_WS_PORT                         = uint(20335)
+	DEFAULT_METRICS_PORT                    = uint(20340)
 	DEFAULT_REST_MAX_CONN                   = uint(1024)
 	DEFAULT_MAX_CONN_IN_BOUND               = uint(1024)
 	DEFAULT_MAX_CONN_OUT_BOUND              = uint(1024)
 	DEFAULT_MAX_CONN_IN_BOUND_FOR_SINGLE_IP = uint(16)
+	DEFAULT_BAN_DURATION                    = uint(86400)
 	DEFAULT_HTTP_INFO_PORT                  = uint(0)
 	DEFAULT_MAX_TX_IN_BLOCK                 = 60000
 	DEFAULT_MAX_SYNC_HEADER                 = 500
@@ -70,9 +72,16 @@ const (
 	DEFUALT_CLI_RPC_ADDRESS                 = "127.0.0.1"
 	DEFAULT_GAS_LIMIT                       = 20000
 	DEFAULT_GAS_PRICE                       = 500
+	DEFAULT_GAS_PRICE_BUMP                  = 0
+	DEFAULT_TXPOOL_JOURNAL                  = "txpool.journal"
+	DEFAULT_TXPOOL_REJOURNAL                = 3600
+	DEFAULT_TXPOOL_PAYER_QUOTA              = 4096
+	DEFAULT_TXPOOL_PEER_QUOTA               = 0
+	DEFAULT_CONSENSUS_WAL                   = "consensus.wal"
 
 	DEFAULT_DATA_DIR      = "./Chain"
 	DEFAULT_RESERVED_FILE = "./peers.rsv"
+	DEFAULT_STORE_BACKEND = "leveldb"
 )
 
 const (
@@ -124,6 +133,65 @@ func GetOpcodeUpdateCheckHeight(id uint32) uint32 {
 	return OPCODE_UPDATE_CHECK_HEIGHT[id]
 }
 
+var TX_ATTRIBUTE_CHECK_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.TX_ATTRIBUTE_HEIGHT_MAINNET, //Network main
+	NETWORK_ID_POLARIS_NET: constants.TX_ATTRIBUTE_HEIGHT_POLARIS, //Network polaris
+	NETWORK_ID_SOLO_NET:    0,                                     //Network solo
+}
+
+//GetTxAttributeCheckHeight return the height from which transaction attributes are accepted in blocks
+func GetTxAttributeCheckHeight(id uint32) uint32 {
+	return TX_ATTRIBUTE_CHECK_HEIGHT[id]
+}
+
+var VOTE_SIG_CHECK_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.VOTE_SIG_HEIGHT_MAINNET, //Network main
+	NETWORK_ID_POLARIS_NET: constants.VOTE_SIG_HEIGHT_POLARIS, //Network polaris
+	NETWORK_ID_SOLO_NET:    0,                                 //Network solo
+}
+
+//GetVoteSigCheckHeight return the height from which the consensus messages
+//must carry valid vote signatures, and equivocation can be reported
+func GetVoteSigCheckHeight(id uint32) uint32 {
+	return VOTE_SIG_CHECK_HEIGHT[id]
+}
+
+var LINK_ENCRYPTION_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.LINK_ENCRYPTION_HEIGHT_MAINNET, //Network main
+	NETWORK_ID_POLARIS_NET: constants.LINK_ENCRYPTION_HEIGHT_POLARIS, //Network polaris
+	NETWORK_ID_SOLO_NET:    0,                                        //Network solo
+}
+
+//GetLinkEncryptionHeight return the height from which the node starts the
+//encrypted handshake with every outbound peer
+func GetLinkEncryptionHeight(id uint32) uint32 {
+	return LINK_ENCRYPTION_HEIGHT[id]
+}
+
+var BLS_PUBKEY_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.BLS_PUBKEY_HEIGHT_MAINNET, //Network main
+	NETWORK_ID_POLARIS_NET: constants.BLS_PUBKEY_HEIGHT_POLARIS, //Network polaris
+	NETWORK_ID_SOLO_NET:    0,                                   //Network solo
+}
+
+//GetBlsPubKeyHeight return the height from which peers can register their bls
+//public keys in governance contract
+func GetBlsPubKeyHeight(id uint32) uint32 {
+	return BLS_PUBKEY_HEIGHT[id]
+}
+
+var BLS_SIG_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.BLS_SIG_HEIGHT_MAINNET, //Network main
+	NETWORK_ID_POLARIS_NET: constants.BLS_SIG_HEIGHT_POLARIS, //Network polaris
+	NETWORK_ID_SOLO_NET:    0,                                //Network solo
+}
+
+//GetBlsSigHeight return the height from which the block headers are signed by
+//an aggregated bls signature of the consensus peers
+func GetBlsSigHeight(id uint32) uint32 {
+	return BLS_SIG_HEIGHT[id]
+}
+
 func GetNetworkName(id uint32) string {
 	name, ok := NETWORK_NAME[id]
 	if ok {
@@ -492,13 +560,21 @@ type SOLOConfig struct {
 }
 
 type CommonConfig struct {
-	LogLevel       uint
-	NodeType       string
-	EnableEventLog bool
-	SystemFee      map[string]int64
-	GasLimit       uint64
-	GasPrice       uint64
-	DataDir        string
+	LogLevel            uint
+	NodeType            string
+	EnableEventLog      bool
+	EnableStateArchive  bool
+	PruneBlocks         uint32
+	EnableAddressIndex  bool
+	EnableTransferIndex bool
+	StoreBackend        string
+	SystemFee           map[string]int64
+	GasLimit            uint64
+	GasPrice            uint64
+	GasPriceBump        uint64
+	TxPoolPayerQuota    uint
+	TxPoolPeerQuota     uint
+	DataDir             string
 }
 
 type ConsensusConfig struct {
@@ -527,12 +603,15 @@ type P2PNodeConfig struct {
 	MaxConnInBound            uint
 	MaxConnOutBound           uint
 	MaxConnInBoundForSingleIP uint
+	BanDuration               uint
+	EnableLinkEncryption      bool
 }
 
 type RpcConfig struct {
-	EnableHttpJsonRpc bool
-	HttpJsonPort      uint
-	HttpLocalPort     uint
+	EnableHttpJsonRpc    bool
+	HttpJsonPort         uint
+	HttpLocalPort        uint
+	EnableCompatibleMode bool
 }
 
 type RestfulConfig struct {
@@ -550,6 +629,11 @@ type WebSocketConfig struct {
 	HttpKeyPath  string
 }
 
+type MetricsConfig struct {
+	EnableMetrics bool
+	MetricsPort   uint
+}
+
 type OntologyConfig struct {
 	Genesis   *GenesisConfig
 	Common    *CommonConfig
@@ -558,17 +642,22 @@ type OntologyConfig struct {
 	Rpc       *RpcConfig
 	Restful   *RestfulConfig
 	Ws        *WebSocketConfig
+	Metrics   *MetricsConfig
 }
 
 func NewOntologyConfig() *OntologyConfig {
 	return &OntologyConfig{
 		Genesis: MainNetConfig,
 		Common: &CommonConfig{
-			LogLevel:       DEFAULT_LOG_LEVEL,
-			EnableEventLog: DEFAULT_ENABLE_EVENT_LOG,
-			SystemFee:      make(map[string]int64),
-			GasLimit:       DEFAULT_GAS_LIMIT,
-			DataDir:        DEFAULT_DATA_DIR,
+			LogLevel:         DEFAULT_LOG_LEVEL,
+			EnableEventLog:   DEFAULT_ENABLE_EVENT_LOG,
+			SystemFee:        make(map[string]int64),
+			GasLimit:         DEFAULT_GAS_LIMIT,
+			GasPriceBump:     DEFAULT_GAS_PRICE_BUMP,
+			TxPoolPayerQuota: DEFAULT_TXPOOL_PAYER_QUOTA,
+			TxPoolPeerQuota:  DEFAULT_TXPOOL_PEER_QUOTA,
+			DataDir:          DEFAULT_DATA_DIR,
+			StoreBackend:     DEFAULT_STORE_BACKEND,
 		},
 		Consensus: &ConsensusConfig{
 			EnableConsensus: true,
@@ -590,11 +679,13 @@ func NewOntologyConfig() *OntologyConfig {
 			MaxConnInBound:            DEFAULT_MAX_CONN_IN_BOUND,
 			MaxConnOutBound:           DEFAULT_MAX_CONN_OUT_BOUND,
 			MaxConnInBoundForSingleIP: DEFAULT_MAX_CONN_IN_BOUND_FOR_SINGLE_IP,
+			BanDuration:               DEFAULT_BAN_DURATION,
 		},
 		Rpc: &RpcConfig{
-			EnableHttpJsonRpc: true,
-			HttpJsonPort:      DEFAULT_RPC_PORT,
-			HttpLocalPort:     DEFAULT_RPC_LOCAL_PORT,
+			EnableHttpJsonRpc:    true,
+			HttpJsonPort:         DEFAULT_RPC_PORT,
+			HttpLocalPort:        DEFAULT_RPC_LOCAL_PORT,
+			EnableCompatibleMode: true,
 		},
 		Restful: &RestfulConfig{
 			EnableHttpRestful: true,
@@ -604,6 +695,10 @@ func NewOntologyConfig() *OntologyConfig {
 			EnableHttpWs: true,
 			HttpWsPort:   DEFAULT_WS_PORT,
 		},
+		Metrics: &MetricsConfig{
+			EnableMetrics: false,
+			MetricsPort:   DEFAULT_METRICS_PORT,
+		},
 	}
 }
 
@@ -19,6 +19,7 @@
 package constants
 
 import (
+	"math"
 	"time"
 )
 
@@ -86,3 +87,23 @@ const STATE_HASH_HEIGHT_POLARIS = 850000
 // neovm opcode update check height
 const OPCODE_HEIGHT_UPDATE_FIRST_MAINNET = 6000000
 const OPCODE_HEIGHT_UPDATE_FIRST_POLARIS = 2100000
+
+// transaction attribute check height, not scheduled yet
+const TX_ATTRIBUTE_HEIGHT_MAINNET = math.MaxUint32
+const TX_ATTRIBUTE_HEIGHT_POLARIS = math.MaxUint32
+
+// vbft vote signature check height, not scheduled yet
+const VOTE_SIG_HEIGHT_MAINNET = math.MaxUint32
+const VOTE_SIG_HEIGHT_POLARIS = math.MaxUint32
+
+// p2p link encryption height, not scheduled yet
+const LINK_ENCRYPTION_HEIGHT_MAINNET = math.MaxUint32
+const LINK_ENCRYPTION_HEIGHT_POLARIS = math.MaxUint32
+
+// vbft bls public key registration height, not scheduled yet
+const BLS_PUBKEY_HEIGHT_MAINNET = math.MaxUint32
+const BLS_PUBKEY_HEIGHT_POLARIS = math.MaxUint32
+
+// vbft aggregated bls signature height of block headers, not scheduled yet
+const BLS_SIG_HEIGHT_MAINNET = math.MaxUint32
+const BLS_SIG_HEIGHT_POLARIS = math.MaxUint32
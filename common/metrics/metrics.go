@@ -0,0 +1,319 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package metrics keeps the counters, gauges and histograms of the node, and
+// writes them in the prometheus text format
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+//metric types
+const (
+	COUNTER   = "counter"
+	GAUGE     = "gauge"
+	HISTOGRAM = "histogram"
+)
+
+//family is a registered metric with its samples writer
+type family struct {
+	name  string
+	help  string
+	typ   string
+	write func(buf *bytes.Buffer)
+}
+
+var (
+	lock       sync.Mutex
+	families   = make(map[string]*family)
+	collectors = make(map[string]func())
+)
+
+//register adds the metric, panic if the name has been registered
+func register(name, help, typ string, write func(buf *bytes.Buffer)) {
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := families[name]; ok {
+		panic(fmt.Sprintf("metric %s registered twice", name))
+	}
+	families[name] = &family{name: name, help: help, typ: typ, write: write}
+}
+
+//RegisterCollector adds the function updating the metrics kept elsewhere
+//before each scrape, a collector of the same name is replaced
+func RegisterCollector(name string, collect func()) {
+	lock.Lock()
+	defer lock.Unlock()
+	collectors[name] = collect
+}
+
+//UnregisterCollector removes the collector of the name
+func UnregisterCollector(name string) {
+	lock.Lock()
+	defer lock.Unlock()
+	delete(collectors, name)
+}
+
+//value is a float64 updated atomically
+type value struct {
+	bits uint64
+}
+
+func (this *value) Add(delta float64) {
+	for {
+		old := atomic.LoadUint64(&this.bits)
+		next := math.Float64bits(math.Float64frombits(old) + delta)
+		if atomic.CompareAndSwapUint64(&this.bits, old, next) {
+			return
+		}
+	}
+}
+
+func (this *value) Set(v float64) {
+	atomic.StoreUint64(&this.bits, math.Float64bits(v))
+}
+
+func (this *value) Get() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&this.bits))
+}
+
+//Counter is a value only increased, Set is for the counters kept elsewhere
+type Counter struct {
+	value
+}
+
+func (this *Counter) Inc() {
+	this.Add(1)
+}
+
+//Gauge is a value increased and decreased
+type Gauge struct {
+	value
+}
+
+func (this *Gauge) Inc() {
+	this.Add(1)
+}
+
+func (this *Gauge) Dec() {
+	this.Add(-1)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func writeSample(buf *bytes.Buffer, name, labels string, v float64) {
+	buf.WriteString(name)
+	if labels != "" {
+		buf.WriteString("{" + labels + "}")
+	}
+	buf.WriteString(" " + formatFloat(v) + "\n")
+}
+
+//NewCounter registers a counter
+func NewCounter(name, help string) *Counter {
+	c := &Counter{}
+	register(name, help, COUNTER, func(buf *bytes.Buffer) {
+		writeSample(buf, name, "", c.Get())
+	})
+	return c
+}
+
+//NewGauge registers a gauge
+func NewGauge(name, help string) *Gauge {
+	g := &Gauge{}
+	register(name, help, GAUGE, func(buf *bytes.Buffer) {
+		writeSample(buf, name, "", g.Get())
+	})
+	return g
+}
+
+//sample is a counter or a gauge in a vec
+type sample interface {
+	Get() float64
+}
+
+//vec is the values of a metric partitioned by a label
+type vec struct {
+	sync.Mutex
+	label  string
+	values map[string]sample
+}
+
+func newVec(label string) vec {
+	return vec{label: label, values: make(map[string]sample)}
+}
+
+func (this *vec) write(buf *bytes.Buffer, name string) {
+	this.Lock()
+	defer this.Unlock()
+	labels := make([]string, 0, len(this.values))
+	for label := range this.values {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		writeSample(buf, name, this.label+"="+strconv.Quote(label), this.values[label].Get())
+	}
+}
+
+//CounterVec is counters partitioned by a label
+type CounterVec struct {
+	vec
+}
+
+//WithLabel returns the counter of the label value
+func (this *CounterVec) WithLabel(label string) *Counter {
+	this.Lock()
+	defer this.Unlock()
+	c, ok := this.values[label]
+	if !ok {
+		c = &Counter{}
+		this.values[label] = c
+	}
+	return c.(*Counter)
+}
+
+//GaugeVec is gauges partitioned by a label
+type GaugeVec struct {
+	vec
+}
+
+//WithLabel returns the gauge of the label value
+func (this *GaugeVec) WithLabel(label string) *Gauge {
+	this.Lock()
+	defer this.Unlock()
+	g, ok := this.values[label]
+	if !ok {
+		g = &Gauge{}
+		this.values[label] = g
+	}
+	return g.(*Gauge)
+}
+
+//NewCounterVec registers counters partitioned by the label
+func NewCounterVec(name, help, label string) *CounterVec {
+	c := &CounterVec{newVec(label)}
+	register(name, help, COUNTER, func(buf *bytes.Buffer) {
+		c.write(buf, name)
+	})
+	return c
+}
+
+//NewGaugeVec registers gauges partitioned by the label
+func NewGaugeVec(name, help, label string) *GaugeVec {
+	g := &GaugeVec{newVec(label)}
+	register(name, help, GAUGE, func(buf *bytes.Buffer) {
+		g.write(buf, name)
+	})
+	return g
+}
+
+//Histogram counts the observed values in cumulative buckets
+type Histogram struct {
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     value
+}
+
+//Observe adds the value to the histogram
+func (this *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(this.buckets, v)
+	if i < len(this.counts) {
+		atomic.AddUint64(&this.counts[i], 1)
+	}
+	atomic.AddUint64(&this.count, 1)
+	this.sum.Add(v)
+}
+
+//NewHistogram registers a histogram with the upper bounds of the buckets
+//in increasing order
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	h := &Histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+	register(name, help, HISTOGRAM, func(buf *bytes.Buffer) {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += atomic.LoadUint64(&h.counts[i])
+			writeSample(buf, name+"_bucket", "le=\""+formatFloat(bound)+"\"", float64(cumulative))
+		}
+		count := atomic.LoadUint64(&h.count)
+		writeSample(buf, name+"_bucket", "le=\"+Inf\"", float64(count))
+		writeSample(buf, name+"_sum", "", h.sum.Get())
+		writeSample(buf, name+"_count", "", float64(count))
+	})
+	return h
+}
+
+//WriteText runs the collectors and writes all the metrics in the prometheus
+//text format
+func WriteText(w io.Writer) error {
+	lock.Lock()
+	fns := make([]func(), 0, len(collectors))
+	for _, collect := range collectors {
+		fns = append(fns, collect)
+	}
+	all := make([]*family, 0, len(families))
+	for _, f := range families {
+		all = append(all, f)
+	}
+	lock.Unlock()
+
+	for _, collect := range fns {
+		collect()
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].name < all[j].name
+	})
+	buf := new(bytes.Buffer)
+	for _, f := range all {
+		fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.typ)
+		f.write(buf)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+//Handler returns the http handler serving the metrics
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := WriteText(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	counter := NewCounter("test_counter_total", "test counter")
+	gauge := NewGauge("test_gauge", "test gauge")
+	vec := NewCounterVec("test_vec_total", "test counter vec", "type")
+	histogram := NewHistogram("test_histogram", "test histogram", []float64{0.1, 1})
+	RegisterCollector("test", func() {
+		gauge.Set(42)
+	})
+	defer UnregisterCollector("test")
+
+	counter.Inc()
+	counter.Add(2)
+	vec.WithLabel("block").Add(10)
+	vec.WithLabel("tx").Inc()
+	histogram.Observe(0.05)
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+
+	buf := new(bytes.Buffer)
+	if err := WriteText(buf); err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+	expected := []string{
+		"# TYPE test_counter_total counter\ntest_counter_total 3\n",
+		"# TYPE test_gauge gauge\ntest_gauge 42\n",
+		"test_vec_total{type=\"block\"} 10\ntest_vec_total{type=\"tx\"} 1\n",
+		"test_histogram_bucket{le=\"0.1\"} 1\ntest_histogram_bucket{le=\"1\"} 2\ntest_histogram_bucket{le=\"+Inf\"} 3\n",
+		"test_histogram_sum 5.55\ntest_histogram_count 3\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(text, e) {
+			t.Errorf("metrics text misses %q:\n%s", e, text)
+		}
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	NewGauge("test_twice", "registered twice")
+	defer func() {
+		if recover() == nil {
+			t.Errorf("register twice should panic")
+		}
+	}()
+	NewGauge("test_twice", "registered twice")
+}
@@ -46,8 +46,8 @@ func (self *TxPoolActor) GetTxnPool(byCount bool, height uint32) []*txpool.TXEnt
 	return txs
 }
 
-func (self *TxPoolActor) VerifyBlock(txs []*types.Transaction, height uint32) error {
-	poolmsg := &txpool.VerifyBlockReq{Txs: txs, Height: height}
+func (self *TxPoolActor) VerifyBlock(txs []*types.Transaction, height uint32, blockHeight uint32) error {
+	poolmsg := &txpool.VerifyBlockReq{Txs: txs, Height: height, BlockHeight: blockHeight}
 	future := self.Pool.RequestFuture(poolmsg, time.Second*10)
 	entry, err := future.Result()
 	if err != nil {
@@ -64,6 +64,10 @@ func (self *TxPoolActor) VerifyBlock(txs []*types.Transaction, height uint32) er
 	return nil
 }
 
+func (self *TxPoolActor) AppendTx(tx *types.Transaction) {
+	self.Pool.Tell(&txpool.TxReq{Tx: tx, Sender: txpool.HttpSender})
+}
+
 type P2PActor struct {
 	P2P *actor.PID
 }
@@ -23,6 +23,12 @@ import "github.com/ontio/ontology/core/types"
 type StartConsensus struct{}
 type StopConsensus struct{}
 
+//request for the status of consensus service
+type GetConsensusStatusReq struct{}
+type GetConsensusStatusRsp struct {
+	Status interface{} // nil if not supported by the consensus service
+}
+
 //internal Message
 type TimeOut struct{}
 type BlockCompleted struct {
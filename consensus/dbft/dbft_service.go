@@ -112,6 +112,10 @@ func (this *DbftService) Receive(context actor.Context) {
 		log.Warn("dbft actor restart")
 	case *actorTypes.StartConsensus:
 		this.start()
+	case *actorTypes.GetConsensusStatusReq:
+		if sender := context.Sender(); sender != nil {
+			sender.Request(&actorTypes.GetConsensusStatusRsp{}, context.Self())
+		}
 	case *actorTypes.StopConsensus:
 		this.incrValidator.Clean()
 		this.halt()
@@ -447,7 +451,7 @@ func (ds *DbftService) PrepareRequestReceived(payload *p2pmsg.ConsensusPayload,
 			log.Infof("incr validator block height %v != ledger block height %v", int(end)-1, height)
 		}
 
-		if err := ds.poolActor.VerifyBlock(ds.context.Transactions, validHeight); err != nil {
+		if err := ds.poolActor.VerifyBlock(ds.context.Transactions, validHeight, ds.context.Height); err != nil {
 			log.Error("PrepareRequestReceived new transaction verification failed, will not sent Prepare Response", err)
 			ds.context = backupContext
 			ds.RequestChangeView()
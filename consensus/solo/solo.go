@@ -106,6 +106,10 @@ func (self *SoloService) Receive(context actor.Context) {
 				}
 			}
 		}()
+	case *actorTypes.GetConsensusStatusReq:
+		if sender := context.Sender(); sender != nil {
+			sender.Request(&actorTypes.GetConsensusStatusRsp{}, context.Self())
+		}
 	case *actorTypes.StopConsensus:
 		if self.existCh != nil {
 			close(self.existCh)
@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 
 	"github.com/ontio/ontology-crypto/keypair"
@@ -39,6 +40,7 @@ var errDupCommit = errors.New("multi commit from same committer")
 type CandidateEndorseSigInfo struct {
 	EndorsedProposer uint32
 	Signature        []byte
+	BlsSignature     []byte // empty before the bls height
 	ForEmpty         bool
 }
 
@@ -288,6 +290,7 @@ func (pool *BlockPool) newBlockEndorsement(msg *blockEndorseMsg) {
 	eSig := &CandidateEndorseSigInfo{
 		EndorsedProposer: msg.EndorsedProposer,
 		Signature:        msg.EndorserSig,
+		BlsSignature:     msg.EndorserBlsSig,
 		ForEmpty:         msg.EndorseForEmpty,
 	}
 	pool.addBlockEndorsementLocked(msg.GetBlockNum(), msg.Endorser, eSig)
@@ -459,6 +462,7 @@ func (pool *BlockPool) newBlockCommitment(msg *blockCommitMsg) error {
 		eSig := &CandidateEndorseSigInfo{
 			EndorsedProposer: msg.BlockProposer,
 			Signature:        sig,
+			BlsSignature:     msg.EndorsersBlsSig[endorser],
 			ForEmpty:         msg.CommitForEmpty,
 		}
 		pool.addBlockEndorsementLocked(blkNum, endorser, eSig)
@@ -468,6 +472,7 @@ func (pool *BlockPool) newBlockCommitment(msg *blockCommitMsg) error {
 	pool.addBlockEndorsementLocked(blkNum, msg.Committer, &CandidateEndorseSigInfo{
 		EndorsedProposer: msg.BlockProposer,
 		Signature:        msg.CommitterSig,
+		BlsSignature:     msg.CommitterBlsSig,
 		ForEmpty:         msg.CommitForEmpty,
 	})
 
@@ -576,6 +581,14 @@ func (pool *BlockPool) addSignaturesToBlockLocked(block *Block, forEmpty bool) e
 	if c == nil {
 		panic(fmt.Errorf("non-candidates for block %d yet when seal block", blkNum))
 	}
+	if pool.server.blsSigActive(blkNum) {
+		err := pool.addBlsSignatureToBlockLocked(block, c, forEmpty)
+		if err == nil {
+			return nil
+		}
+		// fall back to the sigs of each signer
+		log.Warnf("server %d: %s", pool.server.Index, err)
+	}
 
 	bookkeepers := make([]keypair.PublicKey, 0)
 	sigData := make([][]byte, 0)
@@ -737,6 +750,59 @@ func (pool *BlockPool) findConsensusEmptyProposal(blockNum uint32) (*blockPropos
 		blockNum, maxEndorsedProposer, maxCnt)
 }
 
+//
+// @ endorsements and commitments received for each proposal of the block,
+//   ordered by proposer
+//
+func (pool *BlockPool) getVoteTallies(blkNum uint32) []*VoteTally {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	candidate := pool.candidateBlocks[blkNum]
+	if candidate == nil {
+		return nil
+	}
+
+	type tallyKey struct {
+		proposer uint32
+		forEmpty bool
+	}
+	tallies := make(map[tallyKey]*VoteTally)
+	getTally := func(proposer uint32, forEmpty bool) *VoteTally {
+		key := tallyKey{proposer: proposer, forEmpty: forEmpty}
+		t := tallies[key]
+		if t == nil {
+			t = &VoteTally{Proposer: proposer, ForEmpty: forEmpty, Endorsers: []uint32{}, Committers: []uint32{}}
+			tallies[key] = t
+		}
+		return t
+	}
+	for endorser, eSigs := range candidate.EndorseSigs {
+		for _, sig := range eSigs {
+			t := getTally(sig.EndorsedProposer, sig.ForEmpty)
+			t.Endorsers = append(t.Endorsers, endorser)
+		}
+	}
+	for _, msg := range candidate.CommitMsgs {
+		t := getTally(msg.BlockProposer, msg.CommitForEmpty)
+		t.Committers = append(t.Committers, msg.Committer)
+	}
+
+	result := make([]*VoteTally, 0, len(tallies))
+	for _, t := range tallies {
+		sort.Slice(t.Endorsers, func(i, j int) bool { return t.Endorsers[i] < t.Endorsers[j] })
+		sort.Slice(t.Committers, func(i, j int) bool { return t.Committers[i] < t.Committers[j] })
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Proposer != result[j].Proposer {
+			return result[i].Proposer < result[j].Proposer
+		}
+		return !result[i].ForEmpty && result[j].ForEmpty
+	})
+	return result
+}
+
 func (pool *BlockPool) onBlockSealed(blockNum uint32) {
 	if blockNum <= pool.HistoryLen {
 		return
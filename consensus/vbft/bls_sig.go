@@ -0,0 +1,148 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"fmt"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/consensus/vbft/config"
+	"github.com/ontio/ontology/core/signature/bls"
+)
+
+//
+// Since the bls height, endorsers sign the endorsed block with bls key as well,
+// and the sealed block header carries the bls signatures of endorsers aggregated
+// into one, instead of the signature of each endorser. Without C+1 valid bls
+// signatures, the block is sealed with the signature of each endorser as before.
+//
+
+// bls key is derived from the account key, so that no more key to keep.
+// The pubkey and its proof of possession are registered with governance contract.
+func (self *Server) initBlsKey() {
+	key, err := bls.GenerateKey(keypair.SerializePrivateKey(self.account.PrivateKey))
+	if err != nil {
+		log.Warnf("server %d: no bls key, blocks will not be signed with bls: %s", self.Index, err)
+		return
+	}
+	proof, err := key.ProvePossession()
+	if err != nil {
+		log.Warnf("server %d: failed to prove possession of bls key: %s", self.Index, err)
+		return
+	}
+	self.blsKey = key
+	log.Infof("vbft bls pubkey: %x, proof of possession: %x", key.PublicKey().Serialize(), proof.Serialize())
+}
+
+func (self *Server) blsSigActive(blkNum uint32) bool {
+	return blkNum >= config.GetBlsSigHeight(config.DefConfig.P2PNode.NetworkId)
+}
+
+// bls signature on block hash, nil before the bls height
+func (self *Server) signBls(blkNum uint32, blkHash common.Uint256) ([]byte, error) {
+	if !self.blsSigActive(blkNum) || self.blsKey == nil {
+		return nil, nil
+	}
+	sig, err := self.blsKey.Sign(blkHash[:])
+	if err != nil {
+		return nil, err
+	}
+	return sig.Serialize(), nil
+}
+
+func (pool *BlockPool) addBlsSignatureToBlockLocked(block *Block, c *CandidateInfo, forEmpty bool) error {
+	header := block.Block.Header
+	if forEmpty {
+		if block.EmptyBlock == nil {
+			return fmt.Errorf("block has no empty candidate")
+		}
+		header = block.EmptyBlock.Header
+	}
+
+	// endorsers' sig
+	proposer := block.getProposer()
+	signers := make([]uint32, 0)
+	pks := make([]*bls.PublicKey, 0)
+	sigs := make([]*bls.Signature, 0)
+	for endorser, eSigs := range c.EndorseSigs {
+		for _, sig := range eSigs {
+			if sig.EndorsedProposer == proposer && sig.ForEmpty == forEmpty {
+				pk := pool.server.peerPool.getBlsPubKey(endorser)
+				if pk != nil && len(sig.BlsSignature) > 0 {
+					if s, err := bls.DeserializeSignature(sig.BlsSignature); err == nil {
+						signers = append(signers, endorser)
+						pks = append(pks, pk)
+						sigs = append(sigs, s)
+					}
+				}
+				break
+			}
+		}
+	}
+
+	hash := header.Hash()
+	signers, sig, err := aggregateBlsSigs(hash[:], signers, pks, sigs)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate bls sigs of block %d: %s", block.getBlockNum(), err)
+	}
+	if uint32(len(signers)) <= pool.server.config.C {
+		return fmt.Errorf("not enough bls signers of block %d: %d", block.getBlockNum(), len(signers))
+	}
+	header.Bookkeepers = nil
+	header.SigData = vconfig.BlsSigData(sig, signers)
+	return nil
+}
+
+//
+// aggregate bls sigs on hash, sigs failed to verify are dropped
+//
+func aggregateBlsSigs(hash []byte, signers []uint32, pks []*bls.PublicKey, sigs []*bls.Signature) ([]uint32, *bls.Signature, error) {
+	if len(sigs) == 0 {
+		return nil, nil, fmt.Errorf("no bls sigs")
+	}
+	sig, err := bls.AggregateSignatures(sigs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if bls.VerifyAggregate(pks, hash, sig) {
+		return signers, sig, nil
+	}
+
+	validSigners := make([]uint32, 0)
+	validSigs := make([]*bls.Signature, 0)
+	for i := range sigs {
+		if bls.Verify(pks[i], hash, sigs[i]) {
+			validSigners = append(validSigners, signers[i])
+			validSigs = append(validSigs, sigs[i])
+		} else {
+			log.Warnf("invalid bls sig from peer %d", signers[i])
+		}
+	}
+	if len(validSigs) == 0 {
+		return nil, nil, fmt.Errorf("no valid bls sigs")
+	}
+	sig, err = bls.AggregateSignatures(validSigs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return validSigners, sig, nil
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vconfig
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ontio/ontology/core/signature/bls"
+)
+
+//
+// Since the bls height, the header of vbft block is signed by one bls signature
+// aggregated from the consensus peers, instead of one signature per bookkeeper.
+// Header.Bookkeepers is empty, and Header.SigData is [signature, signer bitmap],
+// in which bit i of byte i/8 is set if the peer with index i signed.
+//
+
+// GetBlsPubKey returns the bls public key of the peer, nil if not registered
+func (pc *PeerConfig) GetBlsPubKey() (*bls.PublicKey, error) {
+	if pc.BlsPubKey == "" {
+		return nil, nil
+	}
+	data, err := hex.DecodeString(pc.BlsPubKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bls pubkey of peer %d: %s", pc.Index, err)
+	}
+	pk, err := bls.DeserializePublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bls pubkey of peer %d: %s", pc.Index, err)
+	}
+	return pk, nil
+}
+
+// BlsSigData returns the header SigData of the signature aggregated from signers
+func BlsSigData(sig *bls.Signature, signers []uint32) [][]byte {
+	var bitmap []byte
+	for _, idx := range signers {
+		for uint32(len(bitmap)) <= idx/8 {
+			bitmap = append(bitmap, 0)
+		}
+		bitmap[idx/8] |= 1 << (idx % 8)
+	}
+	return [][]byte{sig.Serialize(), bitmap}
+}
+
+// BlsSigners returns the peer indexes in the signer bitmap of header SigData
+func BlsSigners(sigData [][]byte) ([]uint32, error) {
+	if len(sigData) != 2 {
+		return nil, fmt.Errorf("invalid bls sig data count: %d", len(sigData))
+	}
+	if n := len(sigData[1]); n > 0 && sigData[1][n-1] == 0 {
+		return nil, fmt.Errorf("non-canonical bls signer bitmap")
+	}
+	signers := make([]uint32, 0)
+	for i, b := range sigData[1] {
+		for j := uint32(0); j < 8; j++ {
+			if b&(1<<j) != 0 {
+				signers = append(signers, uint32(i)*8+j)
+			}
+		}
+	}
+	return signers, nil
+}
+
+// VerifyBlsSigData checks the header SigData is signed by at least m of peers
+func VerifyBlsSigData(hash []byte, sigData [][]byte, peers []*PeerConfig, m int) error {
+	signers, err := BlsSigners(sigData)
+	if err != nil {
+		return err
+	}
+	if len(signers) < m {
+		return fmt.Errorf("not enough bls signers: %d, required %d", len(signers), m)
+	}
+	peerMap := make(map[uint32]*PeerConfig)
+	for _, p := range peers {
+		peerMap[p.Index] = p
+	}
+	pks := make([]*bls.PublicKey, 0, len(signers))
+	for _, idx := range signers {
+		p := peerMap[idx]
+		if p == nil {
+			return fmt.Errorf("bls signer %d is not consensus peer", idx)
+		}
+		pk, err := p.GetBlsPubKey()
+		if err != nil {
+			return err
+		}
+		if pk == nil {
+			return fmt.Errorf("bls signer %d has no bls pubkey", idx)
+		}
+		pks = append(pks, pk)
+	}
+	sig, err := bls.DeserializeSignature(sigData[0])
+	if err != nil {
+		return fmt.Errorf("invalid bls signature: %s", err)
+	}
+	if !bls.VerifyAggregate(pks, hash, sig) {
+		return fmt.Errorf("bls signature verification failed")
+	}
+	return nil
+}
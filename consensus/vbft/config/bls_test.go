@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vconfig
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ontio/ontology/core/signature/bls"
+)
+
+func TestBlsSigners(t *testing.T) {
+	key, err := bls.GenerateKey(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	sig, err := key.Sign([]byte("block hash"))
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	signers := []uint32{0, 3, 9, 16}
+	sigData := BlsSigData(sig, signers)
+	if len(sigData[1]) != 3 {
+		t.Fatalf("invalid bitmap length: %d", len(sigData[1]))
+	}
+	signers2, err := BlsSigners(sigData)
+	if err != nil {
+		t.Fatalf("BlsSigners failed: %v", err)
+	}
+	if len(signers2) != len(signers) {
+		t.Fatalf("signers mismatch: %v vs %v", signers2, signers)
+	}
+	for i := range signers {
+		if signers[i] != signers2[i] {
+			t.Errorf("signers mismatch: %v vs %v", signers2, signers)
+		}
+	}
+
+	if _, err := BlsSigners([][]byte{sigData[0], {1, 0}}); err == nil {
+		t.Errorf("non-canonical bitmap accepted")
+	}
+}
+
+func TestVerifyBlsSigData(t *testing.T) {
+	hash := []byte("block hash")
+	peers := make([]*PeerConfig, 0)
+	sigs := make([]*bls.Signature, 0)
+	for i := uint32(0); i < 4; i++ {
+		seed := make([]byte, 32)
+		seed[0] = byte(i + 1)
+		key, err := bls.GenerateKey(seed)
+		if err != nil {
+			t.Fatalf("GenerateKey failed: %v", err)
+		}
+		sig, err := key.Sign(hash)
+		if err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		sigs = append(sigs, sig)
+		peers = append(peers, &PeerConfig{
+			Index:     i,
+			BlsPubKey: hex.EncodeToString(key.PublicKey().Serialize()),
+		})
+	}
+	sig, err := bls.AggregateSignatures(sigs[:3])
+	if err != nil {
+		t.Fatalf("AggregateSignatures failed: %v", err)
+	}
+
+	sigData := BlsSigData(sig, []uint32{0, 1, 2})
+	if err := VerifyBlsSigData(hash, sigData, peers, 3); err != nil {
+		t.Errorf("VerifyBlsSigData failed: %v", err)
+	}
+	if err := VerifyBlsSigData(hash, sigData, peers, 4); err == nil {
+		t.Errorf("verified with not enough signers")
+	}
+	if err := VerifyBlsSigData([]byte("other hash"), sigData, peers, 3); err == nil {
+		t.Errorf("verified on other hash")
+	}
+	if err := VerifyBlsSigData(hash, BlsSigData(sig, []uint32{0, 1, 3}), peers, 3); err == nil {
+		t.Errorf("verified with wrong signers")
+	}
+}
@@ -35,8 +35,9 @@ var (
 )
 
 type PeerConfig struct {
-	Index uint32 `json:"index"`
-	ID    string `json:"id"`
+	Index     uint32 `json:"index"`
+	ID        string `json:"id"`
+	BlsPubKey string `json:"bls_pubkey,omitempty"` // hex of bls public key, empty if not registered
 }
 
 type ChainConfig struct {
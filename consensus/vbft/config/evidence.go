@@ -0,0 +1,185 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vconfig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-crypto/signature"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/serialization"
+)
+
+//vote types
+const (
+	VOTE_PROPOSAL uint8 = iota
+	VOTE_ENDORSE
+	VOTE_COMMIT
+)
+
+//prefix of vote hash, so that the signature of vote can not be taken as block signature
+var votePrefix = []byte("vbft vote")
+
+//Vote is the statement a peer signs on a block of the height for a consensus message.
+//A peer equivocates if it signs two votes with the same type, height and empty flag for different blocks.
+type Vote struct {
+	Type      uint8
+	BlockNum  uint32
+	View      uint32 // view of the chain config the vote is signed in
+	ForEmpty  bool
+	BlockHash common.Uint256
+	Sig       []byte
+}
+
+//Hash returns the hash signed by the vote, which is bound to the network and
+//the chain config view, so that the vote can not be replayed in another one
+func (v *Vote) Hash() common.Uint256 {
+	buf := new(bytes.Buffer)
+	buf.Write(votePrefix)
+	serialization.WriteUint32(buf, config.DefConfig.P2PNode.NetworkId)
+	serialization.WriteUint32(buf, v.View)
+	serialization.WriteUint8(buf, v.Type)
+	serialization.WriteUint32(buf, v.BlockNum)
+	serialization.WriteBool(buf, v.ForEmpty)
+	buf.Write(v.BlockHash[:])
+	hash := sha256.Sum256(buf.Bytes())
+	return hash
+}
+
+func (v *Vote) Verify(pub keypair.PublicKey) error {
+	sig, err := signature.Deserialize(v.Sig)
+	if err != nil {
+		return fmt.Errorf("deserialize vote sig: %s", err)
+	}
+	hash := v.Hash()
+	if !signature.Verify(pub, hash[:], sig) {
+		return fmt.Errorf("failed to verify vote sig")
+	}
+	return nil
+}
+
+func (v *Vote) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint8(w, v.Type); err != nil {
+		return fmt.Errorf("serialize vote type: %s", err)
+	}
+	if err := serialization.WriteUint32(w, v.BlockNum); err != nil {
+		return fmt.Errorf("serialize vote block num: %s", err)
+	}
+	if err := serialization.WriteUint32(w, v.View); err != nil {
+		return fmt.Errorf("serialize vote view: %s", err)
+	}
+	if err := serialization.WriteBool(w, v.ForEmpty); err != nil {
+		return fmt.Errorf("serialize vote empty flag: %s", err)
+	}
+	if err := v.BlockHash.Serialize(w); err != nil {
+		return fmt.Errorf("serialize vote block hash: %s", err)
+	}
+	if err := serialization.WriteVarBytes(w, v.Sig); err != nil {
+		return fmt.Errorf("serialize vote sig: %s", err)
+	}
+	return nil
+}
+
+func (v *Vote) Deserialize(r io.Reader) error {
+	typ, err := serialization.ReadUint8(r)
+	if err != nil {
+		return fmt.Errorf("deserialize vote type: %s", err)
+	}
+	blkNum, err := serialization.ReadUint32(r)
+	if err != nil {
+		return fmt.Errorf("deserialize vote block num: %s", err)
+	}
+	view, err := serialization.ReadUint32(r)
+	if err != nil {
+		return fmt.Errorf("deserialize vote view: %s", err)
+	}
+	forEmpty, err := serialization.ReadBool(r)
+	if err != nil {
+		return fmt.Errorf("deserialize vote empty flag: %s", err)
+	}
+	var blkHash common.Uint256
+	if err := blkHash.Deserialize(r); err != nil {
+		return fmt.Errorf("deserialize vote block hash: %s", err)
+	}
+	sig, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return fmt.Errorf("deserialize vote sig: %s", err)
+	}
+	v.Type = typ
+	v.BlockNum = blkNum
+	v.View = view
+	v.ForEmpty = forEmpty
+	v.BlockHash = blkHash
+	v.Sig = sig
+	return nil
+}
+
+//Equivocation is the evidence that a peer signed two conflicting votes
+type Equivocation struct {
+	First  *Vote
+	Second *Vote
+}
+
+//Verify checks the votes are conflicting and both signed by the public key
+func (e *Equivocation) Verify(pub keypair.PublicKey) error {
+	if e.First == nil || e.Second == nil {
+		return fmt.Errorf("incomplete equivocation evidence")
+	}
+	if e.First.Type > VOTE_COMMIT {
+		return fmt.Errorf("invalid vote type %d", e.First.Type)
+	}
+	if e.First.Type != e.Second.Type || e.First.BlockNum != e.Second.BlockNum || e.First.ForEmpty != e.Second.ForEmpty {
+		return fmt.Errorf("votes are not for the same round")
+	}
+	if e.First.BlockHash == e.Second.BlockHash {
+		return fmt.Errorf("votes are for the same block")
+	}
+	if err := e.First.Verify(pub); err != nil {
+		return fmt.Errorf("first vote: %s", err)
+	}
+	if err := e.Second.Verify(pub); err != nil {
+		return fmt.Errorf("second vote: %s", err)
+	}
+	return nil
+}
+
+func (e *Equivocation) Serialize(w io.Writer) error {
+	if err := e.First.Serialize(w); err != nil {
+		return err
+	}
+	return e.Second.Serialize(w)
+}
+
+func (e *Equivocation) Deserialize(r io.Reader) error {
+	first, second := &Vote{}, &Vote{}
+	if err := first.Deserialize(r); err != nil {
+		return err
+	}
+	if err := second.Deserialize(r); err != nil {
+		return err
+	}
+	e.First = first
+	e.Second = second
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vconfig
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-crypto/signature"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+)
+
+func signVote(t *testing.T, priv keypair.PrivateKey, vote *Vote) *Vote {
+	hash := vote.Hash()
+	sig, err := signature.Sign(signature.SHA256withECDSA, priv, hash[:], nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	vote.Sig, err = signature.Serialize(sig)
+	if err != nil {
+		t.Fatalf("Serialize sig failed: %v", err)
+	}
+	return vote
+}
+
+func TestEquivocationVerify(t *testing.T) {
+	priv, pub, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	first := signVote(t, priv, &Vote{Type: VOTE_ENDORSE, BlockNum: 100, BlockHash: common.Uint256{1}})
+	second := signVote(t, priv, &Vote{Type: VOTE_ENDORSE, BlockNum: 100, BlockHash: common.Uint256{2}})
+	evidence := &Equivocation{First: first, Second: second}
+	if err := evidence.Verify(pub); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := evidence.Serialize(buf); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	evidence2 := &Equivocation{}
+	if err := evidence2.Deserialize(buf); err != nil {
+		t.Fatalf("Deserialize failed: %v", err)
+	}
+	if err := evidence2.Verify(pub); err != nil {
+		t.Errorf("Verify deserialized evidence failed: %v", err)
+	}
+
+	_, pub2, _ := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+	if err := evidence.Verify(pub2); err == nil {
+		t.Errorf("Verify evidence with other key should fail")
+	}
+
+	// the empty endorsement is not conflicting with the endorsement
+	empty := signVote(t, priv, &Vote{Type: VOTE_ENDORSE, BlockNum: 100, ForEmpty: true, BlockHash: common.Uint256{2}})
+	if err := (&Equivocation{First: first, Second: empty}).Verify(pub); err == nil {
+		t.Errorf("Verify votes of different rounds should fail")
+	}
+	if err := (&Equivocation{First: first, Second: first}).Verify(pub); err == nil {
+		t.Errorf("Verify votes of the same block should fail")
+	}
+
+	// the round is signed
+	second.BlockNum = 101
+	if err := (&Equivocation{First: first, Second: second}).Verify(pub); err == nil {
+		t.Errorf("Verify votes of different heights should fail")
+	}
+	second.BlockNum = 100
+	second.ForEmpty = true
+	if err := second.Verify(pub); err == nil {
+		t.Errorf("Verify tampered vote should fail")
+	}
+}
+
+func TestVoteReplay(t *testing.T) {
+	priv, pub, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	vote := signVote(t, priv, &Vote{Type: VOTE_COMMIT, BlockNum: 100, View: 3, BlockHash: common.Uint256{1}})
+	if err := vote.Verify(pub); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	// the vote is bound to the view
+	vote.View = 4
+	if err := vote.Verify(pub); err == nil {
+		t.Errorf("Verify vote of other view should fail")
+	}
+	vote.View = 3
+
+	// the vote is bound to the network
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+	config.DefConfig.P2PNode.NetworkId = networkId + 1
+	if err := vote.Verify(pub); err == nil {
+		t.Errorf("Verify vote of other network should fail")
+	}
+}
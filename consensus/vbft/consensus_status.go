@@ -0,0 +1,173 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"sync"
+
+	"github.com/ontio/ontology/consensus/vbft/config"
+)
+
+// ConsensusStatus is a snapshot of the consensus state of the server,
+// returned by the getconsensusstatus rpc
+type ConsensusStatus struct {
+	State              string               `json:"state"`
+	Index              uint32               `json:"index"`
+	CurrentBlockNum    uint32               `json:"current_block_num"`
+	CommittedBlockNum  uint32               `json:"committed_block_num"`
+	CompletedBlockNum  uint32               `json:"completed_block_num"`
+	LastConfigBlockNum uint32               `json:"last_config_block_num"`
+	K                  uint32               `json:"k"` // number of consensus peers
+	ChainConfig        *vconfig.ChainConfig `json:"chain_config"`
+	Proposers          []uint32             `json:"proposers"`
+	Endorsers          []uint32             `json:"endorsers"`
+	Committers         []uint32             `json:"committers"`
+	Peers              []*PeerStatus        `json:"peers"`
+	Round              *RoundStatus         `json:"round"`
+}
+
+// PeerStatus is the liveness of a consensus peer, from its latest heartbeat
+type PeerStatus struct {
+	Index             uint32 `json:"index"`
+	ID                string `json:"id"`
+	Connected         bool   `json:"connected"`
+	Active            bool   `json:"active"`
+	CommittedBlockNum uint32 `json:"committed_block_num"`
+	ChainConfigView   uint32 `json:"chain_config_view"`
+	LastHeartbeat     int64  `json:"last_heartbeat"` // unix time, 0 if no heartbeat received
+}
+
+// RoundStatus is the progress of the in-flight block
+type RoundStatus struct {
+	BlockNum         uint32            `json:"block_num"`
+	Proposals        []*ProposalStatus `json:"proposals"`
+	Tallies          []*VoteTally      `json:"tallies"`
+	Endorsed         bool              `json:"endorsed"`
+	EndorsedForEmpty bool              `json:"endorsed_for_empty"`
+	Committed        bool              `json:"committed"`
+	ViewChanges      []string          `json:"view_changes"` // timeouts fired in this round
+}
+
+type ProposalStatus struct {
+	Proposer  uint32 `json:"proposer"`
+	Rank      int    `json:"rank"` // -1 if not a proposer of the round
+	BlockHash string `json:"block_hash"`
+}
+
+// VoteTally counts the endorsements and commitments on a proposal
+type VoteTally struct {
+	Proposer   uint32   `json:"proposer"`
+	ForEmpty   bool     `json:"for_empty"`
+	Endorsers  []uint32 `json:"endorsers"`
+	Committers []uint32 `json:"committers"`
+}
+
+// view changes of the rounds not yet sealed
+type viewChangeLog struct {
+	lock   sync.Mutex
+	events map[uint32][]string // blockNum -> timeout events
+}
+
+func (l *viewChangeLog) add(blockNum uint32, event string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.events == nil {
+		l.events = make(map[uint32][]string)
+	}
+	for n := range l.events {
+		if n < blockNum {
+			delete(l.events, n)
+		}
+	}
+	l.events[blockNum] = append(l.events[blockNum], event)
+}
+
+func (l *viewChangeLog) get(blockNum uint32) []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return append([]string{}, l.events[blockNum]...)
+}
+
+func (self *Server) getConsensusStatus() *ConsensusStatus {
+	self.metaLock.RLock()
+	defer self.metaLock.RUnlock()
+
+	status := &ConsensusStatus{
+		State:              self.getState().String(),
+		Index:              self.Index,
+		CurrentBlockNum:    self.currentBlockNum,
+		CommittedBlockNum:  self.GetCommittedBlockNo(),
+		CompletedBlockNum:  self.completedBlockNum,
+		LastConfigBlockNum: self.LastConfigBlockNum,
+		ChainConfig:        self.config,
+		Proposers:          []uint32{},
+		Endorsers:          []uint32{},
+		Committers:         []uint32{},
+	}
+	if self.config != nil {
+		status.K = uint32(len(self.config.Peers))
+	}
+	if cfg := self.currentParticipantConfig; cfg != nil && cfg.BlockNum == self.currentBlockNum {
+		status.Proposers = append(status.Proposers, cfg.Proposers...)
+		status.Endorsers = append(status.Endorsers, cfg.Endorsers...)
+		status.Committers = append(status.Committers, cfg.Committers...)
+	}
+
+	blkNum := self.currentBlockNum
+	status.Peers = self.peerPool.getPeersStatus()
+	for _, p := range status.Peers {
+		p.Active = self.isPeerActive(p.Index, blkNum)
+	}
+
+	round := &RoundStatus{
+		BlockNum:         blkNum,
+		Proposals:        []*ProposalStatus{},
+		Tallies:          self.blockPool.getVoteTallies(blkNum),
+		Endorsed:         self.blockPool.endorsedForBlock(blkNum),
+		EndorsedForEmpty: self.blockPool.endorsedForEmptyBlock(blkNum),
+		Committed:        self.blockPool.committedForBlock(blkNum),
+		ViewChanges:      self.viewChanges.get(blkNum),
+	}
+	if round.Tallies == nil {
+		round.Tallies = []*VoteTally{}
+	}
+	for _, p := range self.blockPool.getBlockProposals(blkNum) {
+		proposer := p.Block.getProposer()
+		hash := p.Block.Block.Hash()
+		round.Proposals = append(round.Proposals, &ProposalStatus{
+			Proposer:  proposer,
+			Rank:      proposerRank(status.Proposers, proposer),
+			BlockHash: hash.ToHexString(),
+		})
+	}
+	status.Round = round
+
+	return status
+}
+
+func proposerRank(proposers []uint32, peerIdx uint32) int {
+	for rank, id := range proposers {
+		if id == peerIdx {
+			return rank
+		}
+	}
+	return -1
+}
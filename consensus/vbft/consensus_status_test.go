@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetVoteTallies(t *testing.T) {
+	pool := &BlockPool{candidateBlocks: make(map[uint32]*CandidateInfo)}
+	if tallies := pool.getVoteTallies(5); tallies != nil {
+		t.Errorf("no tallies expected for unknown block")
+	}
+
+	pool.candidateBlocks[5] = &CandidateInfo{
+		EndorseSigs: map[uint32][]*CandidateEndorseSigInfo{
+			3: {{EndorsedProposer: 2}},
+			1: {{EndorsedProposer: 2}},
+			2: {{EndorsedProposer: 2, ForEmpty: true}},
+		},
+		CommitMsgs: []*blockCommitMsg{
+			{Committer: 3, BlockProposer: 2},
+			{Committer: 1, BlockProposer: 4},
+		},
+	}
+	tallies := pool.getVoteTallies(5)
+	expected := []*VoteTally{
+		{Proposer: 2, ForEmpty: false, Endorsers: []uint32{1, 3}, Committers: []uint32{3}},
+		{Proposer: 2, ForEmpty: true, Endorsers: []uint32{2}, Committers: []uint32{}},
+		{Proposer: 4, ForEmpty: false, Endorsers: []uint32{}, Committers: []uint32{1}},
+	}
+	if !reflect.DeepEqual(tallies, expected) {
+		for _, tally := range tallies {
+			t.Logf("tally: %+v", tally)
+		}
+		t.Fatalf("unexpected tallies")
+	}
+}
+
+func TestViewChangeLog(t *testing.T) {
+	var l viewChangeLog
+	l.add(5, "propose_timeout")
+	l.add(5, "endorse_timeout")
+	if events := l.get(5); !reflect.DeepEqual(events, []string{"propose_timeout", "endorse_timeout"}) {
+		t.Errorf("unexpected view changes: %v", events)
+	}
+	l.add(6, "commit_timeout")
+	if events := l.get(5); len(events) != 0 {
+		t.Errorf("view changes of previous round should be pruned: %v", events)
+	}
+	if events := l.get(6); len(events) != 1 {
+		t.Errorf("unexpected view changes: %v", events)
+	}
+}
+
+func TestServerStateString(t *testing.T) {
+	if s := Synced.String(); s != "Synced" {
+		t.Errorf("unexpected state name: %s", s)
+	}
+	if s := ServerState(100).String(); s != "Unknown(100)" {
+		t.Errorf("unexpected state name: %s", s)
+	}
+}
@@ -0,0 +1,263 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+)
+
+type walRecord struct {
+	blockNum uint32
+	data     []byte
+}
+
+// consensusWAL is the write-ahead log of the consensus msgs signed by the
+// node. Every proposal, endorsement and commit is fsynced to the log before
+// it is broadcast, and the log is replayed when the node restarts, so that
+// the node never signs a vote contradicting the ones it signed before.
+// Each record is a consensus msg serialized by SerializeVbftMsg as var bytes.
+type consensusWAL struct {
+	lock    sync.Mutex
+	path    string      // The log file path
+	writer  *os.File    // The output stream, nil if the log is closed
+	records []walRecord // The records in the log file
+}
+
+func consensusWALPath() string {
+	return filepath.Join(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName,
+		config.DEFAULT_CONSENSUS_WAL)
+}
+
+//
+// openConsensusWAL loads the msgs from the log file, and opens it for appending.
+// A broken record at the end of the file, which is left by an interrupted
+// write, is dropped.
+//
+func openConsensusWAL(path string) (*consensusWAL, []ConsensusMsg, error) {
+	wal := &consensusWAL{
+		path:    path,
+		records: make([]walRecord, 0),
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("read consensus wal: %s", err)
+	}
+
+	msgs := make([]ConsensusMsg, 0)
+	source := common.NewZeroCopySource(data)
+	for source.Len() > 0 {
+		raw, _, irregular, eof := source.NextVarBytes()
+		if irregular || eof {
+			log.Warnf("consensus wal: broken record at offset %d", source.Pos())
+			break
+		}
+		msg, err := DeserializeVbftMsg(raw)
+		if err != nil {
+			log.Warnf("consensus wal: drop record: %s", err)
+			continue
+		}
+		msgs = append(msgs, msg)
+		wal.records = append(wal.records, walRecord{blockNum: msg.GetBlockNum(), data: raw})
+	}
+
+	// rewrite the log to remove the broken tail
+	if err := wal.rewriteLocked(); err != nil {
+		return nil, nil, err
+	}
+	log.Infof("consensus wal: loaded %d msgs", len(msgs))
+	return wal, msgs, nil
+}
+
+//
+// append writes the msg to the log, and returns after it is synced to disk.
+//
+func (wal *consensusWAL) append(msg ConsensusMsg) error {
+	data, err := SerializeVbftMsg(msg)
+	if err != nil {
+		return fmt.Errorf("serialize msg: %s", err)
+	}
+
+	wal.lock.Lock()
+	defer wal.lock.Unlock()
+
+	if wal.writer == nil {
+		return fmt.Errorf("consensus wal closed")
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarBytes(data)
+	if _, err := wal.writer.Write(sink.Bytes()); err != nil {
+		return fmt.Errorf("write consensus wal: %s", err)
+	}
+	if err := wal.writer.Sync(); err != nil {
+		return fmt.Errorf("sync consensus wal: %s", err)
+	}
+	wal.records = append(wal.records, walRecord{blockNum: msg.GetBlockNum(), data: data})
+	return nil
+}
+
+//
+// onBlockSealed drops the msgs of sealed blocks from the log.
+//
+func (wal *consensusWAL) onBlockSealed(blockNum uint32) error {
+	wal.lock.Lock()
+	defer wal.lock.Unlock()
+
+	if wal.writer == nil {
+		return nil
+	}
+	records := make([]walRecord, 0, len(wal.records))
+	for _, r := range wal.records {
+		if r.blockNum > blockNum {
+			records = append(records, r)
+		}
+	}
+	if len(records) == len(wal.records) {
+		return nil
+	}
+	wal.records = records
+	return wal.rewriteLocked()
+}
+
+// rewriteLocked regenerates the log file with the records, and reopens it for appending.
+func (wal *consensusWAL) rewriteLocked() error {
+	if wal.writer != nil {
+		if err := wal.writer.Close(); err != nil {
+			return fmt.Errorf("close consensus wal: %s", err)
+		}
+		wal.writer = nil
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	for _, r := range wal.records {
+		sink.WriteVarBytes(r.data)
+	}
+	if err := writeFileSync(wal.path+".new", sink.Bytes()); err != nil {
+		return fmt.Errorf("write consensus wal: %s", err)
+	}
+	if err := os.Rename(wal.path+".new", wal.path); err != nil {
+		return fmt.Errorf("rename consensus wal: %s", err)
+	}
+	writer, err := os.OpenFile(wal.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("open consensus wal: %s", err)
+	}
+	wal.writer = writer
+	return nil
+}
+
+func (wal *consensusWAL) close() error {
+	wal.lock.Lock()
+	defer wal.lock.Unlock()
+
+	if wal.writer == nil {
+		return nil
+	}
+	err := wal.writer.Close()
+	wal.writer = nil
+	return err
+}
+
+func writeFileSync(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+//
+// logSignedMsg fsyncs the consensus msg signed by the node to wal before it
+// leaves the node, and records its vote. The msg is rejected if it
+// contradicts a vote the node has signed, and is not logged again if the
+// node has signed the same vote.
+//
+func (self *Server) logSignedMsg(msg ConsensusMsg) error {
+	_, vote := getMsgVote(msg)
+	if vote == nil {
+		return nil
+	}
+	if prev := self.votePool.getVote(self.Index, vote.Type, vote.BlockNum, vote.ForEmpty); prev != nil {
+		if prev.BlockHash != vote.BlockHash {
+			return fmt.Errorf("blk %d vote type %d conflicts with signed vote for %s",
+				vote.BlockNum, vote.Type, prev.BlockHash.ToHexString())
+		}
+		// the vote has been logged when it was first signed, e.g. rebroadcast
+		return nil
+	}
+	if self.wal != nil {
+		if err := self.wal.append(msg); err != nil {
+			return err
+		}
+	}
+	self.votePool.addVote(self.Index, vote)
+	return nil
+}
+
+//
+// replayConsensusWAL restores the msgs the node signed for the blocks not
+// sealed yet, so that the node re-enters the round with its earlier votes.
+//
+func (self *Server) replayConsensusWAL(msgs []ConsensusMsg) {
+	chainedBlkNum := self.chainStore.GetChainedBlockNum()
+	for _, msg := range msgs {
+		if msg.GetBlockNum() <= chainedBlkNum {
+			continue
+		}
+		peerIdx, vote := getMsgVote(msg)
+		if vote == nil || peerIdx != self.Index {
+			continue
+		}
+		self.votePool.addVote(self.Index, vote)
+		h, _ := HashMsg(msg)
+		self.msgPool.AddMsg(msg, h)
+
+		switch pMsg := msg.(type) {
+		case *blockProposalMsg:
+			if err := self.blockPool.newBlockProposal(pMsg); err != nil {
+				log.Errorf("server %d, replay proposal of blk %d: %s", self.Index, pMsg.GetBlockNum(), err)
+			}
+		case *blockEndorseMsg:
+			self.blockPool.newBlockEndorsement(pMsg)
+		case *blockCommitMsg:
+			if err := self.blockPool.newBlockCommitment(pMsg); err != nil {
+				log.Errorf("server %d, replay commit of blk %d: %s", self.Index, pMsg.GetBlockNum(), err)
+			}
+		}
+		log.Infof("server %d, replayed signed msg type %d of blk %d", self.Index, msg.Type(), msg.GetBlockNum())
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+)
+
+func TestConsensusWAL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "consensus-wal")
+	if err != nil {
+		t.Fatalf("create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "consensus.wal")
+
+	wal, msgs, err := openConsensusWAL(path)
+	if err != nil {
+		t.Fatalf("open wal: %s", err)
+	}
+	if len(msgs) != 0 {
+		t.Fatalf("new wal should be empty")
+	}
+	for blkNum := uint32(1); blkNum <= 3; blkNum++ {
+		msg := &blockEndorseMsg{
+			Endorser:          1,
+			BlockNum:          blkNum,
+			EndorsedBlockHash: common.Uint256{byte(blkNum)},
+			VoteSig:           []byte{1},
+		}
+		if err := wal.append(msg); err != nil {
+			t.Fatalf("append msg: %s", err)
+		}
+	}
+	if err := wal.onBlockSealed(1); err != nil {
+		t.Fatalf("prune wal: %s", err)
+	}
+	if err := wal.close(); err != nil {
+		t.Fatalf("close wal: %s", err)
+	}
+
+	// interrupted write at the end of file
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("open wal file: %s", err)
+	}
+	f.Write([]byte{0xfd, 0x10})
+	f.Close()
+
+	wal, msgs, err = openConsensusWAL(path)
+	if err != nil {
+		t.Fatalf("reopen wal: %s", err)
+	}
+	defer wal.close()
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 msgs, got %d", len(msgs))
+	}
+	for i, msg := range msgs {
+		e, ok := msg.(*blockEndorseMsg)
+		if !ok || e.BlockNum != uint32(i+2) || e.EndorsedBlockHash != (common.Uint256{byte(i + 2)}) {
+			t.Errorf("unexpected msg %d: %v", i, msg)
+		}
+	}
+	if err := wal.append(&blockEndorseMsg{BlockNum: 4}); err != nil {
+		t.Errorf("append after reopen: %s", err)
+	}
+}
+
+func TestLogSignedMsg(t *testing.T) {
+	dir, err := ioutil.TempDir("", "consensus-wal")
+	if err != nil {
+		t.Fatalf("create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "consensus.wal")
+
+	wal, _, err := openConsensusWAL(path)
+	if err != nil {
+		t.Fatalf("open wal: %s", err)
+	}
+	server := &Server{Index: 1, wal: wal, votePool: newVotePool(10)}
+	endorse := &blockEndorseMsg{
+		Endorser:          1,
+		BlockNum:          1,
+		EndorsedBlockHash: common.Uint256{1},
+		VoteSig:           []byte{1},
+	}
+	if err := server.logSignedMsg(endorse); err != nil {
+		t.Fatalf("log endorse: %s", err)
+	}
+	// the rebroadcast endorsement is not logged again
+	if err := server.logSignedMsg(endorse); err != nil {
+		t.Fatalf("log rebroadcast endorse: %s", err)
+	}
+	conflict := &blockEndorseMsg{
+		Endorser:          1,
+		BlockNum:          1,
+		EndorsedBlockHash: common.Uint256{2},
+		VoteSig:           []byte{1},
+	}
+	if err := server.logSignedMsg(conflict); err == nil {
+		t.Errorf("conflicting endorse logged")
+	}
+	if err := wal.close(); err != nil {
+		t.Fatalf("close wal: %s", err)
+	}
+
+	wal, msgs, err := openConsensusWAL(path)
+	if err != nil {
+		t.Fatalf("reopen wal: %s", err)
+	}
+	defer wal.close()
+	if len(msgs) != 1 {
+		t.Errorf("expected 1 msg, got %d", len(msgs))
+	}
+}
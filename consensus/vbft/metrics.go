@@ -0,0 +1,38 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"github.com/ontio/ontology/common/metrics"
+)
+
+var (
+	roundsCounter      = metrics.NewCounter("ontology_vbft_rounds_total", "Count of consensus rounds started")
+	viewChangesCounter = metrics.NewCounterVec("ontology_vbft_view_changes_total", "Count of consensus timeouts moving the round to the next step", "event")
+)
+
+// timeout events which change the view of the current round
+var viewChangeEvents = map[TimerEventType]string{
+	EventProposeBlockTimeout:      "propose_timeout",
+	EventRandomBackoff:            "random_backoff",
+	EventPropose2ndBlockTimeout:   "propose_2nd_timeout",
+	EventEndorseBlockTimeout:      "endorse_timeout",
+	EventEndorseEmptyBlockTimeout: "endorse_empty_timeout",
+	EventCommitBlockTimeout:       "commit_timeout",
+}
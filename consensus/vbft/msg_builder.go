@@ -21,13 +21,11 @@ package vbft
 import (
 	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/consensus/vbft/config"
-	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/core/signature"
 	"github.com/ontio/ontology/core/types"
 )
@@ -162,7 +160,10 @@ func (self *Server) constructHeartbeatMsg() (*peerHeartbeatMsg, error) {
 	bookkeepers := make([][]byte, 0)
 	endorsePks := block.Block.Header.Bookkeepers
 	sigData := block.Block.Header.SigData
-	if len(endorsePks) == len(sigData) {
+	if self.blsSigActive(blkNum) && len(endorsePks) == 0 {
+		// endorsers are in the signer bitmap of the bls sig
+		sigData = make([][]byte, 0)
+	} else if len(endorsePks) == len(sigData) {
 		for i := 0; i < len(endorsePks); i++ {
 			bookkeepers = append(bookkeepers, keypair.SerializePublicKey(endorsePks[i]))
 		}
@@ -195,7 +196,7 @@ func (self *Server) constructBlock(blkNum uint32, prevBlkHash common.Uint256, tx
 	}
 
 	txRoot := common.ComputeMerkleRoot(txHash)
-	blockRoot := ledger.DefLedger.GetBlockRootWithNewTxRoots(lastBlock.Block.Header.Height, []common.Uint256{lastBlock.Block.Header.TransactionsRoot, txRoot})
+	blockRoot := self.ledger.GetBlockRootWithNewTxRoots(lastBlock.Block.Header.Height, []common.Uint256{lastBlock.Block.Header.TransactionsRoot, txRoot})
 
 	blkHeader := &types.Header{
 		PrevBlockHash:    prevBlkHash,
@@ -227,7 +228,7 @@ func (self *Server) constructProposalMsg(blkNum uint32, sysTxs, userTxs []*types
 	if prevBlk == nil {
 		return nil, fmt.Errorf("failed to get prevBlock (%d)", blkNum-1)
 	}
-	blocktimestamp := uint32(time.Now().Unix())
+	blocktimestamp := uint32(self.clock.Now().Unix())
 	if prevBlk.Block.Header.Timestamp >= blocktimestamp {
 		blocktimestamp = prevBlk.Block.Header.Timestamp + 1
 	}
@@ -269,12 +270,19 @@ func (self *Server) constructProposalMsg(blkNum uint32, sysTxs, userTxs []*types
 		return nil, fmt.Errorf("failed to GetExecMerkleRoot: %s,blkNum:%d", err, (blkNum - 1))
 	}
 
+	voteSig, err := self.signVote(vconfig.VOTE_PROPOSAL, blkNum, false, blk.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign proposal vote: %s", err)
+	}
+
 	msg := &blockProposalMsg{
 		Block: &Block{
 			Block:               blk,
 			EmptyBlock:          emptyBlk,
 			Info:                vbftBlkInfo,
 			PrevBlockMerkleRoot: merkleRoot,
+			VoteView:            self.config.View,
+			VoteSig:             voteSig,
 		},
 	}
 
@@ -305,6 +313,14 @@ func (self *Server) constructEndorseMsg(proposal *blockProposalMsg, forEmpty boo
 	if err != nil {
 		return nil, fmt.Errorf("endorser failed to sign block. hash:%x, err: %s", blkHash, err)
 	}
+	voteSig, err := self.signVote(vconfig.VOTE_ENDORSE, proposal.Block.getBlockNum(), forEmpty, blkHash)
+	if err != nil {
+		return nil, fmt.Errorf("endorser failed to sign vote. hash:%x, err: %s", blkHash, err)
+	}
+	blsSig, err := self.signBls(proposal.Block.getBlockNum(), blkHash)
+	if err != nil {
+		return nil, fmt.Errorf("endorser failed to sign block with bls. hash:%x, err: %s", blkHash, err)
+	}
 
 	msg := &blockEndorseMsg{
 		Endorser:          self.Index,
@@ -314,6 +330,9 @@ func (self *Server) constructEndorseMsg(proposal *blockProposalMsg, forEmpty boo
 		EndorseForEmpty:   forEmpty,
 		ProposerSig:       proposerSig,
 		EndorserSig:       endorserSig,
+		EndorserBlsSig:    blsSig,
+		VoteView:          self.config.View,
+		VoteSig:           voteSig,
 	}
 
 	return msg, nil
@@ -343,10 +362,25 @@ func (self *Server) constructCommitMsg(proposal *blockProposalMsg, endorses []*b
 	if err != nil {
 		return nil, fmt.Errorf("endorser failed to sign block. hash:%x, caused by: %s", blkHash, err)
 	}
+	voteSig, err := self.signVote(vconfig.VOTE_COMMIT, proposal.Block.getBlockNum(), forEmpty, blkHash)
+	if err != nil {
+		return nil, fmt.Errorf("committer failed to sign vote. hash:%x, caused by: %s", blkHash, err)
+	}
+	blsSig, err := self.signBls(proposal.Block.getBlockNum(), blkHash)
+	if err != nil {
+		return nil, fmt.Errorf("committer failed to sign block with bls. hash:%x, caused by: %s", blkHash, err)
+	}
 
 	endorsersSig := make(map[uint32][]byte)
+	var endorsersBlsSig map[uint32][]byte
 	for _, e := range endorses {
 		endorsersSig[e.Endorser] = e.EndorserSig
+		if len(e.EndorserBlsSig) > 0 {
+			if endorsersBlsSig == nil {
+				endorsersBlsSig = make(map[uint32][]byte)
+			}
+			endorsersBlsSig[e.Endorser] = e.EndorserBlsSig
+		}
 	}
 
 	msg := &blockCommitMsg{
@@ -357,7 +391,11 @@ func (self *Server) constructCommitMsg(proposal *blockProposalMsg, endorses []*b
 		CommitForEmpty:  forEmpty,
 		ProposerSig:     proposerSig,
 		EndorsersSig:    endorsersSig,
+		EndorsersBlsSig: endorsersBlsSig,
 		CommitterSig:    committerSig,
+		CommitterBlsSig: blsSig,
+		VoteView:        self.config.View,
+		VoteSig:         voteSig,
 	}
 
 	return msg, nil
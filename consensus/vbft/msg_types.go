@@ -135,6 +135,9 @@ type blockEndorseMsg struct {
 	FaultyProposals   []*FaultyReport `json:"faulty_proposals"`
 	ProposerSig       []byte          `json:"proposer_sig"`
 	EndorserSig       []byte          `json:"endorser_sig"`
+	EndorserBlsSig    []byte          `json:"endorser_bls_sig,omitempty"`
+	VoteView          uint32          `json:"vote_view,omitempty"`
+	VoteSig           []byte          `json:"vote_sig,omitempty"`
 }
 
 func (msg *blockEndorseMsg) Type() MsgType {
@@ -170,7 +173,11 @@ type blockCommitMsg struct {
 	FaultyVerifies  []*FaultyReport   `json:"faulty_verifies"`
 	ProposerSig     []byte            `json:"proposer_sig"`
 	EndorsersSig    map[uint32][]byte `json:"endorsers_sig"`
+	EndorsersBlsSig map[uint32][]byte `json:"endorsers_bls_sig,omitempty"`
 	CommitterSig    []byte            `json:"committer_sig"`
+	CommitterBlsSig []byte            `json:"committer_bls_sig,omitempty"`
+	VoteView        uint32            `json:"vote_view,omitempty"`
+	VoteSig         []byte            `json:"vote_sig,omitempty"`
 }
 
 func (msg *blockCommitMsg) Type() MsgType {
@@ -271,6 +278,7 @@ type BlockInfo_ struct {
 	BlockNum   uint32            `json:"block_num"`
 	Proposer   uint32            `json:"proposer"`
 	Signatures map[uint32][]byte `json:"signatures"`
+	BlockHash  common.Uint256    `json:"block_hash"`
 }
 
 // to fetch committed block from neighbours
@@ -25,7 +25,6 @@ import (
 
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/log"
-	"github.com/ontio/ontology/core/ledger"
 )
 
 type SyncCheckReq struct {
@@ -150,11 +149,14 @@ func (self *Syncer) run() {
 
 		case syncMsg := <-self.syncMsgC:
 			if p, present := self.peers[syncMsg.fromPeer]; present {
+				var msg ConsensusMsg
 				if p.active {
-					p.msgC <- syncMsg.msg
-				} else {
-					// report err
-					p.msgC <- nil
+					msg = syncMsg.msg
+				}
+				// nil reports err, and drop the msg if the peer syncer is not waiting for it
+				select {
+				case p.msgC <- msg:
+				default:
 				}
 			} else {
 				// report error
@@ -179,7 +181,7 @@ func (self *Syncer) run() {
 			for self.nextReqBlkNum <= self.targetBlkNum {
 				// FIXME: compete with ledger syncing
 				var blk *Block
-				if self.nextReqBlkNum <= ledger.DefLedger.GetCurrentBlockHeight() {
+				if self.nextReqBlkNum <= self.server.ledger.GetCurrentBlockHeight() {
 					blk, _ = self.server.chainStore.GetBlock(self.nextReqBlkNum)
 				}
 				if blk == nil {
@@ -239,19 +241,14 @@ func (self *Syncer) run() {
 }
 
 func (self *Syncer) blockConsensusDone(blks BlockFromPeers) *Block {
-	// TODO: also check blockhash
-	proposers := make(map[uint32]int)
+	// count by blockhash, an equivocating proposer may have sealed more than one block
+	hashes := make(map[common.Uint256]int)
 	for _, blk := range blks {
-		proposers[blk.getProposer()] += 1
+		hashes[blk.Block.Hash()] += 1
 	}
-	for proposerId, cnt := range proposers {
-		if cnt > int(self.server.config.C) {
-			// find the block
-			for _, blk := range blks {
-				if blk.getProposer() == proposerId {
-					return blk
-				}
-			}
+	for _, blk := range blks {
+		if hashes[blk.Block.Hash()] > int(self.server.config.C) {
+			return blk
 		}
 	}
 	return nil
@@ -374,15 +371,17 @@ func (self *PeerSyncer) run() {
 	log.Infof("server %d, syncer %d started, start %d, target %d",
 		self.server.Index, self.peerIdx, self.nextReqBlkNum, self.targetBlkNum)
 
-	errQuit := true
 	defer func() {
 		log.Infof("server %d, syncer %d quit, start %d, target %d",
 			self.server.Index, self.peerIdx, self.nextReqBlkNum, self.targetBlkNum)
-		self.stop(errQuit)
+		// the target may have been raised after the last check, still mark the
+		// syncer inactive as it quits, so that it can be restarted
+		self.stop(true)
 	}()
 
 	var err error
 	blkProposers := make(map[uint32]uint32)
+	blkHashes := make(map[uint32]common.Uint256)
 	for self.nextReqBlkNum <= self.targetBlkNum {
 		blkNum := self.nextReqBlkNum
 		if _, present := blkProposers[blkNum]; !present {
@@ -394,6 +393,7 @@ func (self *PeerSyncer) run() {
 			}
 			for _, p := range blkInfos {
 				blkProposers[p.BlockNum] = p.Proposer
+				blkHashes[p.BlockNum] = p.BlockHash
 			}
 		}
 		if _, present := blkProposers[blkNum]; !present {
@@ -408,7 +408,8 @@ func (self *PeerSyncer) run() {
 			if !ok {
 				panic("")
 			}
-			if m.Block.getProposer() == blkProposers[blkNum] {
+			// an equivocating proposer may have sent us another block than the sealed one
+			if m.Block.getProposer() == blkProposers[blkNum] && m.Block.Block.Hash() == blkHashes[blkNum] {
 				proposalBlock = m.Block
 				break
 			}
@@ -426,8 +427,8 @@ func (self *PeerSyncer) run() {
 				blkNum, self.peerIdx, err)
 		}
 		delete(blkProposers, blkNum)
+		delete(blkHashes, blkNum)
 	}
-	errQuit = false
 }
 
 func (self *PeerSyncer) stop(force bool) bool {
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"math"
 
+	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/log"
 	vconfig "github.com/ontio/ontology/consensus/vbft/config"
 	"github.com/ontio/ontology/core/signature"
@@ -38,6 +39,17 @@ func (self *Server) GetCommittedBlockNo() uint32 {
 	return self.chainStore.GetChainedBlockNum()
 }
 
+// GetLeaderProposer returns the leader proposer of current block
+func (self *Server) GetLeaderProposer() (uint32, bool) {
+	self.metaLock.RLock()
+	defer self.metaLock.RUnlock()
+
+	if self.currentParticipantConfig == nil || len(self.currentParticipantConfig.Proposers) == 0 {
+		return 0, false
+	}
+	return self.currentParticipantConfig.Proposers[0], true
+}
+
 func (self *Server) isPeerAlive(peerIdx uint32, blockNum uint32) bool {
 
 	// TODO
@@ -359,6 +371,45 @@ func (self *Server) findBlockProposal(blkNum uint32, proposer uint32, forEmpty b
 	return nil
 }
 
+//
+// hasCommitQuorum checks if the proposal has been signed by a quorum of peers in
+// the endorse and commit msgs on its block hash. Commit consensus is counted by
+// proposer, the conflicting blocks of an equivocating proposer must not both be
+// sealed with it.
+//
+func (self *Server) hasCommitQuorum(proposal *blockProposalMsg, forEmpty bool) bool {
+	blkNum := proposal.GetBlockNum()
+	var blkHash common.Uint256
+	if !forEmpty {
+		blkHash = proposal.Block.Block.Hash()
+	} else {
+		if proposal.Block.EmptyBlock == nil {
+			return false
+		}
+		blkHash = proposal.Block.EmptyBlock.Hash()
+	}
+
+	signers := map[uint32]bool{proposal.Block.getProposer(): true}
+	for _, msg := range self.msgPool.GetEndorsementsMsgs(blkNum) {
+		if e := msg.(*blockEndorseMsg); e != nil {
+			if bytes.Compare(blkHash[:], e.EndorsedBlockHash[:]) == 0 && e.EndorseForEmpty == forEmpty {
+				signers[e.Endorser] = true
+			}
+		}
+	}
+	for _, msg := range self.msgPool.GetCommitMsgs(blkNum) {
+		if c := msg.(*blockCommitMsg); c != nil {
+			if bytes.Compare(blkHash[:], c.CommitBlockHash[:]) == 0 && c.CommitForEmpty == forEmpty {
+				signers[c.Committer] = true
+				for endorser := range c.EndorsersSig {
+					signers[endorser] = true
+				}
+			}
+		}
+	}
+	return uint32(len(signers)) >= self.config.N-(self.config.N-1)/3
+}
+
 func (self *Server) validateTxsInProposal(proposal *blockProposalMsg) error {
 	// TODO: add VBFT specific verifications
 	return nil
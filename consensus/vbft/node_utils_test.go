@@ -68,6 +68,7 @@ func constructServer() *Server {
 		config:                   chainconfig,
 		chainStore:               chainstore,
 		currentParticipantConfig: blockparticipantconfig,
+		clock:                    systemClock{},
 	}
 	return server
 }
@@ -20,11 +20,14 @@ package vbft
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/consensus/vbft/config"
+	"github.com/ontio/ontology/core/signature/bls"
 )
 
 type Peer struct {
@@ -47,6 +50,7 @@ type PeerPool struct {
 
 	peers                  map[uint32]*Peer
 	peerConnectionWaitings map[uint32]chan struct{}
+	blsPubKeys             map[uint32]*bls.PublicKey
 }
 
 func NewPeerPool(maxSize int, server *Server) *PeerPool {
@@ -69,6 +73,7 @@ func (pool *PeerPool) clean() {
 	pool.IDMap = make(map[string]uint32)
 	pool.P2pMap = make(map[uint32]uint64)
 	pool.peers = make(map[uint32]*Peer)
+	pool.blsPubKeys = make(map[uint32]*bls.PublicKey)
 }
 
 // FIXME: should rename to isPeerConnected
@@ -102,6 +107,33 @@ func (pool *PeerPool) addPeer(config *vconfig.PeerConfig) error {
 	return nil
 }
 
+//
+// update bls pubkeys of consensus peers with chain config
+//
+func (pool *PeerPool) updateBlsPubKeys(peers []*vconfig.PeerConfig) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	pool.blsPubKeys = make(map[uint32]*bls.PublicKey)
+	for _, p := range peers {
+		pk, err := p.GetBlsPubKey()
+		if err != nil {
+			log.Errorf("failed to parse bls pubkey of peer %d: %s", p.Index, err)
+			continue
+		}
+		if pk != nil {
+			pool.blsPubKeys[p.Index] = pk
+		}
+	}
+}
+
+func (pool *PeerPool) getBlsPubKey(peerIdx uint32) *bls.PublicKey {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	return pool.blsPubKeys[peerIdx]
+}
+
 func (pool *PeerPool) getActivePeerCount() int {
 	pool.lock.RLock()
 	defer pool.lock.RUnlock()
@@ -155,15 +187,16 @@ func (pool *PeerPool) peerDisconnected(peerIdx uint32) {
 	pool.lock.Lock()
 	defer pool.lock.Unlock()
 
-	var lastUpdateTime time.Time
-	if p, present := pool.peers[peerIdx]; present {
-		lastUpdateTime = p.LastUpdateTime
+	// peers are removed from the pool when the server stops
+	p, present := pool.peers[peerIdx]
+	if !present {
+		return
 	}
 
 	pool.peers[peerIdx] = &Peer{
 		Index:          peerIdx,
-		PubKey:         pool.peers[peerIdx].PubKey,
-		LastUpdateTime: lastUpdateTime,
+		PubKey:         p.PubKey,
+		LastUpdateTime: p.LastUpdateTime,
 		connected:      false,
 	}
 }
@@ -284,3 +317,27 @@ func (pool *PeerPool) RemovePeerIndex(nodeId string) {
 
 	delete(pool.IDMap, nodeId)
 }
+
+func (pool *PeerPool) getPeersStatus() []*PeerStatus {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	peers := make([]*PeerStatus, 0, len(pool.configs))
+	for idx, cfg := range pool.configs {
+		status := &PeerStatus{
+			Index: idx,
+			ID:    cfg.ID,
+		}
+		if p := pool.peers[idx]; p != nil {
+			status.Connected = p.connected
+			if p.LatestInfo != nil {
+				status.CommittedBlockNum = p.LatestInfo.CommittedBlockNumber
+				status.ChainConfigView = p.LatestInfo.ChainConfigView
+				status.LastHeartbeat = p.LastUpdateTime.Unix()
+			}
+		}
+		peers = append(peers, status)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Index < peers[j].Index })
+	return peers
+}
@@ -22,8 +22,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/common"
 	vconfig "github.com/ontio/ontology/consensus/vbft/config"
+	p2pmsg "github.com/ontio/ontology/p2pserver/message/types"
 )
 
 func constructPeerPool(connect bool) *PeerPool {
@@ -135,3 +137,26 @@ func TestGetPeer(t *testing.T) {
 	}
 	t.Logf("TestGetPeer: %v", peer.Index)
 }
+
+func TestBindP2pId(t *testing.T) {
+	acc := account.NewAccount("")
+	server := &Server{msgRecvC: map[uint32]chan *p2pMsgPayload{1: make(chan *p2pMsgPayload, 4)}}
+	server.peerPool = NewPeerPool(3, server)
+	server.peerPool.addPeer(&vconfig.PeerConfig{Index: 1, ID: vconfig.PubkeyID(acc.PublicKey)})
+
+	// the id claimed on a plaintext link is not bound
+	server.NewConsensusPayload(&p2pmsg.ConsensusPayload{Owner: acc.PublicKey, PeerId: 100})
+	if _, present := server.peerPool.getP2pId(1); present {
+		t.Errorf("p2p id bound without authenticated link")
+	}
+	// nor the id of a link authenticated with other key
+	other := account.NewAccount("")
+	server.NewConsensusPayload(&p2pmsg.ConsensusPayload{Owner: acc.PublicKey, PeerId: 100, PeerPubKey: other.PublicKey})
+	if _, present := server.peerPool.getP2pId(1); present {
+		t.Errorf("p2p id bound with link of other key")
+	}
+	server.NewConsensusPayload(&p2pmsg.ConsensusPayload{Owner: acc.PublicKey, PeerId: 100, PeerPubKey: acc.PublicKey})
+	if p2pid, _ := server.peerPool.getP2pId(1); p2pid != 100 {
+		t.Errorf("p2p id of authenticated link not bound: %d", p2pid)
+	}
+}
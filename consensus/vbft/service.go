@@ -36,6 +36,7 @@ import (
 	"github.com/ontio/ontology/consensus/vbft/config"
 	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/signature/bls"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/core/utils"
 	"github.com/ontio/ontology/events"
@@ -94,6 +95,9 @@ type Server struct {
 	ledger        *ledger.Ledger
 	incrValidator *increment.IncrementValidator
 	pid           *actor.PID
+	clock         Clock
+	walPath       string
+	blsKey        *bls.PrivateKey
 
 	// some config
 	msgHistoryDuration uint32
@@ -111,14 +115,18 @@ type Server struct {
 	config                   *vconfig.ChainConfig
 	currentParticipantConfig *BlockParticipantConfig
 
-	chainStore *ChainStore // block store
-	msgPool    *MsgPool    // consensus msg pool
-	blockPool  *BlockPool  // received block proposals
-	peerPool   *PeerPool   // consensus peers
+	chainStore *ChainStore   // block store
+	msgPool    *MsgPool      // consensus msg pool
+	blockPool  *BlockPool    // received block proposals
+	peerPool   *PeerPool     // consensus peers
+	votePool   *VotePool     // signed votes of peers
+	wal        *consensusWAL // consensus msgs signed by self
 	syncer     *Syncer
 	stateMgr   *StateMgr
 	timer      *EventTimer
 
+	viewChanges viewChangeLog // timeouts of the in-flight round
+
 	msgRecvC   map[uint32]chan *p2pMsgPayload
 	msgC       chan ConsensusMsg
 	bftActionC chan *BftAction
@@ -129,7 +137,24 @@ type Server struct {
 	quitWg     sync.WaitGroup
 }
 
+// ServerEnv replaces the dependencies of the server on the node, so that
+// several servers can run in one process, as in simulation.
+type ServerEnv struct {
+	Ledger  *ledger.Ledger // ledger of the server
+	Clock   Clock          // source of time of the consensus timers
+	WALPath string         // path of the consensus wal
+}
+
 func NewVbftServer(account *account.Account, txpool, p2p *actor.PID) (*Server, error) {
+	return NewVbftServerWithEnv(account, txpool, p2p, nil)
+}
+
+//
+// NewVbftServerWithEnv creates the server with its own ledger, clock and wal
+// path, the default ledger, system clock and wal path under data dir are used if env is nil.
+// The server actor is not registered with the name of consensus service if env is set.
+//
+func NewVbftServerWithEnv(account *account.Account, txpool, p2p *actor.PID, env *ServerEnv) (*Server, error) {
 	server := &Server{
 		msgHistoryDuration: 64,
 		account:            account,
@@ -137,14 +162,30 @@ func NewVbftServer(account *account.Account, txpool, p2p *actor.PID) (*Server, e
 		p2p:                &actorTypes.P2PActor{P2P: p2p},
 		ledger:             ledger.DefLedger,
 		incrValidator:      increment.NewIncrementValidator(20),
+		clock:              systemClock{},
+		walPath:            consensusWALPath(),
+	}
+	if env != nil {
+		server.ledger = env.Ledger
+		server.walPath = env.WALPath
+		if env.Clock != nil {
+			server.clock = env.Clock
+		}
 	}
 	server.stateMgr = newStateMgr(server)
+	server.initBlsKey()
 
 	props := actor.FromProducer(func() actor.Actor {
 		return server
 	})
 
-	pid, err := actor.SpawnNamed(props, "consensus_vbft")
+	var pid *actor.PID
+	var err error
+	if env == nil {
+		pid, err = actor.SpawnNamed(props, "consensus_vbft")
+	} else {
+		pid = actor.Spawn(props)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -183,6 +224,8 @@ func (self *Server) Receive(context actor.Context) {
 		self.handleBlockPersistCompleted(msg.Block)
 	case *p2pmsg.ConsensusPayload:
 		self.NewConsensusPayload(msg)
+	case *actorTypes.GetConsensusStatusReq:
+		context.Sender().Request(&actorTypes.GetConsensusStatusRsp{Status: self.getConsensusStatus()}, context.Self())
 
 	default:
 		log.Info("vbft actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
@@ -259,8 +302,11 @@ func (self *Server) NewConsensusPayload(payload *p2pmsg.ConsensusPayload) {
 	if self.peerPool.isNewPeer(peerIdx) {
 		self.peerPool.peerConnected(peerIdx)
 	}
+	// only bind the p2p id of the peer which authenticated with the consensus key
+	// on the link, relayed messages and plaintext links can claim any id
 	p2pid, present := self.peerPool.getP2pId(peerIdx)
-	if !present || p2pid != payload.PeerId {
+	if (!present || p2pid != payload.PeerId) && payload.PeerPubKey != nil &&
+		keypair.ComparePublicKey(payload.PeerPubKey, payload.Owner) {
 		self.peerPool.addP2pId(peerIdx, payload.PeerId)
 	}
 
@@ -403,6 +449,7 @@ func (self *Server) updateChainConfig() error {
 			log.Infof("updateChainConfig add peer index:%v,id:%v", p.ID, p.Index)
 		}
 	}
+	self.peerPool.updateBlsPubKeys(self.config.Peers)
 	for index, peer := range self.peerPool.peers {
 		_, present := peermap[index]
 		if !present {
@@ -443,6 +490,7 @@ func (self *Server) initialize() error {
 		return fmt.Errorf("init blockpool: %s", err)
 	}
 	self.msgPool = newMsgPool(self, self.msgHistoryDuration)
+	self.votePool = newVotePool(self.msgHistoryDuration)
 	self.peerPool = NewPeerPool(0, self) // FIXME: maxSize
 	self.timer = NewEventTimer(self)
 	self.syncer = newSyncer(self)
@@ -473,6 +521,7 @@ func (self *Server) initialize() error {
 		}
 		log.Infof("added peer: %s", p.ID)
 	}
+	self.peerPool.updateBlsPubKeys(self.config.Peers)
 
 	//index equal math.MaxUint32  is noconsensus node
 	id := vconfig.PubkeyID(self.account.PublicKey)
@@ -482,7 +531,19 @@ func (self *Server) initialize() error {
 	} else {
 		self.Index = math.MaxUint32
 	}
-	self.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+
+	wal, msgs, err := openConsensusWAL(self.walPath)
+	if err != nil {
+		log.Errorf("failed to open consensus wal: %s", err)
+		return fmt.Errorf("failed to open consensus wal: %s", err)
+	}
+	self.wal = wal
+	self.replayConsensusWAL(msgs)
+
+	// no event hub for the servers in simulation, ledger events are not published
+	if self.sub.EvtHub != nil {
+		self.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	}
 	go self.syncer.run()
 	go self.stateMgr.run()
 	go self.msgSendLoop()
@@ -544,7 +605,9 @@ func (self *Server) start() error {
 func (self *Server) stop() {
 
 	self.incrValidator.Clean()
-	self.sub.Unsubscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	if self.sub.EvtHub != nil {
+		self.sub.Unsubscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	}
 	// stop syncer, statemgr, msgSendLoop, timer, actionLoop, msgProcessingLoop
 	self.quit = true
 	close(self.quitC)
@@ -553,8 +616,14 @@ func (self *Server) stop() {
 	self.syncer.stop()
 	self.timer.stop()
 	self.msgPool.clean()
+	self.votePool.clean()
 	self.blockPool.clean()
 	self.chainStore.close()
+	if self.wal != nil {
+		if err := self.wal.close(); err != nil {
+			log.Errorf("server %d failed to close consensus wal: %s", self.Index, err)
+		}
+	}
 	self.peerPool.clean()
 }
 
@@ -664,6 +733,7 @@ func (self *Server) updateParticipantConfig() error {
 
 func (self *Server) startNewRound() error {
 	blkNum := self.GetCurrentBlockNo()
+	roundsCounter.Inc()
 
 	if err := self.updateParticipantConfig(); err != nil {
 		log.Errorf("startNewRound error:%s", err)
@@ -772,6 +842,9 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		log.Debugf("dup msg with msg type %d from %d", msg.Type(), peerIdx)
 		return
 	}
+	if !self.checkEquivocation(msg) {
+		return
+	}
 
 	switch msg.Type() {
 	case BlockProposalMessage:
@@ -1027,13 +1100,14 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		blkInfos := make([]*BlockInfo_, 0)
 		targetBlkNum := self.GetCommittedBlockNo()
 		for startBlkNum := pMsg.StartBlockNum; startBlkNum <= targetBlkNum; startBlkNum++ {
-			blk, _ := self.blockPool.getSealedBlock(startBlkNum)
+			blk, blkHash := self.blockPool.getSealedBlock(startBlkNum)
 			if blk == nil {
 				break
 			}
 			blkInfos = append(blkInfos, &BlockInfo_{
-				BlockNum: startBlkNum,
-				Proposer: blk.getProposer(),
+				BlockNum:  startBlkNum,
+				Proposer:  blk.getProposer(),
+				BlockHash: blkHash,
 			})
 			if len(blkInfos) >= maxCnt {
 				break
@@ -1120,7 +1194,7 @@ func (self *Server) processProposalMsg(msg *blockProposalMsg) {
 
 	prevBlockTimestamp := blk.Block.Header.Timestamp
 	currentBlockTimestamp := msg.Block.Block.Header.Timestamp
-	if currentBlockTimestamp <= prevBlockTimestamp || currentBlockTimestamp > uint32(time.Now().Add(time.Minute*10).Unix()) {
+	if currentBlockTimestamp <= prevBlockTimestamp || currentBlockTimestamp > uint32(self.clock.Now().Add(time.Minute*10).Unix()) {
 		log.Errorf("BlockPrposalMessage check  blocknum:%d,prevBlockTimestamp:%d,currentBlockTimestamp:%d", msg.GetBlockNum(), prevBlockTimestamp, currentBlockTimestamp)
 		self.msgPool.DropMsg(msg)
 		return
@@ -1155,7 +1229,7 @@ func (self *Server) processProposalMsg(msg *blockProposalMsg) {
 		}
 		// start new routine to verify txs in proposal block
 		go func() {
-			if err := self.poolActor.VerifyBlock(txs, validHeight); err != nil && err != actor.ErrTimeout {
+			if err := self.poolActor.VerifyBlock(txs, validHeight, uint32(msgBlkNum)); err != nil && err != actor.ErrTimeout {
 				log.Errorf("server %d verify proposal blk from %d failed, blk %d, txs %d, err: %s",
 					self.Index, msg.Block.getProposer(), msgBlkNum, len(txs), err)
 				return
@@ -1496,6 +1570,13 @@ func (self *Server) actionLoop() {
 						break
 					}
 
+					if !self.hasCommitQuorum(proposal, forEmpty) {
+						log.Infof("server %d fastforward stopped at blk %d, no commit quorum on proposal from %d",
+							self.Index, blkNum, proposer)
+						self.restartSyncing()
+						break
+					}
+
 					log.Infof("server %d fastforwarding block %d, proposer %d",
 						self.Index, blkNum, proposal.Block.getProposer())
 
@@ -1556,7 +1637,12 @@ func (self *Server) actionLoop() {
 				} else if proposal, forEmpty := self.blockPool.getEndorsedProposal(blkNum); proposal != nil {
 					// construct endorse msg
 					if endorseMsg, _ := self.constructEndorseMsg(proposal, forEmpty); endorseMsg != nil {
-						self.broadcast(endorseMsg)
+						if err := self.logSignedMsg(endorseMsg); err != nil {
+							log.Errorf("server %d rebroadcasting failed to log endorse (%d): %s",
+								self.Index, blkNum, err)
+						} else {
+							self.broadcast(endorseMsg)
+						}
 					}
 				}
 				if self.isCommitter(blkNum, self.Index) {
@@ -1638,6 +1724,10 @@ func (self *Server) timerLoop() {
 }
 
 func (self *Server) processTimerEvent(evt *TimerEvent) error {
+	if tag, present := viewChangeEvents[evt.evtType]; present {
+		viewChangesCounter.WithLabel(tag).Inc()
+		self.viewChanges.add(evt.blockNum, tag)
+	}
 	switch evt.evtType {
 	case EventProposalBackoff:
 		// 1. if endorsed, return
@@ -1910,6 +2000,9 @@ func (self *Server) endorseBlock(proposal *blockProposalMsg, forEmpty bool) erro
 	if err != nil {
 		return fmt.Errorf("failed to construct endorse msg: %s", err)
 	}
+	if err := self.logSignedMsg(endorseMsg); err != nil {
+		return fmt.Errorf("failed to log endorse msg: %s", err)
+	}
 
 	// set the block as self-endorsed-block
 	if err := self.blockPool.setProposalEndorsed(proposal, forEmpty); err != nil {
@@ -1978,6 +2071,9 @@ func (self *Server) commitBlock(proposal *blockProposalMsg, forEmpty bool) error
 	if err != nil {
 		return fmt.Errorf("failed to construct commit msg: %s", err)
 	}
+	if err := self.logSignedMsg(commitMsg); err != nil {
+		return fmt.Errorf("failed to log commit msg: %s", err)
+	}
 
 	// set the block as committed-block
 	if err := self.blockPool.setProposalCommitted(proposal, forEmpty); err != nil {
@@ -2066,6 +2162,12 @@ func (self *Server) sealBlock(block *Block, empty bool, sigdata bool) error {
 	// notify other modules that block sealed
 	self.timer.onBlockSealed(sealedBlkNum)
 	self.msgPool.onBlockSealed(sealedBlkNum)
+	self.votePool.onBlockSealed(sealedBlkNum)
+	if self.wal != nil {
+		if err := self.wal.onBlockSealed(sealedBlkNum); err != nil {
+			log.Errorf("server %d, failed to prune consensus wal: %s", self.Index, err)
+		}
+	}
 	self.blockPool.onBlockSealed(sealedBlkNum)
 
 	_, h := self.blockPool.getSealedBlock(sealedBlkNum)
@@ -2143,7 +2245,7 @@ func (self *Server) checkNeedUpdateChainConfig(blockNum uint32) bool {
 
 //checkUpdateChainConfig query leveldb check is force update
 func (self *Server) checkUpdateChainConfig(blkNum uint32) bool {
-	force, err := isUpdate(self.chainStore.GetExecWriteSet(blkNum-1), self.config.View)
+	force, err := isUpdate(self.chainStore.GetExecWriteSet(blkNum-1), self.ledger, self.config.View)
 	if err != nil {
 		log.Errorf("checkUpdateChainConfig err:%s", err)
 		return false
@@ -2183,6 +2285,13 @@ func (self *Server) makeProposal(blkNum uint32, forEmpty bool) error {
 		return fmt.Errorf("server %d ignore deprecatd blk proposal %d, current %d",
 			self.Index, blkNum, self.GetCurrentBlockNo())
 	}
+	// signing two proposals for one round is equivocation
+	for _, m := range self.msgPool.GetProposalMsgs(blkNum) {
+		if p, ok := m.(*blockProposalMsg); ok && p.Block.getProposer() == self.Index {
+			log.Infof("server %d has proposed blk %d", self.Index, blkNum)
+			return nil
+		}
+	}
 
 	validHeight := self.validHeight(blkNum)
 	sysTxs := make([]*types.Transaction, 0)
@@ -2192,7 +2301,7 @@ func (self *Server) makeProposal(blkNum uint32, forEmpty bool) error {
 	cfg := &vconfig.ChainConfig{}
 	cfg = nil
 	if self.checkNeedUpdateChainConfig(blkNum) || self.checkUpdateChainConfig(blkNum) {
-		chainconfig, err := getChainConfig(self.chainStore.GetExecWriteSet(blkNum-1), blkNum)
+		chainconfig, err := getChainConfig(self.chainStore.GetExecWriteSet(blkNum-1), self.ledger, blkNum)
 		if err != nil {
 			return fmt.Errorf("getChainConfig failed:%s", err)
 		}
@@ -2223,6 +2332,9 @@ func (self *Server) makeProposal(blkNum uint32, forEmpty bool) error {
 	if err != nil {
 		return fmt.Errorf("failed to construct proposal: %s", err)
 	}
+	if err := self.logSignedMsg(proposal); err != nil {
+		return fmt.Errorf("failed to log proposal: %s", err)
+	}
 
 	log.Infof("server %d make proposal for block %d", self.Index, blkNum)
 
@@ -2249,6 +2361,11 @@ func (self *Server) makeSealed(proposal *blockProposalMsg, forEmpty bool) error
 		self.restartSyncing()
 		return fmt.Errorf("verify prev block hash failed: %s", err)
 	}
+	if !self.hasCommitQuorum(proposal, forEmpty) {
+		// the consensused block of the proposer is not ours, resync it from peers
+		self.restartSyncing()
+		return fmt.Errorf("no commit quorum on block %d from proposer %d", blkNum, proposal.Block.getProposer())
+	}
 
 	log.Infof("server %d ready to seal block %d, for proposer %d, empty: %t",
 		self.Index, blkNum, proposal.Block.getProposer(), forEmpty)
@@ -0,0 +1,173 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sim
+
+import (
+	"container/heap"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ontio/ontology/consensus/vbft"
+)
+
+// VirtualClock is a clock only moved forward by Advance. The timers due
+// are fired in the order of their deadlines, in the goroutine calling Advance.
+type VirtualClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	seq    uint64
+	timers timerHeap
+}
+
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+func (c *VirtualClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *VirtualClock) AfterFunc(d time.Duration, f func()) vbft.Timer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := &virtualTimer{clock: c, f: f, index: -1}
+	c.scheduleLocked(t, d)
+	return t
+}
+
+// Advance moves the clock forward by d, and fires the timers due in the meantime.
+// It returns the number of timers fired.
+func (c *VirtualClock) Advance(d time.Duration) int {
+	c.lock.Lock()
+	end := c.now.Add(d)
+	fired := 0
+	for len(c.timers) > 0 && !c.timers[0].when.After(end) {
+		t := heap.Pop(&c.timers).(*virtualTimer)
+		c.now = t.when
+		fired++
+		// timers may be started or reset by the callback
+		c.lock.Unlock()
+		t.f()
+		c.lock.Lock()
+	}
+	c.now = end
+	c.lock.Unlock()
+	return fired
+}
+
+func (c *VirtualClock) scheduleLocked(t *virtualTimer, d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	c.seq++
+	t.when = c.now.Add(d)
+	t.seq = c.seq
+	heap.Push(&c.timers, t)
+}
+
+type virtualTimer struct {
+	clock *VirtualClock
+	when  time.Time
+	seq   uint64 // timers with the same deadline fire in the order they are scheduled
+	f     func()
+	index int // index in timer heap, -1 if not scheduled
+}
+
+func (t *virtualTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+
+	if t.index < 0 {
+		return false
+	}
+	heap.Remove(&t.clock.timers, t.index)
+	return true
+}
+
+func (t *virtualTimer) Reset(d time.Duration) bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+
+	active := t.index >= 0
+	if active {
+		heap.Remove(&t.clock.timers, t.index)
+	}
+	t.clock.scheduleLocked(t, d)
+	return active
+}
+
+type timerHeap []*virtualTimer
+
+func (h timerHeap) Len() int { return len(h) }
+
+func (h timerHeap) Less(i, j int) bool {
+	if h[i].when.Equal(h[j].when) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].when.Before(h[j].when)
+}
+
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x interface{}) {
+	t := x.(*virtualTimer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *timerHeap) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	t.index = -1
+	return t
+}
+
+// replicaClock is the view of the virtual clock of one server. Its time is
+// shifted by offset, and its timers are dropped after the server crashes.
+type replicaClock struct {
+	clock   *VirtualClock
+	offset  time.Duration
+	crashed int32
+}
+
+func (c *replicaClock) Now() time.Time {
+	return c.clock.Now().Add(c.offset)
+}
+
+func (c *replicaClock) AfterFunc(d time.Duration, f func()) vbft.Timer {
+	return c.clock.AfterFunc(d, func() {
+		if atomic.LoadInt32(&c.crashed) == 0 {
+			f()
+		}
+	})
+}
+
+func (c *replicaClock) crash() {
+	atomic.StoreInt32(&c.crashed, 1)
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology/consensus/vbft"
+)
+
+func TestVirtualClock(t *testing.T) {
+	start := time.Unix(1000, 0)
+	clock := NewVirtualClock(start)
+
+	fired := make([]int, 0)
+	clock.AfterFunc(2*time.Second, func() { fired = append(fired, 2) })
+	clock.AfterFunc(time.Second, func() { fired = append(fired, 1) })
+	clock.AfterFunc(time.Second, func() { fired = append(fired, 11) })
+	stopped := clock.AfterFunc(time.Second, func() { fired = append(fired, -1) })
+	if !stopped.Stop() {
+		t.Errorf("pending timer should be stopped")
+	}
+	var ticker vbft.Timer
+	ticks := 0
+	ticker = clock.AfterFunc(500*time.Millisecond, func() {
+		ticks++
+		ticker.Reset(500 * time.Millisecond)
+	})
+
+	clock.Advance(999 * time.Millisecond)
+	if len(fired) != 0 || ticks != 1 {
+		t.Fatalf("unexpected timers fired: %v, ticks %d", fired, ticks)
+	}
+	clock.Advance(time.Second)
+	if len(fired) != 2 || fired[0] != 1 || fired[1] != 11 {
+		t.Errorf("timers should fire in order, got %v", fired)
+	}
+	if ticks != 3 {
+		t.Errorf("ticker should be reset, ticks %d", ticks)
+	}
+	if now := clock.Now(); !now.Equal(start.Add(1999 * time.Millisecond)) {
+		t.Errorf("unexpected clock time %s", now)
+	}
+	clock.Advance(time.Millisecond)
+	if len(fired) != 3 || fired[2] != 2 {
+		t.Errorf("timer at deadline should fire, got %v", fired)
+	}
+	if stopped.Stop() {
+		t.Errorf("stopped timer should not be stopped again")
+	}
+}
@@ -0,0 +1,540 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package sim runs a cluster of vbft servers in one process, to test the
+// consensus under scripted faults. Each server has its own ledger on the
+// in-memory store backend, whose merkle tree file and the consensus wal of
+// the server are kept in a temp dir of the cluster. The servers exchange
+// payloads through a virtual network, and time their events on a virtual
+// clock driven by the cluster. The network delays are drawn from a seeded
+// random source, so a scenario replays the same delays and faults, while the
+// node keys, and so the proposers of each block, are random in each run.
+// The servers still handle the events of each step in their own goroutines,
+// so a run takes real time, and the tests of the cluster are skipped in
+// short mode.
+//
+// The cluster configures the process wide config.DefConfig, only one
+// cluster can run at a time.
+package sim
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/constants"
+	"github.com/ontio/ontology/consensus/vbft"
+	vconfig "github.com/ontio/ontology/consensus/vbft/config"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/ledger"
+	"github.com/ontio/ontology/core/store/backend"
+	scommon "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	p2pmsg "github.com/ontio/ontology/p2pserver/message/types"
+	p2ppeer "github.com/ontio/ontology/p2pserver/peer"
+	gov "github.com/ontio/ontology/smartcontract/service/native/governance"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+	tc "github.com/ontio/ontology/txnpool/common"
+)
+
+// Config is the config of a simulated cluster
+type Config struct {
+	Nodes    uint32        // number of consensus nodes, at least 7
+	Seed     int64         // seed of the network delays
+	Twins    []uint32      // byzantine nodes, each runs two servers signing conflicting msgs
+	Step     time.Duration // virtual time advanced in each step
+	StepWait time.Duration // real time for the servers to handle the events of a step, if any fired
+}
+
+// Cluster is a set of vbft servers running in one process
+type Cluster struct {
+	config  *Config
+	dir     string
+	clock   *VirtualClock
+	network *Network
+	genesis *config.GenesisConfig
+	nodes   []*node
+
+	lock       sync.Mutex
+	txs        []*types.Transaction // txs submitted by the servers
+	safeBlkNum uint32               // blocks committed by all honest nodes have been checked
+}
+
+type node struct {
+	cluster   *Cluster
+	index     uint32
+	account   *account.Account
+	p2pID     uint64
+	byzantine bool
+	ledgers   []*ledger.Ledger // ledgers of twins, kept on restart
+
+	lock     sync.Mutex
+	crashed  bool
+	replicas []*replica
+}
+
+// replica is a server of a node, byzantine nodes have two
+type replica struct {
+	node   *node
+	twin   int
+	ledger *ledger.Ledger
+	clock  *replicaClock
+	server *vbft.Server
+	p2p    *actor.PID
+	txpool *actor.PID
+}
+
+// NewCluster creates the nodes with the genesis block, and starts their servers
+func NewCluster(cfg *Config) (*Cluster, error) {
+	if cfg.Nodes < 7 {
+		return nil, fmt.Errorf("vbft needs at least 7 nodes, got %d", cfg.Nodes)
+	}
+	if cfg.Step == 0 {
+		cfg.Step = 20 * time.Millisecond
+	}
+	if cfg.StepWait == 0 {
+		cfg.StepWait = 2 * time.Millisecond
+	}
+	dir, err := ioutil.TempDir("", "vbft-sim")
+	if err != nil {
+		return nil, fmt.Errorf("create cluster dir: %s", err)
+	}
+	c := &Cluster{
+		config: cfg,
+		dir:    dir,
+		clock:  NewVirtualClock(time.Unix(int64(constants.GENESIS_BLOCK_TIMESTAMP), 0).Add(time.Hour)),
+	}
+	c.network = newNetwork(c, cfg.Seed)
+
+	byzantine := make(map[uint32]bool)
+	for _, idx := range cfg.Twins {
+		byzantine[idx] = true
+	}
+	peers := make([]*config.VBFTPeerStakeInfo, 0, cfg.Nodes)
+	for i := uint32(1); i <= cfg.Nodes; i++ {
+		acc := account.NewAccount("")
+		c.nodes = append(c.nodes, &node{
+			cluster:   c,
+			index:     i,
+			account:   acc,
+			p2pID:     p2ppeer.IDFromPubKey(acc.PublicKey),
+			byzantine: byzantine[i],
+		})
+		peers = append(peers, &config.VBFTPeerStakeInfo{
+			Index:      i,
+			PeerPubkey: vconfig.PubkeyID(acc.PublicKey),
+			Address:    acc.Address.ToBase58(),
+			InitPos:    10000,
+		})
+	}
+	c.genesis = &config.GenesisConfig{
+		SeedList:      make([]string, 0),
+		ConsensusType: config.CONSENSUS_TYPE_VBFT,
+		VBFT: &config.VBFTConfig{
+			N:                    cfg.Nodes,
+			C:                    (cfg.Nodes - 1) / 3,
+			K:                    cfg.Nodes,
+			L:                    16 * cfg.Nodes,
+			BlockMsgDelay:        10000,
+			HashMsgDelay:         10000,
+			PeerHandshakeTimeout: 10,
+			MaxBlockChangeView:   3000,
+			MinInitStake:         10000,
+			AdminOntID:           config.PolarisConfig.VBFT.AdminOntID,
+			VrfValue:             config.PolarisConfig.VBFT.VrfValue,
+			VrfProof:             config.PolarisConfig.VBFT.VrfProof,
+			Peers:                peers,
+		},
+		DBFT: &config.DBFTConfig{},
+		SOLO: &config.SOLOConfig{},
+	}
+	config.DefConfig.Genesis = c.genesis
+	config.DefConfig.Common.StoreBackend = backend.MEMORY
+	// votes are signed and equivocations can be reported from the first block
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+
+	for _, n := range c.nodes {
+		twins := 1
+		if n.byzantine {
+			twins = 2
+		}
+		for twin := 0; twin < twins; twin++ {
+			l, err := c.newLedger(n, twin)
+			if err != nil {
+				c.Close()
+				return nil, err
+			}
+			n.ledgers = append(n.ledgers, l)
+		}
+		if err := n.start(); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *Cluster) newLedger(n *node, twin int) (*ledger.Ledger, error) {
+	dir := filepath.Join(c.dir, fmt.Sprintf("node%d-%d", n.index, twin))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create ledger dir: %s", err)
+	}
+	l, err := ledger.NewLedger(dir, 0)
+	if err != nil {
+		return nil, fmt.Errorf("node %d new ledger: %s", n.index, err)
+	}
+	bookkeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return nil, fmt.Errorf("get bookkeepers: %s", err)
+	}
+	block, err := genesis.BuildGenesisBlock(bookkeepers, c.genesis)
+	if err != nil {
+		return nil, fmt.Errorf("build genesis block: %s", err)
+	}
+	if err := l.Init(bookkeepers, block); err != nil {
+		return nil, fmt.Errorf("node %d init ledger: %s", n.index, err)
+	}
+	return l, nil
+}
+
+// start starts the servers of the node on its ledgers
+func (n *node) start() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	replicas := make([]*replica, 0, len(n.ledgers))
+	for twin, l := range n.ledgers {
+		r := &replica{
+			node:   n,
+			twin:   twin,
+			ledger: l,
+			// twins sign different blocks with different timestamps
+			clock: &replicaClock{clock: n.cluster.clock, offset: time.Duration(twin) * time.Second},
+		}
+		r.p2p = actor.Spawn(actor.FromProducer(func() actor.Actor {
+			return &p2pActor{network: n.cluster.network, replica: r}
+		}))
+		r.txpool = actor.Spawn(actor.FromProducer(func() actor.Actor {
+			return &txPoolActor{replica: r}
+		}))
+		server, err := vbft.NewVbftServerWithEnv(n.account, r.txpool, r.p2p, &vbft.ServerEnv{
+			Ledger:  l,
+			Clock:   r.clock,
+			WALPath: filepath.Join(n.cluster.dir, fmt.Sprintf("node%d-%d", n.index, twin), config.DEFAULT_CONSENSUS_WAL),
+		})
+		if err != nil {
+			return fmt.Errorf("node %d new server: %s", n.index, err)
+		}
+		if err := server.Start(); err != nil {
+			return fmt.Errorf("node %d start server: %s", n.index, err)
+		}
+		r.server = server
+		replicas = append(replicas, r)
+	}
+	n.replicas = replicas
+	n.crashed = false
+	return nil
+}
+
+// crash halts the servers of the node, the ledgers and wals are kept
+func (n *node) crash() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.crashed {
+		return
+	}
+	n.crashed = true
+	for _, r := range n.replicas {
+		r.clock.crash()
+		r.server.Halt()
+		r.p2p.Stop()
+		r.txpool.Stop()
+	}
+}
+
+func (n *node) isCrashed() bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.crashed
+}
+
+func (n *node) deliver(payload *p2pmsg.ConsensusPayload) {
+	n.lock.Lock()
+	if n.crashed {
+		n.lock.Unlock()
+		return
+	}
+	replicas := n.replicas
+	n.lock.Unlock()
+
+	for _, r := range replicas {
+		r.server.NewConsensusPayload(payload)
+	}
+}
+
+func (c *Cluster) onTxSubmitted(r *replica, req *tc.TxReq) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.txs = append(c.txs, req.Tx)
+}
+
+// SubmittedTxs returns the txs submitted by the servers, which are the equivocation reports
+func (c *Cluster) SubmittedTxs() []*types.Transaction {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]*types.Transaction(nil), c.txs...)
+}
+
+// Network returns the virtual network of the cluster
+func (c *Cluster) Network() *Network {
+	return c.network
+}
+
+// Clock returns the virtual clock of the cluster
+func (c *Cluster) Clock() *VirtualClock {
+	return c.clock
+}
+
+func (c *Cluster) getNode(idx uint32) (*node, error) {
+	if idx == 0 || int(idx) > len(c.nodes) {
+		return nil, fmt.Errorf("invalid node %d", idx)
+	}
+	return c.nodes[idx-1], nil
+}
+
+// Heights returns the ledger heights of the honest nodes
+func (c *Cluster) Heights() map[uint32]uint32 {
+	heights := make(map[uint32]uint32)
+	for _, n := range c.nodes {
+		if !n.byzantine {
+			heights[n.index] = n.ledgers[0].GetCurrentBlockHeight()
+		}
+	}
+	return heights
+}
+
+// Blacklisted returns if the node is in the black list of governance
+// contract, in the ledger of the first running honest node
+func (c *Cluster) Blacklisted(idx uint32) (bool, error) {
+	target, err := c.getNode(idx)
+	if err != nil {
+		return false, err
+	}
+	for _, n := range c.nodes {
+		if n.byzantine || n.isCrashed() {
+			continue
+		}
+		key := append([]byte(gov.BLACK_LIST), keypair.SerializePublicKey(target.account.PublicKey)...)
+		_, err := vbft.GetStorageValue(nil, n.ledgers[0], nutils.GovernanceContractAddress, key)
+		if err == scommon.ErrNotFound {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	return false, fmt.Errorf("no running honest node")
+}
+
+//
+// CheckSafety checks that the honest nodes, including the crashed ones,
+// have committed the same block at each height.
+//
+func (c *Cluster) CheckSafety() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	heights := c.Heights()
+	minHeight, maxHeight := ^uint32(0), uint32(0)
+	for _, h := range heights {
+		if h < minHeight {
+			minHeight = h
+		}
+		if h > maxHeight {
+			maxHeight = h
+		}
+	}
+	for blkNum := c.safeBlkNum + 1; blkNum <= maxHeight; blkNum++ {
+		var first *node
+		for _, n := range c.nodes {
+			if n.byzantine || heights[n.index] < blkNum {
+				continue
+			}
+			if first == nil {
+				first = n
+				continue
+			}
+			h1, h2 := first.ledgers[0].GetBlockHash(blkNum), n.ledgers[0].GetBlockHash(blkNum)
+			if h1 != h2 {
+				return fmt.Errorf("node %d and %d committed conflicting blocks %d: %s, %s",
+					first.index, n.index, blkNum, h1.ToHexString(), h2.ToHexString())
+			}
+		}
+	}
+	if minHeight > c.safeBlkNum {
+		c.safeBlkNum = minHeight
+	}
+	return nil
+}
+
+// Step is a fault injected into the cluster at a virtual time of a run
+type Step struct {
+	At     time.Duration // virtual time since the run starts
+	Action Action
+}
+
+type Action func(c *Cluster) error
+
+// Partition splits the nodes into groups which can not reach each other
+func Partition(groups ...[]uint32) Action {
+	return func(c *Cluster) error {
+		c.network.Partition(groups...)
+		return nil
+	}
+}
+
+// Heal removes the network partition
+func Heal() Action {
+	return func(c *Cluster) error {
+		c.network.Heal()
+		return nil
+	}
+}
+
+// Delay sets the range of the network delays
+func Delay(min, max time.Duration) Action {
+	return func(c *Cluster) error {
+		c.network.SetDelay(min, max)
+		return nil
+	}
+}
+
+// Reorder sets if the payloads on one link can overtake each other
+func Reorder(reorder bool) Action {
+	return func(c *Cluster) error {
+		c.network.SetReorder(reorder)
+		return nil
+	}
+}
+
+// Crash halts the servers of the node
+func Crash(idx uint32) Action {
+	return func(c *Cluster) error {
+		n, err := c.getNode(idx)
+		if err != nil {
+			return err
+		}
+		n.crash()
+		return nil
+	}
+}
+
+// Restart starts the servers of a crashed node, which replay their wals
+func Restart(idx uint32) Action {
+	return func(c *Cluster) error {
+		n, err := c.getNode(idx)
+		if err != nil {
+			return err
+		}
+		if !n.isCrashed() {
+			return fmt.Errorf("node %d is running", idx)
+		}
+		return n.start()
+	}
+}
+
+// CrashProposer crashes the leader proposer of current block
+func CrashProposer() Action {
+	return func(c *Cluster) error {
+		for _, n := range c.nodes {
+			if n.isCrashed() {
+				continue
+			}
+			if idx, present := n.replicas[0].server.GetLeaderProposer(); present {
+				return Crash(idx)(c)
+			}
+		}
+		return fmt.Errorf("no running node")
+	}
+}
+
+// AllReached returns if the running honest nodes all reached the height
+func AllReached(height uint32) func(c *Cluster) bool {
+	return func(c *Cluster) bool {
+		for _, n := range c.nodes {
+			if n.byzantine || n.isCrashed() {
+				continue
+			}
+			if n.ledgers[0].GetCurrentBlockHeight() < height {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+//
+// Run advances the virtual clock step by step, and injects the faults of
+// the script when they are due, until done returns true. It fails if the
+// honest nodes commit conflicting blocks, or done is not reached in timeout.
+//
+func (c *Cluster) Run(script []Step, done func(c *Cluster) bool, timeout time.Duration) error {
+	steps := append([]Step(nil), script...)
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].At < steps[j].At
+	})
+
+	start := c.clock.Now()
+	for {
+		elapsed := c.clock.Now().Sub(start)
+		for len(steps) > 0 && steps[0].At <= elapsed {
+			if err := steps[0].Action(c); err != nil {
+				return fmt.Errorf("step at %s failed: %s", steps[0].At, err)
+			}
+			steps = steps[1:]
+		}
+		if err := c.CheckSafety(); err != nil {
+			return err
+		}
+		if done != nil && done(c) {
+			return nil
+		}
+		if elapsed >= timeout {
+			return fmt.Errorf("not done in %s, heights: %v", timeout, c.Heights())
+		}
+		// the servers only have new events to handle if some timers fired
+		if c.clock.Advance(c.config.Step) > 0 {
+			time.Sleep(c.config.StepWait)
+		}
+	}
+}
+
+// Close halts all servers, and removes the files of the cluster
+func (c *Cluster) Close() {
+	for _, n := range c.nodes {
+		n.crash()
+	}
+	os.RemoveAll(c.dir)
+}
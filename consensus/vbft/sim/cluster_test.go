@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sim
+
+import (
+	"testing"
+	"time"
+)
+
+func runCluster(t *testing.T, cfg *Config, script []Step, done func(c *Cluster) bool, timeout time.Duration) *Cluster {
+	if testing.Short() {
+		t.Skip("skip vbft simulation in short mode")
+	}
+	c, err := NewCluster(cfg)
+	if err != nil {
+		t.Fatalf("new cluster: %s", err)
+	}
+	if err := c.Run(script, done, timeout); err != nil {
+		c.Close()
+		t.Fatalf("run cluster: %s", err)
+	}
+	return c
+}
+
+func TestClusterCommit(t *testing.T) {
+	c := runCluster(t, &Config{Nodes: 7, Seed: 1}, nil, AllReached(5), 10*time.Minute)
+	defer c.Close()
+}
+
+func TestClusterDelayAndReorder(t *testing.T) {
+	c := runCluster(t, &Config{Nodes: 7, Seed: 2}, []Step{
+		{At: 0, Action: Delay(10*time.Millisecond, 500*time.Millisecond)},
+		{At: 0, Action: Reorder(true)},
+	}, AllReached(5), 10*time.Minute)
+	defer c.Close()
+}
+
+func TestClusterPartition(t *testing.T) {
+	c := runCluster(t, &Config{Nodes: 7, Seed: 3}, []Step{
+		{At: 90 * time.Second, Action: Partition([]uint32{1, 2, 3}, []uint32{4, 5, 6, 7})},
+		{At: 150 * time.Second, Action: Heal()},
+	}, AllReached(8), 10*time.Minute)
+	defer c.Close()
+}
+
+func TestClusterCrashProposer(t *testing.T) {
+	c := runCluster(t, &Config{Nodes: 7, Seed: 4}, []Step{
+		{At: 30 * time.Second, Action: CrashProposer()},
+	}, AllReached(8), 10*time.Minute)
+	defer c.Close()
+}
+
+func TestClusterRestart(t *testing.T) {
+	c := runCluster(t, &Config{Nodes: 7, Seed: 5}, []Step{
+		{At: 30 * time.Second, Action: Crash(1)},
+		{At: 60 * time.Second, Action: Restart(1)},
+	}, AllReached(8), 10*time.Minute)
+	defer c.Close()
+}
+
+func TestClusterEquivocation(t *testing.T) {
+	// node 1 equivocates when it proposes, which depends on the random node keys,
+	// so run until it is blacklisted
+	c := runCluster(t, &Config{Nodes: 7, Seed: 6, Twins: []uint32{1}}, nil, func(c *Cluster) bool {
+		black, _ := c.Blacklisted(1)
+		return black && AllReached(10)(c)
+	}, 30*time.Minute)
+	defer c.Close()
+	if len(c.SubmittedTxs()) == 0 {
+		t.Fatal("no equivocation reported")
+	}
+	black, err := c.Blacklisted(2)
+	if err != nil {
+		t.Fatalf("get black list: %s", err)
+	}
+	if black {
+		t.Fatal("honest node 2 is blacklisted")
+	}
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sim
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/common/log"
+	netActor "github.com/ontio/ontology/p2pserver/actor/server"
+	p2pmsg "github.com/ontio/ontology/p2pserver/message/types"
+)
+
+// twinLag is the extra delay of the payloads from the second twin of a
+// byzantine node. Its conflicting msgs are seen after the honest nodes have
+// voted in the round, so they are reported without splitting the votes of
+// the round, as vbft counts the votes by proposer and can not recover from it.
+const twinLag = 5 * time.Second
+
+type link struct {
+	from uint32
+	to   uint32
+}
+
+// Network delivers the consensus payloads between the servers on the
+// virtual clock. The delays are drawn from a random source seeded by the
+// cluster, payloads on one link are delivered in order unless reordering
+// is enabled.
+type Network struct {
+	lock      sync.Mutex
+	cluster   *Cluster
+	rand      *rand.Rand
+	minDelay  time.Duration
+	maxDelay  time.Duration
+	reorder   bool
+	groups    map[uint32]int // partition group of nodes, nil if not partitioned
+	delivered map[link]time.Time
+	sent      uint64
+	dropped   uint64
+}
+
+func newNetwork(cluster *Cluster, seed int64) *Network {
+	return &Network{
+		cluster:   cluster,
+		rand:      rand.New(rand.NewSource(seed)),
+		minDelay:  10 * time.Millisecond,
+		maxDelay:  10 * time.Millisecond,
+		delivered: make(map[link]time.Time),
+	}
+}
+
+// SetDelay sets the range of the delays of payloads
+func (n *Network) SetDelay(min, max time.Duration) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if max < min {
+		max = min
+	}
+	n.minDelay, n.maxDelay = min, max
+}
+
+// SetReorder sets if the payloads on one link can overtake each other
+func (n *Network) SetReorder(reorder bool) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.reorder = reorder
+}
+
+// Partition splits the nodes into groups, payloads between groups are dropped.
+// Nodes not in any group are isolated.
+func (n *Network) Partition(groups ...[]uint32) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.groups = make(map[uint32]int)
+	for i, g := range groups {
+		for _, idx := range g {
+			n.groups[idx] = i + 1
+		}
+	}
+}
+
+// Heal removes the partition
+func (n *Network) Heal() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.groups = nil
+}
+
+// Stats returns the number of payloads sent and dropped
+func (n *Network) Stats() (sent, dropped uint64) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.sent, n.dropped
+}
+
+func (n *Network) connectedLocked(from, to uint32) bool {
+	if n.groups == nil {
+		return true
+	}
+	g, present := n.groups[from]
+	return present && g == n.groups[to]
+}
+
+func (n *Network) delayLocked(l link) time.Duration {
+	delay := n.minDelay
+	if n.maxDelay > n.minDelay {
+		delay += time.Duration(n.rand.Int63n(int64(n.maxDelay - n.minDelay)))
+	}
+	if n.reorder {
+		return delay
+	}
+	// keep the payloads of the link in order
+	now := n.cluster.clock.Now()
+	at := now.Add(delay)
+	if last := n.delivered[l]; at.Before(last) {
+		at = last
+	}
+	n.delivered[l] = at
+	return at.Sub(now)
+}
+
+//
+// send schedules the delivery of the payload from the replica, to the peer of
+// the p2p id, or to all peers if broadcast.
+//
+func (n *Network) send(from *replica, target uint64, broadcast bool, payload *p2pmsg.ConsensusPayload) {
+	// the links of the network are authenticated
+	payload.PeerId = from.node.p2pID
+	payload.PeerPubKey = from.node.account.PublicKey
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for _, to := range n.cluster.nodes {
+		if to == from.node || (!broadcast && to.p2pID != target) {
+			continue
+		}
+		// the second twin of a byzantine node only talks to the upper half of peers
+		if from.twin == 1 && to.index <= uint32(len(n.cluster.nodes)/2) {
+			continue
+		}
+		n.sent++
+		if from.node.isCrashed() || !n.connectedLocked(from.node.index, to.index) {
+			n.dropped++
+			continue
+		}
+		to := to
+		delay := n.delayLocked(link{from: from.node.index, to: to.index})
+		if from.twin == 1 {
+			delay += twinLag
+		}
+		n.cluster.clock.AfterFunc(delay, func() {
+			to.deliver(payload)
+		})
+	}
+}
+
+// p2pActor is the p2p actor of a replica, which hands the consensus
+// payloads sent by the server to the network.
+type p2pActor struct {
+	network *Network
+	replica *replica
+}
+
+func (self *p2pActor) Receive(context actor.Context) {
+	switch msg := context.Message().(type) {
+	case *p2pmsg.ConsensusPayload:
+		self.network.send(self.replica, 0, true, msg)
+	case *netActor.TransmitConsensusMsgReq:
+		if cons, ok := msg.Msg.(*p2pmsg.Consensus); ok {
+			self.network.send(self.replica, msg.Target, false, &cons.Cons)
+		}
+	default:
+		log.Debugf("sim p2p actor: unknown msg %T", msg)
+	}
+}
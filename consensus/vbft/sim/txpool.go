@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sim
+
+import (
+	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/common/log"
+	tc "github.com/ontio/ontology/txnpool/common"
+)
+
+// txPoolActor is the tx pool of a replica. It hands the transactions
+// submitted by the server to the cluster, proposes the submitted transactions
+// not yet in the ledger of the replica, and accepts all blocks.
+type txPoolActor struct {
+	replica *replica
+}
+
+func (self *txPoolActor) Receive(context actor.Context) {
+	switch msg := context.Message().(type) {
+	case *tc.GetTxnPoolReq:
+		if sender := context.Sender(); sender != nil {
+			sender.Request(&tc.GetTxnPoolRsp{TxnPool: self.pendingTxs()}, context.Self())
+		}
+	case *tc.VerifyBlockReq:
+		if sender := context.Sender(); sender != nil {
+			sender.Request(&tc.VerifyBlockRsp{TxnPool: nil}, context.Self())
+		}
+	case *tc.TxReq:
+		self.replica.node.cluster.onTxSubmitted(self.replica, msg)
+	default:
+		log.Debugf("sim tx pool actor: unknown msg %T", msg)
+	}
+}
+
+func (self *txPoolActor) pendingTxs() []*tc.TXEntry {
+	var entries []*tc.TXEntry
+	for _, tx := range self.replica.node.cluster.SubmittedTxs() {
+		if present, _ := self.replica.ledger.IsContainTransaction(tx.Hash()); present {
+			continue
+		}
+		entries = append(entries, &tc.TXEntry{Tx: tx})
+	}
+	return entries
+}
@@ -19,6 +19,7 @@
 package vbft
 
 import (
+	"fmt"
 	"math"
 	"time"
 
@@ -44,6 +45,24 @@ const (
 	SyncingCheck     // potentially lost syncing
 )
 
+var serverStateNames = map[ServerState]string{
+	Init:             "Init",
+	LocalConfigured:  "LocalConfigured",
+	Configured:       "Configured",
+	Syncing:          "Syncing",
+	WaitNetworkReady: "WaitNetworkReady",
+	SyncReady:        "SyncReady",
+	Synced:           "Synced",
+	SyncingCheck:     "SyncingCheck",
+}
+
+func (state ServerState) String() string {
+	if name, present := serverStateNames[state]; present {
+		return name
+	}
+	return fmt.Sprintf("Unknown(%d)", int(state))
+}
+
 func isReady(state ServerState) bool {
 	return state >= SyncReady
 }
@@ -83,7 +102,7 @@ type StateMgr struct {
 	StateEventC      chan *StateEvent
 	peers            map[uint32]*PeerState
 
-	liveTicker             *time.Timer
+	liveTicker             Timer
 	lastTickChainHeight    uint32
 	lastBlockSyncReqHeight uint32
 }
@@ -103,7 +122,7 @@ func (self *StateMgr) getState() ServerState {
 }
 
 func (self *StateMgr) run() {
-	self.liveTicker = time.AfterFunc(peerHandshakeTimeout*5, func() {
+	self.liveTicker = self.server.clock.AfterFunc(peerHandshakeTimeout*5, func() {
 		self.StateEventC <- &StateEvent{
 			Type:     LiveTick,
 			blockNum: self.server.GetCommittedBlockNo(),
@@ -358,7 +377,7 @@ func (self *StateMgr) setSyncedReady() error {
 	if prevState <= SyncReady {
 		log.Infof("server %d start sync ready", self.server.Index)
 		blkNum := self.server.GetCurrentBlockNo()
-		time.AfterFunc(self.syncReadyTimeout, func() {
+		self.server.clock.AfterFunc(self.syncReadyTimeout, func() {
 			self.StateEventC <- &StateEvent{
 				Type:     SyncReadyTimeout,
 				blockNum: blkNum,
@@ -34,6 +34,8 @@ type Block struct {
 	EmptyBlock          *types.Block
 	Info                *vconfig.VbftBlockInfo
 	PrevBlockMerkleRoot common.Uint256
+	VoteView            uint32 // chain config view of the proposal vote
+	VoteSig             []byte // proposer signature on the proposal vote, empty for blocks not from proposal
 }
 
 func (blk *Block) getProposer() uint32 {
@@ -84,6 +86,10 @@ func (blk *Block) Serialize() ([]byte, error) {
 		payload.WriteVarBytes(sink2.Bytes())
 	}
 	payload.WriteHash(blk.PrevBlockMerkleRoot)
+	if len(blk.VoteSig) > 0 {
+		payload.WriteVarBytes(blk.VoteSig)
+		payload.WriteUint32(blk.VoteView)
+	}
 	return payload.Bytes(), nil
 }
 
@@ -110,6 +116,7 @@ func (blk *Block) Deserialize(data []byte) error {
 
 	var emptyBlock *types.Block
 	if source.Len() > 0 {
+		pos := source.Pos()
 		buf2, _, irregular, eof := source.NextVarBytes()
 		if irregular == false && eof == false {
 			block2, err := types.BlockFromRawBytes(buf2)
@@ -117,6 +124,10 @@ func (blk *Block) Deserialize(data []byte) error {
 				emptyBlock = block2
 			}
 		}
+		// no empty block, the merkle root and vote sig follow the block
+		if emptyBlock == nil {
+			source.BackUp(source.Pos() - pos)
+		}
 	}
 	var merkleRoot common.Uint256
 	if source.Len() > 0 {
@@ -126,10 +137,27 @@ func (blk *Block) Deserialize(data []byte) error {
 			return io.ErrUnexpectedEOF
 		}
 	}
+	var voteSig []byte
+	var voteView uint32
+	if source.Len() > 0 {
+		voteSig, _, irregular, eof = source.NextVarBytes()
+		if irregular {
+			return common.ErrIrregularData
+		}
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		voteView, eof = source.NextUint32()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+	}
 	blk.Block = block
 	blk.EmptyBlock = emptyBlock
 	blk.Info = info
 	blk.PrevBlockMerkleRoot = merkleRoot
+	blk.VoteView = voteView
+	blk.VoteSig = voteSig
 	return nil
 }
 
@@ -218,3 +218,26 @@ func TestInitVbftBlock(t *testing.T) {
 	}
 	t.Log("TestInitVbftBlock succ")
 }
+
+func TestDeserializeVoteSig(t *testing.T) {
+	blk, err := constructBlock()
+	if err != nil {
+		t.Fatalf("constructBlock failed: %v", err)
+	}
+	blk.EmptyBlock = nil
+	blk.PrevBlockMerkleRoot = common.Uint256{1, 2, 3}
+	blk.VoteSig = []byte{4, 5, 6}
+	blk.VoteView = 7
+	data, err := blk.Serialize()
+	if err != nil {
+		t.Fatalf("Block Serialize failed :%v", err)
+	}
+	blk2 := &Block{}
+	if err := blk2.Deserialize(data); err != nil {
+		t.Fatalf("Block Deserialize failed: %v", err)
+	}
+	if blk2.EmptyBlock != nil || blk2.PrevBlockMerkleRoot != blk.PrevBlockMerkleRoot ||
+		!reflect.DeepEqual(blk2.VoteSig, blk.VoteSig) || blk2.VoteView != blk.VoteView {
+		t.Errorf("Block Deserialize mismatch: %v", blk2)
+	}
+}
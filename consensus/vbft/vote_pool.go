@@ -0,0 +1,250 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/consensus/vbft/config"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/utils"
+	gover "github.com/ontio/ontology/smartcontract/service/native/governance"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+// a peer can only sign one vote for each voteRound
+type voteRound struct {
+	peerIdx  uint32
+	voteType uint8
+	blockNum uint32
+	forEmpty bool
+}
+
+// VotePool keeps the votes signed by peers, to detect peers signing
+// conflicting votes for the same round.
+type VotePool struct {
+	lock       sync.Mutex
+	historyLen uint32
+	votes      map[voteRound]*vconfig.Vote
+	reported   map[voteRound]bool
+}
+
+func newVotePool(historyLen uint32) *VotePool {
+	return &VotePool{
+		historyLen: historyLen,
+		votes:      make(map[voteRound]*vconfig.Vote),
+		reported:   make(map[voteRound]bool),
+	}
+}
+
+func (pool *VotePool) clean() {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	pool.votes = make(map[voteRound]*vconfig.Vote)
+	pool.reported = make(map[voteRound]bool)
+}
+
+//
+// addVote records the vote signed by the peer. If the peer has signed
+// another vote of the round, the evidence of equivocation is returned.
+// The equivocation of a round is returned only once.
+//
+func (pool *VotePool) addVote(peerIdx uint32, vote *vconfig.Vote) *vconfig.Equivocation {
+	round := voteRound{
+		peerIdx:  peerIdx,
+		voteType: vote.Type,
+		blockNum: vote.BlockNum,
+		forEmpty: vote.ForEmpty,
+	}
+
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	prev, present := pool.votes[round]
+	if !present {
+		pool.votes[round] = vote
+		return nil
+	}
+	if prev.BlockHash == vote.BlockHash || pool.reported[round] {
+		return nil
+	}
+	pool.reported[round] = true
+	return &vconfig.Equivocation{
+		First:  prev,
+		Second: vote,
+	}
+}
+
+// getVote returns the vote signed by the peer in the round, nil if not signed
+func (pool *VotePool) getVote(peerIdx uint32, voteType uint8, blockNum uint32, forEmpty bool) *vconfig.Vote {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	return pool.votes[voteRound{
+		peerIdx:  peerIdx,
+		voteType: voteType,
+		blockNum: blockNum,
+		forEmpty: forEmpty,
+	}]
+}
+
+func (pool *VotePool) onBlockSealed(blockNum uint32) {
+	if blockNum <= pool.historyLen {
+		return
+	}
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	for round := range pool.votes {
+		if round.blockNum < blockNum-pool.historyLen {
+			delete(pool.votes, round)
+			delete(pool.reported, round)
+		}
+	}
+}
+
+func (self *Server) signVote(voteType uint8, blkNum uint32, forEmpty bool, blkHash common.Uint256) ([]byte, error) {
+	vote := &vconfig.Vote{
+		Type:      voteType,
+		BlockNum:  blkNum,
+		View:      self.config.View,
+		ForEmpty:  forEmpty,
+		BlockHash: blkHash,
+	}
+	hash := vote.Hash()
+	return signature.Sign(self.account, hash[:])
+}
+
+// getMsgVote returns the signer and the vote of consensus msg, nil if the msg
+// is not a proposal, endorsement or commitment. The sig of the vote is empty
+// if the msg is not signed with a vote.
+func getMsgVote(msg ConsensusMsg) (uint32, *vconfig.Vote) {
+	switch pMsg := msg.(type) {
+	case *blockProposalMsg:
+		return pMsg.Block.getProposer(), &vconfig.Vote{
+			Type:      vconfig.VOTE_PROPOSAL,
+			BlockNum:  pMsg.GetBlockNum(),
+			View:      pMsg.Block.VoteView,
+			BlockHash: pMsg.Block.Block.Hash(),
+			Sig:       pMsg.Block.VoteSig,
+		}
+	case *blockEndorseMsg:
+		return pMsg.Endorser, &vconfig.Vote{
+			Type:      vconfig.VOTE_ENDORSE,
+			BlockNum:  pMsg.BlockNum,
+			View:      pMsg.VoteView,
+			ForEmpty:  pMsg.EndorseForEmpty,
+			BlockHash: pMsg.EndorsedBlockHash,
+			Sig:       pMsg.VoteSig,
+		}
+	case *blockCommitMsg:
+		return pMsg.Committer, &vconfig.Vote{
+			Type:      vconfig.VOTE_COMMIT,
+			BlockNum:  pMsg.BlockNum,
+			View:      pMsg.VoteView,
+			ForEmpty:  pMsg.CommitForEmpty,
+			BlockHash: pMsg.CommitBlockHash,
+			Sig:       pMsg.VoteSig,
+		}
+	}
+	return 0, nil
+}
+
+//
+// checkEquivocation records the vote of the consensus msg, and reports the
+// equivocation to governance contract if the signer has signed conflicting votes.
+// From the vote sig check height, it returns false for the proposal, endorsement
+// and commitment without valid vote, which should be dropped.
+//
+func (self *Server) checkEquivocation(msg ConsensusMsg) bool {
+	peerIdx, vote := getMsgVote(msg)
+	if vote == nil {
+		return true
+	}
+	required := vote.BlockNum >= config.GetVoteSigCheckHeight(config.DefConfig.P2PNode.NetworkId)
+	if len(vote.Sig) == 0 {
+		if required {
+			log.Errorf("server %d, no vote from %d for blk %d", self.Index, peerIdx, vote.BlockNum)
+		}
+		return !required
+	}
+	pubkey := self.peerPool.GetPeerPubKey(peerIdx)
+	if pubkey == nil {
+		return !required
+	}
+	if err := vote.Verify(pubkey); err != nil {
+		log.Errorf("server %d, invalid vote from %d for blk %d: %s", self.Index, peerIdx, vote.BlockNum, err)
+		return !required
+	}
+	if vote.BlockNum+self.msgHistoryDuration < self.GetCommittedBlockNo() {
+		return true
+	}
+	evidence := self.votePool.addVote(peerIdx, vote)
+	if evidence == nil {
+		return true
+	}
+	log.Errorf("server %d, peer %d equivocated in blk %d, vote type %d, %s vs %s", self.Index, peerIdx,
+		vote.BlockNum, vote.Type, evidence.First.BlockHash.ToHexString(), evidence.Second.BlockHash.ToHexString())
+	if err := self.reportEquivocation(pubkey, evidence); err != nil {
+		log.Errorf("server %d, failed to report equivocation of peer %d: %s", self.Index, peerIdx, err)
+	}
+	return true
+}
+
+// reportEquivocation submits the evidence to governance contract in a transaction paid by the node
+func (self *Server) reportEquivocation(pubkey keypair.PublicKey, evidence *vconfig.Equivocation) error {
+	param := &gover.ReportEquivocationParam{
+		PeerPubkey: vconfig.PubkeyID(pubkey),
+		Evidence:   evidence,
+	}
+	buf := new(bytes.Buffer)
+	if err := param.Serialize(buf); err != nil {
+		return fmt.Errorf("serialize evidence: %s", err)
+	}
+	mutable := utils.BuildNativeTransaction(nutils.GovernanceContractAddress, gover.REPORT_EQUIVOCATION, buf.Bytes())
+	mutable.GasPrice = config.DefConfig.Common.GasPrice
+	mutable.GasLimit = config.DefConfig.Common.GasLimit
+	mutable.Nonce = uint32(time.Now().Unix())
+	mutable.Payer = self.account.Address
+	txHash := mutable.Hash()
+	sig, err := signature.Sign(self.account, txHash[:])
+	if err != nil {
+		return fmt.Errorf("sign tx: %s", err)
+	}
+	mutable.Sigs = []types.Sig{{
+		PubKeys: []keypair.PublicKey{self.account.PublicKey},
+		M:       1,
+		SigData: [][]byte{sig},
+	}}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return fmt.Errorf("build tx: %s", err)
+	}
+	self.poolActor.AppendTx(tx)
+	return nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/consensus/vbft/config"
+)
+
+func TestAddVote(t *testing.T) {
+	pool := newVotePool(10)
+	vote := &vconfig.Vote{Type: vconfig.VOTE_COMMIT, BlockNum: 5, BlockHash: common.Uint256{1}}
+	if evidence := pool.addVote(1, vote); evidence != nil {
+		t.Errorf("first vote should not be equivocation")
+	}
+	if evidence := pool.addVote(1, vote); evidence != nil {
+		t.Errorf("same vote should not be equivocation")
+	}
+	if evidence := pool.addVote(2, &vconfig.Vote{Type: vconfig.VOTE_COMMIT, BlockNum: 5, BlockHash: common.Uint256{2}}); evidence != nil {
+		t.Errorf("votes of different peers should not be equivocation")
+	}
+	if evidence := pool.addVote(1, &vconfig.Vote{Type: vconfig.VOTE_COMMIT, BlockNum: 5, ForEmpty: true, BlockHash: common.Uint256{2}}); evidence != nil {
+		t.Errorf("votes of different rounds should not be equivocation")
+	}
+
+	conflict := &vconfig.Vote{Type: vconfig.VOTE_COMMIT, BlockNum: 5, BlockHash: common.Uint256{3}}
+	evidence := pool.addVote(1, conflict)
+	if evidence == nil || evidence.First != vote || evidence.Second != conflict {
+		t.Fatalf("conflicting votes should be equivocation")
+	}
+	if evidence := pool.addVote(1, conflict); evidence != nil {
+		t.Errorf("equivocation should be reported only once")
+	}
+
+	pool.onBlockSealed(20)
+	if len(pool.votes) != 0 || len(pool.reported) != 0 {
+		t.Errorf("votes should be freed on block sealed")
+	}
+}
+
+func TestCheckEquivocationNoVote(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	server := &Server{votePool: newVotePool(10)}
+	msg := &blockCommitMsg{Committer: 1, BlockNum: 5, CommitBlockHash: common.Uint256{1}}
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	if !server.checkEquivocation(msg) {
+		t.Errorf("msg without vote should be accepted before the check height")
+	}
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	if server.checkEquivocation(msg) {
+		t.Errorf("msg without vote should be rejected from the check height")
+	}
+	if !server.checkEquivocation(&peerHeartbeatMsg{}) {
+		t.Errorf("msg other than votes should be accepted")
+	}
+}
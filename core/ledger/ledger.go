@@ -30,6 +30,7 @@ import (
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/smartcontract/event"
 	cstate "github.com/ontio/ontology/smartcontract/states"
+	"io"
 )
 
 var DefLedger *Ledger
@@ -64,6 +65,10 @@ func (self *Ledger) AddHeaders(headers []*types.Header) error {
 	return self.ldgStore.AddHeaders(headers)
 }
 
+func (self *Ledger) VerifyHeader(header *types.Header) error {
+	return self.ldgStore.VerifyHeader(header)
+}
+
 func (self *Ledger) AddBlock(block *types.Block, stateMerkleRoot common.Uint256) error {
 	err := self.ldgStore.AddBlock(block, stateMerkleRoot)
 	if err != nil {
@@ -167,6 +172,21 @@ func (self *Ledger) GetStorageItem(codeHash common.Address, key []byte) ([]byte,
 	return storageItem.Value, nil
 }
 
+func (self *Ledger) GetStorageItemAtHeight(codeHash common.Address, key []byte, height uint32) ([]byte, error) {
+	storageKey := &states.StorageKey{
+		ContractAddress: codeHash,
+		Key:             key,
+	}
+	storageItem, err := self.ldgStore.GetStorageItemAtHeight(storageKey, height)
+	if err != nil {
+		return nil, err
+	}
+	if storageItem == nil {
+		return nil, nil
+	}
+	return storageItem.Value, nil
+}
+
 func (self *Ledger) GetContractState(contractHash common.Address) (*payload.DeployCode, error) {
 	return self.ldgStore.GetContractState(contractHash)
 }
@@ -187,6 +207,30 @@ func (self *Ledger) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify
 	return self.ldgStore.GetEventNotifyByBlock(height)
 }
 
+func (self *Ledger) GetTransactionsByAddress(address common.Address, height uint32, limit uint32) ([]*store.AddressTx, error) {
+	return self.ldgStore.GetTransactionsByAddress(address, height, limit)
+}
+
+func (self *Ledger) GetTransfersByAddress(contract common.Address, address common.Address, startHeight uint32, endHeight uint32, limit uint32) ([]*store.Transfer, error) {
+	return self.ldgStore.GetTransfersByAddress(contract, address, startHeight, endHeight, limit)
+}
+
+func (self *Ledger) GetTransactionReceipt(txHash common.Uint256) (*store.Receipt, error) {
+	return self.ldgStore.GetTransactionReceipt(txHash)
+}
+
+func (self *Ledger) RollbackTo(height uint32) error {
+	return self.ldgStore.RollbackTo(height)
+}
+
+func (self *Ledger) ExportStateSnapshot(w io.Writer) (*store.StateSnapshotCheckpoint, error) {
+	return self.ldgStore.ExportStateSnapshot(w)
+}
+
+func (self *Ledger) ImportStateSnapshot(genesisHash common.Uint256, checkpoint *store.StateSnapshotCheckpoint, r io.Reader) error {
+	return self.ldgStore.ImportStateSnapshot(genesisHash, checkpoint, r)
+}
+
 func (self *Ledger) Close() error {
 	return self.ldgStore.Close()
 }
@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package bls implements BLS signatures on the BLS12-381 curve, used by the
+// consensus peers to sign blocks with signatures which aggregate into one.
+//
+// Public keys are in G1 and signatures in G2, as the minimal-pubkey-size
+// variant of draft-irtf-cfrg-bls-signature. Aggregation of signatures on the
+// same message is protected against rogue key attacks by proof of possession,
+// which should be checked before a public key is accepted.
+package bls
+
+import (
+	"crypto/sha512"
+	"errors"
+	"math/big"
+
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+const (
+	PUBLIC_KEY_SIZE = 48 // size of compressed public key
+	SIGNATURE_SIZE  = 96 // size of compressed signature
+)
+
+var (
+	sigDomain    = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+	popDomain    = []byte("BLS_POP_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+	keyGenDomain = []byte("ONTOLOGY_BLS_KEYGEN")
+)
+
+type PrivateKey struct {
+	s *big.Int
+}
+
+type PublicKey struct {
+	p *bls12381.PointG1
+}
+
+type Signature struct {
+	p *bls12381.PointG2
+}
+
+// GenerateKey derives the private key from the secret seed deterministically
+func GenerateKey(seed []byte) (*PrivateKey, error) {
+	if len(seed) < 32 {
+		return nil, errors.New("seed of bls key should be at least 32 bytes")
+	}
+	order := bls12381.NewG1().Q()
+	h := sha512.New()
+	h.Write(keyGenDomain)
+	h.Write(seed)
+	for {
+		digest := h.Sum(nil)
+		s := new(big.Int).Mod(new(big.Int).SetBytes(digest), order)
+		if s.Sign() != 0 {
+			return &PrivateKey{s: s}, nil
+		}
+		h.Write(digest)
+	}
+}
+
+func (k *PrivateKey) PublicKey() *PublicKey {
+	g := bls12381.NewG1()
+	p := g.New()
+	g.MulScalarBig(p, g.One(), k.s)
+	return &PublicKey{p: g.Affine(p)}
+}
+
+// Sign returns the signature of msg
+func (k *PrivateKey) Sign(msg []byte) (*Signature, error) {
+	return k.sign(msg, sigDomain)
+}
+
+// ProvePossession returns the proof of possession of the private key,
+// a signature on the public key
+func (k *PrivateKey) ProvePossession() (*Signature, error) {
+	return k.sign(k.PublicKey().Serialize(), popDomain)
+}
+
+func (k *PrivateKey) sign(msg, domain []byte) (*Signature, error) {
+	g := bls12381.NewG2()
+	h, err := g.HashToCurve(msg, domain)
+	if err != nil {
+		return nil, err
+	}
+	g.MulScalarBig(h, h, k.s)
+	return &Signature{p: g.Affine(h)}, nil
+}
+
+func (pk *PublicKey) Serialize() []byte {
+	return bls12381.NewG1().ToCompressed(bls12381.NewG1().New().Set(pk.p))
+}
+
+func DeserializePublicKey(data []byte) (*PublicKey, error) {
+	g := bls12381.NewG1()
+	p, err := g.FromCompressed(data)
+	if err != nil {
+		return nil, err
+	}
+	if g.IsZero(p) {
+		return nil, errors.New("public key is infinity")
+	}
+	return &PublicKey{p: p}, nil
+}
+
+func (sig *Signature) Serialize() []byte {
+	return bls12381.NewG2().ToCompressed(bls12381.NewG2().New().Set(sig.p))
+}
+
+func DeserializeSignature(data []byte) (*Signature, error) {
+	p, err := bls12381.NewG2().FromCompressed(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{p: p}, nil
+}
+
+// Verify checks the signature of msg using pk
+func Verify(pk *PublicKey, msg []byte, sig *Signature) bool {
+	return verify(pk, msg, sig, sigDomain)
+}
+
+// VerifyPossession checks the proof of possession of the private key of pk
+func VerifyPossession(pk *PublicKey, proof *Signature) bool {
+	return verify(pk, pk.Serialize(), proof, popDomain)
+}
+
+func verify(pk *PublicKey, msg []byte, sig *Signature, domain []byte) bool {
+	h, err := bls12381.NewG2().HashToCurve(msg, domain)
+	if err != nil {
+		return false
+	}
+	engine := bls12381.NewEngine()
+	engine.AddPair(engine.G1.New().Set(pk.p), h)
+	engine.AddPairInv(engine.G1.One(), engine.G2.New().Set(sig.p))
+	return engine.Check()
+}
+
+// AggregateSignatures returns the signature aggregated from sigs
+func AggregateSignatures(sigs []*Signature) (*Signature, error) {
+	if len(sigs) == 0 {
+		return nil, errors.New("no signature to aggregate")
+	}
+	g := bls12381.NewG2()
+	p := g.Zero()
+	for _, sig := range sigs {
+		g.Add(p, p, sig.p)
+	}
+	return &Signature{p: g.Affine(p)}, nil
+}
+
+// AggregatePublicKeys returns the public key aggregated from pks, which
+// verifies the aggregated signatures of pks on the same message
+func AggregatePublicKeys(pks []*PublicKey) (*PublicKey, error) {
+	if len(pks) == 0 {
+		return nil, errors.New("no public key to aggregate")
+	}
+	g := bls12381.NewG1()
+	p := g.Zero()
+	for _, pk := range pks {
+		g.Add(p, p, pk.p)
+	}
+	return &PublicKey{p: g.Affine(p)}, nil
+}
+
+// VerifyAggregate checks the aggregated signature of pks on the same msg.
+// Possession of the private keys of pks should have been verified.
+func VerifyAggregate(pks []*PublicKey, msg []byte, sig *Signature) bool {
+	pk, err := AggregatePublicKeys(pks)
+	if err != nil {
+		return false
+	}
+	return Verify(pk, msg, sig)
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bls
+
+import (
+	"bytes"
+	"testing"
+)
+
+func genKeys(t *testing.T, n int) []*PrivateKey {
+	keys := make([]*PrivateKey, 0, n)
+	for i := 0; i < n; i++ {
+		k, err := GenerateKey(bytes.Repeat([]byte{byte(i + 1)}, 32))
+		if err != nil {
+			t.Fatalf("generate key: %s", err)
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestSignVerify(t *testing.T) {
+	keys := genKeys(t, 2)
+	msg := []byte("block hash")
+	sig, err := keys[0].Sign(msg)
+	if err != nil {
+		t.Fatalf("sign: %s", err)
+	}
+	if !Verify(keys[0].PublicKey(), msg, sig) {
+		t.Errorf("valid signature failed to verify")
+	}
+	if Verify(keys[1].PublicKey(), msg, sig) {
+		t.Errorf("signature verified with other key")
+	}
+	if Verify(keys[0].PublicKey(), []byte("other msg"), sig) {
+		t.Errorf("signature verified with other msg")
+	}
+
+	pk, err := DeserializePublicKey(keys[0].PublicKey().Serialize())
+	if err != nil {
+		t.Fatalf("deserialize public key: %s", err)
+	}
+	sig2, err := DeserializeSignature(sig.Serialize())
+	if err != nil {
+		t.Fatalf("deserialize signature: %s", err)
+	}
+	if len(pk.Serialize()) != PUBLIC_KEY_SIZE || len(sig2.Serialize()) != SIGNATURE_SIZE {
+		t.Errorf("unexpected serialized size")
+	}
+	if !Verify(pk, msg, sig2) {
+		t.Errorf("deserialized signature failed to verify")
+	}
+	if _, err := GenerateKey([]byte("short")); err == nil {
+		t.Errorf("short seed should be rejected")
+	}
+}
+
+func TestProvePossession(t *testing.T) {
+	keys := genKeys(t, 2)
+	proof, err := keys[0].ProvePossession()
+	if err != nil {
+		t.Fatalf("prove possession: %s", err)
+	}
+	if !VerifyPossession(keys[0].PublicKey(), proof) {
+		t.Errorf("valid proof failed to verify")
+	}
+	if VerifyPossession(keys[1].PublicKey(), proof) {
+		t.Errorf("proof verified with other key")
+	}
+	// the proof is not a valid signature on the public key
+	if Verify(keys[0].PublicKey(), keys[0].PublicKey().Serialize(), proof) {
+		t.Errorf("proof verified as signature")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	keys := genKeys(t, 4)
+	msg := []byte("block hash")
+	pks := make([]*PublicKey, 0)
+	sigs := make([]*Signature, 0)
+	for _, k := range keys {
+		sig, err := k.Sign(msg)
+		if err != nil {
+			t.Fatalf("sign: %s", err)
+		}
+		pks = append(pks, k.PublicKey())
+		sigs = append(sigs, sig)
+	}
+	agg, err := AggregateSignatures(sigs)
+	if err != nil {
+		t.Fatalf("aggregate: %s", err)
+	}
+	if !VerifyAggregate(pks, msg, agg) {
+		t.Errorf("aggregated signature failed to verify")
+	}
+	if VerifyAggregate(pks[:3], msg, agg) {
+		t.Errorf("aggregated signature verified with missing signer")
+	}
+	agg, err = AggregateSignatures(sigs[:3])
+	if err != nil {
+		t.Fatalf("aggregate: %s", err)
+	}
+	if !VerifyAggregate(pks[:3], msg, agg) {
+		t.Errorf("aggregated signature of subset failed to verify")
+	}
+	if _, err := AggregateSignatures(nil); err == nil {
+		t.Errorf("empty aggregation should fail")
+	}
+}
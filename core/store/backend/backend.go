@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ontio/ontology/core/store/badgerstore"
+	"github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/store/memstore"
+)
+
+//Name of builtin store backends
+const (
+	LEVELDB = "leveldb"
+	BADGER  = "badger"
+	MEMORY  = "memory"
+)
+
+//NewStoreFunc open a persist store at path
+type NewStoreFunc func(path string) (common.PersistStore, error)
+
+var (
+	lock     sync.RWMutex
+	backends = make(map[string]NewStoreFunc)
+)
+
+func init() {
+	Register(LEVELDB, func(path string) (common.PersistStore, error) {
+		return leveldbstore.NewLevelDBStore(path)
+	})
+	Register(BADGER, func(path string) (common.PersistStore, error) {
+		return badgerstore.NewBadgerStore(path)
+	})
+	Register(MEMORY, func(path string) (common.PersistStore, error) {
+		return memstore.NewMemStore(), nil
+	})
+}
+
+//Register add a store backend with name, panic if the name has been registered
+func Register(name string, newStore NewStoreFunc) {
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := backends[name]; ok {
+		panic(fmt.Sprintf("store backend %s registered twice", name))
+	}
+	backends[name] = newStore
+}
+
+//NewStore open a persist store at path with the backend of name, empty name means leveldb
+func NewStore(name string, path string) (common.PersistStore, error) {
+	if name == "" {
+		name = LEVELDB
+	}
+	lock.RLock()
+	newStore, ok := backends[name]
+	lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown store backend %s", name)
+	}
+	return newStore(path)
+}
+
+//Backends return the sorted names of all registered backends
+func Backends() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
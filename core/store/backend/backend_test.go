@@ -0,0 +1,352 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/ontio/ontology/core/store/common"
+	"github.com/stretchr/testify/assert"
+)
+
+//every registered backend must pass the test suite
+func TestBackendConformance(t *testing.T) {
+	suite := []struct {
+		name       string
+		persistent bool
+		test       func(t *testing.T, open func() common.PersistStore)
+	}{
+		{"PutGetDelete", false, testPutGetDelete},
+		{"Batch", false, testBatch},
+		{"IteratorForward", false, testIteratorForward},
+		{"IteratorBackward", false, testIteratorBackward},
+		{"IteratorSeek", false, testIteratorSeek},
+		{"IteratorPrefixEdge", false, testIteratorPrefixEdge},
+		{"Reopen", true, testReopen},
+	}
+	for _, name := range Backends() {
+		for _, c := range suite {
+			t.Run(name+"/"+c.name, func(t *testing.T) {
+				dir, err := ioutil.TempDir("", "store-"+name)
+				assert.Nil(t, err)
+				defer os.RemoveAll(dir)
+				if c.persistent && name == MEMORY {
+					t.Skip("data is not persisted by memory store")
+				}
+				var store common.PersistStore
+				// close the store opened before and open it again
+				open := func() common.PersistStore {
+					if store != nil {
+						assert.Nil(t, store.Close())
+					}
+					store, err = NewStore(name, filepath.Join(dir, "db"))
+					if err != nil {
+						t.Fatalf("NewStore %s error %s", name, err)
+					}
+					return store
+				}
+				c.test(t, open)
+				assert.Nil(t, store.Close())
+			})
+		}
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	_, err := NewStore("unknown", "")
+	assert.NotNil(t, err)
+	store, err := NewStore(MEMORY, "")
+	assert.Nil(t, err)
+	assert.Nil(t, store.Close())
+	assert.Equal(t, []string{BADGER, LEVELDB, MEMORY}, Backends())
+}
+
+func testPutGetDelete(t *testing.T, open func() common.PersistStore) {
+	store := open()
+	key, value := []byte("key"), []byte("value")
+	_, err := store.Get(key)
+	assert.Equal(t, common.ErrNotFound, err)
+	has, err := store.Has(key)
+	assert.Nil(t, err)
+	assert.False(t, has)
+
+	assert.Nil(t, store.Put(key, value))
+	data, err := store.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, value, data)
+	has, err = store.Has(key)
+	assert.Nil(t, err)
+	assert.True(t, has)
+
+	// returned value should not share memory with store
+	data[0] = 'x'
+	data, _ = store.Get(key)
+	assert.Equal(t, value, data)
+
+	assert.Nil(t, store.Put(key, []byte{}))
+	data, err = store.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(data))
+
+	assert.Nil(t, store.Delete(key))
+	_, err = store.Get(key)
+	assert.Equal(t, common.ErrNotFound, err)
+	assert.Nil(t, store.Delete([]byte("not exist")))
+}
+
+func testBatch(t *testing.T, open func() common.PersistStore) {
+	store := open()
+	assert.Nil(t, store.Put([]byte("k0"), []byte("v0")))
+
+	store.NewBatch()
+	key := []byte("k1")
+	store.BatchPut(key, []byte("v1"))
+	// batch should copy the key
+	key[1] = '2'
+	store.BatchPut(key, []byte("v2"))
+	store.BatchDelete([]byte("k0"))
+	store.BatchPut([]byte("k3"), []byte("v3"))
+	store.BatchDelete([]byte("k3"))
+
+	_, err := store.Get([]byte("k1"))
+	assert.Equal(t, common.ErrNotFound, err)
+	_, err = store.Get([]byte("k0"))
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.BatchCommit())
+	for _, k := range []string{"k1", "k2"} {
+		data, err := store.Get([]byte(k))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("v"+k[1:]), data)
+	}
+	for _, k := range []string{"k0", "k3"} {
+		_, err := store.Get([]byte(k))
+		assert.Equal(t, common.ErrNotFound, err)
+	}
+}
+
+func putKeys(t *testing.T, store common.PersistStore, keys [][]byte) {
+	store.NewBatch()
+	for _, key := range keys {
+		store.BatchPut(key, append([]byte("v"), key...))
+	}
+	assert.Nil(t, store.BatchCommit())
+}
+
+func testKeys() (all [][]byte, prefixed [][]byte) {
+	for i := 0; i < 50; i++ {
+		all = append(all, []byte(fmt.Sprintf("a%03d", i)))
+		all = append(all, []byte(fmt.Sprintf("b%03d", i)))
+		all = append(all, []byte(fmt.Sprintf("c%03d", i)))
+		prefixed = append(prefixed, []byte(fmt.Sprintf("b%03d", i)))
+	}
+	all = append(all, []byte("b"))
+	prefixed = append(prefixed, []byte("b"))
+	sort.Slice(prefixed, func(i, j int) bool {
+		return bytes.Compare(prefixed[i], prefixed[j]) < 0
+	})
+	return
+}
+
+func testIteratorForward(t *testing.T, open func() common.PersistStore) {
+	store := open()
+	all, prefixed := testKeys()
+	putKeys(t, store, all)
+
+	iter := store.NewIterator([]byte("b"))
+	i := 0
+	for has := iter.First(); has; has = iter.Next() {
+		assert.Equal(t, prefixed[i], iter.Key())
+		assert.Equal(t, append([]byte("v"), prefixed[i]...), iter.Value())
+		i++
+	}
+	assert.Equal(t, len(prefixed), i)
+	assert.False(t, iter.Next())
+	assert.Nil(t, iter.Error())
+	iter.Release()
+
+	// Next of new iterator move to first item
+	iter = store.NewIterator(nil)
+	sorted := append([][]byte{}, all...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+	i = 0
+	for iter.Next() {
+		assert.Equal(t, sorted[i], iter.Key())
+		i++
+	}
+	assert.Equal(t, len(sorted), i)
+	iter.Release()
+
+	iter = store.NewIterator([]byte("d"))
+	assert.False(t, iter.First())
+	assert.False(t, iter.Last())
+	assert.False(t, iter.Seek([]byte("d")))
+	assert.Nil(t, iter.Error())
+	iter.Release()
+}
+
+func testIteratorBackward(t *testing.T, open func() common.PersistStore) {
+	store := open()
+	all, prefixed := testKeys()
+	putKeys(t, store, all)
+
+	iter := store.NewIterator([]byte("b"))
+	defer iter.Release()
+	i := len(prefixed) - 1
+	for has := iter.Last(); has; has = iter.Prev() {
+		assert.Equal(t, prefixed[i], iter.Key())
+		assert.Equal(t, append([]byte("v"), prefixed[i]...), iter.Value())
+		i--
+	}
+	assert.Equal(t, -1, i)
+	assert.False(t, iter.Prev())
+	// Next at start of iteration move to first item
+	assert.True(t, iter.Next())
+	assert.Equal(t, prefixed[0], iter.Key())
+	assert.False(t, iter.Prev())
+
+	// Prev at end of iteration move to last item
+	assert.True(t, iter.Last())
+	assert.False(t, iter.Next())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, prefixed[len(prefixed)-1], iter.Key())
+
+	// change direction in the middle
+	assert.True(t, iter.First())
+	for i := 1; i < 10; i++ {
+		assert.True(t, iter.Next())
+	}
+	assert.Equal(t, prefixed[9], iter.Key())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, prefixed[8], iter.Key())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, prefixed[7], iter.Key())
+	assert.True(t, iter.Next())
+	assert.Equal(t, prefixed[8], iter.Key())
+	assert.Nil(t, iter.Error())
+}
+
+func testIteratorSeek(t *testing.T, open func() common.PersistStore) {
+	store := open()
+	all, prefixed := testKeys()
+	putKeys(t, store, all)
+
+	iter := store.NewIterator([]byte("b"))
+	defer iter.Release()
+	assert.True(t, iter.Seek([]byte("b010")))
+	assert.Equal(t, []byte("b010"), iter.Key())
+	assert.Equal(t, []byte("vb010"), iter.Value())
+	assert.True(t, iter.Next())
+	assert.Equal(t, []byte("b011"), iter.Key())
+
+	// seek to the key not exist
+	assert.True(t, iter.Seek([]byte("b0105")))
+	assert.Equal(t, []byte("b011"), iter.Key())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, []byte("b010"), iter.Key())
+
+	// seek before the prefix range
+	assert.True(t, iter.Seek([]byte("a")))
+	assert.Equal(t, prefixed[0], iter.Key())
+
+	// seek after the prefix range
+	assert.False(t, iter.Seek([]byte("c")))
+	assert.True(t, iter.Prev())
+	assert.Equal(t, prefixed[len(prefixed)-1], iter.Key())
+	assert.Nil(t, iter.Error())
+}
+
+func testIteratorPrefixEdge(t *testing.T, open func() common.PersistStore) {
+	store := open()
+	keys := [][]byte{{0x01}, {0x01, 0xff}, {0x01, 0xff, 0x00}, {0x01, 0xff, 0xff}, {0x02}, {0xff}, {0xff, 0xff}}
+	putKeys(t, store, keys)
+
+	iter := store.NewIterator([]byte{0x01, 0xff})
+	assert.True(t, iter.Last())
+	assert.Equal(t, []byte{0x01, 0xff, 0xff}, iter.Key())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, []byte{0x01, 0xff, 0x00}, iter.Key())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, []byte{0x01, 0xff}, iter.Key())
+	assert.False(t, iter.Prev())
+	iter.Release()
+
+	iter = store.NewIterator([]byte{0xff})
+	assert.True(t, iter.Last())
+	assert.Equal(t, []byte{0xff, 0xff}, iter.Key())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, []byte{0xff}, iter.Key())
+	assert.False(t, iter.Prev())
+	iter.Release()
+
+	iter = store.NewIterator(nil)
+	assert.True(t, iter.Last())
+	assert.Equal(t, []byte{0xff, 0xff}, iter.Key())
+	assert.True(t, iter.First())
+	assert.Equal(t, []byte{0x01}, iter.Key())
+	iter.Release()
+}
+
+func testReopen(t *testing.T, open func() common.PersistStore) {
+	store := open()
+	assert.Nil(t, store.Put([]byte("k0"), []byte("v0")))
+	store.NewBatch()
+	store.BatchPut([]byte("k1"), []byte("v1"))
+	assert.Nil(t, store.BatchCommit())
+
+	store = open()
+	for _, k := range []string{"k0", "k1"} {
+		data, err := store.Get([]byte(k))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("v"+k[1:]), data)
+	}
+}
+
+func TestBadgerBatchTooBig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store-"+BADGER)
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	store, err := NewStore(BADGER, filepath.Join(dir, "db"))
+	assert.Nil(t, err)
+	defer store.Close()
+
+	assert.Nil(t, store.Put([]byte("k0"), []byte("v0")))
+	store.NewBatch()
+	store.BatchPut([]byte("k0"), []byte("v1"))
+	// values kept in LSM tree count in the transaction size limit of badger
+	value := make([]byte, 60*1024)
+	for i := 0; i < 200; i++ {
+		store.BatchPut([]byte(fmt.Sprintf("big%03d", i)), value)
+	}
+	// batch is applied atomically or not at all
+	assert.NotNil(t, store.BatchCommit())
+	data, err := store.Get([]byte("k0"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("v0"), data)
+	_, err = store.Get([]byte("big000"))
+	assert.Equal(t, common.ErrNotFound, err)
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package badgerstore
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store/common"
+)
+
+//interval of value log garbage collection
+const GC_INTERVAL = 10 * time.Minute
+
+//discard ratio of value log file to be rewritten by garbage collection
+const GC_DISCARD_RATIO = 0.5
+
+//BadgerDB store
+type BadgerStore struct {
+	db     *badger.DB // BadgerDB instance
+	batch  []batchOp
+	closed chan struct{}
+	wg     sync.WaitGroup
+}
+
+type batchOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+//NewBadgerStore return BadgerStore instance
+func NewBadgerStore(file string) (*BadgerStore, error) {
+	// values are kept in LSM tree like leveldb, value log largely acting as write-ahead log.
+	// truncate the corrupted data at the end of value log after crash
+	opts := badger.LSMOnlyOptions(file).
+		WithSyncWrites(false).
+		WithTruncate(true).
+		WithLogger(logger{})
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	store := &BadgerStore{
+		db:     db,
+		closed: make(chan struct{}),
+	}
+	store.wg.Add(1)
+	go store.runValueLogGC()
+	return store, nil
+}
+
+func (self *BadgerStore) runValueLogGC() {
+	defer self.wg.Done()
+	ticker := time.NewTicker(GC_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// one call rewrites at most one file, returns ErrNoRewrite if nothing to rewrite
+			for self.db.RunValueLogGC(GC_DISCARD_RATIO) == nil {
+			}
+		case <-self.closed:
+			return
+		}
+	}
+}
+
+//Put a key-value pair to badger
+func (self *BadgerStore) Put(key []byte, value []byte) error {
+	return self.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key, value)
+	})
+}
+
+//Get the value of a key from badger
+func (self *BadgerStore) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := self.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+//Has return whether the key is exist in badger
+func (self *BadgerStore) Has(key []byte) (bool, error) {
+	err := self.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(key)
+		return err
+	})
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+//Delete the the in badger
+func (self *BadgerStore) Delete(key []byte) error {
+	return self.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+//NewBatch start commit batch
+func (self *BadgerStore) NewBatch() {
+	self.batch = make([]batchOp, 0)
+}
+
+//BatchPut put a key-value pair to badger batch
+func (self *BadgerStore) BatchPut(key []byte, value []byte) {
+	self.batch = append(self.batch, batchOp{
+		key:   append([]byte{}, key...),
+		value: append([]byte{}, value...),
+	})
+}
+
+//BatchDelete delete a key to badger batch
+func (self *BadgerStore) BatchDelete(key []byte) {
+	self.batch = append(self.batch, batchOp{
+		key:    append([]byte{}, key...),
+		delete: true,
+	})
+}
+
+//BatchCommit commit batch to badger in one transaction, so that the batch is applied atomically.
+//Batch too big to fit into one transaction of badger is not committed.
+func (self *BadgerStore) BatchCommit() error {
+	txn := self.db.NewTransaction(true)
+	defer txn.Discard()
+	for _, op := range self.batch {
+		err := op.apply(txn)
+		if err == badger.ErrTxnTooBig {
+			return fmt.Errorf("batch of %d ops is too big for one badger transaction", len(self.batch))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	err := txn.Commit()
+	if err != nil {
+		return err
+	}
+	self.batch = nil
+	return nil
+}
+
+func (self *batchOp) apply(txn *badger.Txn) error {
+	if self.delete {
+		return txn.Delete(self.key)
+	}
+	return txn.Set(self.key, self.value)
+}
+
+//Close badger
+func (self *BadgerStore) Close() error {
+	close(self.closed)
+	self.wg.Wait()
+	return self.db.Close()
+}
+
+//NewIterator return a iterator of badger with the key prefix
+func (self *BadgerStore) NewIterator(prefix []byte) common.StoreIterator {
+	return newIterator(self.db.NewTransaction(false), prefix)
+}
+
+//logger output badger log by ontology log
+type logger struct{}
+
+func (logger) Errorf(format string, a ...interface{}) {
+	log.Errorf(format, a...)
+}
+
+func (logger) Warningf(format string, a ...interface{}) {
+	log.Warnf(format, a...)
+}
+
+func (logger) Infof(format string, a ...interface{}) {
+	log.Debugf(format, a...)
+}
+
+func (logger) Debugf(format string, a ...interface{}) {
+	log.Debugf(format, a...)
+}
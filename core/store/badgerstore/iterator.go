@@ -0,0 +1,197 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package badgerstore
+
+import (
+	"bytes"
+
+	"github.com/dgraph-io/badger"
+)
+
+type iterPos byte
+
+const (
+	posSOI iterPos = iota // start of iteration, also the position of a new iterator
+	posValid
+	posEOI // end of iteration
+)
+
+//Iterator of badger store. Badger iterator can only iterate in one direction,
+//so a new one is created at current key when the direction changes
+type Iterator struct {
+	txn        *badger.Txn
+	prefix     []byte
+	iter       *badger.Iterator
+	reverse    bool
+	pos        iterPos
+	key, value []byte
+	err        error
+}
+
+func newIterator(txn *badger.Txn, prefix []byte) *Iterator {
+	return &Iterator{
+		txn:    txn,
+		prefix: append([]byte{}, prefix...),
+	}
+}
+
+func (self *Iterator) setDirection(reverse bool) {
+	if self.iter != nil {
+		if self.reverse == reverse {
+			return
+		}
+		self.iter.Close()
+	}
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = reverse
+	self.iter = self.txn.NewIterator(opts)
+	self.reverse = reverse
+}
+
+func (self *Iterator) fill() bool {
+	if self.err == nil && self.iter.ValidForPrefix(self.prefix) {
+		item := self.iter.Item()
+		self.key = item.KeyCopy(nil)
+		self.value, self.err = item.ValueCopy(nil)
+		if self.err == nil {
+			self.pos = posValid
+			return true
+		}
+	}
+	self.key = nil
+	self.value = nil
+	if self.reverse {
+		self.pos = posSOI
+	} else {
+		self.pos = posEOI
+	}
+	return false
+}
+
+//seek to key and skip it if found, the iterator is at the item next to key in current direction
+func (self *Iterator) seekAfter(key []byte) {
+	self.iter.Seek(key)
+	if self.iter.Valid() && bytes.Equal(self.iter.Item().Key(), key) {
+		self.iter.Next()
+	}
+}
+
+//First item. If item available return true, otherwise return false
+func (self *Iterator) First() bool {
+	self.setDirection(false)
+	self.iter.Seek(self.prefix)
+	return self.fill()
+}
+
+//Last item. If item available return true, otherwise return false
+func (self *Iterator) Last() bool {
+	self.setDirection(true)
+	limit := prefixLimit(self.prefix)
+	if limit == nil {
+		self.iter.Rewind()
+	} else {
+		self.seekAfter(limit)
+	}
+	return self.fill()
+}
+
+//Seek to the first item whose key is greater than or equal to key.
+//If item available return true, otherwise return false
+func (self *Iterator) Seek(key []byte) bool {
+	self.setDirection(false)
+	if bytes.Compare(key, self.prefix) < 0 {
+		key = self.prefix
+	}
+	self.iter.Seek(key)
+	return self.fill()
+}
+
+//Next item. If item available return true, otherwise return false
+func (self *Iterator) Next() bool {
+	switch self.pos {
+	case posSOI:
+		return self.First()
+	case posEOI:
+		return false
+	}
+	if self.reverse {
+		self.setDirection(false)
+		self.seekAfter(self.key)
+	} else {
+		self.iter.Next()
+	}
+	return self.fill()
+}
+
+//Prev item. If item available return true, otherwise return false
+func (self *Iterator) Prev() bool {
+	switch self.pos {
+	case posSOI:
+		return false
+	case posEOI:
+		return self.Last()
+	}
+	if !self.reverse {
+		self.setDirection(true)
+		self.seekAfter(self.key)
+	} else {
+		self.iter.Next()
+	}
+	return self.fill()
+}
+
+//Key return the current item key
+func (self *Iterator) Key() []byte {
+	return self.key
+}
+
+//Value return the current item value
+func (self *Iterator) Value() []byte {
+	return self.value
+}
+
+//Release iterator and discard the read transaction
+func (self *Iterator) Release() {
+	if self.iter != nil {
+		self.iter.Close()
+		self.iter = nil
+	}
+	self.txn.Discard()
+	self.key = nil
+	self.value = nil
+}
+
+//Error returns any accumulated error.
+func (self *Iterator) Error() error {
+	return self.err
+}
+
+//prefixLimit return the smallest key greater than all keys with the prefix,
+//nil if there is no such key
+func prefixLimit(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] < 0xff {
+			limit := make([]byte, i+1)
+			copy(limit, prefix)
+			limit[i]++
+			return limit
+		}
+	}
+	return nil
+}
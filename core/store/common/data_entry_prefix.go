@@ -27,6 +27,8 @@ const (
 	DATA_HEADER                            = 0x01 //Block hash => block hash key prefix
 	DATA_TRANSACTION                       = 0x02 //Transction hash = > transaction key prefix
 	DATA_STATE_MERKLE_ROOT                 = 0x21 // block height => write set hash + state merkle root
+	DATA_STATE_HISTORY                     = 0x22 // state key + block height => state value before the block
+	DATA_STATE_UNDO                        = 0x23 // block height => undo log of the state changed by the block
 
 	// Transaction
 	ST_BOOKKEEPER DataEntryPrefix = 0x03 //BookKeeper state key prefix
@@ -36,6 +38,10 @@ const (
 	ST_VOTE       DataEntryPrefix = 0x08 //Vote state key prefix
 
 	IX_HEADER_HASH_LIST DataEntryPrefix = 0x09 //Block height => block hash key prefix
+	IX_ADDRESS_TX       DataEntryPrefix = 0x24 // address + block height + tx index => transaction hash
+	IX_ADDRESS_BLOCK    DataEntryPrefix = 0x25 // block height => addresses indexed in the block
+	IX_TRANSFER         DataEntryPrefix = 0x26 // contract + address + block height + tx index + notify index => transfer
+	IX_TRANSFER_BLOCK   DataEntryPrefix = 0x27 // block height => contract and addresses of transfers in the block
 
 	//SYSTEM
 	SYS_CURRENT_BLOCK      DataEntryPrefix = 0x10 //Current block key prefix
@@ -43,6 +49,9 @@ const (
 	SYS_CURRENT_STATE_ROOT DataEntryPrefix = 0x12 //no use
 	SYS_BLOCK_MERKLE_TREE  DataEntryPrefix = 0x13 // Block merkle tree root key prefix
 	SYS_STATE_MERKLE_TREE  DataEntryPrefix = 0x20 // state merkle tree root key prefix
+	SYS_STATE_ARCHIVE      DataEntryPrefix = 0x15 // first block height of state archive
+	SYS_PRUNED_HEIGHT      DataEntryPrefix = 0x16 // lowest block height whose transactions are not pruned
 
-	EVENT_NOTIFY DataEntryPrefix = 0x14 //Event notify key prefix
+	EVENT_NOTIFY  DataEntryPrefix = 0x14 //Event notify key prefix
+	EVENT_RECEIPT DataEntryPrefix = 0x28 // transaction hash => receipt, block height => transaction hashes of the receipts
 )
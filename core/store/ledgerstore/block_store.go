@@ -24,19 +24,20 @@ import (
 	"fmt"
 
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/serialization"
+	"github.com/ontio/ontology/core/store/backend"
 	scom "github.com/ontio/ontology/core/store/common"
-	"github.com/ontio/ontology/core/store/leveldbstore"
 	"github.com/ontio/ontology/core/types"
 	"io"
 )
 
 //Block store save the data of block & transaction
 type BlockStore struct {
-	enableCache bool                       //Is enable lru cache
-	dbDir       string                     //The path of store file
-	cache       *BlockCache                //The cache of block, if have.
-	store       *leveldbstore.LevelDBStore //block store handler
+	enableCache bool              //Is enable lru cache
+	dbDir       string            //The path of store file
+	cache       *BlockCache       //The cache of block, if have.
+	store       scom.PersistStore //block store handler
 }
 
 //NewBlockStore return the block store instance
@@ -50,7 +51,7 @@ func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
 		}
 	}
 
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	store, err := backend.NewStore(config.DefConfig.Common.StoreBackend, dbDir)
 	if err != nil {
 		return nil, err
 	}
@@ -123,6 +124,9 @@ func (this *BlockStore) GetBlock(blockHash common.Uint256) (*types.Block, error)
 	txList := make([]*types.Transaction, 0, len(txHashes))
 	for _, txHash := range txHashes {
 		tx, _, err := this.GetTransaction(txHash)
+		if err == scom.ErrPruned {
+			return nil, err
+		}
 		if err != nil {
 			return nil, fmt.Errorf("GetTransaction %s error %s", txHash.ToHexString(), err)
 		}
@@ -407,6 +411,10 @@ func (this *BlockStore) loadTransaction(txHash common.Uint256) (*types.Transacti
 	if eof {
 		return nil, 0, io.ErrUnexpectedEOF
 	}
+	//only height is kept for pruned transaction
+	if source.Len() == 0 {
+		return nil, height, scom.ErrPruned
+	}
 	tx = new(types.Transaction)
 	err = tx.Deserialization(source)
 	if err != nil {
@@ -451,6 +459,70 @@ func (this *BlockStore) SaveVersion(ver byte) error {
 	return this.store.Put(key, []byte{ver})
 }
 
+//BatchDeleteBlock delete the block, its transactions and height index from store
+func (this *BlockStore) BatchDeleteBlock(block *types.Block) {
+	blockHash := block.Hash()
+	this.store.BatchDelete(this.getHeaderKey(blockHash))
+	for _, tx := range block.Transactions {
+		this.store.BatchDelete(this.getTransactionKey(tx.Hash()))
+	}
+	this.store.BatchDelete(this.getBlockHashKey(block.Header.Height))
+}
+
+//BatchPruneBlock replace the transactions of block with their height, header of block is kept
+func (this *BlockStore) BatchPruneBlock(blockHash common.Uint256) error {
+	header, txHashes, err := this.loadHeaderWithTx(blockHash)
+	if err != nil {
+		return err
+	}
+	value := make([]byte, 4)
+	binary.LittleEndian.PutUint32(value, header.Height)
+	for _, txHash := range txHashes {
+		this.store.BatchPut(this.getTransactionKey(txHash), value)
+	}
+	return nil
+}
+
+//GetPrunedHeight return the lowest block height whose transactions are not pruned
+func (this *BlockStore) GetPrunedHeight() (uint32, error) {
+	value, err := this.store.Get(this.getPrunedHeightKey())
+	if err == scom.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(value) != 4 {
+		return 0, fmt.Errorf("invalid pruned height")
+	}
+	return binary.LittleEndian.Uint32(value), nil
+}
+
+//SavePrunedHeight persist the lowest block height whose transactions are not pruned to batch
+func (this *BlockStore) SavePrunedHeight(height uint32) {
+	value := make([]byte, 4)
+	binary.LittleEndian.PutUint32(value, height)
+	this.store.BatchPut(this.getPrunedHeightKey(), value)
+}
+
+//BatchDeleteHeaderIndexList delete the header index list start from startIndex
+func (this *BlockStore) BatchDeleteHeaderIndexList(startIndex uint32) {
+	this.store.BatchDelete(this.getHeaderIndexListKey(startIndex))
+}
+
+//ResetCache drop all the blocks and transactions in cache
+func (this *BlockStore) ResetCache() error {
+	if !this.enableCache {
+		return nil
+	}
+	cache, err := NewBlockCache()
+	if err != nil {
+		return fmt.Errorf("NewBlockCache error %s", err)
+	}
+	this.cache = cache
+	return nil
+}
+
 //ClearAll clear all the data of block store
 func (this *BlockStore) ClearAll() error {
 	this.NewBatch()
@@ -505,6 +577,10 @@ func (this *BlockStore) getBlockMerkleTreeKey() []byte {
 	return []byte{byte(scom.SYS_BLOCK_MERKLE_TREE)}
 }
 
+func (this *BlockStore) getPrunedHeightKey() []byte {
+	return []byte{byte(scom.SYS_PRUNED_HEIGHT)}
+}
+
 func (this *BlockStore) getVersionKey() []byte {
 	return []byte{byte(scom.SYS_VERSION)}
 }
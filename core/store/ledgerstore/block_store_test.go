@@ -26,6 +26,7 @@ import (
 	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/payload"
+	scom "github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/core/utils"
 	"github.com/ontio/ontology/smartcontract/service/native/ont"
@@ -373,6 +374,48 @@ func TestBlock(t *testing.T) {
 	}
 }
 
+func TestPruneBlock(t *testing.T) {
+	header := &types.Header{
+		Version:          123,
+		PrevBlockHash:    common.Uint256{},
+		TransactionsRoot: common.Uint256{},
+		Timestamp:        uint32(uint32(time.Date(2017, time.February, 23, 0, 0, 0, 0, time.UTC).Unix())),
+		Height:           uint32(3),
+		ConsensusData:    1234567890,
+	}
+	tx1, err := transferTx(common.Address{1}, common.Address{2}, 10)
+	assert.Nil(t, err)
+	block := &types.Block{
+		Header:       header,
+		Transactions: []*types.Transaction{tx1},
+	}
+	blockHash := block.Hash()
+
+	testBlockStore.NewBatch()
+	assert.Nil(t, testBlockStore.SaveBlock(block))
+	assert.Nil(t, testBlockStore.CommitTo())
+
+	testBlockStore.NewBatch()
+	assert.Nil(t, testBlockStore.BatchPruneBlock(blockHash))
+	testBlockStore.SavePrunedHeight(4)
+	assert.Nil(t, testBlockStore.CommitTo())
+
+	_, err = testBlockStore.GetBlock(blockHash)
+	assert.Equal(t, scom.ErrPruned, err)
+	_, height, err := testBlockStore.GetTransaction(tx1.Hash())
+	assert.Equal(t, scom.ErrPruned, err)
+	assert.Equal(t, uint32(3), height)
+	exist, err := testBlockStore.ContainTransaction(tx1.Hash())
+	assert.Nil(t, err)
+	assert.True(t, exist)
+	h, err := testBlockStore.GetHeader(blockHash)
+	assert.Nil(t, err)
+	assert.Equal(t, blockHash, h.Hash())
+	prunedHeight, err := testBlockStore.GetPrunedHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(4), prunedHeight)
+}
+
 func transferTx(from, to common.Address, amount uint64) (*types.Transaction, error) {
 	buf := bytes.NewBuffer(nil)
 	var sts []ont.State
@@ -24,22 +24,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/common/serialization"
+	"github.com/ontio/ontology/core/store"
+	"github.com/ontio/ontology/core/store/backend"
 	scom "github.com/ontio/ontology/core/store/common"
-	"github.com/ontio/ontology/core/store/leveldbstore"
 	"github.com/ontio/ontology/smartcontract/event"
+	"math"
 )
 
 //Saving event notifies gen by smart contract execution
 type EventStore struct {
-	dbDir string                     //Store path
-	store *leveldbstore.LevelDBStore //Store handler
+	dbDir string            //Store path
+	store scom.PersistStore //Store handler
 }
 
 //NewEventStore return event store instance
 func NewEventStore(dbDir string) (*EventStore, error) {
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	store, err := backend.NewStore(config.DefConfig.Common.StoreBackend, dbDir)
 	if err != nil {
 		return nil, err
 	}
@@ -134,6 +137,352 @@ func (this *EventStore) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNo
 	return evtNotifies, nil
 }
 
+//BatchDeleteEventNotifyByBlock delete all event notify of transaction in block
+func (this *EventStore) BatchDeleteEventNotifyByBlock(height uint32) error {
+	key, err := this.getEventNotifyByBlockKey(height)
+	if err != nil {
+		return err
+	}
+	data, err := this.store.Get(key)
+	if err == scom.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	reader := bytes.NewBuffer(data)
+	size, err := serialization.ReadUint32(reader)
+	if err != nil {
+		return fmt.Errorf("ReadUint32 error %s", err)
+	}
+	for i := uint32(0); i < size; i++ {
+		var txHash common.Uint256
+		err = txHash.Deserialize(reader)
+		if err != nil {
+			return fmt.Errorf("txHash.Deserialize error %s", err)
+		}
+		this.store.BatchDelete(this.getEventNotifyByTxKey(txHash))
+	}
+	this.store.BatchDelete(key)
+	return nil
+}
+
+//SaveAddressIndexByBlock persist the index of address => transaction hash for the transactions in block,
+//txAddrs[i] is the addresses related to txHashes[i]
+func (this *EventStore) SaveAddressIndexByBlock(height uint32, txHashes []common.Uint256, txAddrs [][]common.Address) error {
+	indexed := make(map[common.Address]bool)
+	addrs := make([]common.Address, 0)
+	for i, txHash := range txHashes {
+		for _, addr := range txAddrs[i] {
+			this.store.BatchPut(this.getAddressTxKey(addr, height, uint32(i)), txHash.ToArray())
+			if !indexed[addr] {
+				indexed[addr] = true
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	if len(addrs) == 0 {
+		return nil
+	}
+	value := bytes.NewBuffer(nil)
+	err := serialization.WriteUint32(value, uint32(len(addrs)))
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		err = addr.Serialize(value)
+		if err != nil {
+			return err
+		}
+	}
+	this.store.BatchPut(this.getAddressBlockKey(height), value.Bytes())
+	return nil
+}
+
+//BatchDeleteAddressIndexByBlock delete the address index of transactions in block
+func (this *EventStore) BatchDeleteAddressIndexByBlock(height uint32) error {
+	key := this.getAddressBlockKey(height)
+	data, err := this.store.Get(key)
+	if err == scom.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	reader := bytes.NewBuffer(data)
+	size, err := serialization.ReadUint32(reader)
+	if err != nil {
+		return fmt.Errorf("ReadUint32 error %s", err)
+	}
+	for i := uint32(0); i < size; i++ {
+		var addr common.Address
+		err = addr.Deserialize(reader)
+		if err != nil {
+			return fmt.Errorf("address.Deserialize error %s", err)
+		}
+		iter := this.store.NewIterator(this.getAddressHeightTxPrefix(addr, height))
+		for iter.Next() {
+			this.store.BatchDelete(iter.Key())
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+	this.store.BatchDelete(key)
+	return nil
+}
+
+//GetTransactionsByAddress return the transactions related to address at or below the block height, in descending
+//order of block height. Transactions of a block are never split, so more than limit transactions may be returned.
+func (this *EventStore) GetTransactionsByAddress(address common.Address, height uint32, limit uint32) ([]*store.AddressTx, error) {
+	txs := make([]*store.AddressTx, 0)
+	if limit == 0 {
+		return txs, nil
+	}
+	prefix := this.getAddressTxPrefix(address)
+	iter := this.store.NewIterator(prefix)
+	defer iter.Release()
+	var has bool
+	if height == math.MaxUint32 || !iter.Seek(this.getAddressTxKey(address, height+1, 0)) {
+		has = iter.Last()
+	} else {
+		has = iter.Prev()
+	}
+	for ; has; has = iter.Prev() {
+		key := iter.Key()
+		if len(key) != len(prefix)+8 {
+			return nil, fmt.Errorf("invalid address index key %x", key)
+		}
+		txHeight := binary.BigEndian.Uint32(key[len(prefix):])
+		if uint32(len(txs)) >= limit && txs[len(txs)-1].Height != txHeight {
+			break
+		}
+		txHash, err := common.Uint256ParseFromBytes(iter.Value())
+		if err != nil {
+			return nil, fmt.Errorf("Uint256ParseFromBytes error %s", err)
+		}
+		txs = append(txs, &store.AddressTx{TxHash: txHash, Height: txHeight})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+//SaveTransferIndexByBlock persist the transfer records of contract and address for the transfers in block,
+//txTransfers[i] is the transfers of the i-th transaction in block
+func (this *EventStore) SaveTransferIndexByBlock(height uint32, txTransfers [][]*store.Transfer) error {
+	type contractAddress struct {
+		contract common.Address
+		address  common.Address
+	}
+	indexed := make(map[contractAddress]bool)
+	keys := make([]contractAddress, 0)
+	for i, transfers := range txTransfers {
+		for j, transfer := range transfers {
+			value := bytes.NewBuffer(nil)
+			err := transfer.TxHash.Serialize(value)
+			if err != nil {
+				return err
+			}
+			err = transfer.From.Serialize(value)
+			if err != nil {
+				return err
+			}
+			err = transfer.To.Serialize(value)
+			if err != nil {
+				return err
+			}
+			err = serialization.WriteVarBytes(value, common.BigIntToNeoBytes(transfer.Amount))
+			if err != nil {
+				return err
+			}
+			for _, addr := range []common.Address{transfer.From, transfer.To} {
+				this.store.BatchPut(this.getTransferKey(transfer.Contract, addr, height, uint32(i), uint32(j)), value.Bytes())
+				key := contractAddress{contract: transfer.Contract, address: addr}
+				if !indexed[key] {
+					indexed[key] = true
+					keys = append(keys, key)
+				}
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	value := bytes.NewBuffer(nil)
+	err := serialization.WriteUint32(value, uint32(len(keys)))
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		err = key.contract.Serialize(value)
+		if err != nil {
+			return err
+		}
+		err = key.address.Serialize(value)
+		if err != nil {
+			return err
+		}
+	}
+	this.store.BatchPut(this.getTransferBlockKey(height), value.Bytes())
+	return nil
+}
+
+//BatchDeleteTransferIndexByBlock delete the transfer records of transfers in block
+func (this *EventStore) BatchDeleteTransferIndexByBlock(height uint32) error {
+	key := this.getTransferBlockKey(height)
+	data, err := this.store.Get(key)
+	if err == scom.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	reader := bytes.NewBuffer(data)
+	size, err := serialization.ReadUint32(reader)
+	if err != nil {
+		return fmt.Errorf("ReadUint32 error %s", err)
+	}
+	for i := uint32(0); i < size; i++ {
+		var contract, addr common.Address
+		err = contract.Deserialize(reader)
+		if err != nil {
+			return fmt.Errorf("contract.Deserialize error %s", err)
+		}
+		err = addr.Deserialize(reader)
+		if err != nil {
+			return fmt.Errorf("address.Deserialize error %s", err)
+		}
+		iter := this.store.NewIterator(this.getTransferHeightPrefix(contract, addr, height))
+		for iter.Next() {
+			this.store.BatchDelete(iter.Key())
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+	this.store.BatchDelete(key)
+	return nil
+}
+
+//GetTransfersByAddress return the transfers of contract in which address is sender or receiver, between the start
+//and end block height, in ascending order of block height. Transfers of a block are never split, so more than
+//limit transfers may be returned.
+func (this *EventStore) GetTransfersByAddress(contract common.Address, address common.Address, startHeight uint32, endHeight uint32, limit uint32) ([]*store.Transfer, error) {
+	transfers := make([]*store.Transfer, 0)
+	if limit == 0 || startHeight > endHeight {
+		return transfers, nil
+	}
+	prefix := this.getTransferPrefix(contract, address)
+	iter := this.store.NewIterator(prefix)
+	defer iter.Release()
+	for has := iter.Seek(this.getTransferHeightPrefix(contract, address, startHeight)); has; has = iter.Next() {
+		key := iter.Key()
+		if len(key) != len(prefix)+12 {
+			return nil, fmt.Errorf("invalid transfer index key %x", key)
+		}
+		height := binary.BigEndian.Uint32(key[len(prefix):])
+		if height > endHeight {
+			break
+		}
+		if uint32(len(transfers)) >= limit && transfers[len(transfers)-1].Height != height {
+			break
+		}
+		transfer := &store.Transfer{Height: height, Contract: contract}
+		reader := bytes.NewBuffer(iter.Value())
+		err := transfer.TxHash.Deserialize(reader)
+		if err != nil {
+			return nil, fmt.Errorf("TxHash.Deserialize error %s", err)
+		}
+		err = transfer.From.Deserialize(reader)
+		if err != nil {
+			return nil, fmt.Errorf("From.Deserialize error %s", err)
+		}
+		err = transfer.To.Deserialize(reader)
+		if err != nil {
+			return nil, fmt.Errorf("To.Deserialize error %s", err)
+		}
+		amount, err := serialization.ReadVarBytes(reader)
+		if err != nil {
+			return nil, fmt.Errorf("ReadVarBytes error %s", err)
+		}
+		transfer.Amount = common.BigIntFromNeoBytes(amount)
+		transfers = append(transfers, transfer)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+//SaveReceiptByBlock persist the receipts of transactions in block
+func (this *EventStore) SaveReceiptByBlock(height uint32, receipts []*store.Receipt) error {
+	if len(receipts) == 0 {
+		return nil
+	}
+	values := bytes.NewBuffer(nil)
+	err := serialization.WriteUint32(values, uint32(len(receipts)))
+	if err != nil {
+		return err
+	}
+	for _, receipt := range receipts {
+		data, err := json.Marshal(receipt)
+		if err != nil {
+			return fmt.Errorf("json.Marshal error %s", err)
+		}
+		this.store.BatchPut(this.getReceiptKey(receipt.TxHash), data)
+		err = receipt.TxHash.Serialize(values)
+		if err != nil {
+			return err
+		}
+	}
+	this.store.BatchPut(this.getReceiptBlockKey(height), values.Bytes())
+	return nil
+}
+
+//GetReceipt return the receipt of transaction
+func (this *EventStore) GetReceipt(txHash common.Uint256) (*store.Receipt, error) {
+	data, err := this.store.Get(this.getReceiptKey(txHash))
+	if err != nil {
+		return nil, err
+	}
+	var receipt store.Receipt
+	if err = json.Unmarshal(data, &receipt); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal error %s", err)
+	}
+	return &receipt, nil
+}
+
+//BatchDeleteReceiptByBlock delete the receipts of transactions in block
+func (this *EventStore) BatchDeleteReceiptByBlock(height uint32) error {
+	key := this.getReceiptBlockKey(height)
+	data, err := this.store.Get(key)
+	if err == scom.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	reader := bytes.NewBuffer(data)
+	size, err := serialization.ReadUint32(reader)
+	if err != nil {
+		return fmt.Errorf("ReadUint32 error %s", err)
+	}
+	for i := uint32(0); i < size; i++ {
+		var txHash common.Uint256
+		err = txHash.Deserialize(reader)
+		if err != nil {
+			return fmt.Errorf("txHash.Deserialize error %s", err)
+		}
+		this.store.BatchDelete(this.getReceiptKey(txHash))
+	}
+	this.store.BatchDelete(key)
+	return nil
+}
+
 //CommitTo event store batch to store
 func (this *EventStore) CommitTo() error {
 	return this.store.BatchCommit()
@@ -207,3 +556,76 @@ func (this *EventStore) getEventNotifyByTxKey(txHash common.Uint256) []byte {
 	copy(key[1:], data)
 	return key
 }
+
+func (this *EventStore) getAddressTxPrefix(address common.Address) []byte {
+	key := make([]byte, 1+common.ADDR_LEN, 1+common.ADDR_LEN+8)
+	key[0] = byte(scom.IX_ADDRESS_TX)
+	copy(key[1:], address[:])
+	return key
+}
+
+func (this *EventStore) getAddressHeightTxPrefix(address common.Address, height uint32) []byte {
+	key := this.getAddressTxPrefix(address)
+	//big endian to keep the order of block height in iteration
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], height)
+	return append(key, buf[:]...)
+}
+
+func (this *EventStore) getAddressTxKey(address common.Address, height uint32, txIndex uint32) []byte {
+	key := this.getAddressHeightTxPrefix(address, height)
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], txIndex)
+	return append(key, buf[:]...)
+}
+
+func (this *EventStore) getAddressBlockKey(height uint32) []byte {
+	key := make([]byte, 5)
+	key[0] = byte(scom.IX_ADDRESS_BLOCK)
+	binary.LittleEndian.PutUint32(key[1:], height)
+	return key
+}
+
+func (this *EventStore) getTransferPrefix(contract common.Address, address common.Address) []byte {
+	key := make([]byte, 1+2*common.ADDR_LEN, 1+2*common.ADDR_LEN+12)
+	key[0] = byte(scom.IX_TRANSFER)
+	copy(key[1:], contract[:])
+	copy(key[1+common.ADDR_LEN:], address[:])
+	return key
+}
+
+func (this *EventStore) getTransferHeightPrefix(contract common.Address, address common.Address, height uint32) []byte {
+	key := this.getTransferPrefix(contract, address)
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], height)
+	return append(key, buf[:]...)
+}
+
+func (this *EventStore) getTransferKey(contract common.Address, address common.Address, height uint32, txIndex uint32, notifyIndex uint32) []byte {
+	key := this.getTransferHeightPrefix(contract, address, height)
+	var buf [8]byte
+	binary.BigEndian.PutUint32(buf[:4], txIndex)
+	binary.BigEndian.PutUint32(buf[4:], notifyIndex)
+	return append(key, buf[:]...)
+}
+
+func (this *EventStore) getTransferBlockKey(height uint32) []byte {
+	key := make([]byte, 5)
+	key[0] = byte(scom.IX_TRANSFER_BLOCK)
+	binary.LittleEndian.PutUint32(key[1:], height)
+	return key
+}
+
+func (this *EventStore) getReceiptKey(txHash common.Uint256) []byte {
+	key := make([]byte, 1+common.UINT256_SIZE)
+	key[0] = byte(scom.EVENT_RECEIPT)
+	copy(key[1:], txHash[:])
+	return key
+}
+
+func (this *EventStore) getReceiptBlockKey(height uint32) []byte {
+	key := make([]byte, 5)
+	key[0] = byte(scom.EVENT_RECEIPT)
+	binary.LittleEndian.PutUint32(key[1:], height)
+	return key
+}
@@ -0,0 +1,262 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/smartcontract/event"
+)
+
+func TestAddressIndex(t *testing.T) {
+	eventStore, err := NewEventStore("test/event")
+	if err != nil {
+		t.Errorf("NewEventStore error %s", err)
+		return
+	}
+	defer eventStore.Close()
+
+	addr1 := common.Address{1}
+	addr2 := common.Address{2}
+	tx1 := common.Uint256{1}
+	tx2 := common.Uint256{2}
+	tx3 := common.Uint256{3}
+	tx4 := common.Uint256{4}
+
+	eventStore.NewBatch()
+	err = eventStore.SaveAddressIndexByBlock(1, []common.Uint256{tx1}, [][]common.Address{{addr1, addr2}})
+	if err != nil {
+		t.Errorf("SaveAddressIndexByBlock error %s", err)
+		return
+	}
+	err = eventStore.SaveAddressIndexByBlock(2, []common.Uint256{tx2, tx3}, [][]common.Address{{addr1}, {addr1}})
+	if err != nil {
+		t.Errorf("SaveAddressIndexByBlock error %s", err)
+		return
+	}
+	err = eventStore.SaveAddressIndexByBlock(3, []common.Uint256{tx4}, [][]common.Address{{addr2}})
+	if err != nil {
+		t.Errorf("SaveAddressIndexByBlock error %s", err)
+		return
+	}
+	err = eventStore.CommitTo()
+	if err != nil {
+		t.Errorf("CommitTo error %s", err)
+		return
+	}
+
+	txs, err := eventStore.GetTransactionsByAddress(addr1, math.MaxUint32, 10)
+	if err != nil {
+		t.Errorf("GetTransactionsByAddress error %s", err)
+		return
+	}
+	if len(txs) != 3 || txs[0].TxHash != tx3 || txs[1].TxHash != tx2 || txs[2].TxHash != tx1 {
+		t.Errorf("GetTransactionsByAddress unexpected result %v", txs)
+		return
+	}
+	//transactions of block 2 should not be split
+	txs, err = eventStore.GetTransactionsByAddress(addr1, math.MaxUint32, 1)
+	if err != nil {
+		t.Errorf("GetTransactionsByAddress error %s", err)
+		return
+	}
+	if len(txs) != 2 || txs[0].Height != 2 || txs[1].Height != 2 {
+		t.Errorf("GetTransactionsByAddress unexpected result %v", txs)
+		return
+	}
+	txs, err = eventStore.GetTransactionsByAddress(addr2, 2, 10)
+	if err != nil {
+		t.Errorf("GetTransactionsByAddress error %s", err)
+		return
+	}
+	if len(txs) != 1 || txs[0].TxHash != tx1 || txs[0].Height != 1 {
+		t.Errorf("GetTransactionsByAddress unexpected result %v", txs)
+		return
+	}
+
+	eventStore.NewBatch()
+	err = eventStore.BatchDeleteAddressIndexByBlock(3)
+	if err != nil {
+		t.Errorf("BatchDeleteAddressIndexByBlock error %s", err)
+		return
+	}
+	err = eventStore.CommitTo()
+	if err != nil {
+		t.Errorf("CommitTo error %s", err)
+		return
+	}
+	txs, err = eventStore.GetTransactionsByAddress(addr2, math.MaxUint32, 10)
+	if err != nil {
+		t.Errorf("GetTransactionsByAddress error %s", err)
+		return
+	}
+	if len(txs) != 1 || txs[0].TxHash != tx1 {
+		t.Errorf("GetTransactionsByAddress after delete unexpected result %v", txs)
+		return
+	}
+}
+
+func TestTransferIndex(t *testing.T) {
+	eventStore, err := NewEventStore("test/transfer")
+	if err != nil {
+		t.Errorf("NewEventStore error %s", err)
+		return
+	}
+	defer eventStore.Close()
+
+	contract := common.Address{1}
+	addr1 := common.Address{2}
+	addr2 := common.Address{3}
+	transfer := func(height uint32, from, to common.Address, amount int64) *store.Transfer {
+		return &store.Transfer{
+			TxHash:   common.Uint256{byte(height)},
+			Height:   height,
+			Contract: contract,
+			From:     from,
+			To:       to,
+			Amount:   big.NewInt(amount),
+		}
+	}
+
+	eventStore.NewBatch()
+	for height := uint32(1); height <= 3; height++ {
+		transfers := [][]*store.Transfer{{transfer(height, addr1, addr2, int64(height)), transfer(height, addr2, addr1, 1)}}
+		err = eventStore.SaveTransferIndexByBlock(height, transfers)
+		if err != nil {
+			t.Errorf("SaveTransferIndexByBlock error %s", err)
+			return
+		}
+	}
+	err = eventStore.CommitTo()
+	if err != nil {
+		t.Errorf("CommitTo error %s", err)
+		return
+	}
+
+	transfers, err := eventStore.GetTransfersByAddress(contract, addr1, 2, 3, 10)
+	if err != nil {
+		t.Errorf("GetTransfersByAddress error %s", err)
+		return
+	}
+	if len(transfers) != 4 || transfers[0].Height != 2 || transfers[3].Height != 3 {
+		t.Errorf("GetTransfersByAddress unexpected result %v", transfers)
+		return
+	}
+	if transfers[0].From != addr1 || transfers[0].To != addr2 || transfers[0].Amount.Int64() != 2 {
+		t.Errorf("GetTransfersByAddress unexpected transfer %v", transfers[0])
+		return
+	}
+	//transfers of block 1 should not be split
+	transfers, err = eventStore.GetTransfersByAddress(contract, addr2, 0, math.MaxUint32, 1)
+	if err != nil {
+		t.Errorf("GetTransfersByAddress error %s", err)
+		return
+	}
+	if len(transfers) != 2 || transfers[1].Height != 1 {
+		t.Errorf("GetTransfersByAddress unexpected result %v", transfers)
+		return
+	}
+
+	eventStore.NewBatch()
+	err = eventStore.BatchDeleteTransferIndexByBlock(3)
+	if err != nil {
+		t.Errorf("BatchDeleteTransferIndexByBlock error %s", err)
+		return
+	}
+	err = eventStore.CommitTo()
+	if err != nil {
+		t.Errorf("CommitTo error %s", err)
+		return
+	}
+	transfers, err = eventStore.GetTransfersByAddress(contract, addr1, 0, math.MaxUint32, 10)
+	if err != nil {
+		t.Errorf("GetTransfersByAddress error %s", err)
+		return
+	}
+	if len(transfers) != 4 || transfers[3].Height != 2 {
+		t.Errorf("GetTransfersByAddress after delete unexpected result %v", transfers)
+		return
+	}
+}
+
+func TestReceipt(t *testing.T) {
+	eventStore, err := NewEventStore("test/event")
+	if err != nil {
+		t.Errorf("NewEventStore error %s", err)
+		return
+	}
+	defer eventStore.Close()
+
+	receipt := &store.Receipt{
+		TxHash:      common.Uint256{1},
+		BlockHash:   common.Uint256{2},
+		Height:      10,
+		TxIndex:     1,
+		State:       event.CONTRACT_STATE_SUCCESS,
+		GasConsumed: 10000000,
+		GasUsed:     20000,
+		Payer:       common.Address{1},
+		Notify: []*event.NotifyEventInfo{
+			{ContractAddress: common.Address{3}, States: "hello"},
+		},
+	}
+	eventStore.NewBatch()
+	err = eventStore.SaveReceiptByBlock(10, []*store.Receipt{receipt})
+	if err != nil {
+		t.Errorf("SaveReceiptByBlock error %s", err)
+		return
+	}
+	err = eventStore.CommitTo()
+	if err != nil {
+		t.Errorf("CommitTo error %s", err)
+		return
+	}
+	r, err := eventStore.GetReceipt(receipt.TxHash)
+	if err != nil {
+		t.Errorf("GetReceipt error %s", err)
+		return
+	}
+	if r.BlockHash != receipt.BlockHash || r.Height != 10 || r.TxIndex != 1 || r.Payer != receipt.Payer ||
+		r.GasConsumed != 10000000 || r.GasUsed != 20000 || len(r.Notify) != 1 || r.Notify[0].States != "hello" {
+		t.Errorf("GetReceipt unexpected result %v", r)
+		return
+	}
+
+	eventStore.NewBatch()
+	err = eventStore.BatchDeleteReceiptByBlock(10)
+	if err != nil {
+		t.Errorf("BatchDeleteReceiptByBlock error %s", err)
+		return
+	}
+	err = eventStore.CommitTo()
+	if err != nil {
+		t.Errorf("CommitTo error %s", err)
+		return
+	}
+	_, err = eventStore.GetReceipt(receipt.TxHash)
+	if err != scom.ErrNotFound {
+		t.Errorf("GetReceipt after delete should return ErrNotFound, got %v", err)
+		return
+	}
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/common/metrics"
 	"github.com/ontio/ontology/common/serialization"
 	"github.com/ontio/ontology/consensus/vbft/config"
 	"github.com/ontio/ontology/core/payload"
@@ -60,6 +61,8 @@ import (
 const (
 	SYSTEM_VERSION          = byte(1)      //Version of ledger store
 	HEADER_INDEX_BATCH_SIZE = uint32(2000) //Bath size of saving header index
+	MAX_ROLLBACK_BLOCKS     = uint32(5000) //Max count of blocks can be rollback
+	MAX_PRUNE_BLOCKS        = uint32(10)   //Max count of blocks pruned when saving a block
 )
 
 var (
@@ -70,6 +73,12 @@ var (
 	MerkleTreeStorePath = "merkle_tree.db"
 )
 
+var (
+	blockHeightGauge = metrics.NewGauge("ontology_ledger_block_height", "Current block height of ledger")
+	commitLatency    = metrics.NewHistogram("ontology_ledger_commit_seconds", "Time of committing a block to store",
+		[]float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10})
+)
+
 //LedgerStoreImp is main store struct fo ledger
 type LedgerStoreImp struct {
 	blockStore           *BlockStore                      //BlockStore for saving block & transaction data
@@ -82,10 +91,11 @@ type LedgerStoreImp struct {
 	headerIndex          map[uint32]common.Uint256        //Header index, Mapping header height => block hash
 	savingBlockSemaphore chan bool
 	closing              bool
-	vbftPeerInfoheader   map[string]uint32 //pubInfo save pubkey,peerindex
-	vbftPeerInfoblock    map[string]uint32 //pubInfo save pubkey,peerindex
+	vbftPeerInfoheader   map[string]*vconfig.PeerConfig //pubInfo save pubkey,peer config
+	vbftPeerInfoblock    map[string]*vconfig.PeerConfig //pubInfo save pubkey,peer config
 	lock                 sync.RWMutex
 	stateHashCheckHeight uint32
+	prunedHeight         uint32 //Lowest block height whose transactions and events are not pruned
 }
 
 //NewLedgerStore return LedgerStoreImp instance
@@ -93,8 +103,8 @@ func NewLedgerStore(dataDir string, stateHashHeight uint32) (*LedgerStoreImp, er
 	ledgerStore := &LedgerStoreImp{
 		headerIndex:          make(map[uint32]common.Uint256),
 		headerCache:          make(map[common.Uint256]*types.Header, 0),
-		vbftPeerInfoheader:   make(map[string]uint32),
-		vbftPeerInfoblock:    make(map[string]uint32),
+		vbftPeerInfoheader:   make(map[string]*vconfig.PeerConfig),
+		vbftPeerInfoblock:    make(map[string]*vconfig.PeerConfig),
 		savingBlockSemaphore: make(chan bool, 1),
 		stateHashCheckHeight: stateHashHeight,
 	}
@@ -141,6 +151,10 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 		if err != nil {
 			return fmt.Errorf("eventStore.ClearAll error %s", err)
 		}
+		err = this.stateStore.InitStateArchive(config.DefConfig.Common.EnableStateArchive)
+		if err != nil {
+			return fmt.Errorf("InitStateArchive error %s", err)
+		}
 		defaultBookkeeper = keypair.SortPublicKeys(defaultBookkeeper)
 		bookkeeperState := &states.BookkeeperState{
 			CurrBookkeeper: defaultBookkeeper,
@@ -174,12 +188,26 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 		if !exist {
 			return fmt.Errorf("GenesisBlock arenot init correctly")
 		}
+		err = this.stateStore.InitStateArchive(config.DefConfig.Common.EnableStateArchive)
+		if err != nil {
+			return fmt.Errorf("InitStateArchive error %s", err)
+		}
 		err = this.init()
 		if err != nil {
 			return fmt.Errorf("init error %s", err)
 		}
 	}
-	//load vbft peerInfo
+	err = this.loadVbftPeerInfo()
+	if err != nil {
+		return err
+	}
+	// check and fix imcompatible states
+	err = this.stateStore.CheckStorage()
+	return err
+}
+
+//loadVbftPeerInfo load vbft peer info of current block
+func (this *LedgerStoreImp) loadVbftPeerInfo() error {
 	consensusType := strings.ToLower(config.DefConfig.Genesis.ConsensusType)
 	if consensusType == "vbft" {
 		header, err := this.GetHeaderByHash(this.currBlockHash)
@@ -208,17 +236,15 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 			cfg = Info.NewChainConfig
 		}
 		this.lock.Lock()
-		this.vbftPeerInfoheader = make(map[string]uint32)
-		this.vbftPeerInfoblock = make(map[string]uint32)
+		this.vbftPeerInfoheader = make(map[string]*vconfig.PeerConfig)
+		this.vbftPeerInfoblock = make(map[string]*vconfig.PeerConfig)
 		for _, p := range cfg.Peers {
-			this.vbftPeerInfoheader[p.ID] = p.Index
-			this.vbftPeerInfoblock[p.ID] = p.Index
+			this.vbftPeerInfoheader[p.ID] = p
+			this.vbftPeerInfoblock[p.ID] = p
 		}
 		this.lock.Unlock()
 	}
-	// check and fix imcompatible states
-	err = this.stateStore.CheckStorage()
-	return err
+	return nil
 }
 
 func (this *LedgerStoreImp) hasAlreadyInitGenesisBlock() (bool, error) {
@@ -238,6 +264,11 @@ func (this *LedgerStoreImp) init() error {
 	if err != nil {
 		return fmt.Errorf("loadCurrentBlock error %s", err)
 	}
+	prunedHeight, err := this.blockStore.GetPrunedHeight()
+	if err != nil {
+		return fmt.Errorf("GetPrunedHeight error %s", err)
+	}
+	this.setPrunedHeight(prunedHeight)
 	err = this.loadHeaderIndexList()
 	if err != nil {
 		return fmt.Errorf("loadHeaderIndexList error %s", err)
@@ -291,6 +322,14 @@ func (this *LedgerStoreImp) recoverStore() error {
 	if err != nil {
 		return fmt.Errorf("stateStore.GetCurrentBlock error %s", err)
 	}
+	if stateHeight > blockHeight {
+		//rollback was interrupted after block store committed
+		err = this.rollbackEventAndStateStore(blockHeight, this.GetCurrentBlockHash())
+		if err != nil {
+			return fmt.Errorf("rollbackEventAndStateStore height:%d error %s", blockHeight, err)
+		}
+		return nil
+	}
 	for i := stateHeight; i < blockHeight; i++ {
 		blockHash, err := this.blockStore.GetBlockHash(i)
 		if err != nil {
@@ -310,7 +349,7 @@ func (this *LedgerStoreImp) recoverStore() error {
 		if err != nil {
 			return fmt.Errorf("save to state store height:%d error:%s", i, err)
 		}
-		err = this.saveBlockToEventStore(block)
+		err = this.saveBlockToEventStore(block, result)
 		if err != nil {
 			return fmt.Errorf("save to event store height:%d error:%s", i, err)
 		}
@@ -326,6 +365,142 @@ func (this *LedgerStoreImp) recoverStore() error {
 	return nil
 }
 
+//RollbackTo revert the ledger to the block of height. The blocks higher than height are removed from block store,
+//and the state store and event store are restored to the block of height.
+func (this *LedgerStoreImp) RollbackTo(height uint32) error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	if this.closing {
+		return errors.NewErr("rollback error: ledger is closing")
+	}
+	currHeight, _ := this.GetCurrentBlock()
+	if height >= currHeight {
+		return fmt.Errorf("rollback height %d should be lower than current block height %d", height, currHeight)
+	}
+	if currHeight-height > MAX_ROLLBACK_BLOCKS {
+		return fmt.Errorf("can not rollback more than %d blocks", MAX_ROLLBACK_BLOCKS)
+	}
+	err := this.stateStore.CheckRollback(height)
+	if err != nil {
+		return fmt.Errorf("stateStore.CheckRollback error %s", err)
+	}
+	blockHash, err := this.blockStore.GetBlockHash(height)
+	if err != nil {
+		return fmt.Errorf("blockStore.GetBlockHash height:%d error %s", height, err)
+	}
+
+	this.lock.RLock()
+	storedIndexCount := this.storedIndexCount
+	this.lock.RUnlock()
+	//header index list is saved only when all the blocks in it have been saved
+	newIndexCount := height / HEADER_INDEX_BATCH_SIZE * HEADER_INDEX_BATCH_SIZE
+	this.blockStore.NewBatch()
+	for start := newIndexCount; start < storedIndexCount; start += HEADER_INDEX_BATCH_SIZE {
+		this.blockStore.BatchDeleteHeaderIndexList(start)
+	}
+	err = this.blockStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("blockStore.CommitTo error %s", err)
+	}
+	//blocks are removed one batch per block like they are saved, so that the batch fits into one
+	//transaction of the store. An interrupted rollback leaves the block store at a lower height
+	for h := currHeight; h > height; h-- {
+		this.blockStore.NewBatch()
+		hash, err := this.blockStore.GetBlockHash(h)
+		if err != nil {
+			return fmt.Errorf("blockStore.GetBlockHash height:%d error %s", h, err)
+		}
+		block, err := this.blockStore.GetBlock(hash)
+		if err != nil {
+			return fmt.Errorf("blockStore.GetBlock height:%d error %s", h, err)
+		}
+		this.blockStore.BatchDeleteBlock(block)
+		err = this.blockStore.SaveCurrentBlock(h-1, block.Header.PrevBlockHash)
+		if err != nil {
+			return fmt.Errorf("blockStore.SaveCurrentBlock error %s", err)
+		}
+		err = this.blockStore.CommitTo()
+		if err != nil {
+			return fmt.Errorf("blockStore.CommitTo height:%d error %s", h, err)
+		}
+	}
+	err = this.blockStore.ResetCache()
+	if err != nil {
+		return fmt.Errorf("blockStore.ResetCache error %s", err)
+	}
+
+	err = this.rollbackEventAndStateStore(height, blockHash)
+	if err != nil {
+		return err
+	}
+
+	this.lock.Lock()
+	for h := range this.headerIndex {
+		if h > height {
+			delete(this.headerIndex, h)
+		}
+	}
+	this.headerCache = make(map[common.Uint256]*types.Header, 0)
+	if newIndexCount < this.storedIndexCount {
+		this.storedIndexCount = newIndexCount
+	}
+	this.currBlockHeight = height
+	this.currBlockHash = blockHash
+	this.lock.Unlock()
+
+	err = this.loadVbftPeerInfo()
+	if err != nil {
+		return fmt.Errorf("loadVbftPeerInfo error %s", err)
+	}
+	log.Infof("ledger rollback from height %d to height %d", currHeight, height)
+	return nil
+}
+
+func (this *LedgerStoreImp) rollbackEventAndStateStore(height uint32, blockHash common.Uint256) error {
+	_, eventHeight, err := this.eventStore.GetCurrentBlock()
+	if err != nil && err != scom.ErrNotFound {
+		return fmt.Errorf("eventStore.GetCurrentBlock error %s", err)
+	}
+	if eventHeight > height {
+		//the records of each block are deleted in one batch, the current block is saved with the last one.
+		//The deletion is idempotent, so an interrupted rollback is resumed from the same height
+		for h := eventHeight; h > height; h-- {
+			this.eventStore.NewBatch()
+			err = this.eventStore.BatchDeleteEventNotifyByBlock(h)
+			if err != nil {
+				return fmt.Errorf("eventStore.BatchDeleteEventNotifyByBlock height:%d error %s", h, err)
+			}
+			err = this.eventStore.BatchDeleteAddressIndexByBlock(h)
+			if err != nil {
+				return fmt.Errorf("eventStore.BatchDeleteAddressIndexByBlock height:%d error %s", h, err)
+			}
+			err = this.eventStore.BatchDeleteTransferIndexByBlock(h)
+			if err != nil {
+				return fmt.Errorf("eventStore.BatchDeleteTransferIndexByBlock height:%d error %s", h, err)
+			}
+			err = this.eventStore.BatchDeleteReceiptByBlock(h)
+			if err != nil {
+				return fmt.Errorf("eventStore.BatchDeleteReceiptByBlock height:%d error %s", h, err)
+			}
+			if h-1 == height {
+				err = this.eventStore.SaveCurrentBlock(height, blockHash)
+				if err != nil {
+					return fmt.Errorf("eventStore.SaveCurrentBlock error %s", err)
+				}
+			}
+			err = this.eventStore.CommitTo()
+			if err != nil {
+				return fmt.Errorf("eventStore.CommitTo height:%d error %s", h, err)
+			}
+		}
+	}
+	err = this.stateStore.RollbackTo(height)
+	if err != nil {
+		return fmt.Errorf("stateStore.RollbackTo error %s", err)
+	}
+	return nil
+}
+
 func (this *LedgerStoreImp) setHeaderIndex(height uint32, blockHash common.Uint256) {
 	this.lock.Lock()
 	defer this.lock.Unlock()
@@ -417,18 +592,29 @@ func (this *LedgerStoreImp) getHeaderCache(blockHash common.Uint256) *types.Head
 	return header
 }
 
-func (this *LedgerStoreImp) verifyHeader(header *types.Header, vbftPeerInfo map[string]uint32) (map[string]uint32, error) {
+//verifyHeader verifies the header against the previous one, the header failed
+//to verify is rejected with VerifyError
+func (this *LedgerStoreImp) verifyHeader(header *types.Header, vbftPeerInfo map[string]*vconfig.PeerConfig) (map[string]*vconfig.PeerConfig, error) {
 	if header.Height == 0 {
 		return vbftPeerInfo, nil
 	}
-	var prevHeader *types.Header
 	prevHeaderHash := header.PrevBlockHash
 	prevHeader, err := this.GetHeaderByHash(prevHeaderHash)
 	if err != nil && err != scom.ErrNotFound {
-		return vbftPeerInfo, fmt.Errorf("get prev header error %s", err)
+		return vbftPeerInfo, fmt.Errorf("verifyHeader get prev header error %s", err)
+	}
+	peerInfo, err := this.checkHeader(header, prevHeader, vbftPeerInfo)
+	if err != nil {
+		return vbftPeerInfo, &scom.VerifyError{Err: fmt.Errorf("verifyHeader error %s", err)}
 	}
+	return peerInfo, nil
+}
+
+//checkHeader checks the header is the next one of prevHeader and signed by the bookkeepers
+func (this *LedgerStoreImp) checkHeader(header *types.Header, prevHeader *types.Header, vbftPeerInfo map[string]*vconfig.PeerConfig) (map[string]*vconfig.PeerConfig, error) {
+	var err error
 	if prevHeader == nil {
-		return vbftPeerInfo, fmt.Errorf("cannot find pre header by blockHash %s", prevHeaderHash.ToHexString())
+		return vbftPeerInfo, fmt.Errorf("cannot find pre header by blockHash %s", header.PrevBlockHash.ToHexString())
 	}
 
 	if prevHeader.Height+1 != header.Height {
@@ -440,8 +626,23 @@ func (this *LedgerStoreImp) verifyHeader(header *types.Header, vbftPeerInfo map[
 	}
 	consensusType := strings.ToLower(config.DefConfig.Genesis.ConsensusType)
 	if consensusType == "vbft" {
-		//check bookkeeppers
 		m := len(vbftPeerInfo) - (len(vbftPeerInfo)*6)/7
+		// blocks without enough bls sigs are still sealed with the sig of each bookkeeper
+		blsSigActive := header.Height >= config.GetBlsSigHeight(config.DefConfig.P2PNode.NetworkId)
+		if blsSigActive && len(header.Bookkeepers) == 0 {
+			peers := make([]*vconfig.PeerConfig, 0, len(vbftPeerInfo))
+			for _, p := range vbftPeerInfo {
+				peers = append(peers, p)
+			}
+			hash := header.Hash()
+			err = vconfig.VerifyBlsSigData(hash[:], header.SigData, peers, m)
+			if err != nil {
+				log.Errorf("VerifyBlsSigData:%s,pubkey:%d,heigh:%d", err, len(vbftPeerInfo), header.Height)
+				return vbftPeerInfo, err
+			}
+			return this.nextVbftPeerInfo(header, vbftPeerInfo)
+		}
+		//check bookkeeppers
 		if len(header.Bookkeepers) < m {
 			return vbftPeerInfo, fmt.Errorf("header Bookkeepers %d more than 6/7 len vbftPeerInfo%d", len(header.Bookkeepers), len(vbftPeerInfo))
 		}
@@ -459,18 +660,7 @@ func (this *LedgerStoreImp) verifyHeader(header *types.Header, vbftPeerInfo map[
 			log.Errorf("VerifyMultiSignature:%s,Bookkeepers:%d,pubkey:%d,heigh:%d", err, len(header.Bookkeepers), len(vbftPeerInfo), header.Height)
 			return vbftPeerInfo, err
 		}
-		blkInfo, err := vconfig.VbftBlock(header)
-		if err != nil {
-			return vbftPeerInfo, err
-		}
-		if blkInfo.NewChainConfig != nil {
-			peerInfo := make(map[string]uint32)
-			for _, p := range blkInfo.NewChainConfig.Peers {
-				peerInfo[p.ID] = p.Index
-			}
-			return peerInfo, nil
-		}
-		return vbftPeerInfo, nil
+		return this.nextVbftPeerInfo(header, vbftPeerInfo)
 	} else {
 		address, err := types.AddressFromBookkeepers(header.Bookkeepers)
 		if err != nil {
@@ -490,6 +680,49 @@ func (this *LedgerStoreImp) verifyHeader(header *types.Header, vbftPeerInfo map[
 	return vbftPeerInfo, nil
 }
 
+//nextVbftPeerInfo returns the vbft peers of the block following header
+func (this *LedgerStoreImp) nextVbftPeerInfo(header *types.Header, vbftPeerInfo map[string]*vconfig.PeerConfig) (map[string]*vconfig.PeerConfig, error) {
+	blkInfo, err := vconfig.VbftBlock(header)
+	if err != nil {
+		return vbftPeerInfo, err
+	}
+	if blkInfo.NewChainConfig != nil {
+		peerInfo := make(map[string]*vconfig.PeerConfig)
+		for _, p := range blkInfo.NewChainConfig.Peers {
+			peerInfo[p.ID] = p
+		}
+		return peerInfo, nil
+	}
+	return vbftPeerInfo, nil
+}
+
+//VerifyHeader checks the header of the next block is signed by the bookkeepers
+//and proposed by one of them, without saving it
+func (this *LedgerStoreImp) VerifyHeader(header *types.Header) error {
+	nextBlockHeight := this.GetCurrentBlockHeight() + 1
+	if header.Height != nextBlockHeight {
+		return fmt.Errorf("header height %d not equal next block height %d", header.Height, nextBlockHeight)
+	}
+	vbftPeerInfo := this.vbftPeerInfoblock
+	_, err := this.verifyHeader(header, vbftPeerInfo)
+	if err != nil {
+		return err
+	}
+	if strings.ToLower(config.DefConfig.Genesis.ConsensusType) != "vbft" {
+		return nil
+	}
+	blkInfo, err := vconfig.VbftBlock(header)
+	if err != nil {
+		return &scom.VerifyError{Err: fmt.Errorf("verifyHeader error %s", err)}
+	}
+	for _, p := range vbftPeerInfo {
+		if p.Index == blkInfo.Proposer {
+			return nil
+		}
+	}
+	return &scom.VerifyError{Err: fmt.Errorf("verifyHeader error invalid proposer %d", blkInfo.Proposer)}
+}
+
 //AddHeader add header to cache, and add the mapping of block height to block hash. Using in block sync
 func (this *LedgerStoreImp) AddHeader(header *types.Header) error {
 	nextHeaderHeight := this.GetCurrentHeaderHeight() + 1
@@ -499,7 +732,7 @@ func (this *LedgerStoreImp) AddHeader(header *types.Header) error {
 	var err error
 	this.vbftPeerInfoheader, err = this.verifyHeader(header, this.vbftPeerInfoheader)
 	if err != nil {
-		return fmt.Errorf("verifyHeader error %s", err)
+		return err
 	}
 	this.addHeaderCache(header)
 	this.setHeaderIndex(header.Height, header.Hash())
@@ -562,7 +795,7 @@ func (this *LedgerStoreImp) SubmitBlock(block *types.Block, result store.Execute
 	var err error
 	this.vbftPeerInfoblock, err = this.verifyHeader(block.Header, this.vbftPeerInfoblock)
 	if err != nil {
-		return fmt.Errorf("verifyHeader error %s", err)
+		return err
 	}
 
 	err = this.submitBlock(block, result)
@@ -588,11 +821,14 @@ func (this *LedgerStoreImp) AddBlock(block *types.Block, stateMerkleRoot common.
 	var err error
 	this.vbftPeerInfoblock, err = this.verifyHeader(block.Header, this.vbftPeerInfoblock)
 	if err != nil {
-		return fmt.Errorf("verifyHeader error %s", err)
+		return err
 	}
 
 	err = this.saveBlock(block, stateMerkleRoot)
 	if err != nil {
+		if scom.IsVerifyError(err) {
+			return &scom.VerifyError{Err: fmt.Errorf("saveBlock error %s", err)}
+		}
 		return fmt.Errorf("saveBlock error %s", err)
 	}
 	this.delHeaderCache(block.Hash())
@@ -621,6 +857,15 @@ func (this *LedgerStoreImp) saveBlockToBlockStore(block *types.Block) error {
 }
 
 func (this *LedgerStoreImp) executeBlock(block *types.Block) (result store.ExecuteResult, err error) {
+	for _, tx := range block.Transactions {
+		if e := tx.CheckHeight(block.Header.Height); e != nil {
+			txHash := tx.Hash()
+			err = &scom.VerifyError{Err: fmt.Errorf("transaction %s in block %d error %s",
+				txHash.ToHexString(), block.Header.Height, e)}
+			return
+		}
+	}
+
 	overlay := this.stateStore.NewOverlayDB()
 	if block.Header.Height != 0 {
 		config := &smartcontract.Config{
@@ -722,6 +967,11 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 
 	log.Debugf("the state transition hash of block %d is:%s", blockHeight, result.Hash.ToHexString())
 
+	err = this.stateStore.BatchSaveStateHistory(blockHeight, result.WriteSet)
+	if err != nil {
+		return fmt.Errorf("BatchSaveStateHistory error %s", err)
+	}
+
 	result.WriteSet.ForEach(func(key, val []byte) {
 		if len(val) == 0 {
 			this.stateStore.BatchDeleteRawKey(key)
@@ -730,10 +980,14 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 		}
 	})
 
+	err = this.stateStore.BatchSaveUndoLog(blockHeight)
+	if err != nil {
+		return fmt.Errorf("BatchSaveUndoLog error %s", err)
+	}
 	return nil
 }
 
-func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block) error {
+func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block, result store.ExecuteResult) error {
 	blockHash := block.Hash()
 	blockHeight := block.Header.Height
 	txs := make([]common.Uint256, 0)
@@ -747,13 +1001,122 @@ func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block) error {
 			return fmt.Errorf("SaveEventNotifyByBlock error %s", err)
 		}
 	}
-	err := this.eventStore.SaveCurrentBlock(blockHeight, blockHash)
+	receipts := make([]*store.Receipt, 0, len(result.Notify))
+	for i, notify := range result.Notify {
+		tx := block.Transactions[i]
+		var gasUsed uint64
+		if tx.GasPrice > 0 {
+			gasUsed = notify.GasConsumed / tx.GasPrice
+		}
+		receipts = append(receipts, &store.Receipt{
+			TxHash:      txs[i],
+			BlockHash:   blockHash,
+			Height:      blockHeight,
+			TxIndex:     uint32(i),
+			State:       notify.State,
+			GasConsumed: notify.GasConsumed,
+			GasUsed:     gasUsed,
+			Payer:       tx.Payer,
+			Notify:      notify.Notify,
+		})
+	}
+	err := this.eventStore.SaveReceiptByBlock(blockHeight, receipts)
+	if err != nil {
+		return fmt.Errorf("SaveReceiptByBlock error %s", err)
+	}
+	if config.DefConfig.Common.EnableAddressIndex {
+		txAddrs := make([][]common.Address, 0, len(block.Transactions))
+		for i, tx := range block.Transactions {
+			var notify *event.ExecuteNotify
+			if i < len(result.Notify) {
+				notify = result.Notify[i]
+			}
+			txAddrs = append(txAddrs, getTxAddresses(tx, notify))
+		}
+		err := this.eventStore.SaveAddressIndexByBlock(blockHeight, txs, txAddrs)
+		if err != nil {
+			return fmt.Errorf("SaveAddressIndexByBlock error %s", err)
+		}
+	}
+	if config.DefConfig.Common.EnableTransferIndex {
+		txTransfers := make([][]*store.Transfer, 0, len(result.Notify))
+		for _, notify := range result.Notify {
+			txTransfers = append(txTransfers, getTxTransfers(blockHeight, notify))
+		}
+		err := this.eventStore.SaveTransferIndexByBlock(blockHeight, txTransfers)
+		if err != nil {
+			return fmt.Errorf("SaveTransferIndexByBlock error %s", err)
+		}
+	}
+	err = this.eventStore.SaveCurrentBlock(blockHeight, blockHash)
 	if err != nil {
 		return fmt.Errorf("SaveCurrentBlock error %s", err)
 	}
 	return nil
 }
 
+//pruneBlocks remove the transactions, event notifies, receipts, address and transfer index of the blocks older
+//than the prune range to batch, and return the new pruned height. Blocks in rollback range are never pruned.
+func (this *LedgerStoreImp) pruneBlocks(blockHeight uint32) (uint32, error) {
+	prunedHeight := this.getPrunedHeight()
+	keepBlocks := config.DefConfig.Common.PruneBlocks
+	if keepBlocks == 0 {
+		return prunedHeight, nil
+	}
+	//blocks in rollback window are always kept
+	if keepBlocks < MAX_ROLLBACK_BLOCKS {
+		keepBlocks = MAX_ROLLBACK_BLOCKS
+	}
+	if blockHeight <= keepBlocks || blockHeight-keepBlocks < prunedHeight {
+		return prunedHeight, nil
+	}
+	end := blockHeight - keepBlocks
+	//prune gradually when pruning is enabled on an existing ledger
+	if end-prunedHeight >= MAX_PRUNE_BLOCKS {
+		end = prunedHeight + MAX_PRUNE_BLOCKS - 1
+	}
+	for height := prunedHeight; height <= end; height++ {
+		blockHash, err := this.blockStore.GetBlockHash(height)
+		if err != nil {
+			return 0, fmt.Errorf("GetBlockHash height:%d error %s", height, err)
+		}
+		err = this.blockStore.BatchPruneBlock(blockHash)
+		if err != nil {
+			return 0, fmt.Errorf("BatchPruneBlock height:%d error %s", height, err)
+		}
+		err = this.eventStore.BatchDeleteEventNotifyByBlock(height)
+		if err != nil {
+			return 0, fmt.Errorf("BatchDeleteEventNotifyByBlock height:%d error %s", height, err)
+		}
+		err = this.eventStore.BatchDeleteReceiptByBlock(height)
+		if err != nil {
+			return 0, fmt.Errorf("BatchDeleteReceiptByBlock height:%d error %s", height, err)
+		}
+		err = this.eventStore.BatchDeleteAddressIndexByBlock(height)
+		if err != nil {
+			return 0, fmt.Errorf("BatchDeleteAddressIndexByBlock height:%d error %s", height, err)
+		}
+		err = this.eventStore.BatchDeleteTransferIndexByBlock(height)
+		if err != nil {
+			return 0, fmt.Errorf("BatchDeleteTransferIndexByBlock height:%d error %s", height, err)
+		}
+	}
+	this.blockStore.SavePrunedHeight(end + 1)
+	return end + 1, nil
+}
+
+func (this *LedgerStoreImp) setPrunedHeight(height uint32) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.prunedHeight = height
+}
+
+func (this *LedgerStoreImp) getPrunedHeight() uint32 {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.prunedHeight
+}
+
 func (this *LedgerStoreImp) tryGetSavingBlockLock() (hasLocked bool) {
 	select {
 	case this.savingBlockSemaphore <- true:
@@ -778,6 +1141,7 @@ func (this *LedgerStoreImp) releaseSavingBlockLock() {
 
 //saveBlock do the job of execution samrt contract and commit block to store.
 func (this *LedgerStoreImp) submitBlock(block *types.Block, result store.ExecuteResult) error {
+	start := time.Now()
 	blockHash := block.Hash()
 	blockHeight := block.Header.Height
 	blockRoot := this.GetBlockRootWithNewTxRoots(block.Header.Height, []common.Uint256{block.Header.TransactionsRoot})
@@ -797,10 +1161,14 @@ func (this *LedgerStoreImp) submitBlock(block *types.Block, result store.Execute
 	if err != nil {
 		return fmt.Errorf("save to state store height:%d error:%s", blockHeight, err)
 	}
-	err = this.saveBlockToEventStore(block)
+	err = this.saveBlockToEventStore(block, result)
 	if err != nil {
 		return fmt.Errorf("save to event store height:%d error:%s", blockHeight, err)
 	}
+	prunedHeight, err := this.pruneBlocks(blockHeight)
+	if err != nil {
+		return fmt.Errorf("prune blocks height:%d error:%s", blockHeight, err)
+	}
 	err = this.blockStore.CommitTo()
 	if err != nil {
 		return fmt.Errorf("blockStore.CommitTo height:%d error %s", blockHeight, err)
@@ -815,6 +1183,9 @@ func (this *LedgerStoreImp) submitBlock(block *types.Block, result store.Execute
 		return fmt.Errorf("stateStore.CommitTo height:%d error %s", blockHeight, err)
 	}
 	this.setCurrentBlock(blockHeight, blockHash)
+	this.setPrunedHeight(prunedHeight)
+	blockHeightGauge.Set(float64(blockHeight))
+	commitLatency.Observe(time.Since(start).Seconds())
 
 	if events.DefActorPublisher != nil {
 		events.DefActorPublisher.Publish(
@@ -847,7 +1218,7 @@ func (this *LedgerStoreImp) saveBlock(block *types.Block, stateMerkleRoot common
 	}
 
 	if result.MerkleRoot != stateMerkleRoot {
-		return errors.NewErr("state merkle root mismatch!")
+		return &scom.VerifyError{Err: errors.NewErr("state merkle root mismatch!")}
 	}
 
 	return this.submitBlock(block, result)
@@ -1006,16 +1377,52 @@ func (this *LedgerStoreImp) GetStorageItem(key *states.StorageKey) (*states.Stor
 	return this.stateStore.GetStorageState(key)
 }
 
+//GetStorageItemAtHeight return the storage value of the key in smart contract after the block of height has been saved.
+//It's only available in state archive mode. Wrap function of StateStore.GetStorageStateAtHeight
+func (this *LedgerStoreImp) GetStorageItemAtHeight(key *states.StorageKey, height uint32) (*states.StorageItem, error) {
+	if height > this.GetCurrentBlockHeight() {
+		return nil, fmt.Errorf("height %d is higher than current block height", height)
+	}
+	return this.stateStore.GetStorageStateAtHeight(key, height)
+}
+
 //GetEventNotifyByTx return the events notify gen by executing of smart contract.  Wrap function of EventStore.GetEventNotifyByTx
 func (this *LedgerStoreImp) GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error) {
-	return this.eventStore.GetEventNotifyByTx(tx)
+	notify, err := this.eventStore.GetEventNotifyByTx(tx)
+	if err == scom.ErrNotFound {
+		if _, _, txErr := this.blockStore.GetTransaction(tx); txErr == scom.ErrPruned {
+			return nil, scom.ErrPruned
+		}
+	}
+	return notify, err
 }
 
 //GetEventNotifyByBlock return the transaction hash which have event notice after execution of smart contract. Wrap function of EventStore.GetEventNotifyByBlock
 func (this *LedgerStoreImp) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error) {
+	if height < this.getPrunedHeight() {
+		return nil, scom.ErrPruned
+	}
 	return this.eventStore.GetEventNotifyByBlock(height)
 }
 
+//GetTransactionsByAddress return the transactions related to address at or below the block height.
+//Wrap function of EventStore.GetTransactionsByAddress
+func (this *LedgerStoreImp) GetTransactionsByAddress(address common.Address, height uint32, limit uint32) ([]*store.AddressTx, error) {
+	return this.eventStore.GetTransactionsByAddress(address, height, limit)
+}
+
+//GetTransfersByAddress return the transfers of contract related to address between the start and end block height.
+//Wrap function of EventStore.GetTransfersByAddress
+func (this *LedgerStoreImp) GetTransfersByAddress(contract common.Address, address common.Address, startHeight uint32, endHeight uint32, limit uint32) ([]*store.Transfer, error) {
+	return this.eventStore.GetTransfersByAddress(contract, address, startHeight, endHeight, limit)
+}
+
+//GetTransactionReceipt return the receipt of transaction.
+//Wrap function of EventStore.GetReceipt
+func (this *LedgerStoreImp) GetTransactionReceipt(txHash common.Uint256) (*store.Receipt, error) {
+	return this.eventStore.GetReceipt(txHash)
+}
+
 //PreExecuteContract return the result of smart contract execution without commit to store
 func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.PreExecResult, error) {
 	height := this.GetCurrentBlockHeight()
@@ -19,13 +19,29 @@
 package ledgerstore
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
+	vconfig "github.com/ontio/ontology/consensus/vbft/config"
 	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/signature/bls"
+	"github.com/ontio/ontology/core/store"
+	"github.com/ontio/ontology/core/store/backend"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -137,3 +153,272 @@ func TestInitLedgerStoreWithGenesisBlock(t *testing.T) {
 		return
 	}
 }
+
+func TestPruneBlocks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prune")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	ledgerStore, err := NewLedgerStore(dir, 0)
+	assert.Nil(t, err)
+	defer ledgerStore.Close()
+
+	pruneBlocks := config.DefConfig.Common.PruneBlocks
+	config.DefConfig.Common.PruneBlocks = 10
+	defer func() { config.DefConfig.Common.PruneBlocks = pruneBlocks }()
+
+	height := MAX_ROLLBACK_BLOCKS + 2
+	addr := common.Address{1}
+	hashes := make([]common.Uint256, 0, height+1)
+	for h := uint32(0); h <= height; h++ {
+		tx, err := transferTx(addr, common.Address{2}, uint64(h)+1)
+		assert.Nil(t, err)
+		block := &types.Block{
+			Header:       &types.Header{Height: h, Timestamp: h},
+			Transactions: []*types.Transaction{tx},
+		}
+		ledgerStore.blockStore.NewBatch()
+		ledgerStore.eventStore.NewBatch()
+		assert.Nil(t, ledgerStore.blockStore.SaveBlock(block))
+		ledgerStore.blockStore.SaveBlockHash(h, block.Hash())
+		txHashes := []common.Uint256{tx.Hash()}
+		assert.Nil(t, ledgerStore.eventStore.SaveAddressIndexByBlock(h, txHashes, [][]common.Address{{addr}}))
+		transfer := &store.Transfer{TxHash: tx.Hash(), Height: h, Contract: nutils.OntContractAddress,
+			From: addr, To: common.Address{2}, Amount: big.NewInt(int64(h) + 1)}
+		assert.Nil(t, ledgerStore.eventStore.SaveTransferIndexByBlock(h, [][]*store.Transfer{{transfer}}))
+		assert.Nil(t, ledgerStore.eventStore.SaveReceiptByBlock(h, []*store.Receipt{{TxHash: tx.Hash(), Height: h}}))
+		prunedHeight, err := ledgerStore.pruneBlocks(h)
+		assert.Nil(t, err)
+		assert.Nil(t, ledgerStore.blockStore.CommitTo())
+		assert.Nil(t, ledgerStore.eventStore.CommitTo())
+		ledgerStore.setPrunedHeight(prunedHeight)
+		hashes = append(hashes, block.Hash())
+
+		//blocks in rollback window are kept at low height
+		if h <= MAX_ROLLBACK_BLOCKS {
+			assert.Equal(t, uint32(0), prunedHeight)
+		}
+	}
+	//the last MAX_ROLLBACK_BLOCKS blocks are kept
+	prunedHeight := height - MAX_ROLLBACK_BLOCKS + 1
+	assert.Equal(t, prunedHeight, ledgerStore.getPrunedHeight())
+	assert.Nil(t, ledgerStore.blockStore.ResetCache())
+	for h := uint32(0); h <= height; h++ {
+		_, err := ledgerStore.blockStore.GetBlock(hashes[h])
+		if h < prunedHeight {
+			assert.Equal(t, scom.ErrPruned, err)
+		} else if err != nil {
+			t.Fatalf("GetBlock height:%d error %s", h, err)
+		}
+	}
+	//the index and receipts of pruned blocks are removed with them
+	txs, err := ledgerStore.eventStore.GetTransactionsByAddress(addr, height, height+1)
+	assert.Nil(t, err)
+	assert.Equal(t, int(height-prunedHeight+1), len(txs))
+	assert.Equal(t, prunedHeight, txs[len(txs)-1].Height)
+	transfers, err := ledgerStore.eventStore.GetTransfersByAddress(nutils.OntContractAddress, addr, 0, height, height+1)
+	assert.Nil(t, err)
+	assert.Equal(t, int(height-prunedHeight+1), len(transfers))
+	assert.Equal(t, prunedHeight, transfers[0].Height)
+	for _, tx := range txs {
+		_, err := ledgerStore.eventStore.GetReceipt(tx.TxHash)
+		assert.Nil(t, err)
+	}
+}
+
+func TestRollbackBadger(t *testing.T) {
+	storeBackend := config.DefConfig.Common.StoreBackend
+	genesisConfig := config.DefConfig.Genesis
+	config.DefConfig.Common.StoreBackend = backend.BADGER
+	config.DefConfig.Genesis = &config.GenesisConfig{ConsensusType: "solo"}
+	defer func() {
+		config.DefConfig.Common.StoreBackend = storeBackend
+		config.DefConfig.Genesis = genesisConfig
+	}()
+	dir, err := ioutil.TempDir("", "rollback")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	store, err := NewLedgerStore(dir, 0)
+	assert.Nil(t, err)
+	defer store.Close()
+
+	//the blocks to rollback are too big for one transaction of badger
+	height := uint32(200)
+	hashes := make([]common.Uint256, 0, height+1)
+	var prevHash common.Uint256
+	for h := uint32(0); h <= height; h++ {
+		txs := make([]*types.Transaction, 0, 1000)
+		for i := uint32(0); i < 1000; i++ {
+			mutable := &types.MutableTransaction{
+				TxType:  types.Invoke,
+				Nonce:   h*1000 + i,
+				Payload: &payload.InvokeCode{Code: []byte{}},
+			}
+			tx, err := mutable.IntoImmutable()
+			assert.Nil(t, err)
+			txs = append(txs, tx)
+		}
+		block := &types.Block{
+			Header:       &types.Header{Height: h, Timestamp: h, PrevBlockHash: prevHash},
+			Transactions: txs,
+		}
+		prevHash = block.Hash()
+		hashes = append(hashes, prevHash)
+		store.blockStore.NewBatch()
+		assert.Nil(t, store.blockStore.SaveBlock(block))
+		store.blockStore.SaveBlockHash(h, prevHash)
+		assert.Nil(t, store.blockStore.SaveCurrentBlock(h, prevHash))
+		assert.Nil(t, store.blockStore.CommitTo())
+		store.stateStore.NewBatch()
+		assert.Nil(t, store.stateStore.BatchSaveUndoLog(h))
+		assert.Nil(t, store.stateStore.SaveCurrentBlock(h, prevHash))
+		assert.Nil(t, store.stateStore.CommitTo())
+		store.eventStore.NewBatch()
+		assert.Nil(t, store.eventStore.SaveCurrentBlock(h, prevHash))
+		assert.Nil(t, store.eventStore.CommitTo())
+	}
+	store.currBlockHeight = height
+	store.currBlockHash = prevHash
+
+	assert.Nil(t, store.RollbackTo(1))
+	currHeight, currHash := store.GetCurrentBlock()
+	assert.Equal(t, uint32(1), currHeight)
+	assert.Equal(t, hashes[1], currHash)
+	_, blockHeight, err := store.blockStore.GetCurrentBlock()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(1), blockHeight)
+	_, err = store.blockStore.GetBlock(hashes[2])
+	assert.NotNil(t, err)
+	_, err = store.blockStore.GetBlock(hashes[1])
+	assert.Nil(t, err)
+}
+
+func TestExecuteBlockTxHeight(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txheight")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	store, err := NewLedgerStore(dir, 0)
+	assert.Nil(t, err)
+	defer store.Close()
+
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	tx, err := transferTx(common.Address{1}, common.Address{2}, 1)
+	assert.Nil(t, err)
+	mutable, err := tx.IntoMutable()
+	assert.Nil(t, err)
+	mutable.Attributes = []*types.TxAttribute{types.NewValidUntilHeightAttribute(9)}
+	tx, err = mutable.IntoImmutable()
+	assert.Nil(t, err)
+	block := &types.Block{
+		Header:       &types.Header{Height: 10},
+		Transactions: []*types.Transaction{tx},
+	}
+
+	//attributes are not accepted before the activation height
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	_, err = store.executeBlock(block)
+	if err == nil || !strings.Contains(err.Error(), types.ErrTxAttributeInactive.Error()) {
+		t.Fatalf("executeBlock expect inactive attribute error, got %v", err)
+	}
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	_, err = store.executeBlock(block)
+	if err == nil || !strings.Contains(err.Error(), types.ErrTxExpired.Error()) {
+		t.Fatalf("executeBlock expect expired error, got %v", err)
+	}
+}
+
+func TestAddHeaderVerifyError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verifyheader")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	store, err := NewLedgerStore(dir, 0)
+	assert.Nil(t, err)
+	defer store.Close()
+
+	//the header not following the previous one is rejected for failing to verify
+	err = store.AddHeader(&types.Header{Height: 1, PrevBlockHash: common.Uint256{1}})
+	assert.True(t, scom.IsVerifyError(err))
+	//the header not at the next height is not its fault
+	err = store.AddHeader(&types.Header{Height: 2})
+	assert.NotNil(t, err)
+	assert.False(t, scom.IsVerifyError(err))
+}
+
+func TestVerifyHeaderBlsSig(t *testing.T) {
+	genesis := config.DefConfig.Genesis
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.Genesis = &config.GenesisConfig{
+		ConsensusType: "vbft",
+		VBFT:          &config.VBFTConfig{},
+	}
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() {
+		config.DefConfig.Genesis = genesis
+		config.DefConfig.P2PNode.NetworkId = networkId
+	}()
+
+	//14 peers, at least 2 of them sign the header
+	accs := make([]*account.Account, 0)
+	keys := make([]*bls.PrivateKey, 0)
+	peerInfo := make(map[string]*vconfig.PeerConfig)
+	for i := uint32(0); i < 14; i++ {
+		acc := account.NewAccount("")
+		key, err := bls.GenerateKey(keypair.SerializePrivateKey(acc.PrivateKey))
+		assert.Nil(t, err)
+		accs = append(accs, acc)
+		keys = append(keys, key)
+		id := vconfig.PubkeyID(acc.PublicKey)
+		peerInfo[id] = &vconfig.PeerConfig{
+			Index:     i,
+			ID:        id,
+			BlsPubKey: hex.EncodeToString(key.PublicKey().Serialize()),
+		}
+	}
+	payload, err := json.Marshal(&vconfig.VbftBlockInfo{})
+	assert.Nil(t, err)
+	prevHeader := &types.Header{Height: 1, Timestamp: 1}
+	header := &types.Header{Height: 2, Timestamp: 2, ConsensusPayload: payload}
+	hash := header.Hash()
+	sigs := make([]*bls.Signature, 0)
+	for _, key := range keys {
+		sig, err := key.Sign(hash[:])
+		assert.Nil(t, err)
+		sigs = append(sigs, sig)
+	}
+
+	sig, err := bls.AggregateSignatures(sigs[:3])
+	assert.Nil(t, err)
+	header.SigData = vconfig.BlsSigData(sig, []uint32{0, 1, 2})
+	_, err = testLedgerStore.checkHeader(header, prevHeader, peerInfo)
+	assert.Nil(t, err)
+
+	//aggregated signature not matching the signers
+	header.SigData = vconfig.BlsSigData(sig, []uint32{0, 1, 3})
+	_, err = testLedgerStore.checkHeader(header, prevHeader, peerInfo)
+	assert.NotNil(t, err)
+
+	//not enough signers
+	header.SigData = vconfig.BlsSigData(sigs[0], []uint32{0})
+	_, err = testLedgerStore.checkHeader(header, prevHeader, peerInfo)
+	assert.NotNil(t, err)
+
+	//header sealed with the sig of each bookkeeper without enough bls sigs
+	header.SigData = nil
+	for _, acc := range accs[:2] {
+		sig, err := signature.Sign(acc, hash[:])
+		assert.Nil(t, err)
+		header.Bookkeepers = append(header.Bookkeepers, acc.PublicKey)
+		header.SigData = append(header.SigData, sig)
+	}
+	_, err = testLedgerStore.checkHeader(header, prevHeader, peerInfo)
+	assert.Nil(t, err)
+
+	//bls sig is not accepted before the bls sig height of the network
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	header.Bookkeepers = nil
+	header.SigData = vconfig.BlsSigData(sig, []uint32{0, 1, 2})
+	_, err = testLedgerStore.checkHeader(header, prevHeader, peerInfo)
+	assert.NotNil(t, err)
+}
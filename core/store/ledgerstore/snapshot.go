@@ -0,0 +1,496 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/common/serialization"
+	"github.com/ontio/ontology/core/store"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/merkle"
+)
+
+const (
+	STATE_SNAPSHOT_VERSION    = byte(1)      //Version of state snapshot format
+	STATE_SNAPSHOT_BATCH_SIZE = uint32(2000) //Count of entries per commit when importing snapshot
+)
+
+//state prefixes dumped to snapshot
+var snapshotStatePrefixes = []scom.DataEntryPrefix{scom.ST_BOOKKEEPER, scom.ST_CONTRACT, scom.ST_STORAGE}
+
+//ExportStateSnapshot write the state of current block to w. The snapshot contains the header chain,
+//the state merkle tree and all the contract, storage and bookkeeper entries, followed by sha256 checksum.
+//The returned checkpoint is needed to import the snapshot.
+func (this *LedgerStoreImp) ExportStateSnapshot(w io.Writer) (*store.StateSnapshotCheckpoint, error) {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	height, blockHash := this.GetCurrentBlock()
+	checksum := sha256.New()
+	writer := io.MultiWriter(w, checksum)
+	err := serialization.WriteByte(writer, STATE_SNAPSHOT_VERSION)
+	if err != nil {
+		return nil, err
+	}
+	err = serialization.WriteUint32(writer, height)
+	if err != nil {
+		return nil, err
+	}
+	err = blockHash.Serialize(writer)
+	if err != nil {
+		return nil, err
+	}
+
+	for h := uint32(0); h <= height; h++ {
+		hash, err := this.blockStore.GetBlockHash(h)
+		if err != nil {
+			return nil, fmt.Errorf("GetBlockHash height:%d error %s", h, err)
+		}
+		data, err := this.blockStore.store.Get(this.blockStore.getHeaderKey(hash))
+		if err != nil {
+			return nil, fmt.Errorf("get header height:%d error %s", h, err)
+		}
+		err = serialization.WriteVarBytes(writer, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stateHasher := newSnapshotStateHasher()
+	stateKeys := [][]byte{this.stateStore.genStateMerkleTreeKey(), this.stateStore.genStateMerkleRootKey(height)}
+	for _, key := range stateKeys {
+		data, err := this.stateStore.store.Get(key)
+		if err != nil && err != scom.ErrNotFound {
+			return nil, err
+		}
+		err = serialization.WriteVarBytes(writer, data)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			stateHasher.add(key, data)
+		}
+	}
+
+	for _, prefix := range snapshotStatePrefixes {
+		iter := this.stateStore.store.NewIterator([]byte{byte(prefix)})
+		for iter.Next() {
+			err = serialization.WriteVarBytes(writer, iter.Key())
+			if err == nil {
+				err = serialization.WriteVarBytes(writer, iter.Value())
+			}
+			if err != nil {
+				break
+			}
+			stateHasher.add(iter.Key(), iter.Value())
+		}
+		iter.Release()
+		if err != nil {
+			return nil, err
+		}
+		if err := iter.Error(); err != nil {
+			return nil, err
+		}
+	}
+	//empty key means the end of state entries
+	err = serialization.WriteVarBytes(writer, nil)
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.Write(checksum.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return &store.StateSnapshotCheckpoint{
+		Height:    height,
+		BlockHash: blockHash,
+		StateRoot: stateHasher.root(),
+	}, nil
+}
+
+//ImportStateSnapshot init an empty ledger store with the snapshot read from r, so that the node can start
+//from the snapshot height. The snapshot file is not trusted, it must match the checkpoint got from a trusted
+//node: the header chain is checked from genesis block to the block hash of checkpoint, and the state root is
+//recomputed from the imported state entries and checked against the state root of checkpoint. The checksum
+//only detects corrupted file. Transactions up to the snapshot height are not included, they are treated
+//as pruned and only their heights are kept. The ledger store is left uninitialized if importing failed.
+func (this *LedgerStoreImp) ImportStateSnapshot(genesisHash common.Uint256, checkpoint *store.StateSnapshotCheckpoint,
+	r io.Reader) error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	hasInit, err := this.hasAlreadyInitGenesisBlock()
+	if err != nil {
+		return fmt.Errorf("hasAlreadyInit error %s", err)
+	}
+	if hasInit {
+		return fmt.Errorf("ledger has already been initialized")
+	}
+	err = this.blockStore.ClearAll()
+	if err != nil {
+		return fmt.Errorf("blockStore.ClearAll error %s", err)
+	}
+	err = this.stateStore.ClearAll()
+	if err != nil {
+		return fmt.Errorf("stateStore.ClearAll error %s", err)
+	}
+	err = this.eventStore.ClearAll()
+	if err != nil {
+		return fmt.Errorf("eventStore.ClearAll error %s", err)
+	}
+
+	checksum := sha256.New()
+	reader := io.TeeReader(r, checksum)
+	version, err := serialization.ReadByte(reader)
+	if err != nil {
+		return err
+	}
+	if version != STATE_SNAPSHOT_VERSION {
+		return fmt.Errorf("unsupported snapshot version %d", version)
+	}
+	height, err := serialization.ReadUint32(reader)
+	if err != nil {
+		return err
+	}
+	var blockHash common.Uint256
+	err = blockHash.Deserialize(reader)
+	if err != nil {
+		return err
+	}
+	if height != checkpoint.Height || blockHash != checkpoint.BlockHash {
+		return fmt.Errorf("snapshot of height %d block %s unmatch checkpoint of height %d block %s", height,
+			blockHash.ToHexString(), checkpoint.Height, checkpoint.BlockHash.ToHexString())
+	}
+
+	blockTree, err := this.importSnapshotHeaders(genesisHash, height, blockHash, reader)
+	if err != nil {
+		return err
+	}
+
+	stateTreeData, err := serialization.ReadVarBytes(reader)
+	if err != nil {
+		return err
+	}
+	stateRootData, err := serialization.ReadVarBytes(reader)
+	if err != nil {
+		return err
+	}
+	err = this.checkSnapshotStateMerkleTree(height, stateTreeData, stateRootData)
+	if err != nil {
+		return err
+	}
+	stateHasher := newSnapshotStateHasher()
+	if len(stateTreeData) > 0 {
+		stateHasher.add(this.stateStore.genStateMerkleTreeKey(), stateTreeData)
+		stateHasher.add(this.stateStore.genStateMerkleRootKey(height), stateRootData)
+	}
+	err = this.importSnapshotStates(reader, stateHasher)
+	if err != nil {
+		return err
+	}
+	sum := make([]byte, sha256.Size)
+	_, err = io.ReadFull(r, sum)
+	if err != nil {
+		return fmt.Errorf("read checksum error %s", err)
+	}
+	if !bytes.Equal(sum, checksum.Sum(nil)) {
+		return fmt.Errorf("snapshot checksum unmatch")
+	}
+	if stateRoot := stateHasher.root(); stateRoot != checkpoint.StateRoot {
+		return fmt.Errorf("state root unmatch, expected:%s, got:%s", checkpoint.StateRoot.ToHexString(),
+			stateRoot.ToHexString())
+	}
+
+	this.stateStore.NewBatch()
+	this.stateStore.store.BatchPut(this.stateStore.genBlockMerkleTreeKey(), merkleTreeToBytes(blockTree))
+	if len(stateTreeData) > 0 {
+		this.stateStore.store.BatchPut(this.stateStore.genStateMerkleTreeKey(), stateTreeData)
+		this.stateStore.store.BatchPut(this.stateStore.genStateMerkleRootKey(height), stateRootData)
+	}
+	this.stateStore.SaveCurrentBlock(height, blockHash)
+	err = this.stateStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("stateStore.CommitTo error %s", err)
+	}
+	this.eventStore.NewBatch()
+	this.eventStore.SaveCurrentBlock(height, blockHash)
+	err = this.eventStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("eventStore.CommitTo error %s", err)
+	}
+	this.blockStore.NewBatch()
+	this.blockStore.SaveCurrentBlock(height, blockHash)
+	this.blockStore.SavePrunedHeight(height + 1)
+	err = this.blockStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("blockStore.CommitTo error %s", err)
+	}
+	err = this.stateStore.init(height)
+	if err != nil {
+		return fmt.Errorf("stateStore.init error %s", err)
+	}
+	//version is saved at last, ledger store will be cleared at next start if failed before
+	err = this.initGenesisBlock()
+	if err != nil {
+		return fmt.Errorf("init error %s", err)
+	}
+	log.Infof("import state snapshot of height %d, block hash %s", height, blockHash.ToHexString())
+	return nil
+}
+
+//importSnapshotHeaders save the header chain of snapshot to block store and rebuild the block merkle tree
+func (this *LedgerStoreImp) importSnapshotHeaders(genesisHash common.Uint256, height uint32, currBlockHash common.Uint256,
+	reader io.Reader) (*merkle.CompactMerkleTree, error) {
+	if this.stateStore.merkleHashStore != nil {
+		this.stateStore.merkleHashStore.Close()
+	}
+	hashStore, err := newSnapshotHashStore(this.stateStore.merklePath)
+	if err != nil {
+		return nil, err
+	}
+	defer hashStore.Close()
+	blockTree := merkle.NewTree(0, nil, hashStore)
+
+	var prevHash common.Uint256
+	this.blockStore.NewBatch()
+	for h := uint32(0); h <= height; h++ {
+		data, err := serialization.ReadVarBytes(reader)
+		if err != nil {
+			return nil, fmt.Errorf("read header height:%d error %s", h, err)
+		}
+		source := common.NewZeroCopySource(data)
+		sysFee := new(common.Fixed64)
+		err = sysFee.Deserialization(source)
+		if err != nil {
+			return nil, err
+		}
+		header := new(types.Header)
+		err = header.Deserialization(source)
+		if err != nil {
+			return nil, fmt.Errorf("header height:%d deserialize error %s", h, err)
+		}
+		txSize, eof := source.NextUint32()
+		if eof {
+			return nil, io.ErrUnexpectedEOF
+		}
+		blockHash := header.Hash()
+		if header.Height != h {
+			return nil, fmt.Errorf("header height unmatch, expected:%d, got:%d", h, header.Height)
+		}
+		if h == 0 && blockHash != genesisHash {
+			return nil, fmt.Errorf("genesis block unmatch, expected:%s, got:%s", genesisHash.ToHexString(), blockHash.ToHexString())
+		}
+		if h > 0 && header.PrevBlockHash != prevHash {
+			return nil, fmt.Errorf("prev block hash of height %d unmatch", h)
+		}
+		blockTree.AppendHash(header.TransactionsRoot)
+		if h > 0 && blockTree.Root() != header.BlockRoot {
+			return nil, fmt.Errorf("wrong block root at height:%d", h)
+		}
+		//keep height of transaction only as pruned, to reject duplicated transaction
+		txHeight := make([]byte, 4)
+		binary.LittleEndian.PutUint32(txHeight, h)
+		txHashes := make([]common.Uint256, 0, txSize)
+		for i := uint32(0); i < txSize; i++ {
+			txHash, eof := source.NextHash()
+			if eof {
+				return nil, io.ErrUnexpectedEOF
+			}
+			txHashes = append(txHashes, txHash)
+			this.blockStore.store.BatchPut(this.blockStore.getTransactionKey(txHash), txHeight)
+		}
+		if common.ComputeMerkleRoot(txHashes) != header.TransactionsRoot {
+			return nil, fmt.Errorf("wrong transactions root at height:%d", h)
+		}
+		this.blockStore.store.BatchPut(this.blockStore.getHeaderKey(blockHash), data)
+		this.blockStore.SaveBlockHash(h, blockHash)
+		if h%STATE_SNAPSHOT_BATCH_SIZE == 0 {
+			err = this.blockStore.CommitTo()
+			if err != nil {
+				return nil, fmt.Errorf("blockStore.CommitTo error %s", err)
+			}
+			this.blockStore.NewBatch()
+		}
+		prevHash = blockHash
+	}
+	err = this.blockStore.CommitTo()
+	if err != nil {
+		return nil, fmt.Errorf("blockStore.CommitTo error %s", err)
+	}
+	if prevHash != currBlockHash {
+		return nil, fmt.Errorf("block hash of height %d unmatch", height)
+	}
+	hashStore.Close()
+	if hashStore.err != nil {
+		return nil, fmt.Errorf("save merkle hashes error %s", hashStore.err)
+	}
+	return blockTree, nil
+}
+
+//checkSnapshotStateMerkleTree check the state merkle tree of snapshot is consistent with the state merkle root
+//of height. Both of them are covered by the state root of checkpoint.
+func (this *LedgerStoreImp) checkSnapshotStateMerkleTree(height uint32, treeData, rootData []byte) error {
+	if height < this.stateHashCheckHeight {
+		if len(treeData) != 0 || len(rootData) != 0 {
+			return fmt.Errorf("unexpected state merkle tree before height %d", this.stateHashCheckHeight)
+		}
+		return nil
+	}
+	treeSize, hashes, err := parseMerkleTree(treeData)
+	if err != nil {
+		return fmt.Errorf("parse state merkle tree error %s", err)
+	}
+	if treeSize != height-this.stateHashCheckHeight+1 {
+		return fmt.Errorf("state merkle tree size is inconsistent with height %d", height)
+	}
+	source := common.NewZeroCopySource(rootData)
+	_, eof := source.NextHash()
+	root, eof := source.NextHash()
+	if eof {
+		return fmt.Errorf("invalid state merkle root")
+	}
+	if merkle.NewTree(treeSize, hashes, nil).Root() != root {
+		return fmt.Errorf("state merkle root of height %d unmatch", height)
+	}
+	return nil
+}
+
+//importSnapshotStates save the state entries of snapshot to state store
+func (this *LedgerStoreImp) importSnapshotStates(reader io.Reader, stateHasher *snapshotStateHasher) error {
+	count := uint32(0)
+	this.stateStore.store.NewBatch()
+	for {
+		key, err := serialization.ReadVarBytes(reader)
+		if err != nil {
+			return fmt.Errorf("read state key error %s", err)
+		}
+		if len(key) == 0 {
+			break
+		}
+		if !isSnapshotStateKey(key) {
+			return fmt.Errorf("unexpected state key prefix %x", key[0])
+		}
+		value, err := serialization.ReadVarBytes(reader)
+		if err != nil {
+			return fmt.Errorf("read state value error %s", err)
+		}
+		this.stateStore.store.BatchPut(key, value)
+		stateHasher.add(key, value)
+		count++
+		if count%STATE_SNAPSHOT_BATCH_SIZE == 0 {
+			err = this.stateStore.store.BatchCommit()
+			if err != nil {
+				return fmt.Errorf("stateStore.BatchCommit error %s", err)
+			}
+			this.stateStore.store.NewBatch()
+		}
+	}
+	return this.stateStore.store.BatchCommit()
+}
+
+func isSnapshotStateKey(key []byte) bool {
+	for _, prefix := range snapshotStatePrefixes {
+		if key[0] == byte(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+//snapshotStateHasher compute the state root of snapshot, which is the root of merkle tree of state entries
+//in the order of snapshot.
+type snapshotStateHasher struct {
+	tree *merkle.CompactMerkleTree
+}
+
+func newSnapshotStateHasher() *snapshotStateHasher {
+	return &snapshotStateHasher{tree: merkle.NewTree(0, nil, nil)}
+}
+
+func (self *snapshotStateHasher) add(key, value []byte) {
+	sink := common.NewZeroCopySink(make([]byte, 0, len(key)+len(value)+16))
+	sink.WriteVarBytes(key)
+	sink.WriteVarBytes(value)
+	self.tree.AppendHash(common.Uint256(sha256.Sum256(sink.Bytes())))
+}
+
+func (self *snapshotStateHasher) root() common.Uint256 {
+	return self.tree.Root()
+}
+
+//snapshotHashStore write merkle hashes to file without sync every append when importing snapshot
+type snapshotHashStore struct {
+	file   *os.File
+	writer *bufio.Writer
+	err    error
+}
+
+func newSnapshotHashStore(name string) (*snapshotHashStore, error) {
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotHashStore{
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+func (self *snapshotHashStore) Append(hash []common.Uint256) error {
+	for _, h := range hash {
+		if self.err != nil {
+			return self.err
+		}
+		_, self.err = self.writer.Write(h[:])
+	}
+	return self.err
+}
+
+func (self *snapshotHashStore) Flush() error {
+	return nil
+}
+
+func (self *snapshotHashStore) Close() {
+	if self.file == nil {
+		return
+	}
+	if self.err == nil {
+		self.err = self.writer.Flush()
+	}
+	if self.err == nil {
+		self.err = self.file.Sync()
+	}
+	self.file.Close()
+	self.file = nil
+}
+
+func (self *snapshotHashStore) GetHash(pos uint32) (common.Uint256, error) {
+	return merkle.EMPTY_HASH, errors.New("snapshotHashStore is write only")
+}
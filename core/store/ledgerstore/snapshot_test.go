@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSnapshotStateMerkleTree(t *testing.T) {
+	const checkHeight = 10
+	db := NewMemStateStore(checkHeight)
+	ledger := &LedgerStoreImp{stateStore: db, stateHashCheckHeight: checkHeight}
+
+	height := uint32(20)
+	for h := uint32(checkHeight); h <= height; h++ {
+		var hash common.Uint256
+		rand.Read(hash[:])
+		db.NewBatch()
+		assert.Nil(t, db.AddStateMerkleTreeRoot(h, hash))
+		assert.Nil(t, db.CommitTo())
+	}
+	treeData, err := db.store.Get(db.genStateMerkleTreeKey())
+	assert.Nil(t, err)
+	rootData, err := db.store.Get(db.genStateMerkleRootKey(height))
+	assert.Nil(t, err)
+
+	assert.Nil(t, ledger.checkSnapshotStateMerkleTree(height, treeData, rootData))
+	assert.NotNil(t, ledger.checkSnapshotStateMerkleTree(height+1, treeData, rootData))
+	wrongRoot := append([]byte{}, rootData...)
+	wrongRoot[len(wrongRoot)-1] ^= 0xff
+	assert.NotNil(t, ledger.checkSnapshotStateMerkleTree(height, treeData, wrongRoot))
+	assert.Nil(t, ledger.checkSnapshotStateMerkleTree(checkHeight-1, nil, nil))
+	assert.NotNil(t, ledger.checkSnapshotStateMerkleTree(checkHeight-1, treeData, rootData))
+}
+
+func TestImportStateSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	acc := account.NewAccount("")
+	bookkeepers := []keypair.PublicKey{acc.PublicKey}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	src, err := NewLedgerStore(filepath.Join(dir, "src"), 0)
+	assert.Nil(t, err)
+	assert.Nil(t, src.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	buf := new(bytes.Buffer)
+	checkpoint, err := src.ExportStateSnapshot(buf)
+	assert.Nil(t, err)
+	assert.Nil(t, src.Close())
+	data := buf.Bytes()
+
+	importSnapshot := func(name string, checkpoint *store.StateSnapshotCheckpoint, data []byte) error {
+		dst, err := NewLedgerStore(filepath.Join(dir, name), 0)
+		assert.Nil(t, err)
+		defer dst.Close()
+		return dst.ImportStateSnapshot(genesisBlock.Hash(), checkpoint, bytes.NewReader(data))
+	}
+	assert.Nil(t, importSnapshot("ok", checkpoint, data))
+
+	wrongHash := *checkpoint
+	wrongHash.BlockHash[0] ^= 0xff
+	assert.NotNil(t, importSnapshot("hash", &wrongHash, data))
+	wrongRoot := *checkpoint
+	wrongRoot.StateRoot[0] ^= 0xff
+	assert.NotNil(t, importSnapshot("root", &wrongRoot, data))
+
+	//tamper the last state value and fix the checksum
+	tampered := append([]byte{}, data...)
+	n := len(tampered) - sha256.Size
+	tampered[n-2] ^= 0xff
+	sum := sha256.Sum256(tampered[:n])
+	copy(tampered[n:], sum[:])
+	err = importSnapshot("tampered", checkpoint, tampered)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "state root unmatch")
+	}
+}
@@ -26,10 +26,12 @@ import (
 	"io"
 
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/common/serialization"
 	"github.com/ontio/ontology/core/payload"
 	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/core/store/backend"
 	scom "github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/ontology/core/store/leveldbstore"
 	"github.com/ontio/ontology/core/store/overlaydb"
@@ -51,12 +53,24 @@ type StateStore struct {
 	deltaMerkleTree      *merkle.CompactMerkleTree //Merkle tree of delta state root
 	merkleHashStore      merkle.HashStore
 	stateHashCheckHeight uint32
+	archive              bool             //Whether keep the history of state
+	archiveHeight        uint32           //The lowest block height whose state can be queried from history
+	undoKeys             map[string]bool  //Keys changed in current batch
+	undoEntries          []stateUndoEntry //Values before current batch of changed keys
+	undoErr              error            //Error when recording undo entries
+}
+
+//stateUndoEntry is the value of a key before a block, used to rollback the block
+type stateUndoEntry struct {
+	key   []byte
+	exist bool
+	value []byte
 }
 
 //NewStateStore return state store instance
 func NewStateStore(dbDir, merklePath string, stateHashCheckHeight uint32) (*StateStore, error) {
 	var err error
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	store, err := backend.NewStore(config.DefConfig.Common.StoreBackend, dbDir)
 	if err != nil {
 		return nil, err
 	}
@@ -93,16 +107,247 @@ func NewMemStateStore(stateHashHeight uint32) *StateStore {
 //NewBatch start new commit batch
 func (self *StateStore) NewBatch() {
 	self.store.NewBatch()
+	self.undoKeys = make(map[string]bool)
+	self.undoEntries = nil
+	self.undoErr = nil
 }
 
 func (self *StateStore) BatchPutRawKeyVal(key, val []byte) {
-	self.store.BatchPut(key, val)
+	self.batchPut(key, val)
 }
 
 func (self *StateStore) BatchDeleteRawKey(key []byte) {
+	self.batchDelete(key)
+}
+
+func (self *StateStore) batchPut(key, val []byte) {
+	self.recordUndo(key)
+	self.store.BatchPut(key, val)
+}
+
+func (self *StateStore) batchDelete(key []byte) {
+	self.recordUndo(key)
 	self.store.BatchDelete(key)
 }
 
+//recordUndo keep the value before current batch of the key, only the first change of the key is recorded
+func (self *StateStore) recordUndo(key []byte) {
+	if self.undoKeys == nil {
+		self.undoKeys = make(map[string]bool)
+	}
+	if self.undoKeys[string(key)] {
+		return
+	}
+	self.undoKeys[string(key)] = true
+	value, err := self.store.Get(key)
+	if err != nil && err != scom.ErrNotFound {
+		if self.undoErr == nil {
+			self.undoErr = err
+		}
+		return
+	}
+	self.undoEntries = append(self.undoEntries, stateUndoEntry{
+		key:   append([]byte{}, key...),
+		exist: err == nil,
+		value: value,
+	})
+}
+
+//BatchSaveUndoLog save the undo log of the block to batch, and drop the undo log which is out of rollback range
+func (self *StateStore) BatchSaveUndoLog(height uint32) error {
+	if self.undoErr != nil {
+		return self.undoErr
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(uint64(len(self.undoEntries)))
+	for _, entry := range self.undoEntries {
+		sink.WriteVarBytes(entry.key)
+		sink.WriteBool(entry.exist)
+		sink.WriteVarBytes(entry.value)
+	}
+	self.store.BatchPut(self.genStateUndoKey(height), sink.Bytes())
+	if height >= MAX_ROLLBACK_BLOCKS {
+		self.store.BatchDelete(self.genStateUndoKey(height - MAX_ROLLBACK_BLOCKS))
+	}
+	return nil
+}
+
+func (self *StateStore) getUndoLog(height uint32) ([]stateUndoEntry, error) {
+	data, err := self.store.Get(self.genStateUndoKey(height))
+	if err != nil {
+		return nil, err
+	}
+	source := common.NewZeroCopySource(data)
+	count, _, irregular, eof := source.NextVarUint()
+	if irregular || eof {
+		return nil, fmt.Errorf("invalid undo log of height %d", height)
+	}
+	entries := make([]stateUndoEntry, 0, count)
+	for i := uint64(0); i < count; i++ {
+		key, _, irregular, eof := source.NextVarBytes()
+		if irregular || eof {
+			return nil, fmt.Errorf("invalid undo log of height %d", height)
+		}
+		exist, irr, eof := source.NextBool()
+		if irr || eof {
+			return nil, fmt.Errorf("invalid undo log of height %d", height)
+		}
+		value, _, irregular, eof := source.NextVarBytes()
+		if irregular || eof {
+			return nil, fmt.Errorf("invalid undo log of height %d", height)
+		}
+		entries = append(entries, stateUndoEntry{key: key, exist: exist, value: value})
+	}
+	return entries, nil
+}
+
+//CheckRollback return error if the state store can not be rollback to the block of height
+func (self *StateStore) CheckRollback(height uint32) error {
+	_, currHeight, err := self.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("GetCurrentBlock error %s", err)
+	}
+	for h := currHeight; h > height; h-- {
+		_, err := self.store.Get(self.genStateUndoKey(h))
+		if err == scom.ErrNotFound {
+			return fmt.Errorf("undo log of height %d not found", h)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//RollbackTo revert the state store to the block of height by applying the undo logs block by block
+func (self *StateStore) RollbackTo(height uint32) error {
+	_, currHeight, err := self.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("GetCurrentBlock error %s", err)
+	}
+	if currHeight <= height {
+		return nil
+	}
+	for h := currHeight; h > height; h-- {
+		entries, err := self.getUndoLog(h)
+		if err != nil {
+			return fmt.Errorf("getUndoLog height:%d error %s", h, err)
+		}
+		self.store.NewBatch()
+		for _, entry := range entries {
+			if entry.exist {
+				self.store.BatchPut(entry.key, entry.value)
+			} else {
+				self.store.BatchDelete(entry.key)
+			}
+		}
+		self.store.BatchDelete(self.genStateUndoKey(h))
+		err = self.store.BatchCommit()
+		if err != nil {
+			return fmt.Errorf("BatchCommit height:%d error %s", h, err)
+		}
+	}
+	if self.merkleHashStore != nil {
+		self.merkleHashStore.Close()
+	}
+	return self.init(height)
+}
+
+//InitStateArchive enable or disable keeping history of state. Should be called before any block is saved.
+//The archive mode is persisted once enabled, and the store refuses to open without it, since the history
+//would be incomplete once a block is saved without archiving
+func (self *StateStore) InitStateArchive(enable bool) error {
+	key := self.genStateArchiveKey()
+	data, err := self.store.Get(key)
+	if err == nil {
+		if len(data) != 4 {
+			return fmt.Errorf("invalid state archive height")
+		}
+		archiveHeight := binary.LittleEndian.Uint32(data)
+		if !enable {
+			return fmt.Errorf("state is archived since height %d, the ledger can only be opened with state archive enabled",
+				archiveHeight)
+		}
+		self.archive = true
+		self.archiveHeight = archiveHeight
+		return nil
+	}
+	if err != scom.ErrNotFound {
+		return err
+	}
+	if !enable {
+		self.archive = false
+		return nil
+	}
+	//the state before current block has not been archived
+	_, height, err := self.GetCurrentBlock()
+	if err != nil && err != scom.ErrNotFound {
+		return err
+	}
+	value := make([]byte, 4)
+	binary.LittleEndian.PutUint32(value, height)
+	err = self.store.Put(key, value)
+	if err != nil {
+		return err
+	}
+	self.archive = true
+	self.archiveHeight = height
+	return nil
+}
+
+//IsStateArchived return whether the state at block height can be queried from history
+func (self *StateStore) IsStateArchived(height uint32) bool {
+	return self.archive && height >= self.archiveHeight
+}
+
+//BatchSaveStateHistory save the value before the block of each key in the write set to batch
+func (self *StateStore) BatchSaveStateHistory(height uint32, writeSet *overlaydb.MemDB) error {
+	if !self.archive {
+		return nil
+	}
+	var err error
+	writeSet.ForEach(func(key, val []byte) {
+		if err != nil {
+			return
+		}
+		prev, e := self.store.Get(key)
+		if e != nil && e != scom.ErrNotFound {
+			err = e
+			return
+		}
+		self.batchPut(self.genStateHistoryKey(key, height), prev)
+	})
+	return err
+}
+
+//getStateAtHeight return the value of the raw key after the block of height has been saved
+func (self *StateStore) getStateAtHeight(key []byte, height uint32) ([]byte, error) {
+	if !self.IsStateArchived(height) {
+		return nil, fmt.Errorf("state of height %d is not archived", height)
+	}
+	start := self.genStateHistoryKey(key, height)
+	prefix := start[:len(start)-4]
+	iter := self.store.NewIterator(prefix)
+	defer iter.Release()
+	for ok := iter.Seek(start); ok; ok = iter.Next() {
+		histKey := iter.Key()
+		if len(histKey) != len(prefix)+4 {
+			continue
+		}
+		//the first change after height keeps the value at height
+		if binary.BigEndian.Uint32(histKey[len(prefix):]) > height {
+			value := iter.Value()
+			if len(value) == 0 {
+				return nil, scom.ErrNotFound
+			}
+			return append([]byte{}, value...), nil
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return self.store.Get(key)
+}
+
 func (self *StateStore) init(currBlockHeight uint32) error {
 	treeSize, hashes, err := self.GetBlockMerkleTree()
 	if err != nil && err != scom.ErrNotFound {
@@ -146,6 +391,10 @@ func (self *StateStore) getMerkleTree(key []byte) (uint32, []common.Uint256, err
 	if err != nil {
 		return 0, nil, err
 	}
+	return parseMerkleTree(data)
+}
+
+func parseMerkleTree(data []byte) (uint32, []common.Uint256, error) {
 	value := bytes.NewBuffer(data)
 	treeSize, err := serialization.ReadUint32(value)
 	if err != nil {
@@ -192,20 +441,13 @@ func (self *StateStore) AddStateMerkleTreeRoot(blockHeight uint32, writeSetHash
 	key := self.genStateMerkleTreeKey()
 
 	self.deltaMerkleTree.AppendHash(writeSetHash)
-	treeSize := self.deltaMerkleTree.TreeSize()
-	hashes := self.deltaMerkleTree.Hashes()
-	value := common.NewZeroCopySink(make([]byte, 0, 4+len(hashes)*common.UINT256_SIZE))
-	value.WriteUint32(treeSize)
-	for _, hash := range hashes {
-		value.WriteHash(hash)
-	}
-	self.store.BatchPut(key, value.Bytes())
+	self.batchPut(key, merkleTreeToBytes(self.deltaMerkleTree))
 
 	key = self.genStateMerkleRootKey(blockHeight)
-	value.Reset()
+	value := common.NewZeroCopySink(make([]byte, 0, 2*common.UINT256_SIZE))
 	value.WriteHash(writeSetHash)
 	value.WriteHash(self.deltaMerkleTree.Root())
-	self.store.BatchPut(key, value.Bytes())
+	self.batchPut(key, value.Bytes())
 
 	return nil
 }
@@ -215,15 +457,18 @@ func (self *StateStore) AddBlockMerkleTreeRoot(txRoot common.Uint256) error {
 	key := self.genBlockMerkleTreeKey()
 
 	self.merkleTree.AppendHash(txRoot)
-	treeSize := self.merkleTree.TreeSize()
-	hashes := self.merkleTree.Hashes()
+	self.batchPut(key, merkleTreeToBytes(self.merkleTree))
+	return nil
+}
+
+func merkleTreeToBytes(tree *merkle.CompactMerkleTree) []byte {
+	hashes := tree.Hashes()
 	value := common.NewZeroCopySink(make([]byte, 0, 4+len(hashes)*common.UINT256_SIZE))
-	value.WriteUint32(treeSize)
+	value.WriteUint32(tree.TreeSize())
 	for _, hash := range hashes {
 		value.WriteHash(hash)
 	}
-	self.store.BatchPut(key, value.Bytes())
-	return nil
+	return value.Bytes()
 }
 
 //GetMerkleProof return merkle proof of block
@@ -315,6 +560,26 @@ func (self *StateStore) GetStorageState(key *states.StorageKey) (*states.Storage
 	return storageState, nil
 }
 
+//GetStorageStateAtHeight return the storage value of the key in smart contract at block height.
+func (self *StateStore) GetStorageStateAtHeight(key *states.StorageKey, height uint32) (*states.StorageItem, error) {
+	storeKey, err := self.getStorageKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := self.getStateAtHeight(storeKey, height)
+	if err != nil {
+		return nil, err
+	}
+	reader := bytes.NewReader(data)
+	storageState := new(states.StorageItem)
+	err = storageState.Deserialize(reader)
+	if err != nil {
+		return nil, err
+	}
+	return storageState, nil
+}
+
 //GetCurrentBlock return current block height and current hash in state store
 func (self *StateStore) GetCurrentBlock() (common.Uint256, uint32, error) {
 	key := self.getCurrentBlockKey()
@@ -341,7 +606,7 @@ func (self *StateStore) SaveCurrentBlock(height uint32, blockHash common.Uint256
 	value := bytes.NewBuffer(nil)
 	blockHash.Serialize(value)
 	serialization.WriteUint32(value, height)
-	self.store.BatchPut(key, value.Bytes())
+	self.batchPut(key, value.Bytes())
 	return nil
 }
 
@@ -388,6 +653,26 @@ func (self *StateStore) genStateMerkleTreeKey() []byte {
 	return []byte{byte(scom.SYS_STATE_MERKLE_TREE)}
 }
 
+func (self *StateStore) genStateArchiveKey() []byte {
+	return []byte{byte(scom.SYS_STATE_ARCHIVE)}
+}
+
+func (self *StateStore) genStateUndoKey(height uint32) []byte {
+	key := make([]byte, 5, 5)
+	key[0] = byte(scom.DATA_STATE_UNDO)
+	binary.BigEndian.PutUint32(key[1:], height)
+	return key
+}
+
+//history key is ordered by height for the same state key
+func (self *StateStore) genStateHistoryKey(key []byte, height uint32) []byte {
+	histKey := make([]byte, 1+len(key)+4)
+	histKey[0] = byte(scom.DATA_STATE_HISTORY)
+	copy(histKey[1:], key)
+	binary.BigEndian.PutUint32(histKey[1+len(key):], height)
+	return histKey
+}
+
 func (self *StateStore) genStateMerkleRootKey(height uint32) []byte {
 	key := make([]byte, 5, 5)
 	key[0] = byte(scom.DATA_STATE_MERKLE_ROOT)
@@ -19,10 +19,14 @@
 package ledgerstore
 
 import (
+	"bytes"
 	"math/rand"
 	"testing"
 
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/states"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
 	"github.com/ontio/ontology/merkle"
 	"github.com/stretchr/testify/assert"
 )
@@ -71,3 +75,108 @@ func TestStateMerkleRoot(t *testing.T) {
 	}
 
 }
+
+func TestStateHistory(t *testing.T) {
+	db := NewMemStateStore(0)
+	err := db.InitStateArchive(true)
+	assert.Nil(t, err)
+
+	key := &states.StorageKey{ContractAddress: common.ADDRESS_EMPTY, Key: []byte("key")}
+	rawKey, _ := db.getStorageKey(key)
+	longKey := append(append([]byte{}, rawKey...), 0x01)
+	values := map[uint32][]byte{1: []byte("v1"), 3: []byte("v3"), 4: nil, 6: []byte("v6")}
+	for height := uint32(0); height <= 7; height++ {
+		writeSet := overlaydb.NewMemDB(0, 0)
+		if val, ok := values[height]; ok {
+			item := &states.StorageItem{Value: val}
+			buf := bytes.NewBuffer(nil)
+			item.Serialize(buf)
+			if val == nil {
+				writeSet.Delete(rawKey)
+			} else {
+				writeSet.Put(rawKey, buf.Bytes())
+			}
+		}
+		writeSet.Put(longKey, []byte{byte(height)})
+		db.NewBatch()
+		err = db.BatchSaveStateHistory(height, writeSet)
+		assert.Nil(t, err)
+		writeSet.ForEach(func(key, val []byte) {
+			if len(val) == 0 {
+				db.BatchDeleteRawKey(key)
+			} else {
+				db.BatchPutRawKeyVal(key, val)
+			}
+		})
+		err = db.CommitTo()
+		assert.Nil(t, err)
+	}
+
+	expected := []string{"", "v1", "v1", "v3", "", "", "v6", "v6"}
+	for height, exp := range expected {
+		item, err := db.GetStorageStateAtHeight(key, uint32(height))
+		if exp == "" {
+			assert.Equal(t, scom.ErrNotFound, err)
+			continue
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, exp, string(item.Value))
+	}
+
+	//the history would be incomplete if the ledger is opened without archiving
+	assert.NotNil(t, db.InitStateArchive(false))
+	assert.Nil(t, db.InitStateArchive(true))
+	assert.True(t, db.IsStateArchived(0))
+
+	db = NewMemStateStore(0)
+	assert.Nil(t, db.InitStateArchive(false))
+	assert.False(t, db.IsStateArchived(0))
+}
+
+func TestStateRollback(t *testing.T) {
+	db := NewMemStateStore(0)
+	dump := func() map[string]string {
+		result := make(map[string]string)
+		iter := db.store.NewIterator(nil)
+		defer iter.Release()
+		for iter.Next() {
+			result[string(iter.Key())] = string(iter.Value())
+		}
+		return result
+	}
+
+	snapshots := make([]map[string]string, 0)
+	roots := make([]common.Uint256, 0)
+	for height := uint32(0); height <= 5; height++ {
+		var hash common.Uint256
+		rand.Read(hash[:])
+		db.NewBatch()
+		err := db.AddBlockMerkleTreeRoot(hash)
+		assert.Nil(t, err)
+		err = db.SaveCurrentBlock(height, hash)
+		assert.Nil(t, err)
+		db.BatchPutRawKeyVal([]byte{0x05, byte(height)}, []byte{byte(height)})
+		db.BatchPutRawKeyVal([]byte{0x05, 0xff}, []byte{byte(height)})
+		if height > 0 {
+			db.BatchDeleteRawKey([]byte{0x05, byte(height - 1)})
+		}
+		err = db.BatchSaveUndoLog(height)
+		assert.Nil(t, err)
+		err = db.CommitTo()
+		assert.Nil(t, err)
+		snapshots = append(snapshots, dump())
+		roots = append(roots, db.merkleTree.Root())
+	}
+
+	assert.Nil(t, db.CheckRollback(1))
+	err := db.RollbackTo(1)
+	assert.Nil(t, err)
+	assert.Equal(t, snapshots[1], dump())
+	assert.Equal(t, roots[1], db.merkleTree.Root())
+	_, height, err := db.GetCurrentBlock()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(1), height)
+
+	db.store.Delete(db.genStateUndoKey(1))
+	assert.NotNil(t, db.CheckRollback(0))
+}
@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"fmt"
 	"math"
+	"math/big"
 	"strconv"
 
 	"github.com/ontio/ontology/common"
@@ -253,6 +254,115 @@ func SaveNotify(eventStore scommon.EventStore, txHash common.Uint256, notify *ev
 	return nil
 }
 
+//getTxAddresses return the payer and signers of transaction, and the addresses in ONT/ONG transfer events
+func getTxAddresses(tx *types.Transaction, notify *event.ExecuteNotify) []common.Address {
+	addrs := make([]common.Address, 0)
+	added := make(map[common.Address]bool)
+	add := func(addr common.Address) {
+		if addr != common.ADDRESS_EMPTY && !added[addr] {
+			added[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	add(tx.Payer)
+	signers, _ := tx.GetSignatureAddresses()
+	for _, addr := range signers {
+		add(addr)
+	}
+	if notify == nil {
+		return addrs
+	}
+	for _, n := range notify.Notify {
+		if n.ContractAddress != utils.OntContractAddress && n.ContractAddress != utils.OngContractAddress {
+			continue
+		}
+		if from, to, _, ok := parseTransferNotify(n); ok {
+			add(from)
+			add(to)
+		}
+	}
+	return addrs
+}
+
+//getTxTransfers return the token transfers recognised from the notifications of a successful transaction
+func getTxTransfers(height uint32, notify *event.ExecuteNotify) []*store.Transfer {
+	transfers := make([]*store.Transfer, 0)
+	if notify == nil || notify.State != event.CONTRACT_STATE_SUCCESS {
+		return transfers
+	}
+	for _, n := range notify.Notify {
+		from, to, amount, ok := parseTransferNotify(n)
+		if !ok {
+			continue
+		}
+		transfers = append(transfers, &store.Transfer{
+			TxHash:   notify.TxHash,
+			Height:   height,
+			Contract: n.ContractAddress,
+			From:     from,
+			To:       to,
+			Amount:   amount,
+		})
+	}
+	return transfers
+}
+
+//parseTransferNotify parse the ["transfer", from, to, amount] notification. States of native ONT/ONG contract
+//are the raw values with base58 addresses, and states of NeoVM contract are hex strings.
+func parseTransferNotify(n *event.NotifyEventInfo) (common.Address, common.Address, *big.Int, bool) {
+	var from, to common.Address
+	states, ok := n.States.([]interface{})
+	if !ok || len(states) != 4 {
+		return from, to, nil, false
+	}
+	if n.ContractAddress == utils.OntContractAddress || n.ContractAddress == utils.OngContractAddress {
+		if states[0] != ont.TRANSFER_NAME {
+			return from, to, nil, false
+		}
+		fromStr, ok1 := states[1].(string)
+		toStr, ok2 := states[2].(string)
+		value, ok3 := states[3].(uint64)
+		if !ok1 || !ok2 || !ok3 {
+			return from, to, nil, false
+		}
+		var err error
+		if from, err = common.AddressFromBase58(fromStr); err != nil {
+			return from, to, nil, false
+		}
+		if to, err = common.AddressFromBase58(toStr); err != nil {
+			return from, to, nil, false
+		}
+		return from, to, new(big.Int).SetUint64(value), true
+	}
+	data := make([][]byte, 0, len(states))
+	for _, state := range states {
+		str, ok := state.(string)
+		if !ok {
+			return from, to, nil, false
+		}
+		buf, err := common.HexToBytes(str)
+		if err != nil {
+			return from, to, nil, false
+		}
+		data = append(data, buf)
+	}
+	if string(data[0]) != ont.TRANSFER_NAME {
+		return from, to, nil, false
+	}
+	var err error
+	if from, err = common.AddressParseFromBytes(data[1]); err != nil {
+		return from, to, nil, false
+	}
+	if to, err = common.AddressParseFromBytes(data[2]); err != nil {
+		return from, to, nil, false
+	}
+	amount := common.BigIntFromNeoBytes(data[3])
+	if amount.Sign() < 0 {
+		return from, to, nil, false
+	}
+	return from, to, amount, true
+}
+
 func genNativeTransferCode(from, to common.Address, value uint64) []byte {
 	transfer := ont.Transfers{States: []ont.State{{From: from, To: to, Value: value}}}
 	tr := new(bytes.Buffer)
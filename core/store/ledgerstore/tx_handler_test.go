@@ -20,9 +20,15 @@ package ledgerstore
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
 	"sync"
 	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
 )
 
 func TestSyncMapRange(t *testing.T) {
@@ -75,3 +81,36 @@ func TestSyncMapRW(t *testing.T) {
 func addsync(m *sync.Map, va int) {
 	m.Store("key", va)
 }
+
+func TestParseTransferNotify(t *testing.T) {
+	from := common.Address{1}
+	to := common.Address{2}
+	native := &event.NotifyEventInfo{
+		ContractAddress: utils.OngContractAddress,
+		States:          []interface{}{ont.TRANSFER_NAME, from.ToBase58(), to.ToBase58(), uint64(100)},
+	}
+	f, tt, amount, ok := parseTransferNotify(native)
+	if !ok || f != from || tt != to || amount.Int64() != 100 {
+		t.Errorf("parseTransferNotify native transfer error")
+		return
+	}
+	neo := &event.NotifyEventInfo{
+		ContractAddress: common.Address{3},
+		States: []interface{}{common.ToHexString([]byte(ont.TRANSFER_NAME)), common.ToHexString(from[:]),
+			common.ToHexString(to[:]), common.ToHexString(common.BigIntToNeoBytes(big.NewInt(1000)))},
+	}
+	f, tt, amount, ok = parseTransferNotify(neo)
+	if !ok || f != from || tt != to || amount.Int64() != 1000 {
+		t.Errorf("parseTransferNotify neovm transfer error")
+		return
+	}
+	approve := &event.NotifyEventInfo{
+		ContractAddress: common.Address{3},
+		States: []interface{}{common.ToHexString([]byte("approve")), common.ToHexString(from[:]),
+			common.ToHexString(to[:]), common.ToHexString(common.BigIntToNeoBytes(big.NewInt(1000)))},
+	}
+	if _, _, _, ok = parseTransferNotify(approve); ok {
+		t.Errorf("parseTransferNotify should ignore approve notify")
+		return
+	}
+}
@@ -20,6 +20,7 @@ package leveldbstore
 
 import (
 	"github.com/ethereum/go-ethereum/common/fdlimit"
+	"github.com/ontio/ontology/common/metrics"
 	"github.com/ontio/ontology/core/store/common"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
@@ -33,6 +34,7 @@ import (
 type LevelDBStore struct {
 	db    *leveldb.DB // LevelDB instance
 	batch *leveldb.Batch
+	file  string //path of the db, empty for memory db
 }
 
 // used to compute the size of bloom filter bits array .
@@ -68,10 +70,13 @@ func NewLevelDBStore(file string) (*LevelDBStore, error) {
 		return nil, err
 	}
 
-	return &LevelDBStore{
+	store := &LevelDBStore{
 		db:    db,
 		batch: nil,
-	}, nil
+		file:  file,
+	}
+	metrics.RegisterCollector("leveldb:"+file, store.collectMetrics)
+	return store, nil
 }
 
 func NewMemLevelDBStore() (*LevelDBStore, error) {
@@ -146,6 +151,9 @@ func (self *LevelDBStore) BatchCommit() error {
 
 //Close leveldb
 func (self *LevelDBStore) Close() error {
+	if self.file != "" {
+		metrics.UnregisterCollector("leveldb:" + self.file)
+	}
 	err := self.db.Close()
 	return err
 }
@@ -140,3 +140,23 @@ func TestIterator(t *testing.T) {
 	}
 
 }
+
+func TestParseCompactionStats(t *testing.T) {
+	stats := "Compactions\n" +
+		" Level |   Tables   |    Size(MB)   |    Time(sec)  |    Read(MB)   |   Write(MB)\n" +
+		"-------+------------+---------------+---------------+---------------+---------------\n" +
+		"   0   |          2 |       1.50000 |       0.25000 |       0.00000 |       1.50000\n" +
+		"   1   |          3 |       4.00000 |       0.75000 |       2.00000 |       4.00000\n"
+	sum := parseCompactionStats(stats)
+	if sum.Tables != 5 || sum.Size != 5.5 || sum.Time != 1 || sum.Read != 2 || sum.Written != 5.5 {
+		t.Errorf("TestParseCompactionStats unexpected stats:%+v", sum)
+		return
+	}
+
+	_, err := testLevelDB.db.GetProperty("leveldb.stats")
+	if err != nil {
+		t.Errorf("GetProperty error:%s", err)
+		return
+	}
+	testLevelDB.collectMetrics()
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package leveldbstore
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ontio/ontology/common/metrics"
+)
+
+var (
+	compactionTables  = metrics.NewGaugeVec("ontology_leveldb_tables", "Count of tables in leveldb", "store")
+	compactionSize    = metrics.NewGaugeVec("ontology_leveldb_size_bytes", "Size of tables in leveldb", "store")
+	compactionTime    = metrics.NewGaugeVec("ontology_leveldb_compaction_seconds", "Time spent in leveldb compaction", "store")
+	compactionRead    = metrics.NewGaugeVec("ontology_leveldb_compaction_read_bytes", "Bytes read by leveldb compaction", "store")
+	compactionWritten = metrics.NewGaugeVec("ontology_leveldb_compaction_write_bytes", "Bytes written by leveldb compaction", "store")
+)
+
+//compactionStats is the sum of per level compaction stats of leveldb
+type compactionStats struct {
+	Tables  float64
+	Size    float64 //MB
+	Time    float64 //second
+	Read    float64 //MB
+	Written float64 //MB
+}
+
+//parseCompactionStats parses the table of leveldb.stats property like
+// Level |   Tables   |    Size(MB)   |    Time(sec)  |    Read(MB)   |   Write(MB)
+//-------+------------+---------------+---------------+---------------+---------------
+//   0   |          1 |       0.00012 |       0.00000 |       0.00000 |       0.00000
+func parseCompactionStats(stats string) compactionStats {
+	var sum compactionStats
+	for _, line := range strings.Split(stats, "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) != 6 {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimSpace(fields[0])); err != nil {
+			continue
+		}
+		var vals [5]float64
+		valid := true
+		for i := range vals {
+			v, err := strconv.ParseFloat(strings.TrimSpace(fields[i+1]), 64)
+			if err != nil {
+				valid = false
+				break
+			}
+			vals[i] = v
+		}
+		if !valid {
+			continue
+		}
+		sum.Tables += vals[0]
+		sum.Size += vals[1]
+		sum.Time += vals[2]
+		sum.Read += vals[3]
+		sum.Written += vals[4]
+	}
+	return sum
+}
+
+//collectMetrics updates the compaction metrics of the store
+func (self *LevelDBStore) collectMetrics() {
+	stats, err := self.db.GetProperty("leveldb.stats")
+	if err != nil {
+		return
+	}
+	sum := parseCompactionStats(stats)
+	compactionTables.WithLabel(self.file).Set(sum.Tables)
+	compactionSize.WithLabel(self.file).Set(sum.Size * 1024 * 1024)
+	compactionTime.WithLabel(self.file).Set(sum.Time)
+	compactionRead.WithLabel(self.file).Set(sum.Read * 1024 * 1024)
+	compactionWritten.WithLabel(self.file).Set(sum.Written * 1024 * 1024)
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package memstore
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ontio/ontology/core/store/common"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var ErrClosed = errors.New("memstore: closed")
+
+//MemStore keep all data in memory, data is lost after close.
+//It is used for test and temporary node.
+type MemStore struct {
+	lock  sync.RWMutex // make batch commit atomic to Get and Has
+	db    *memdb.DB
+	batch *leveldb.Batch
+}
+
+//NewMemStore return MemStore instance
+func NewMemStore() *MemStore {
+	return &MemStore{
+		db: memdb.New(comparer.DefaultComparer, 0),
+	}
+}
+
+//Put a key-value pair to store
+func (self *MemStore) Put(key []byte, value []byte) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.db == nil {
+		return ErrClosed
+	}
+	return self.db.Put(key, value)
+}
+
+//Get the value of a key from store
+func (self *MemStore) Get(key []byte) ([]byte, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	if self.db == nil {
+		return nil, ErrClosed
+	}
+	value, err := self.db.Get(key)
+	if err != nil {
+		if err == memdb.ErrNotFound {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return append([]byte{}, value...), nil
+}
+
+//Has return whether the key is exist in store
+func (self *MemStore) Has(key []byte) (bool, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	if self.db == nil {
+		return false, ErrClosed
+	}
+	return self.db.Contains(key), nil
+}
+
+//Delete the the in store
+func (self *MemStore) Delete(key []byte) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.db == nil {
+		return ErrClosed
+	}
+	err := self.db.Delete(key)
+	if err == memdb.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+//NewBatch start commit batch
+func (self *MemStore) NewBatch() {
+	self.batch = new(leveldb.Batch)
+}
+
+//BatchPut put a key-value pair to batch
+func (self *MemStore) BatchPut(key []byte, value []byte) {
+	self.batch.Put(key, value)
+}
+
+//BatchDelete delete a key to batch
+func (self *MemStore) BatchDelete(key []byte) {
+	self.batch.Delete(key)
+}
+
+//BatchCommit commit batch to store
+func (self *MemStore) BatchCommit() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.db == nil {
+		return ErrClosed
+	}
+	self.batch.Replay(batchReplay{self.db})
+	self.batch = nil
+	return nil
+}
+
+//Close store and release all data
+func (self *MemStore) Close() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.db = nil
+	return nil
+}
+
+//NewIterator return a iterator of store with the key prefix
+func (self *MemStore) NewIterator(prefix []byte) common.StoreIterator {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	if self.db == nil {
+		return iterator.NewEmptyIterator(ErrClosed)
+	}
+	return self.db.NewIterator(util.BytesPrefix(prefix))
+}
+
+type batchReplay struct {
+	db *memdb.DB
+}
+
+func (self batchReplay) Put(key, value []byte) {
+	self.db.Put(key, value)
+}
+
+func (self batchReplay) Delete(key []byte) {
+	self.db.Delete(key)
+}
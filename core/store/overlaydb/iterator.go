@@ -18,6 +18,8 @@
 package overlaydb
 
 import (
+	"bytes"
+
 	"github.com/ontio/ontology/core/store/common"
 	"github.com/syndtr/goleveldb/leveldb/comparer"
 )
@@ -30,6 +32,15 @@ const (
 	FromBoth           = iota
 )
 
+type iterDir byte
+
+const (
+	dirSOI iterDir = iota // start of iteration, also the state of a new iterator
+	dirForward
+	dirBackward
+	dirEOI // end of iteration
+)
+
 type JoinIter struct {
 	backend     common.StoreIterator
 	memdb       common.StoreIterator
@@ -37,6 +48,7 @@ type JoinIter struct {
 	keyOrigin   KeyOrigin
 	nextMemEnd  bool
 	nextBackEnd bool
+	dir         iterDir
 	cmp         comparer.BasicComparer
 }
 
@@ -48,63 +60,52 @@ func NewJoinIter(memIter, backendIter common.StoreIterator) *JoinIter {
 	}
 }
 
-func (iter *JoinIter) First() bool {
-	f := iter.first()
-	if f == false {
-		return false
-	}
+// the value of key deleted in memdb is empty, skip it
+func (iter *JoinIter) skipDeleted(move func() bool) bool {
 	for len(iter.value) == 0 {
-		if iter.next() == false {
+		if move() == false {
 			return false
 		}
 	}
-
 	return true
 }
 
+func (iter *JoinIter) First() bool {
+	if iter.first() == false {
+		return false
+	}
+	return iter.skipDeleted(iter.next)
+}
+
 func (iter *JoinIter) first() bool {
-	var bkey, bval, mkey, mval []byte
-	back := iter.backend.First()
-	mem := iter.memdb.First()
-	// check error
-	if iter.Error() != nil {
+	iter.nextMemEnd = !iter.memdb.First()
+	iter.nextBackEnd = !iter.backend.First()
+	iter.dir = dirForward
+	return iter.pick()
+}
+
+func (iter *JoinIter) Last() bool {
+	if iter.last() == false {
 		return false
 	}
-	if back {
-		bkey = iter.backend.Key()
-		bval = iter.backend.Value()
-		if mem == false {
-			iter.key = bkey
-			iter.value = bval
-			iter.keyOrigin = FromBack
-			return true
-		}
-		mkey = iter.memdb.Key()
-		mval = iter.memdb.Value()
-		cmp := iter.cmp.Compare(mkey, bkey)
-		if cmp < 1 {
-			iter.key = mkey
-			iter.value = mval
-			if cmp == 0 {
-				iter.keyOrigin = FromBoth
-			} else {
-				iter.keyOrigin = FromMem
-			}
-		} else {
-			iter.key = bkey
-			iter.value = bval
-			iter.keyOrigin = FromBack
-		}
-		return true
-	} else {
-		if mem {
-			iter.key = iter.memdb.Key()
-			iter.value = iter.memdb.Value()
-			iter.keyOrigin = FromMem
-			return true
-		}
+	return iter.skipDeleted(iter.prev)
+}
+
+func (iter *JoinIter) last() bool {
+	iter.nextMemEnd = !iter.memdb.Last()
+	iter.nextBackEnd = !iter.backend.Last()
+	iter.dir = dirBackward
+	return iter.pick()
+}
+
+func (iter *JoinIter) Seek(key []byte) bool {
+	iter.nextMemEnd = !iter.memdb.Seek(key)
+	iter.nextBackEnd = !iter.backend.Seek(key)
+	iter.dir = dirForward
+	if iter.pick() == false {
 		return false
 	}
+	return iter.skipDeleted(iter.next)
 }
 
 func (iter *JoinIter) Key() []byte {
@@ -116,28 +117,68 @@ func (iter *JoinIter) Value() []byte {
 }
 
 func (iter *JoinIter) Next() bool {
-	f := iter.next()
-	if f == false {
+	if iter.next() == false {
 		return false
 	}
+	return iter.skipDeleted(iter.next)
+}
 
-	for len(iter.value) == 0 {
-		if iter.next() == false {
-			return false
+func (iter *JoinIter) next() bool {
+	switch iter.dir {
+	case dirEOI:
+		return false
+	case dirSOI:
+		return iter.first()
+	case dirBackward:
+		// move both iterators to the first key greater than current key
+		key := append([]byte{}, iter.key...)
+		iter.nextMemEnd = !seekGT(iter.memdb, key)
+		iter.nextBackEnd = !seekGT(iter.backend, key)
+	default:
+		if (iter.keyOrigin == FromMem || iter.keyOrigin == FromBoth) && iter.nextMemEnd == false {
+			iter.nextMemEnd = !iter.memdb.Next()
+		}
+		if (iter.keyOrigin == FromBack || iter.keyOrigin == FromBoth) && iter.nextBackEnd == false {
+			iter.nextBackEnd = !iter.backend.Next()
 		}
 	}
-
-	return true
+	iter.dir = dirForward
+	return iter.pick()
 }
 
-func (iter *JoinIter) next() bool {
-	if (iter.keyOrigin == FromMem || iter.keyOrigin == FromBoth) && iter.nextMemEnd == false {
-		iter.nextMemEnd = !iter.memdb.Next()
+func (iter *JoinIter) Prev() bool {
+	if iter.prev() == false {
+		return false
 	}
-	if (iter.keyOrigin == FromBack || iter.keyOrigin == FromBoth) && iter.nextBackEnd == false {
-		iter.nextBackEnd = !iter.backend.Next()
+	return iter.skipDeleted(iter.prev)
+}
+
+func (iter *JoinIter) prev() bool {
+	switch iter.dir {
+	case dirSOI:
+		return false
+	case dirEOI:
+		return iter.last()
+	case dirForward:
+		// move both iterators to the last key less than current key
+		key := append([]byte{}, iter.key...)
+		iter.nextMemEnd = !seekLT(iter.memdb, key)
+		iter.nextBackEnd = !seekLT(iter.backend, key)
+	default:
+		if (iter.keyOrigin == FromMem || iter.keyOrigin == FromBoth) && iter.nextMemEnd == false {
+			iter.nextMemEnd = !iter.memdb.Prev()
+		}
+		if (iter.keyOrigin == FromBack || iter.keyOrigin == FromBoth) && iter.nextBackEnd == false {
+			iter.nextBackEnd = !iter.backend.Prev()
+		}
 	}
+	iter.dir = dirBackward
+	return iter.pick()
+}
 
+// pick the current item from memdb and backend according to the iterate direction,
+// the item of memdb override the one of backend when they have same key
+func (iter *JoinIter) pick() bool {
 	// check error
 	if iter.Error() != nil {
 		return false
@@ -147,6 +188,11 @@ func (iter *JoinIter) next() bool {
 		if iter.nextMemEnd {
 			iter.key = nil
 			iter.value = nil
+			if iter.dir == dirForward {
+				iter.dir = dirEOI
+			} else {
+				iter.dir = dirSOI
+			}
 			return false
 		} else {
 			iter.key = iter.memdb.Key()
@@ -162,21 +208,22 @@ func (iter *JoinIter) next() bool {
 			bkey := iter.backend.Key()
 			mkey := iter.memdb.Key()
 			cmp := iter.cmp.Compare(mkey, bkey)
-			switch cmp {
-			case -1:
+			if iter.dir == dirBackward {
+				cmp = -cmp
+			}
+			switch {
+			case cmp < 0:
 				iter.key = mkey
 				iter.value = iter.memdb.Value()
 				iter.keyOrigin = FromMem
-			case 0:
+			case cmp == 0:
 				iter.key = mkey
 				iter.value = iter.memdb.Value()
 				iter.keyOrigin = FromBoth
-			case 1:
+			default:
 				iter.key = bkey
 				iter.value = iter.backend.Value()
 				iter.keyOrigin = FromBack
-			default:
-				panic("unreachable")
 			}
 		}
 	}
@@ -184,6 +231,23 @@ func (iter *JoinIter) next() bool {
 	return true
 }
 
+func seekGT(iter common.StoreIterator, key []byte) bool {
+	if iter.Seek(key) == false {
+		return false
+	}
+	if bytes.Equal(iter.Key(), key) {
+		return iter.Next()
+	}
+	return true
+}
+
+func seekLT(iter common.StoreIterator, key []byte) bool {
+	if iter.Seek(key) {
+		return iter.Prev()
+	}
+	return iter.Last()
+}
+
 func (iter *JoinIter) Release() {
 	iter.memdb.Release()
 	iter.backend.Release()
@@ -68,6 +68,55 @@ func TestNewOverlayDB(t *testing.T) {
 	}
 }
 
+func TestJoinIterBidirection(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	assert.Nil(t, err)
+
+	N := 100
+	for i := 0; i < N; i += 2 {
+		assert.Nil(t, store.Put(makeKey(i), []byte("back"+strconv.Itoa(i))))
+	}
+	overlay := NewOverlayDB(store)
+	for i := 0; i < N; i += 3 {
+		overlay.Put(makeKey(i), []byte("mem"+strconv.Itoa(i)))
+	}
+	for i := 0; i < N; i += 5 {
+		overlay.Delete(makeKey(i))
+	}
+	var keys [][]byte
+	for i := 0; i < N; i++ {
+		if i%5 != 0 && (i%2 == 0 || i%3 == 0) {
+			keys = append(keys, makeKey(i))
+		}
+	}
+
+	iter := overlay.NewIterator([]byte("key"))
+	defer iter.Release()
+	for i, has := len(keys)-1, iter.Last(); i >= 0; i, has = i-1, iter.Prev() {
+		assert.True(t, has)
+		assert.Equal(t, keys[i], iter.Key())
+	}
+	assert.False(t, iter.Prev())
+	assert.True(t, iter.Next())
+	assert.Equal(t, keys[0], iter.Key())
+
+	// switch direction in the middle
+	assert.True(t, iter.Seek(makeKey(50)))
+	assert.Equal(t, makeKey(51), iter.Key())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, makeKey(48), iter.Key())
+	assert.Equal(t, []byte("mem48"), iter.Value())
+	assert.True(t, iter.Next())
+	assert.Equal(t, makeKey(51), iter.Key())
+	assert.True(t, iter.Next())
+	assert.Equal(t, makeKey(52), iter.Key())
+	assert.Equal(t, []byte("back52"), iter.Value())
+
+	assert.False(t, iter.Seek(makeKey(N)))
+	assert.True(t, iter.Prev())
+	assert.Equal(t, keys[len(keys)-1], iter.Key())
+}
+
 func BenchmarkOverlayDBSerialPut(b *testing.B) {
 	store, _ := leveldbstore.NewMemLevelDBStore()
 
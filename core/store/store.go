@@ -19,6 +19,9 @@
 package store
 
 import (
+	"io"
+	"math/big"
+
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/payload"
@@ -29,6 +32,43 @@ import (
 	cstates "github.com/ontio/ontology/smartcontract/states"
 )
 
+//AddressTx is a transaction related to an address
+type AddressTx struct {
+	TxHash common.Uint256
+	Height uint32
+}
+
+//StateSnapshotCheckpoint identifies a state snapshot. StateRoot is the merkle root of the state entries
+//in the snapshot, the checkpoint should be got from a trusted node to import the snapshot.
+type StateSnapshotCheckpoint struct {
+	Height    uint32
+	BlockHash common.Uint256
+	StateRoot common.Uint256
+}
+
+//Transfer is a token transfer recognised from the ["transfer", from, to, amount] notification of contract
+type Transfer struct {
+	TxHash   common.Uint256
+	Height   uint32
+	Contract common.Address
+	From     common.Address
+	To       common.Address
+	Amount   *big.Int
+}
+
+//Receipt is the execution result of transaction, which is recorded even if event log is disabled
+type Receipt struct {
+	TxHash      common.Uint256
+	BlockHash   common.Uint256
+	Height      uint32
+	TxIndex     uint32
+	State       byte
+	GasConsumed uint64 // the ONG paid for the gas
+	GasUsed     uint64 // the gas used in units, 0 if the gas price is 0
+	Payer       common.Address
+	Notify      []*event.NotifyEventInfo
+}
+
 type ExecuteResult struct {
 	WriteSet   *overlaydb.MemDB
 	Hash       common.Uint256
@@ -41,9 +81,13 @@ type LedgerStore interface {
 	InitLedgerStoreWithGenesisBlock(genesisblock *types.Block, defaultBookkeeper []keypair.PublicKey) error
 	Close() error
 	AddHeaders(headers []*types.Header) error
+	VerifyHeader(header *types.Header) error
 	AddBlock(block *types.Block, stateMerkleRoot common.Uint256) error
 	ExecuteBlock(b *types.Block) (ExecuteResult, error)   // called by consensus
 	SubmitBlock(b *types.Block, exec ExecuteResult) error // called by consensus
+	RollbackTo(height uint32) error
+	ExportStateSnapshot(w io.Writer) (*StateSnapshotCheckpoint, error)
+	ImportStateSnapshot(genesisHash common.Uint256, checkpoint *StateSnapshotCheckpoint, r io.Reader) error
 	GetStateMerkleRoot(height uint32) (result common.Uint256, err error)
 	GetCurrentBlockHash() common.Uint256
 	GetCurrentBlockHeight() uint32
@@ -63,7 +107,11 @@ type LedgerStore interface {
 	GetContractState(contractHash common.Address) (*payload.DeployCode, error)
 	GetBookkeeperState() (*states.BookkeeperState, error)
 	GetStorageItem(key *states.StorageKey) (*states.StorageItem, error)
+	GetStorageItemAtHeight(key *states.StorageKey, height uint32) (*states.StorageItem, error)
 	PreExecuteContract(tx *types.Transaction) (*cstates.PreExecResult, error)
 	GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error)
 	GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error)
+	GetTransactionsByAddress(address common.Address, height uint32, limit uint32) ([]*AddressTx, error)
+	GetTransfersByAddress(contract common.Address, address common.Address, startHeight uint32, endHeight uint32, limit uint32) ([]*Transfer, error)
+	GetTransactionReceipt(txHash common.Uint256) (*Receipt, error)
 }
@@ -36,8 +36,8 @@ type MutableTransaction struct {
 	GasLimit uint64
 	Payer    common.Address
 	Payload  Payload
-	//Attributes []*TxAttribute
-	attributes byte //this must be 0 now, Attribute Array length use VarUint encoding, so byte is enough for extension
+	//only ValidUntilHeight is supported now, Attribute Array length use VarUint encoding
+	Attributes []*TxAttribute
 	Sigs       []Sig
 }
 
@@ -123,7 +123,15 @@ func (tx *MutableTransaction) serializeUnsigned(sink *common.ZeroCopySink) error
 	default:
 		return errors.New("wrong transaction payload type")
 	}
-	sink.WriteVarUint(uint64(tx.attributes))
+	if err := CheckTxAttributes(tx.Attributes); err != nil {
+		return err
+	}
+	sink.WriteVarUint(uint64(len(tx.Attributes)))
+	for _, attr := range tx.Attributes {
+		if err := attr.Serialization(sink); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -174,10 +182,17 @@ func (tx *MutableTransaction) DeserializeUnsigned(r io.Reader) error {
 	if err != nil {
 		return err
 	}
-	if length != 0 {
-		return fmt.Errorf("transaction attribute must be 0, got %d", length)
+	if length > MAX_TX_ATTRIBUTES {
+		return fmt.Errorf("transaction attribute number %d execced %d", length, MAX_TX_ATTRIBUTES)
+	}
+	tx.Attributes = nil
+	for i := 0; i < int(length); i++ {
+		attr := new(TxAttribute)
+		if err := attr.Deserialize(r); err != nil {
+			return err
+		}
+		tx.Attributes = append(tx.Attributes, attr)
 	}
-	tx.attributes = 0
 
-	return nil
+	return CheckTxAttributes(tx.Attributes)
 }
@@ -21,12 +21,14 @@ package types
 import (
 	"bytes"
 	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/constants"
 	"github.com/ontio/ontology/common/serialization"
 	"github.com/ontio/ontology/core/payload"
@@ -35,6 +37,11 @@ import (
 
 const MAX_TX_SIZE = 1024 * 1024 // The max size of a transaction to prevent DOS attacks
 
+var (
+	ErrTxAttributeInactive = errors.New("transaction attributes are not accepted at the height")
+	ErrTxExpired           = errors.New("transaction is expired at the height")
+)
+
 type Transaction struct {
 	Version  byte
 	TxType   TransactionType
@@ -43,8 +50,8 @@ type Transaction struct {
 	GasLimit uint64
 	Payer    common.Address
 	Payload  Payload
-	//Attributes []*TxAttribute
-	attributes byte //this must be 0 now, Attribute Array length use VarUint encoding, so byte is enough for extension
+	//only ValidUntilHeight is supported now, Attribute Array length use VarUint encoding
+	Attributes []*TxAttribute
 	Sigs       []RawSig
 
 	Raw []byte // raw transaction data
@@ -129,6 +136,7 @@ func (tx *Transaction) IntoMutable() (*MutableTransaction, error) {
 		Payer:    tx.Payer,
 		Payload:  tx.Payload,
 	}
+	mutable.Attributes = append(mutable.Attributes, tx.Attributes...)
 
 	for _, raw := range tx.Sigs {
 		sig, err := raw.GetSig()
@@ -185,11 +193,44 @@ func (tx *Transaction) deserializationUnsigned(source *common.ZeroCopySource) er
 		return io.ErrUnexpectedEOF
 	}
 
-	if length != 0 {
-		return fmt.Errorf("transaction attribute must be 0, got %d", length)
+	if length > MAX_TX_ATTRIBUTES {
+		return fmt.Errorf("transaction attribute number %d execced %d", length, MAX_TX_ATTRIBUTES)
+	}
+	tx.Attributes = nil
+	for i := 0; i < int(length); i++ {
+		attr := new(TxAttribute)
+		err := attr.Deserialization(source)
+		if err != nil {
+			return err
+		}
+		tx.Attributes = append(tx.Attributes, attr)
 	}
-	tx.attributes = 0
 
+	return CheckTxAttributes(tx.Attributes)
+}
+
+// GetValidUntilHeight returns the max height of the block which can include
+// the transaction, and false if the transaction never expires
+func (tx *Transaction) GetValidUntilHeight() (uint32, bool) {
+	for _, attr := range tx.Attributes {
+		if attr.Usage == ValidUntilHeight && len(attr.Data) == 4 {
+			return binary.LittleEndian.Uint32(attr.Data), true
+		}
+	}
+	return 0, false
+}
+
+// CheckHeight checks whether the transaction can be included in the block at
+// the height. Attributes are only accepted from the configured height, and the
+// transaction is expired in the blocks higher than its ValidUntilHeight.
+func (tx *Transaction) CheckHeight(height uint32) error {
+	if len(tx.Attributes) != 0 &&
+		height < config.GetTxAttributeCheckHeight(config.DefConfig.P2PNode.NetworkId) {
+		return ErrTxAttributeInactive
+	}
+	if validUntil, ok := tx.GetValidUntilHeight(); ok && validUntil < height {
+		return ErrTxExpired
+	}
 	return nil
 }
 
@@ -20,27 +20,57 @@ package types
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 
+	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/serialization"
 )
 
 type TransactionAttributeUsage byte
 
 const (
-	Nonce          TransactionAttributeUsage = 0x00
-	Script         TransactionAttributeUsage = 0x20
-	DescriptionUrl TransactionAttributeUsage = 0x81
-	Description    TransactionAttributeUsage = 0x90
+	Nonce            TransactionAttributeUsage = 0x00
+	Script           TransactionAttributeUsage = 0x20
+	ValidUntilHeight TransactionAttributeUsage = 0x40 // the transaction can only be included in blocks up to the height
+	DescriptionUrl   TransactionAttributeUsage = 0x81
+	Description      TransactionAttributeUsage = 0x90
 )
 
+const MAX_TX_ATTRIBUTES = 1 // The max number of attributes of a transaction
+
 func IsValidAttributeType(usage TransactionAttributeUsage) bool {
-	return usage == Nonce || usage == Script ||
+	return usage == Nonce || usage == Script || usage == ValidUntilHeight ||
 		usage == DescriptionUrl || usage == Description
 }
 
+// CheckTxAttributes checks the attributes of a transaction, only one
+// ValidUntilHeight attribute with a uint32 height is supported now
+func CheckTxAttributes(attrs []*TxAttribute) error {
+	if len(attrs) > MAX_TX_ATTRIBUTES {
+		return fmt.Errorf("transaction attribute number %d execced %d", len(attrs), MAX_TX_ATTRIBUTES)
+	}
+	for _, attr := range attrs {
+		if attr.Usage != ValidUntilHeight {
+			return fmt.Errorf("unsupported transaction attribute usage %d", attr.Usage)
+		}
+		if len(attr.Data) != 4 {
+			return fmt.Errorf("invalid valid until height attribute length %d", len(attr.Data))
+		}
+	}
+	return nil
+}
+
+// NewValidUntilHeightAttribute creates an attribute to make the transaction
+// invalid in the blocks higher than the height
+func NewValidUntilHeightAttribute(height uint32) *TxAttribute {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, height)
+	return &TxAttribute{Usage: ValidUntilHeight, Data: data}
+}
+
 type TxAttribute struct {
 	Usage TransactionAttributeUsage
 	Data  []byte
@@ -73,6 +103,35 @@ func (tx *TxAttribute) Serialize(w io.Writer) error {
 	return nil
 }
 
+func (tx *TxAttribute) Serialization(sink *common.ZeroCopySink) error {
+	if !IsValidAttributeType(tx.Usage) {
+		return errors.New("Unsupported attribute Description.")
+	}
+	sink.WriteByte(byte(tx.Usage))
+	sink.WriteVarBytes(tx.Data)
+	return nil
+}
+
+func (tx *TxAttribute) Deserialization(source *common.ZeroCopySource) error {
+	usage, eof := source.NextByte()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	tx.Usage = TransactionAttributeUsage(usage)
+	if !IsValidAttributeType(tx.Usage) {
+		return errors.New("[TxAttribute] Unsupported attribute Description.")
+	}
+	data, _, irregular, eof := source.NextVarBytes()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	tx.Data = data
+	return nil
+}
+
 func (tx *TxAttribute) Deserialize(r io.Reader) error {
 	val, err := serialization.ReadBytes(r, 1)
 	if err != nil {
@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionValidUntilHeight(t *testing.T) {
+	mutable := &MutableTransaction{
+		TxType:  Invoke,
+		Nonce:   1,
+		Payload: &payload.InvokeCode{Code: []byte("ont")},
+	}
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	_, ok := tx.GetValidUntilHeight()
+	assert.False(t, ok)
+
+	mutable.Attributes = []*TxAttribute{NewValidUntilHeightAttribute(100)}
+	expiring, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	assert.NotEqual(t, tx.Hash(), expiring.Hash())
+	height, ok := expiring.GetValidUntilHeight()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(100), height)
+
+	tx, err = TransactionFromRawBytes(expiring.ToArray())
+	assert.Nil(t, err)
+	height, ok = tx.GetValidUntilHeight()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(100), height)
+
+	mutable2 := &MutableTransaction{}
+	err = mutable2.DeserializeUnsigned(bytes.NewReader(expiring.ToArray()))
+	assert.Nil(t, err)
+	assert.Equal(t, expiring.Hash(), mutable2.Hash())
+
+	mutable.Attributes = []*TxAttribute{{Usage: Description, Data: []byte("ont")}}
+	_, err = mutable.IntoImmutable()
+	assert.NotNil(t, err)
+	mutable.Attributes = []*TxAttribute{NewValidUntilHeightAttribute(100), NewValidUntilHeightAttribute(200)}
+	_, err = mutable.IntoImmutable()
+	assert.NotNil(t, err)
+}
+
+func TestTransactionCheckHeight(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	mutable := &MutableTransaction{
+		TxType:     Invoke,
+		Nonce:      1,
+		Payload:    &payload.InvokeCode{Code: []byte("ont")},
+		Attributes: []*TxAttribute{NewValidUntilHeightAttribute(100)},
+	}
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	assert.Equal(t, ErrTxAttributeInactive, tx.CheckHeight(100))
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	assert.Nil(t, tx.CheckHeight(100))
+	assert.Equal(t, ErrTxExpired, tx.CheckHeight(101))
+}
@@ -44,9 +44,27 @@ func VerifyTransaction(tx *types.Transaction) ontErrors.ErrCode {
 		return ontErrors.ErrTransactionPayload
 	}
 
+	if err := types.CheckTxAttributes(tx.Attributes); err != nil {
+		log.Warn("[VerifyTransaction],", err)
+		return ontErrors.ErrTxAttribute
+	}
+
 	return ontErrors.ErrNoError
 }
 
+// VerifyTransactionHeight checks whether the transaction can be included in
+// the block at the height
+func VerifyTransactionHeight(tx *types.Transaction, height uint32) ontErrors.ErrCode {
+	switch tx.CheckHeight(height) {
+	case nil:
+		return ontErrors.ErrNoError
+	case types.ErrTxExpired:
+		return ontErrors.ErrTxExpired
+	default:
+		return ontErrors.ErrTxAttribute
+	}
+}
+
 func VerifyTransactionWithLedger(tx *types.Transaction, ledger *ledger.Ledger) ontErrors.ErrCode {
 	//TODO: replay check
 	return ontErrors.ErrNoError
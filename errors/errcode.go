@@ -52,6 +52,13 @@ const (
 	ErrNetVerifyFail        ErrCode = 45019
 	ErrGasPrice             ErrCode = 45020
 	ErrVerifySignature      ErrCode = 45021
+	ErrReplaceUnderpriced   ErrCode = 45022
+	ErrPayerQuota           ErrCode = 45023
+	ErrPeerQuota            ErrCode = 45024
+	ErrTxExpired            ErrCode = 45025
+	ErrTxAttribute          ErrCode = 45026
+	ErrInsufficientGas      ErrCode = 45027
+	ErrNonceConflict        ErrCode = 45028
 )
 
 func (err ErrCode) Error() string {
@@ -100,6 +107,20 @@ func (err ErrCode) Error() string {
 		return "invalid gas price"
 	case ErrVerifySignature:
 		return "transaction verify signature fail"
+	case ErrReplaceUnderpriced:
+		return "replacement transaction underpriced"
+	case ErrPayerQuota:
+		return "payer exceeds the tx pool quota"
+	case ErrPeerQuota:
+		return "peer exceeds the tx pool quota"
+	case ErrTxExpired:
+		return "transaction expired"
+	case ErrTxAttribute:
+		return "invalid transaction attribute"
+	case ErrInsufficientGas:
+		return "insufficient balance to cover gas cost"
+	case ErrNonceConflict:
+		return "transaction nonce conflicts with a packed transaction"
 
 	}
 
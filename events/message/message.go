@@ -29,6 +29,7 @@ const (
 	TOPIC_NODE_DISCONNECT           = "noddis"
 	TOPIC_NODE_CONSENSUS_DISCONNECT = "nodcnsdis"
 	TOPIC_SMART_CODE_EVENT          = "scevt"
+	TOPIC_NEW_TRANSACTION           = "newtx"
 )
 
 type SaveBlockCompleteMsg struct {
@@ -43,6 +44,10 @@ type SmartCodeEventMsg struct {
 	Event *types.SmartCodeEvent
 }
 
+type NewTransactionMsg struct {
+	Tx *types.Transaction
+}
+
 type BlockConsensusComplete struct {
 	Block *types.Block
 }
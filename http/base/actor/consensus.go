@@ -19,7 +19,11 @@
 package actor
 
 import (
+	"errors"
+	"time"
+
 	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/common/log"
 	cactor "github.com/ontio/ontology/consensus/actor"
 )
 
@@ -44,3 +48,24 @@ func ConsensusSrvHalt() error {
 	}
 	return nil
 }
+
+//get consensus status from consensus actor
+func GetConsensusStatus() (interface{}, error) {
+	if consensusSrvPid == nil {
+		return nil, errors.New("consensus service not started")
+	}
+	future := consensusSrvPid.RequestFuture(&cactor.GetConsensusStatusReq{}, REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return nil, err
+	}
+	rsp, ok := result.(*cactor.GetConsensusStatusRsp)
+	if !ok {
+		return nil, errors.New("fail")
+	}
+	if rsp.Status == nil {
+		return nil, errors.New("consensus status not supported")
+	}
+	return rsp.Status, nil
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/store"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/smartcontract/event"
 	cstate "github.com/ontio/ontology/smartcontract/states"
@@ -72,6 +73,11 @@ func GetStorageItem(address common.Address, key []byte) ([]byte, error) {
 	return ledger.DefLedger.GetStorageItem(address, key)
 }
 
+//GetStorageItemAtHeight from ledger
+func GetStorageItemAtHeight(address common.Address, key []byte, height uint32) ([]byte, error) {
+	return ledger.DefLedger.GetStorageItemAtHeight(address, key, height)
+}
+
 //GetContractStateFromStore from ledger
 func GetContractStateFromStore(hash common.Address) (*payload.DeployCode, error) {
 	hash = updateNativeSCAddr(hash)
@@ -99,6 +105,21 @@ func GetEventNotifyByHeight(height uint32) ([]*event.ExecuteNotify, error) {
 	return ledger.DefLedger.GetEventNotifyByBlock(height)
 }
 
+//GetTransactionsByAddress from ledger
+func GetTransactionsByAddress(address common.Address, height uint32, limit uint32) ([]*store.AddressTx, error) {
+	return ledger.DefLedger.GetTransactionsByAddress(address, height, limit)
+}
+
+//GetTransfersByAddress from ledger
+func GetTransfersByAddress(contract common.Address, address common.Address, startHeight uint32, endHeight uint32, limit uint32) ([]*store.Transfer, error) {
+	return ledger.DefLedger.GetTransfersByAddress(contract, address, startHeight, endHeight, limit)
+}
+
+//GetTransactionReceipt from ledger
+func GetTransactionReceipt(txHash common.Uint256) (*store.Receipt, error) {
+	return ledger.DefLedger.GetTransactionReceipt(txHash)
+}
+
 //GetMerkleProof from ledger
 func GetMerkleProof(proofHeight uint32, rootHeight uint32) ([]common.Uint256, error) {
 	return ledger.DefLedger.GetMerkleProof(proofHeight, rootHeight)
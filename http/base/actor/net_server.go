@@ -26,6 +26,7 @@ import (
 	"github.com/ontio/ontology/common/log"
 	ac "github.com/ontio/ontology/p2pserver/actor/server"
 	"github.com/ontio/ontology/p2pserver/common"
+	"github.com/ontio/ontology/p2pserver/peer"
 )
 
 var netServerPid *actor.PID
@@ -204,3 +205,56 @@ func GetNodeType() (uint64, error) {
 	}
 	return r.NodeType, nil
 }
+
+//GetBanList from netSever actor
+func GetBanList() ([]*peer.BanEntry, error) {
+	if netServerPid == nil {
+		return []*peer.BanEntry{}, nil
+	}
+	future := netServerPid.RequestFuture(&ac.GetBanListReq{}, REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return nil, err
+	}
+	r, ok := result.(*ac.GetBanListRsp)
+	if !ok {
+		return nil, errors.New("fail")
+	}
+	return r.Bans, nil
+}
+
+//AddBan to netSever actor
+func AddBan(ip string, id uint64, duration time.Duration) error {
+	if netServerPid == nil {
+		return nil
+	}
+	future := netServerPid.RequestFuture(&ac.AddBanReq{IP: ip, ID: id, Duration: duration}, REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return err
+	}
+	if _, ok := result.(*ac.AddBanRsp); !ok {
+		return errors.New("fail")
+	}
+	return nil
+}
+
+//RemoveBan to netSever actor
+func RemoveBan(ip string, id uint64) (bool, error) {
+	if netServerPid == nil {
+		return false, nil
+	}
+	future := netServerPid.RequestFuture(&ac.RemoveBanReq{IP: ip, ID: id}, REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return false, err
+	}
+	r, ok := result.(*ac.RemoveBanRsp)
+	if !ok {
+		return false, errors.New("fail")
+	}
+	return r.Removed, nil
+}
@@ -45,7 +45,7 @@ func SetTxnPoolPid(actr *actor.PID) {
 //append transaction to pool to txpool actor
 func AppendTxToPool(txn *types.Transaction) (ontErrors.ErrCode, string) {
 	if DisableSyncVerifyTx {
-		txReq := &tcomn.TxReq{txn, tcomn.HttpSender, nil}
+		txReq := &tcomn.TxReq{Tx: txn, Sender: tcomn.HttpSender}
 		txnPid.Tell(txReq)
 		return ontErrors.ErrNoError, ""
 	}
@@ -55,7 +55,7 @@ func AppendTxToPool(txn *types.Transaction) (ontErrors.ErrCode, string) {
 		return ontErrors.ErrUnknown, err.Error()
 	}
 	ch := make(chan *tcomn.TxResult, 1)
-	txReq := &tcomn.TxReq{txn, tcomn.HttpSender, ch}
+	txReq := &tcomn.TxReq{Tx: txn, Sender: tcomn.HttpSender, TxResultCh: ch}
 	txnPid.Tell(txReq)
 	if msg, ok := <-ch; ok {
 		return msg.Err, msg.Desc
@@ -110,10 +110,25 @@ func GetTxFromPool(hash common.Uint256) (tcomn.TXEntry, error) {
 	if !ok {
 		return tcomn.TXEntry{}, errors.New("fail")
 	}
-	txnEntry := tcomn.TXEntry{rsp.Txn, txStatus.TxStatus}
+	txnEntry := tcomn.TXEntry{Tx: rsp.Txn, Attrs: txStatus.TxStatus, Replaces: txStatus.Replaces}
 	return txnEntry, nil
 }
 
+//GetTxnStatus from txpool actor, the status of replaced transaction is kept after it is removed from pool
+func GetTxnStatus(hash common.Uint256) (*tcomn.GetTxnStatusRsp, error) {
+	future := txnPid.RequestFuture(&tcomn.GetTxnStatusReq{Hash: hash}, REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return nil, err
+	}
+	txStatus, ok := result.(*tcomn.GetTxnStatusRsp)
+	if !ok {
+		return nil, errors.New("fail")
+	}
+	return txStatus, nil
+}
+
 //GetTxnCount from txpool actor
 func GetTxnCount() ([]uint32, error) {
 	future := txnPid.RequestFuture(&tcomn.GetTxnCountReq{}, REQ_TIMEOUT*time.Second)
@@ -30,6 +30,8 @@ import (
 	"github.com/ontio/ontology/common/serialization"
 	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/store"
+	scom "github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/ontology/core/types"
 	cutils "github.com/ontio/ontology/core/utils"
 	ontErrors "github.com/ontio/ontology/errors"
@@ -39,18 +41,54 @@ import (
 	"github.com/ontio/ontology/smartcontract/service/native/utils"
 	cstate "github.com/ontio/ontology/smartcontract/states"
 	"github.com/ontio/ontology/vm/neovm"
+	"math/big"
 	"strings"
 	"time"
 )
 
 const MAX_SEARCH_HEIGHT uint32 = 100
 const MAX_REQUEST_BODY_SIZE = 1 << 20
+const MAX_BATCH_REQUEST_SIZE = 100
+
+//default and max number of transactions returned by gettransactionsbyaddress
+const DEFAULT_ADDRESS_TX_LIMIT uint32 = 20
+const MAX_ADDRESS_TX_LIMIT uint32 = 100
+
+//default and max number of balance changes returned by getbalancechanges
+const DEFAULT_BALANCE_CHANGE_LIMIT uint32 = 20
+const MAX_BALANCE_CHANGE_LIMIT uint32 = 100
 
 type BalanceOfRsp struct {
 	Ont string `json:"ont"`
 	Ong string `json:"ong"`
 }
 
+type AddressTxInfo struct {
+	TxHash string
+	Height uint32
+}
+
+type BalanceChangeInfo struct {
+	TxHash string
+	Height uint32
+	From   string
+	To     string
+	Amount string
+	Change string
+}
+
+type ReceiptInfo struct {
+	TxHash      string
+	BlockHash   string
+	Height      uint32
+	TxIndex     uint32
+	State       byte
+	GasConsumed uint64
+	GasUsed     uint64
+	Payer       string
+	Notify      []NotifyEventInfo
+}
+
 type MerkleProof struct {
 	Type             string
 	TransactionsRoot string
@@ -167,7 +205,9 @@ type TXNAttrInfo struct {
 }
 
 type TXNEntryInfo struct {
-	State []TXNAttrInfo // the result from each validator
+	State      []TXNAttrInfo // the result from each validator
+	Replaces   string        `json:",omitempty"` // the transaction replaced by this one
+	ReplacedBy string        `json:",omitempty"` // the transaction replacing this one
 }
 
 func GetLogEvent(obj *event.LogEventArgs) (map[string]bool, LogEventArgs) {
@@ -205,7 +245,10 @@ func TransArryByteToHexString(ptx *types.Transaction) *Transactions {
 	trans.Payer = ptx.Payer.ToBase58()
 	trans.Payload = TransPayloadToHex(ptx.Payload)
 
-	trans.Attributes = make([]TxAttributeInfo, 0)
+	trans.Attributes = make([]TxAttributeInfo, 0, len(ptx.Attributes))
+	for _, attr := range ptx.Attributes {
+		trans.Attributes = append(trans.Attributes, TxAttributeInfo{Usage: attr.Usage, Data: common.ToHexString(attr.Data)})
+	}
 	trans.Sigs = []Sig{}
 	for _, sigdata := range ptx.Sigs {
 		sig, _ := sigdata.GetSig()
@@ -291,6 +334,104 @@ func GetBalance(address common.Address) (*BalanceOfRsp, error) {
 	}, nil
 }
 
+//GetTransactionsByAddress return the transactions related to address at or below the block height
+func GetTransactionsByAddress(address common.Address, height uint32, limit uint32) ([]AddressTxInfo, error) {
+	txs, err := bactor.GetTransactionsByAddress(address, height, limit)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]AddressTxInfo, 0, len(txs))
+	for _, tx := range txs {
+		infos = append(infos, AddressTxInfo{TxHash: tx.TxHash.ToHexString(), Height: tx.Height})
+	}
+	return infos, nil
+}
+
+//GetBalanceChanges return the balance changes of address caused by the transfers of contract between the start
+//and end block height. Change is the signed amount from the view of address.
+func GetBalanceChanges(contract common.Address, address common.Address, startHeight uint32, endHeight uint32, limit uint32) ([]BalanceChangeInfo, error) {
+	transfers, err := bactor.GetTransfersByAddress(contract, address, startHeight, endHeight, limit)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]BalanceChangeInfo, 0, len(transfers))
+	for _, transfer := range transfers {
+		change := new(big.Int)
+		if transfer.To == address {
+			change.Add(change, transfer.Amount)
+		}
+		if transfer.From == address {
+			change.Sub(change, transfer.Amount)
+		}
+		infos = append(infos, BalanceChangeInfo{
+			TxHash: transfer.TxHash.ToHexString(),
+			Height: transfer.Height,
+			From:   transfer.From.ToBase58(),
+			To:     transfer.To.ToBase58(),
+			Amount: transfer.Amount.String(),
+			Change: change.String(),
+		})
+	}
+	return infos, nil
+}
+
+//GetMemPoolTxState return the verified result of transaction in tx pool and its replace-by-fee outcome.
+//A replaced transaction is no longer in the pool, but its outcome is kept for a while.
+func GetMemPoolTxState(hash common.Uint256) (*TXNEntryInfo, error) {
+	txEntry, err := bactor.GetTxFromPool(hash)
+	if err != nil {
+		status, e := bactor.GetTxnStatus(hash)
+		if e != nil || status.ReplacedBy == common.UINT256_EMPTY {
+			return nil, err
+		}
+		return &TXNEntryInfo{State: []TXNAttrInfo{}, ReplacedBy: status.ReplacedBy.ToHexString()}, nil
+	}
+	attrs := []TXNAttrInfo{}
+	for _, t := range txEntry.Attrs {
+		attrs = append(attrs, TXNAttrInfo{t.Height, int(t.Type), int(t.ErrCode)})
+	}
+	info := &TXNEntryInfo{State: attrs}
+	if txEntry.Replaces != common.UINT256_EMPTY {
+		info.Replaces = txEntry.Replaces.ToHexString()
+	}
+	return info, nil
+}
+
+//GetReceiptInfo convert the receipt of transaction to the format of response
+func GetReceiptInfo(receipt *store.Receipt) ReceiptInfo {
+	evts := []NotifyEventInfo{}
+	for _, v := range receipt.Notify {
+		evts = append(evts, NotifyEventInfo{v.ContractAddress.ToHexString(), v.States})
+	}
+	return ReceiptInfo{
+		TxHash:      receipt.TxHash.ToHexString(),
+		BlockHash:   receipt.BlockHash.ToHexString(),
+		Height:      receipt.Height,
+		TxIndex:     receipt.TxIndex,
+		State:       receipt.State,
+		GasConsumed: receipt.GasConsumed,
+		GasUsed:     receipt.GasUsed,
+		Payer:       receipt.Payer.ToBase58(),
+		Notify:      evts,
+	}
+}
+
+//GetBalanceAtHeight return the balance of address after the block of height has been saved
+func GetBalanceAtHeight(address common.Address, height uint32) (*BalanceOfRsp, error) {
+	ont, err := GetContractBalanceAtHeight(utils.OntContractAddress, address, height)
+	if err != nil {
+		return nil, fmt.Errorf("get ont balance error:%s", err)
+	}
+	ong, err := GetContractBalanceAtHeight(utils.OngContractAddress, address, height)
+	if err != nil {
+		return nil, fmt.Errorf("get ong balance error:%s", err)
+	}
+	return &BalanceOfRsp{
+		Ont: fmt.Sprintf("%d", ont),
+		Ong: fmt.Sprintf("%d", ong),
+	}, nil
+}
+
 func GetGrantOng(addr common.Address) (string, error) {
 	key := append([]byte(ont.UNBOUND_TIME_OFFSET), addr[:]...)
 	value, err := ledger.DefLedger.GetStorageItem(utils.OntContractAddress, key)
@@ -326,6 +467,44 @@ func GetAllowance(asset string, from, to common.Address) (string, error) {
 	return fmt.Sprintf("%v", allowance), nil
 }
 
+//GetAllowanceAtHeight return the allowance after the block of height has been saved
+func GetAllowanceAtHeight(asset string, from, to common.Address, height uint32) (string, error) {
+	var contractAddr common.Address
+	switch strings.ToLower(asset) {
+	case "ont":
+		contractAddr = utils.OntContractAddress
+	case "ong":
+		contractAddr = utils.OngContractAddress
+	default:
+		return "", fmt.Errorf("unsupport asset")
+	}
+	key := append(from[:], to[:]...)
+	allowance, err := getStorageUint64AtHeight(contractAddr, key, height)
+	if err != nil {
+		return "", fmt.Errorf("get allowance error:%s", err)
+	}
+	return fmt.Sprintf("%v", allowance), nil
+}
+
+//GetContractBalanceAtHeight read the balance of native token contract from the state history
+func GetContractBalanceAtHeight(contractAddr, accAddr common.Address, height uint32) (uint64, error) {
+	return getStorageUint64AtHeight(contractAddr, accAddr[:], height)
+}
+
+func getStorageUint64AtHeight(contractAddr common.Address, key []byte, height uint32) (uint64, error) {
+	value, err := bactor.GetStorageItemAtHeight(contractAddr, key, height)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(value) == 0 {
+		return 0, nil
+	}
+	return serialization.ReadUint64(bytes.NewBuffer(value))
+}
+
 func GetContractBalance(cVersion byte, contractAddr, accAddr common.Address) (uint64, error) {
 	mutable, err := NewNativeInvokeTransaction(0, 0, contractAddr, cVersion, "balanceOf", []interface{}{accAddr[:]})
 	if err != nil {
@@ -30,6 +30,7 @@ import (
 	bcomn "github.com/ontio/ontology/http/base/common"
 	berr "github.com/ontio/ontology/http/base/error"
 	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"math"
 	"strconv"
 )
 
@@ -94,6 +95,9 @@ func GetBlockHash(cmd map[string]interface{}) map[string]interface{} {
 
 func getBlock(hash common.Uint256, getTxBytes bool) (interface{}, int64) {
 	block, err := bactor.GetBlockFromStore(hash)
+	if err == scom.ErrPruned {
+		return nil, berr.PRUNED_DATA
+	}
 	if err != nil {
 		return nil, berr.UNKNOWN_BLOCK
 	}
@@ -140,7 +144,12 @@ func GetBlockHeightByTxHash(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
+	//height of pruned transaction is still kept
 	height, tx, err := bactor.GetTxnWithHeightByTxHash(hash)
+	if err == scom.ErrPruned {
+		resp["Result"] = height
+		return resp
+	}
 	if err != nil {
 		return ResponsePack(berr.INTERNAL_ERROR)
 	}
@@ -169,6 +178,9 @@ func GetBlockTxsByHeight(cmd map[string]interface{}) map[string]interface{} {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
 	block, err := bactor.GetBlockFromStore(hash)
+	if err == scom.ErrPruned {
+		return ResponsePack(berr.PRUNED_DATA)
+	}
 	if err != nil {
 		return ResponsePack(berr.UNKNOWN_BLOCK)
 	}
@@ -197,6 +209,9 @@ func GetBlockByHeight(cmd map[string]interface{}) map[string]interface{} {
 	}
 	index := uint32(height)
 	block, err := bactor.GetBlockByHeight(index)
+	if err == scom.ErrPruned {
+		return ResponsePack(berr.PRUNED_DATA)
+	}
 	if err != nil || block == nil {
 		return ResponsePack(berr.UNKNOWN_BLOCK)
 	}
@@ -221,6 +236,9 @@ func GetTransactionByHash(cmd map[string]interface{}) map[string]interface{} {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
 	height, tx, err := bactor.GetTxnWithHeightByTxHash(hash)
+	if err == scom.ErrPruned {
+		return ResponsePack(berr.PRUNED_DATA)
+	}
 	if tx == nil {
 		return ResponsePack(berr.UNKNOWN_TRANSACTION)
 	}
@@ -308,6 +326,9 @@ func GetSmartCodeEventTxsByHeight(cmd map[string]interface{}) map[string]interfa
 		if scom.ErrNotFound == err {
 			return ResponsePack(berr.SUCCESS)
 		}
+		if scom.ErrPruned == err {
+			return ResponsePack(berr.PRUNED_DATA)
+		}
 		return ResponsePack(berr.INTERNAL_ERROR)
 	}
 	eInfos := make([]*bcomn.ExecuteNotify, 0, len(eventInfos))
@@ -340,6 +361,9 @@ func GetSmartCodeEventByTxHash(cmd map[string]interface{}) map[string]interface{
 		if scom.ErrNotFound == err {
 			return ResponsePack(berr.SUCCESS)
 		}
+		if scom.ErrPruned == err {
+			return ResponsePack(berr.PRUNED_DATA)
+		}
 		return ResponsePack(berr.INTERNAL_ERROR)
 	}
 	if eventInfo == nil {
@@ -350,6 +374,29 @@ func GetSmartCodeEventByTxHash(cmd map[string]interface{}) map[string]interface{
 	return resp
 }
 
+//get the receipt of transaction
+func GetTransactionReceipt(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+
+	str, ok := cmd["Hash"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	hash, err := common.Uint256FromHexString(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	receipt, err := bactor.GetTransactionReceipt(hash)
+	if err != nil {
+		if scom.ErrNotFound == err {
+			return ResponsePack(berr.SUCCESS)
+		}
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = bcomn.GetReceiptInfo(receipt)
+	return resp
+}
+
 //get contract state
 func GetContractState(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
@@ -394,7 +441,16 @@ func GetStorage(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
-	value, err := bactor.GetStorageItem(address, item)
+	var value []byte
+	if param, ok := cmd["Height"].(string); ok && len(param) > 0 {
+		height, perr := strconv.ParseUint(param, 10, 32)
+		if perr != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		value, err = bactor.GetStorageItemAtHeight(address, item, uint32(height))
+	} else {
+		value, err = bactor.GetStorageItem(address, item)
+	}
 	if err != nil {
 		if err == scom.ErrNotFound {
 			return ResponsePack(berr.SUCCESS)
@@ -416,7 +472,16 @@ func GetBalance(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
-	balance, err := bcomn.GetBalance(address)
+	var balance *bcomn.BalanceOfRsp
+	if param, ok := cmd["Height"].(string); ok && len(param) > 0 {
+		height, perr := strconv.ParseUint(param, 10, 32)
+		if perr != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		balance, err = bcomn.GetBalanceAtHeight(address, uint32(height))
+	} else {
+		balance, err = bcomn.GetBalance(address)
+	}
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
@@ -424,6 +489,102 @@ func GetBalance(cmd map[string]interface{}) map[string]interface{} {
 	return resp
 }
 
+//get transactions related to address in descending order of block height, transactions of a block are always
+//returned together, so use the lowest height in result minus one to query next page
+func GetTransactionsByAddress(cmd map[string]interface{}) map[string]interface{} {
+	if !config.DefConfig.Common.EnableAddressIndex {
+		return ResponsePack(berr.INVALID_METHOD)
+	}
+	resp := ResponsePack(berr.SUCCESS)
+	addrBase58, ok := cmd["Addr"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	address, err := common.AddressFromBase58(addrBase58)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	height := uint32(math.MaxUint32)
+	if param, ok := cmd["Height"].(string); ok && len(param) > 0 {
+		h, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		height = uint32(h)
+	}
+	limit := bcomn.DEFAULT_ADDRESS_TX_LIMIT
+	if param, ok := cmd["Limit"].(string); ok && len(param) > 0 {
+		l, err := strconv.ParseUint(param, 10, 32)
+		if err != nil || l == 0 || l > uint64(bcomn.MAX_ADDRESS_TX_LIMIT) {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		limit = uint32(l)
+	}
+	txs, err := bcomn.GetTransactionsByAddress(address, height, limit)
+	if err != nil {
+		log.Errorf("GetTransactionsByAddress address:%s error:%s", addrBase58, err)
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = txs
+	return resp
+}
+
+//get balance changes of address caused by the transfers of contract in ascending order of block height, balance
+//changes of a block are always returned together, so use the highest height in result plus one to query next page
+func GetBalanceChanges(cmd map[string]interface{}) map[string]interface{} {
+	if !config.DefConfig.Common.EnableTransferIndex {
+		return ResponsePack(berr.INVALID_METHOD)
+	}
+	resp := ResponsePack(berr.SUCCESS)
+	str, ok := cmd["Contract"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	addrBase58, ok := cmd["Addr"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	address, err := common.AddressFromBase58(addrBase58)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	startHeight := uint32(0)
+	if param, ok := cmd["Start"].(string); ok && len(param) > 0 {
+		h, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		startHeight = uint32(h)
+	}
+	endHeight := uint32(math.MaxUint32)
+	if param, ok := cmd["End"].(string); ok && len(param) > 0 {
+		h, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		endHeight = uint32(h)
+	}
+	limit := bcomn.DEFAULT_BALANCE_CHANGE_LIMIT
+	if param, ok := cmd["Limit"].(string); ok && len(param) > 0 {
+		l, err := strconv.ParseUint(param, 10, 32)
+		if err != nil || l == 0 || l > uint64(bcomn.MAX_BALANCE_CHANGE_LIMIT) {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		limit = uint32(l)
+	}
+	changes, err := bcomn.GetBalanceChanges(contract, address, startHeight, endHeight, limit)
+	if err != nil {
+		log.Errorf("GetBalanceChanges contract:%s address:%s error:%s", str, addrBase58, err)
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = changes
+	return resp
+}
+
 //get merkle proof by transaction hash
 func GetMerkleProof(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
@@ -499,7 +660,16 @@ func GetAllowance(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
-	rsp, err := bcomn.GetAllowance(asset, fromAddr, toAddr)
+	var rsp string
+	if param, ok := cmd["Height"].(string); ok && len(param) > 0 {
+		height, perr := strconv.ParseUint(param, 10, 32)
+		if perr != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		rsp, err = bcomn.GetAllowanceAtHeight(asset, fromAddr, toAddr, uint32(height))
+	} else {
+		rsp, err = bcomn.GetAllowance(asset, fromAddr, toAddr)
+	}
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
@@ -569,14 +739,10 @@ func GetMemPoolTxState(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
-	txEntry, err := bactor.GetTxFromPool(hash)
+	info, err := bcomn.GetMemPoolTxState(hash)
 	if err != nil {
 		return ResponsePack(berr.UNKNOWN_TRANSACTION)
 	}
-	attrs := []bcomn.TXNAttrInfo{}
-	for _, t := range txEntry.Attrs {
-		attrs = append(attrs, bcomn.TXNAttrInfo{t.Height, int(t.Type), int(t.ErrCode)})
-	}
-	resp["Result"] = bcomn.TXNEntryInfo{attrs}
+	resp["Result"] = info
 	return resp
 }
@@ -32,6 +32,7 @@ import (
 	bcomn "github.com/ontio/ontology/http/base/common"
 	berr "github.com/ontio/ontology/http/base/error"
 	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"math"
 )
 
 //get best block hash
@@ -69,6 +70,9 @@ func GetBlock(params []interface{}) map[string]interface{} {
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
 	block, err := bactor.GetBlockFromStore(hash)
+	if err == scom.ErrPruned {
+		return responsePack(berr.PRUNED_DATA, "pruned block")
+	}
 	if err != nil {
 		return responsePack(berr.UNKNOWN_BLOCK, "unknown block")
 	}
@@ -155,15 +159,10 @@ func GetMemPoolTxState(params []interface{}) map[string]interface{} {
 		if err != nil {
 			return responsePack(berr.INVALID_PARAMS, "")
 		}
-		txEntry, err := bactor.GetTxFromPool(hash)
+		info, err := bcomn.GetMemPoolTxState(hash)
 		if err != nil {
 			return responsePack(berr.UNKNOWN_TRANSACTION, "unknown transaction")
 		}
-		attrs := []bcomn.TXNAttrInfo{}
-		for _, t := range txEntry.Attrs {
-			attrs = append(attrs, bcomn.TXNAttrInfo{t.Height, int(t.Type), int(t.ErrCode)})
-		}
-		info := bcomn.TXNEntryInfo{attrs}
 		return responseSuccess(info)
 	default:
 		return responsePack(berr.INVALID_PARAMS, "")
@@ -187,6 +186,9 @@ func GetRawTransaction(params []interface{}) map[string]interface{} {
 			return responsePack(berr.INVALID_PARAMS, "")
 		}
 		h, t, err := bactor.GetTxnWithHeightByTxHash(hash)
+		if err == scom.ErrPruned {
+			return responsePack(berr.PRUNED_DATA, "pruned transaction")
+		}
 		if err != nil {
 			return responsePack(berr.UNKNOWN_TRANSACTION, "unknown transaction")
 		}
@@ -214,8 +216,8 @@ func GetRawTransaction(params []interface{}) map[string]interface{} {
 	return responseSuccess(common.ToHexString(w.Bytes()))
 }
 
-//get storage from contract
-//   {"jsonrpc": "2.0", "method": "getstorage", "params": ["code hash", "key"], "id": 0}
+//get storage from contract, the optional height param query the storage at past block height
+//   {"jsonrpc": "2.0", "method": "getstorage", "params": ["code hash", "key", height], "id": 0}
 func GetStorage(params []interface{}) map[string]interface{} {
 	if len(params) < 2 {
 		return responsePack(berr.INVALID_PARAMS, nil)
@@ -246,7 +248,17 @@ func GetStorage(params []interface{}) map[string]interface{} {
 	default:
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
-	value, err := bactor.GetStorageItem(address, key)
+	var value []byte
+	var err error
+	if len(params) > 2 {
+		height, ok := params[2].(float64)
+		if !ok || height < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		value, err = bactor.GetStorageItemAtHeight(address, key, uint32(height))
+	} else {
+		value, err = bactor.GetStorageItem(address, key)
+	}
 	if err != nil {
 		if err == scom.ErrNotFound {
 			return responseSuccess(nil)
@@ -368,6 +380,9 @@ func GetSmartCodeEvent(params []interface{}) map[string]interface{} {
 			if err == scom.ErrNotFound {
 				return responseSuccess(nil)
 			}
+			if err == scom.ErrPruned {
+				return responsePack(berr.PRUNED_DATA, "pruned event")
+			}
 			return responsePack(berr.INTERNAL_ERROR, "")
 		}
 		eInfos := make([]*bcomn.ExecuteNotify, 0, len(eventInfos))
@@ -388,6 +403,9 @@ func GetSmartCodeEvent(params []interface{}) map[string]interface{} {
 			if scom.ErrNotFound == err {
 				return responseSuccess(nil)
 			}
+			if scom.ErrPruned == err {
+				return responsePack(berr.PRUNED_DATA, "pruned event")
+			}
 			return responsePack(berr.INTERNAL_ERROR, "")
 		}
 		_, notify := bcomn.GetExecuteNotify(eventInfo)
@@ -412,8 +430,9 @@ func GetBlockHeightByTxHash(params []interface{}) map[string]interface{} {
 		if err != nil {
 			return responsePack(berr.INVALID_PARAMS, "")
 		}
+		//height of pruned transaction is still kept
 		height, _, err := bactor.GetTxnWithHeightByTxHash(hash)
-		if err != nil {
+		if err != nil && err != scom.ErrPruned {
 			return responsePack(berr.INVALID_PARAMS, "")
 		}
 		return responseSuccess(height)
@@ -423,7 +442,32 @@ func GetBlockHeightByTxHash(params []interface{}) map[string]interface{} {
 	return responsePack(berr.INVALID_PARAMS, "")
 }
 
-//get balance of address
+//get the receipt of transaction, which is recorded even if event log is disabled
+//   {"jsonrpc": "2.0", "method": "gettransactionreceipt", "params": ["txhash"], "id": 0}
+func GetTransactionReceipt(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	hash, err := common.Uint256FromHexString(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	receipt, err := bactor.GetTransactionReceipt(hash)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return responseSuccess(nil)
+		}
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(bcomn.GetReceiptInfo(receipt))
+}
+
+//get balance of address, the optional height param query the balance at past block height
+//   {"jsonrpc": "2.0", "method": "getbalance", "params": ["address", height], "id": 0}
 func GetBalance(params []interface{}) map[string]interface{} {
 	if len(params) < 1 {
 		return responsePack(berr.INVALID_PARAMS, "")
@@ -436,14 +480,24 @@ func GetBalance(params []interface{}) map[string]interface{} {
 	if err != nil {
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
-	rsp, err := bcomn.GetBalance(address)
+	var rsp *bcomn.BalanceOfRsp
+	if len(params) > 1 {
+		height, ok := params[1].(float64)
+		if !ok || height < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		rsp, err = bcomn.GetBalanceAtHeight(address, uint32(height))
+	} else {
+		rsp, err = bcomn.GetBalance(address)
+	}
 	if err != nil {
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
 	return responseSuccess(rsp)
 }
 
-//get allowance
+//get allowance, the optional height param query the allowance at past block height
+//   {"jsonrpc": "2.0", "method": "getallowance", "params": ["ont", "from address", "to address", height], "id": 0}
 func GetAllowance(params []interface{}) map[string]interface{} {
 	if len(params) < 3 {
 		return responsePack(berr.INVALID_PARAMS, "")
@@ -468,13 +522,125 @@ func GetAllowance(params []interface{}) map[string]interface{} {
 	if err != nil {
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
-	rsp, err := bcomn.GetAllowance(asset, fromAddr, toAddr)
+	var rsp string
+	if len(params) > 3 {
+		height, ok := params[3].(float64)
+		if !ok || height < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		rsp, err = bcomn.GetAllowanceAtHeight(asset, fromAddr, toAddr, uint32(height))
+	} else {
+		rsp, err = bcomn.GetAllowance(asset, fromAddr, toAddr)
+	}
 	if err != nil {
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
 	return responseSuccess(rsp)
 }
 
+//get transactions related to address in descending order of block height. The optional height param is the
+//highest block height to query, and limit param is the min number of transactions to return. Transactions of
+//a block are always returned together, so use the lowest height in result minus one to query next page.
+//   {"jsonrpc": "2.0", "method": "gettransactionsbyaddress", "params": ["address", height, limit], "id": 0}
+func GetTransactionsByAddress(params []interface{}) map[string]interface{} {
+	if !config.DefConfig.Common.EnableAddressIndex {
+		return responsePack(berr.INVALID_METHOD, "address index is disabled")
+	}
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	addrBase58, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	address, err := common.AddressFromBase58(addrBase58)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	height := uint32(math.MaxUint32)
+	if len(params) > 1 {
+		h, ok := params[1].(float64)
+		if !ok || h < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		height = uint32(h)
+	}
+	limit := bcomn.DEFAULT_ADDRESS_TX_LIMIT
+	if len(params) > 2 {
+		l, ok := params[2].(float64)
+		if !ok || l <= 0 || l > float64(bcomn.MAX_ADDRESS_TX_LIMIT) {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		limit = uint32(l)
+	}
+	txs, err := bcomn.GetTransactionsByAddress(address, height, limit)
+	if err != nil {
+		log.Errorf("GetTransactionsByAddress address:%s error:%s", addrBase58, err)
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(txs)
+}
+
+//get balance changes of address caused by the transfers of contract in ascending order of block height. The optional
+//start and end height params are the block height range to query, and limit param is the min number of balance changes
+//to return. Balance changes of a block are always returned together, so use the highest height in result plus one as
+//start height to query next page.
+//   {"jsonrpc": "2.0", "method": "getbalancechanges", "params": ["contract", "address", start, end, limit], "id": 0}
+func GetBalanceChanges(params []interface{}) map[string]interface{} {
+	if !config.DefConfig.Common.EnableTransferIndex {
+		return responsePack(berr.INVALID_METHOD, "transfer index is disabled")
+	}
+	if len(params) < 2 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	addrBase58, ok := params[1].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	address, err := common.AddressFromBase58(addrBase58)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	startHeight := uint32(0)
+	if len(params) > 2 {
+		h, ok := params[2].(float64)
+		if !ok || h < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		startHeight = uint32(h)
+	}
+	endHeight := uint32(math.MaxUint32)
+	if len(params) > 3 {
+		h, ok := params[3].(float64)
+		if !ok || h < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		endHeight = uint32(h)
+	}
+	limit := bcomn.DEFAULT_BALANCE_CHANGE_LIMIT
+	if len(params) > 4 {
+		l, ok := params[4].(float64)
+		if !ok || l <= 0 || l > float64(bcomn.MAX_BALANCE_CHANGE_LIMIT) {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		limit = uint32(l)
+	}
+	changes, err := bcomn.GetBalanceChanges(contract, address, startHeight, endHeight, limit)
+	if err != nil {
+		log.Errorf("GetBalanceChanges contract:%s address:%s error:%s", contract.ToHexString(), addrBase58, err)
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(changes)
+}
+
 //get merkle proof by transaction hash
 func GetMerkleProof(params []interface{}) map[string]interface{} {
 	if len(params) < 1 {
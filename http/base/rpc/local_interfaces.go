@@ -19,9 +19,13 @@
 package rpc
 
 import (
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	bactor "github.com/ontio/ontology/http/base/actor"
 	"github.com/ontio/ontology/http/base/common"
@@ -107,6 +111,15 @@ func StopConsensus(params []interface{}) map[string]interface{} {
 	return responsePack(berr.SUCCESS, true)
 }
 
+//get the current round, participants, peer liveness and vote tallies of consensus
+func GetConsensusStatus(params []interface{}) map[string]interface{} {
+	status, err := bactor.GetConsensusStatus()
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(status)
+}
+
 func SetDebugInfo(params []interface{}) map[string]interface{} {
 	if len(params) < 1 {
 		return responsePack(berr.INVALID_PARAMS, "")
@@ -122,3 +135,66 @@ func SetDebugInfo(params []interface{}) map[string]interface{} {
 	}
 	return responsePack(berr.SUCCESS, true)
 }
+
+//parseBanTarget returns the ip or the peer id of the ban target
+func parseBanTarget(params []interface{}) (string, uint64, bool) {
+	if len(params) < 1 {
+		return "", 0, false
+	}
+	target, ok := params[0].(string)
+	if !ok {
+		return "", 0, false
+	}
+	if net.ParseIP(target) != nil {
+		return target, 0, true
+	}
+	id, err := strconv.ParseUint(target, 10, 64)
+	if err != nil || id == 0 {
+		return "", 0, false
+	}
+	return "", id, true
+}
+
+func GetBanList(params []interface{}) map[string]interface{} {
+	bans, err := bactor.GetBanList()
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, false)
+	}
+	return responseSuccess(bans)
+}
+
+func AddBan(params []interface{}) map[string]interface{} {
+	ip, id, ok := parseBanTarget(params)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	duration := time.Duration(config.DefConfig.P2PNode.BanDuration) * time.Second
+	if len(params) > 1 {
+		switch params[1].(type) {
+		case float64:
+			seconds := params[1].(float64)
+			if seconds <= 0 {
+				return responsePack(berr.INVALID_PARAMS, "")
+			}
+			duration = time.Duration(seconds) * time.Second
+		default:
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+	}
+	if err := bactor.AddBan(ip, id, duration); err != nil {
+		return responsePack(berr.INTERNAL_ERROR, false)
+	}
+	return responsePack(berr.SUCCESS, true)
+}
+
+func RemoveBan(params []interface{}) map[string]interface{} {
+	ip, id, ok := parseBanTarget(params)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	removed, err := bactor.RemoveBan(ip, id)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, false)
+	}
+	return responseSuccess(removed)
+}
@@ -22,6 +22,23 @@ import (
 	Err "github.com/ontio/ontology/http/base/error"
 )
 
+//error codes defined by JSON-RPC 2.0
+const (
+	JSONRPC_PARSE_ERROR      int64 = -32700
+	JSONRPC_INVALID_REQUEST  int64 = -32600
+	JSONRPC_METHOD_NOT_FOUND int64 = -32601
+	JSONRPC_INVALID_PARAMS   int64 = -32602
+	JSONRPC_INTERNAL_ERROR   int64 = -32603
+)
+
+var jsonRpcErrMap = map[int64]string{
+	JSONRPC_PARSE_ERROR:      "Parse error",
+	JSONRPC_INVALID_REQUEST:  "Invalid Request",
+	JSONRPC_METHOD_NOT_FOUND: "Method not found",
+	JSONRPC_INVALID_PARAMS:   "Invalid params",
+	JSONRPC_INTERNAL_ERROR:   "Internal error",
+}
+
 func responseSuccess(result interface{}) map[string]interface{} {
 	return responsePack(Err.SUCCESS, result)
 }
@@ -33,3 +50,83 @@ func responsePack(errcode int64, result interface{}) map[string]interface{} {
 	}
 	return resp
 }
+
+//jsonRpcResponse build the response of request from the result of rpc function. In compatible mode the error
+//code and desc of ontology are returned, otherwise the JSON-RPC 2.0 error object is returned on failure.
+func jsonRpcResponse(id interface{}, resp map[string]interface{}, compatible bool) map[string]interface{} {
+	if compatible {
+		return map[string]interface{}{
+			"jsonrpc": "2.0",
+			"error":   resp["error"],
+			"desc":    resp["desc"],
+			"result":  resp["result"],
+			"id":      id,
+		}
+	}
+	errcode, _ := resp["error"].(int64)
+	if errcode == Err.SUCCESS {
+		return map[string]interface{}{
+			"jsonrpc": "2.0",
+			"result":  resp["result"],
+			"id":      id,
+		}
+	}
+	return jsonRpcError(id, jsonRpcErrorCode(errcode), resp["desc"], resp["result"])
+}
+
+//errorResponse build the response of request which fails before calling the rpc function
+func errorResponse(id interface{}, code int64, compatible bool) map[string]interface{} {
+	if !compatible {
+		return jsonRpcError(id, code, jsonRpcErrMap[code], nil)
+	}
+	errcode := Err.INVALID_PARAMS
+	switch code {
+	case JSONRPC_PARSE_ERROR:
+		errcode = Err.ILLEGAL_DATAFORMAT
+	case JSONRPC_METHOD_NOT_FOUND:
+		errcode = Err.INVALID_METHOD
+	}
+	result := map[string]interface{}{
+		"code":    code,
+		"message": jsonRpcErrMap[code],
+	}
+	if code == JSONRPC_METHOD_NOT_FOUND {
+		result["data"] = "The called method was not found on the server"
+	}
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"error":   errcode,
+		"desc":    Err.ErrMap[errcode],
+		"result":  result,
+		"id":      id,
+	}
+}
+
+func jsonRpcError(id interface{}, code int64, message interface{}, data interface{}) map[string]interface{} {
+	rpcErr := map[string]interface{}{
+		"code":    code,
+		"message": message,
+	}
+	if data != nil && data != "" {
+		rpcErr["data"] = data
+	}
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"error":   rpcErr,
+		"id":      id,
+	}
+}
+
+//jsonRpcErrorCode map the error code of ontology to the one defined by JSON-RPC 2.0. Codes without counterpart
+//are kept, since they are out of the range reserved by JSON-RPC 2.0.
+func jsonRpcErrorCode(errcode int64) int64 {
+	switch errcode {
+	case Err.INVALID_METHOD:
+		return JSONRPC_METHOD_NOT_FOUND
+	case Err.INVALID_PARAMS:
+		return JSONRPC_INVALID_PARAMS
+	case Err.INTERNAL_ERROR:
+		return JSONRPC_INTERNAL_ERROR
+	}
+	return errcode
+}
@@ -22,9 +22,9 @@ package rpc
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/http/base/common"
-	berr "github.com/ontio/ontology/http/base/error"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -92,63 +92,121 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	request := make(map[string]interface{})
 	defer r.Body.Close()
-	decoder := json.NewDecoder(io.LimitReader(r.Body, common.MAX_REQUEST_BODY_SIZE))
-	err := decoder.Decode(&request)
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, common.MAX_REQUEST_BODY_SIZE))
 	if err != nil {
-		log.Error("HTTP JSON RPC Handle - json.Unmarshal: ", err)
+		log.Error("HTTP JSON RPC Handle - read request body: ", err)
 		return
 	}
-	if request["method"] == nil {
-		log.Error("HTTP JSON RPC Handle - method not found: ")
+	data := handleMessage(body, config.DefConfig.Rpc.EnableCompatibleMode, nil)
+	//no response for notifications
+	if data == nil {
 		return
 	}
+	w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("content-type", "application/json;charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(data)
+}
+
+//HandleMessage handle the request or batch request in msg with the registered functions, and return the
+//response, which is nil if no response is needed. Functions in extra take precedence over the registered
+//ones, they serve the methods bound to the connection, such as subscriptions of websocket.
+func HandleMessage(msg []byte, compatible bool, extra map[string]func([]interface{}) map[string]interface{}) []byte {
+	mainMux.RLock()
+	defer mainMux.RUnlock()
+	return handleMessage(msg, compatible, extra)
+}
+
+func handleMessage(msg []byte, compatible bool, extra map[string]func([]interface{}) map[string]interface{}) []byte {
+	var request interface{}
+	var response interface{}
+	err := json.Unmarshal(msg, &request)
+	if err != nil {
+		log.Error("HTTP JSON RPC Handle - json.Unmarshal: ", err)
+		response = errorResponse(nil, JSONRPC_PARSE_ERROR, compatible)
+	} else if requests, ok := request.([]interface{}); ok {
+		response = handleBatch(requests, compatible, extra)
+	} else if resp := handleRequest(request, compatible, extra); resp != nil {
+		response = resp
+	}
+	if response == nil {
+		return nil
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Error("HTTP JSON RPC Handle - json.Marshal: ", err)
+		return nil
+	}
+	return data
+}
+
+//handleBatch call the requests in batch one by one, return nil if all of the requests are notifications
+func handleBatch(requests []interface{}, compatible bool, extra map[string]func([]interface{}) map[string]interface{}) interface{} {
+	if len(requests) == 0 || len(requests) > common.MAX_BATCH_REQUEST_SIZE {
+		log.Errorf("HTTP JSON RPC Handle - invalid batch size %d", len(requests))
+		return errorResponse(nil, JSONRPC_INVALID_REQUEST, compatible)
+	}
+	responses := make([]map[string]interface{}, 0, len(requests))
+	for _, request := range requests {
+		if resp := handleRequest(request, compatible, extra); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+//handleRequest call the function registered for the method of request, return nil if the request is a
+//notification. Requests without id are notifications only when compatible mode is disabled.
+func handleRequest(req interface{}, compatible bool, extra map[string]func([]interface{}) map[string]interface{}) map[string]interface{} {
+	request, ok := req.(map[string]interface{})
+	if !ok {
+		log.Error("HTTP JSON RPC Handle - request is not object")
+		return errorResponse(nil, JSONRPC_INVALID_REQUEST, compatible)
+	}
+	id, hasId := request["id"]
+	notification := !hasId && !compatible
 	method, ok := request["method"].(string)
 	if !ok {
 		log.Error("HTTP JSON RPC Handle - method is not string: ")
-		return
+		return errorResponse(id, JSONRPC_INVALID_REQUEST, compatible)
 	}
-	//get the corresponding function
-	function, ok := mainMux.m[method]
-	if ok {
-		response := function(request["params"].([]interface{}))
-		data, err := json.Marshal(map[string]interface{}{
-			"jsonrpc": "2.0",
-			"error":   response["error"],
-			"desc":    response["desc"],
-			"result":  response["result"],
-			"id":      request["id"],
-		})
-		if err != nil {
-			log.Error("HTTP JSON RPC Handle - json.Marshal: ", err)
-			return
+	if !compatible && request["jsonrpc"] != "2.0" {
+		log.Error("HTTP JSON RPC Handle - jsonrpc version is not 2.0")
+		return errorResponse(id, JSONRPC_INVALID_REQUEST, compatible)
+	}
+	params := make([]interface{}, 0)
+	if request["params"] != nil {
+		params, ok = request["params"].([]interface{})
+		if !ok {
+			log.Error("HTTP JSON RPC Handle - params is not array")
+			if notification {
+				return nil
+			}
+			return errorResponse(id, JSONRPC_INVALID_PARAMS, compatible)
 		}
-		w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
-		w.Header().Set("content-type", "application/json;charset=utf-8")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Write(data)
-	} else {
+	}
+	//get the corresponding function
+	function, ok := extra[method]
+	if !ok {
+		function, ok = mainMux.m[method]
+	}
+	if !ok {
 		//if the function does not exist
-		log.Warn("HTTP JSON RPC Handle - No function to call for ", request["method"])
-		data, err := json.Marshal(map[string]interface{}{
-			"error": berr.INVALID_METHOD,
-			"result": map[string]interface{}{
-				"code":    -32601,
-				"message": "Method not found",
-				"data":    "The called method was not found on the server",
-			},
-			"id": request["id"],
-		})
-		if err != nil {
-			log.Error("HTTP JSON RPC Handle - json.Marshal: ", err)
-			return
+		log.Warn("HTTP JSON RPC Handle - No function to call for ", method)
+		if notification {
+			return nil
 		}
-		w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
-		w.Header().Set("content-type", "application/json;charset=utf-8")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Write(data)
+		return errorResponse(id, JSONRPC_METHOD_NOT_FOUND, compatible)
+	}
+	response := function(params)
+	if notification {
+		return nil
 	}
+	return jsonRpcResponse(id, response, compatible)
 }
 
 // Call sends RPC request to server
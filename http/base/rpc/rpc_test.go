@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	berr "github.com/ontio/ontology/http/base/error"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	log.InitLog(log.InfoLog)
+	HandleFunc("echo", func(params []interface{}) map[string]interface{} {
+		if len(params) == 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		return responseSuccess(params[0])
+	})
+}
+
+func call(t *testing.T, body string) []byte {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	Handle(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	return w.Body.Bytes()
+}
+
+func TestHandleCompatible(t *testing.T) {
+	config.DefConfig.Rpc.EnableCompatibleMode = true
+
+	resp := make(map[string]interface{})
+	err := json.Unmarshal(call(t, `{"jsonrpc":"2.0","method":"echo","params":["a"],"id":1}`), &resp)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(berr.SUCCESS), resp["error"])
+	assert.Equal(t, "a", resp["result"])
+
+	err = json.Unmarshal(call(t, `{"method":"echo","params":[]}`), &resp)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(berr.INVALID_PARAMS), resp["error"])
+	assert.Nil(t, resp["id"])
+
+	var batch []map[string]interface{}
+	err = json.Unmarshal(call(t, `[{"method":"echo","params":["a"],"id":1},{"method":"none","id":2}]`), &batch)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(batch))
+	assert.Equal(t, float64(berr.INVALID_METHOD), batch[1]["error"])
+}
+
+func TestHandleStrict(t *testing.T) {
+	config.DefConfig.Rpc.EnableCompatibleMode = false
+	defer func() { config.DefConfig.Rpc.EnableCompatibleMode = true }()
+
+	resp := make(map[string]interface{})
+	err := json.Unmarshal(call(t, `{"jsonrpc":"2.0","method":"echo","params":["a"],"id":"x"}`), &resp)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", resp["result"])
+	assert.Equal(t, "x", resp["id"])
+	_, ok := resp["error"]
+	assert.False(t, ok)
+
+	resp = make(map[string]interface{})
+	err = json.Unmarshal(call(t, `{"jsonrpc":"2.0","method":"echo","params":[],"id":1}`), &resp)
+	assert.Nil(t, err)
+	rpcErr, ok := resp["error"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, float64(JSONRPC_INVALID_PARAMS), rpcErr["code"])
+
+	resp = make(map[string]interface{})
+	err = json.Unmarshal(call(t, `{"jsonrpc":"2.0","method":`), &resp)
+	assert.Nil(t, err)
+	rpcErr, ok = resp["error"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, float64(JSONRPC_PARSE_ERROR), rpcErr["code"])
+
+	//notification has no response
+	assert.Equal(t, 0, len(call(t, `{"jsonrpc":"2.0","method":"echo","params":["a"]}`)))
+	assert.Equal(t, 0, len(call(t, `[{"jsonrpc":"2.0","method":"echo","params":["a"]}]`)))
+
+	var batch []map[string]interface{}
+	err = json.Unmarshal(call(t, `[{"jsonrpc":"2.0","method":"echo","params":["a"],"id":1},
+		{"jsonrpc":"2.0","method":"echo","params":["b"]}, 1, {"jsonrpc":"2.0","method":"none","id":2}]`), &batch)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(batch))
+	assert.Equal(t, "a", batch[0]["result"])
+	assert.Equal(t, float64(JSONRPC_INVALID_REQUEST), batch[1]["error"].(map[string]interface{})["code"])
+	assert.Equal(t, float64(JSONRPC_METHOD_NOT_FOUND), batch[2]["error"].(map[string]interface{})["code"])
+
+	resp = make(map[string]interface{})
+	err = json.Unmarshal(call(t, `[]`), &resp)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(JSONRPC_INVALID_REQUEST), resp["error"].(map[string]interface{})["code"])
+}
+
+func TestNegativeHeight(t *testing.T) {
+	addr := "AFmseVrdL9f9oyCzZefL9tG6UbvhPbdYzM"
+	resp := GetBalance([]interface{}{addr, float64(-1)})
+	assert.Equal(t, berr.INVALID_PARAMS, resp["error"])
+	resp = GetAllowance([]interface{}{"ont", addr, addr, float64(-1)})
+	assert.Equal(t, berr.INVALID_PARAMS, resp["error"])
+}
@@ -29,10 +29,8 @@ import (
 	"github.com/ontio/ontology/http/base/rpc"
 )
 
-func StartRPCServer() error {
-	log.Debug()
-	http.HandleFunc("/", rpc.Handle)
-
+//register the methods of json rpc, they are also served by the json rpc endpoint of websocket
+func init() {
 	rpc.HandleFunc("getbestblockhash", rpc.GetBestBlockHash)
 	rpc.HandleFunc("getblock", rpc.GetBlock)
 	rpc.HandleFunc("getblockcount", rpc.GetBlockCount)
@@ -51,14 +49,23 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getmempooltxstate", rpc.GetMemPoolTxState)
 	rpc.HandleFunc("getsmartcodeevent", rpc.GetSmartCodeEvent)
 	rpc.HandleFunc("getblockheightbytxhash", rpc.GetBlockHeightByTxHash)
+	rpc.HandleFunc("gettransactionreceipt", rpc.GetTransactionReceipt)
 
 	rpc.HandleFunc("getbalance", rpc.GetBalance)
+	rpc.HandleFunc("gettransactionsbyaddress", rpc.GetTransactionsByAddress)
+	rpc.HandleFunc("getbalancechanges", rpc.GetBalanceChanges)
 	rpc.HandleFunc("getallowance", rpc.GetAllowance)
 	rpc.HandleFunc("getmerkleproof", rpc.GetMerkleProof)
 	rpc.HandleFunc("getblocktxsbyheight", rpc.GetBlockTxsByHeight)
 	rpc.HandleFunc("getgasprice", rpc.GetGasPrice)
 	rpc.HandleFunc("getunboundong", rpc.GetUnboundOng)
 	rpc.HandleFunc("getgrantong", rpc.GetGrantOng)
+	rpc.HandleFunc("getconsensusstatus", rpc.GetConsensusStatus)
+}
+
+func StartRPCServer() error {
+	log.Debug()
+	http.HandleFunc("/", rpc.Handle)
 
 	err := http.ListenAndServe(":"+strconv.Itoa(int(cfg.DefConfig.Rpc.HttpJsonPort)), nil)
 	if err != nil {
@@ -42,7 +42,11 @@ func StartLocalServer() error {
 	rpc.HandleFunc("getnodestate", rpc.GetNodeState)
 	rpc.HandleFunc("startconsensus", rpc.StartConsensus)
 	rpc.HandleFunc("stopconsensus", rpc.StopConsensus)
+	rpc.HandleFunc("getconsensusstatus", rpc.GetConsensusStatus)
 	rpc.HandleFunc("setdebuginfo", rpc.SetDebugInfo)
+	rpc.HandleFunc("getbanlist", rpc.GetBanList)
+	rpc.HandleFunc("addban", rpc.AddBan)
+	rpc.HandleFunc("removeban", rpc.RemoveBan)
 
 	// TODO: only listen to local host
 	err := http.ListenAndServe(LOCAL_HOST+":"+strconv.Itoa(int(cfg.DefConfig.Rpc.HttpLocalPort)), nil)
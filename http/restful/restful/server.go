@@ -60,8 +60,11 @@ const (
 	GET_BLK_HEIGHT        = "/api/v1/block/height"
 	GET_BLK_HASH          = "/api/v1/block/hash/:height"
 	GET_TX                = "/api/v1/transaction/:hash"
+	GET_TX_RECEIPT        = "/api/v1/transaction/receipt/:hash"
 	GET_STORAGE           = "/api/v1/storage/:hash/:key"
 	GET_BALANCE           = "/api/v1/balance/:addr"
+	GET_ADDRESS_TXS       = "/api/v1/address/transactions/:addr"
+	GET_BALANCE_CHANGES   = "/api/v1/balancechanges/:contract/:addr"
 	GET_CONTRACT_STATE    = "/api/v1/contract/:hash"
 	GET_SMTCOCE_EVT_TXS   = "/api/v1/smartcode/event/transactions/:height"
 	GET_SMTCOCE_EVTS      = "/api/v1/smartcode/event/txhash/:hash"
@@ -140,12 +143,15 @@ func (this *restServer) registryMethod() {
 		GET_BLK_HEIGHT:        {name: "getblockheight", handler: rest.GetBlockHeight},
 		GET_BLK_HASH:          {name: "getblockhash", handler: rest.GetBlockHash},
 		GET_TX:                {name: "gettransaction", handler: rest.GetTransactionByHash},
+		GET_TX_RECEIPT:        {name: "gettransactionreceipt", handler: rest.GetTransactionReceipt},
 		GET_CONTRACT_STATE:    {name: "getcontract", handler: rest.GetContractState},
 		GET_SMTCOCE_EVT_TXS:   {name: "getsmartcodeeventbyheight", handler: rest.GetSmartCodeEventTxsByHeight},
 		GET_SMTCOCE_EVTS:      {name: "getsmartcodeeventbyhash", handler: rest.GetSmartCodeEventByTxHash},
 		GET_BLK_HGT_BY_TXHASH: {name: "getblockheightbytxhash", handler: rest.GetBlockHeightByTxHash},
 		GET_STORAGE:           {name: "getstorage", handler: rest.GetStorage},
 		GET_BALANCE:           {name: "getbalance", handler: rest.GetBalance},
+		GET_ADDRESS_TXS:       {name: "gettransactionsbyaddress", handler: rest.GetTransactionsByAddress},
+		GET_BALANCE_CHANGES:   {name: "getbalancechanges", handler: rest.GetBalanceChanges},
 		GET_ALLOWANCE:         {name: "getallowance", handler: rest.GetAllowance},
 		GET_MERKLE_PROOF:      {name: "getmerkleproof", handler: rest.GetMerkleProof},
 		GET_GAS_PRICE:         {name: "getgasprice", handler: rest.GetGasPrice},
@@ -173,6 +179,8 @@ func (this *restServer) getPath(url string) string {
 		return GET_BLK_HASH
 	} else if strings.Contains(url, strings.TrimRight(GET_BLK_BY_HASH, ":hash")) {
 		return GET_BLK_BY_HASH
+	} else if strings.Contains(url, strings.TrimRight(GET_TX_RECEIPT, ":hash")) {
+		return GET_TX_RECEIPT
 	} else if strings.Contains(url, strings.TrimRight(GET_TX, ":hash")) {
 		return GET_TX
 	} else if strings.Contains(url, strings.TrimRight(GET_CONTRACT_STATE, ":hash")) {
@@ -187,6 +195,10 @@ func (this *restServer) getPath(url string) string {
 		return GET_STORAGE
 	} else if strings.Contains(url, strings.TrimRight(GET_BALANCE, ":addr")) {
 		return GET_BALANCE
+	} else if strings.Contains(url, strings.TrimRight(GET_ADDRESS_TXS, ":addr")) {
+		return GET_ADDRESS_TXS
+	} else if strings.Contains(url, strings.TrimRight(GET_BALANCE_CHANGES, ":contract/:addr")) {
+		return GET_BALANCE_CHANGES
 	} else if strings.Contains(url, strings.TrimRight(GET_MERKLE_PROOF, ":hash")) {
 		return GET_MERKLE_PROOF
 	} else if strings.Contains(url, strings.TrimRight(GET_ALLOWANCE, ":asset/:from/:to")) {
@@ -216,12 +228,15 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 		req["Height"] = getParam(r, "height")
 	case GET_TX:
 		req["Hash"], req["Raw"] = getParam(r, "hash"), r.FormValue("raw")
+	case GET_TX_RECEIPT:
+		req["Hash"] = getParam(r, "hash")
 	case GET_CONTRACT_STATE:
 		req["Hash"], req["Raw"] = getParam(r, "hash"), r.FormValue("raw")
 	case POST_RAW_TX:
 		req["PreExec"] = r.FormValue("preExec")
 	case GET_STORAGE:
 		req["Hash"], req["Key"] = getParam(r, "hash"), getParam(r, "key")
+		req["Height"] = r.FormValue("height")
 	case GET_SMTCOCE_EVT_TXS:
 		req["Height"] = getParam(r, "height")
 	case GET_SMTCOCE_EVTS:
@@ -229,12 +244,20 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 	case GET_BLK_HGT_BY_TXHASH:
 		req["Hash"] = getParam(r, "hash")
 	case GET_BALANCE:
-		req["Addr"] = getParam(r, "addr")
+		req["Addr"], req["Height"] = getParam(r, "addr"), r.FormValue("height")
+	case GET_ADDRESS_TXS:
+		req["Addr"], req["Height"] = getParam(r, "addr"), r.FormValue("height")
+		req["Limit"] = r.FormValue("limit")
+	case GET_BALANCE_CHANGES:
+		req["Contract"], req["Addr"] = getParam(r, "contract"), getParam(r, "addr")
+		req["Start"], req["End"] = r.FormValue("start"), r.FormValue("end")
+		req["Limit"] = r.FormValue("limit")
 	case GET_MERKLE_PROOF:
 		req["Hash"] = getParam(r, "hash")
 	case GET_ALLOWANCE:
 		req["Asset"] = getParam(r, "asset")
 		req["From"], req["To"] = getParam(r, "from"), getParam(r, "to")
+		req["Height"] = r.FormValue("height")
 	case GET_UNBOUNDONG:
 		req["Addr"] = getParam(r, "addr")
 	case GET_GRANTONG:
@@ -38,6 +38,7 @@ var ws *websocket.WsServer
 func StartServer() {
 	bactor.SubscribeEvent(message.TOPIC_SAVE_BLOCK_COMPLETE, sendBlock2WSclient)
 	bactor.SubscribeEvent(message.TOPIC_SMART_CODE_EVENT, pushSmartCodeEvent)
+	bactor.SubscribeEvent(message.TOPIC_NEW_TRANSACTION, pushPendingTransaction)
 	go func() {
 		ws = websocket.InitWsServer()
 		ws.Start()
@@ -48,6 +49,7 @@ func sendBlock2WSclient(v interface{}) {
 		go func() {
 			pushBlock(v)
 			pushBlockTransactions(v)
+			pushNewHead(v)
 		}()
 	}
 }
@@ -83,6 +85,7 @@ func pushSmartCodeEvent(v interface{}) {
 		case *event.ExecuteNotify:
 			contractAddrs, notify := bcomn.GetExecuteNotify(object)
 			pushEvent(contractAddrs, rs.TxHash.ToHexString(), rs.Error, rs.Action, notify)
+			ws.PushLogs(notify)
 		default:
 		}
 	}()
@@ -126,3 +129,28 @@ func pushBlockTransactions(v interface{}) {
 		ws.BroadcastToSubscribers(nil, websocket.WSTOPIC_TXHASHS, resp)
 	}
 }
+
+func pushNewHead(v interface{}) {
+	if ws == nil {
+		return
+	}
+	if block, ok := v.(types.Block); ok {
+		ws.PushNewHead(bcomn.GetBlockInfo(&block).Header)
+		txHashes := make([]string, 0, len(block.Transactions))
+		for _, tx := range block.Transactions {
+			txHash := tx.Hash()
+			txHashes = append(txHashes, txHash.ToHexString())
+		}
+		ws.PushConfirmedTransactions(block.Header.Height, txHashes)
+	}
+}
+
+func pushPendingTransaction(v interface{}) {
+	if ws == nil || cfg.DefConfig.Ws.HttpWsPort == 0 {
+		return
+	}
+	if tx, ok := v.(*types.Transaction); ok {
+		txHash := tx.Hash()
+		go ws.PushPendingTransaction(txHash.ToHexString())
+	}
+}
@@ -44,6 +44,9 @@ type Session struct {
 
 const SESSION_TIMEOUT int64 = 300
 
+//timeout of sending data to client
+const SESSION_SEND_TIMEOUT = 10 * time.Second
+
 //create new session
 func newSession(wsConn *websocket.Conn) *Session {
 	sessionid := uuid.NewUUID().String()
@@ -89,6 +92,7 @@ func (self *Session) Send(data []byte) error {
 		return errors.New("WebSocket is null")
 	}
 
+	self.mConnection.SetWriteDeadline(time.Now().Add(SESSION_SEND_TIMEOUT))
 	return self.mConnection.WriteMessage(websocket.TextMessage, data)
 }
 
@@ -0,0 +1,396 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/json"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	bactor "github.com/ontio/ontology/http/base/actor"
+	bcomn "github.com/ontio/ontology/http/base/common"
+	Err "github.com/ontio/ontology/http/base/error"
+	"github.com/ontio/ontology/http/base/rpc"
+	"github.com/ontio/ontology/http/websocket/session"
+)
+
+//path of the JSON-RPC 2.0 endpoint
+const JSONRPC_PATH = "/jsonrpc"
+
+//subscription kinds of the JSON-RPC endpoint
+const (
+	SUBSCRIBE_NEW_HEADS            = "newHeads"
+	SUBSCRIBE_LOGS                 = "logs"
+	SUBSCRIBE_PENDING_TRANSACTIONS = "pendingTransactions"
+	SUBSCRIBE_TX_STATUS            = "txStatus"
+)
+
+//max number of subscriptions of a session
+const MAX_SESSION_SUBSCRIPTIONS = 100
+
+//status of transaction pushed to txStatus subscription
+const (
+	TX_STATUS_PENDING   = "pending"
+	TX_STATUS_CONFIRMED = "confirmed"
+)
+
+type TxStatus struct {
+	TxHash string
+	Status string
+	Height uint32
+}
+
+//subscription of the JSON-RPC endpoint
+type subscription struct {
+	id        string
+	sessionId string
+	kind      string
+	contracts map[string]bool //contract filter of logs, empty means any contract
+	events    map[string]bool //event name filter of logs, empty means any event
+	txHash    string          //transaction hash of txStatus
+}
+
+//OnJsonRpcDataHandle handle the JSON-RPC request from the session of JSON-RPC endpoint
+func (self *WsServer) OnJsonRpcDataHandle(curSession *session.Session, bysMsg []byte) {
+	sessionId := curSession.GetSessionId()
+	var subscribed []*subscription
+	extra := map[string]func([]interface{}) map[string]interface{}{
+		"subscribe": func(params []interface{}) map[string]interface{} {
+			sub, errCode := self.subscribe(sessionId, params)
+			if errCode != Err.SUCCESS {
+				return jsonRpcResult(errCode, "")
+			}
+			subscribed = append(subscribed, sub)
+			return jsonRpcResult(Err.SUCCESS, sub.id)
+		},
+		"unsubscribe": func(params []interface{}) map[string]interface{} {
+			if len(params) < 1 {
+				return jsonRpcResult(Err.INVALID_PARAMS, "")
+			}
+			id, ok := params[0].(string)
+			if !ok {
+				return jsonRpcResult(Err.INVALID_PARAMS, "")
+			}
+			return jsonRpcResult(Err.SUCCESS, self.unsubscribe(sessionId, id))
+		},
+	}
+	if data := rpc.HandleMessage(bysMsg, false, extra); data != nil {
+		curSession.Send(data)
+	}
+	//push the current status after the subscription id has been responded
+	for _, sub := range subscribed {
+		if sub.kind == SUBSCRIBE_TX_STATUS {
+			self.pushCurrentTxStatus(sub)
+		}
+	}
+}
+
+func (self *WsServer) subscribe(sessionId string, params []interface{}) (*subscription, int64) {
+	if len(params) < 1 {
+		return nil, Err.INVALID_PARAMS
+	}
+	kind, ok := params[0].(string)
+	if !ok {
+		return nil, Err.INVALID_PARAMS
+	}
+	sub := &subscription{sessionId: sessionId, kind: kind}
+	switch kind {
+	case SUBSCRIBE_NEW_HEADS, SUBSCRIBE_PENDING_TRANSACTIONS:
+	case SUBSCRIBE_LOGS:
+		sub.contracts = make(map[string]bool)
+		sub.events = make(map[string]bool)
+		if len(params) < 2 {
+			break
+		}
+		filter, ok := params[1].(map[string]interface{})
+		if !ok {
+			return nil, Err.INVALID_PARAMS
+		}
+		if filter["contracts"] != nil {
+			contracts, ok := filter["contracts"].([]interface{})
+			if !ok {
+				return nil, Err.INVALID_PARAMS
+			}
+			for _, v := range contracts {
+				str, ok := v.(string)
+				if !ok {
+					return nil, Err.INVALID_PARAMS
+				}
+				addr, err := bcomn.GetAddress(str)
+				if err != nil {
+					return nil, Err.INVALID_PARAMS
+				}
+				sub.contracts[addr.ToHexString()] = true
+			}
+		}
+		if filter["events"] != nil {
+			events, ok := filter["events"].([]interface{})
+			if !ok {
+				return nil, Err.INVALID_PARAMS
+			}
+			for _, v := range events {
+				name, ok := v.(string)
+				if !ok {
+					return nil, Err.INVALID_PARAMS
+				}
+				//event name of native contract is string, and that of NeoVM contract is hex string
+				sub.events[name] = true
+				sub.events[common.ToHexString([]byte(name))] = true
+			}
+		}
+	case SUBSCRIBE_TX_STATUS:
+		if len(params) < 2 {
+			return nil, Err.INVALID_PARAMS
+		}
+		str, ok := params[1].(string)
+		if !ok {
+			return nil, Err.INVALID_PARAMS
+		}
+		txHash, err := common.Uint256FromHexString(str)
+		if err != nil {
+			return nil, Err.INVALID_PARAMS
+		}
+		sub.txHash = txHash.ToHexString()
+	default:
+		return nil, Err.INVALID_PARAMS
+	}
+	var id [16]byte
+	_, err := rand.Read(id[:])
+	if err != nil {
+		log.Errorf("websocket subscribe rand.Read error:%s", err)
+		return nil, Err.INTERNAL_ERROR
+	}
+	sub.id = common.ToHexString(id[:])
+
+	self.Lock()
+	defer self.Unlock()
+	if self.JsonRpcSessions[sessionId] >= MAX_SESSION_SUBSCRIPTIONS {
+		return nil, Err.SERVICE_CEILING
+	}
+	self.JsonRpcSessions[sessionId]++
+	self.Subscriptions[sub.id] = sub
+	return sub, Err.SUCCESS
+}
+
+func (self *WsServer) unsubscribe(sessionId string, id string) bool {
+	self.Lock()
+	defer self.Unlock()
+	sub, ok := self.Subscriptions[id]
+	if !ok || sub.sessionId != sessionId {
+		return false
+	}
+	self.removeSubscription(sub)
+	return true
+}
+
+//removeSubscription should be called with lock
+func (self *WsServer) removeSubscription(sub *subscription) {
+	delete(self.Subscriptions, sub.id)
+	if _, ok := self.JsonRpcSessions[sub.sessionId]; ok {
+		self.JsonRpcSessions[sub.sessionId]--
+	}
+}
+
+func (self *WsServer) addJsonRpcSession(sessionId string) {
+	self.Lock()
+	defer self.Unlock()
+	self.JsonRpcSessions[sessionId] = 0
+}
+
+func (self *WsServer) deleteJsonRpcSession(sessionId string) {
+	self.Lock()
+	defer self.Unlock()
+	if _, ok := self.JsonRpcSessions[sessionId]; !ok {
+		return
+	}
+	for id, sub := range self.Subscriptions {
+		if sub.sessionId == sessionId {
+			delete(self.Subscriptions, id)
+		}
+	}
+	delete(self.JsonRpcSessions, sessionId)
+}
+
+//getJsonRpcSession return whether the session is of the JSON-RPC endpoint and has subscriptions
+func (self *WsServer) getJsonRpcSession(sessionId string) (bool, bool) {
+	self.RLock()
+	defer self.RUnlock()
+	count, ok := self.JsonRpcSessions[sessionId]
+	return ok, count > 0
+}
+
+//PushNewHead push the header of new block to newHeads subscriptions
+func (self *WsServer) PushNewHead(header *bcomn.BlockHead) {
+	self.RLock()
+	notifications := make([]notification, 0)
+	for _, sub := range self.Subscriptions {
+		if sub.kind == SUBSCRIBE_NEW_HEADS {
+			notifications = append(notifications, notification{sub, header})
+		}
+	}
+	self.RUnlock()
+	self.notify(notifications)
+}
+
+//PushLogs push the notifications of transaction matching the filter to logs subscriptions
+func (self *WsServer) PushLogs(notify bcomn.ExecuteNotify) {
+	self.RLock()
+	notifications := make([]notification, 0)
+	for _, sub := range self.Subscriptions {
+		if sub.kind != SUBSCRIBE_LOGS {
+			continue
+		}
+		evts := make([]bcomn.NotifyEventInfo, 0)
+		for _, evt := range notify.Notify {
+			if sub.matchLog(evt) {
+				evts = append(evts, evt)
+			}
+		}
+		if len(evts) == 0 {
+			continue
+		}
+		result := notify
+		result.Notify = evts
+		notifications = append(notifications, notification{sub, result})
+	}
+	self.RUnlock()
+	self.notify(notifications)
+}
+
+//PushPendingTransaction push the hash of transaction entering tx pool to pendingTransactions and txStatus subscriptions
+func (self *WsServer) PushPendingTransaction(txHash string) {
+	self.RLock()
+	notifications := make([]notification, 0)
+	for _, sub := range self.Subscriptions {
+		if sub.kind == SUBSCRIBE_PENDING_TRANSACTIONS {
+			notifications = append(notifications, notification{sub, txHash})
+		} else if sub.kind == SUBSCRIBE_TX_STATUS && sub.txHash == txHash {
+			notifications = append(notifications, notification{sub, &TxStatus{TxHash: txHash, Status: TX_STATUS_PENDING}})
+		}
+	}
+	self.RUnlock()
+	self.notify(notifications)
+}
+
+//PushConfirmedTransactions push the confirmed status to txStatus subscriptions of the transactions in block,
+//the subscriptions are removed since the status will not change any more
+func (self *WsServer) PushConfirmedTransactions(height uint32, txHashes []string) {
+	self.Lock()
+	if len(self.Subscriptions) == 0 {
+		self.Unlock()
+		return
+	}
+	confirmed := make(map[string]bool, len(txHashes))
+	for _, txHash := range txHashes {
+		confirmed[txHash] = true
+	}
+	notifications := make([]notification, 0)
+	for _, sub := range self.Subscriptions {
+		if sub.kind == SUBSCRIBE_TX_STATUS && confirmed[sub.txHash] {
+			notifications = append(notifications, notification{sub,
+				&TxStatus{TxHash: sub.txHash, Status: TX_STATUS_CONFIRMED, Height: height}})
+			self.removeSubscription(sub)
+		}
+	}
+	self.Unlock()
+	self.notify(notifications)
+}
+
+func (self *WsServer) pushCurrentTxStatus(sub *subscription) {
+	txHash, _ := common.Uint256FromHexString(sub.txHash)
+	height, tx, err := bactor.GetTxnWithHeightByTxHash(txHash)
+	if err == nil && tx != nil {
+		self.Lock()
+		_, ok := self.Subscriptions[sub.id]
+		if ok {
+			self.removeSubscription(sub)
+		}
+		self.Unlock()
+		if ok {
+			self.notifySubscription(sub, &TxStatus{TxHash: sub.txHash, Status: TX_STATUS_CONFIRMED, Height: height})
+		}
+		return
+	}
+	if _, err := bactor.GetTxFromPool(txHash); err == nil {
+		self.RLock()
+		_, ok := self.Subscriptions[sub.id]
+		self.RUnlock()
+		if ok {
+			self.notifySubscription(sub, &TxStatus{TxHash: sub.txHash, Status: TX_STATUS_PENDING})
+		}
+	}
+}
+
+//notification to be sent to a subscription. Notifications are collected with lock and sent without lock,
+//so that a slow client does not block the others.
+type notification struct {
+	sub    *subscription
+	result interface{}
+}
+
+func (self *WsServer) notify(notifications []notification) {
+	for _, n := range notifications {
+		self.notifySubscription(n.sub, n.result)
+	}
+}
+
+//notifySubscription should be called without lock, it blocks until the data is sent or timeout
+func (self *WsServer) notifySubscription(sub *subscription, result interface{}) {
+	s := self.SessionList.GetSessionById(sub.sessionId)
+	if s == nil {
+		return
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "subscription",
+		"params": map[string]interface{}{
+			"subscription": sub.id,
+			"result":       result,
+		},
+	})
+	if err != nil {
+		log.Infof("Websocket marshal json error:", err)
+		return
+	}
+	s.Send(data)
+}
+
+func (sub *subscription) matchLog(evt bcomn.NotifyEventInfo) bool {
+	if len(sub.contracts) > 0 && !sub.contracts[evt.ContractAddress] {
+		return false
+	}
+	if len(sub.events) == 0 {
+		return true
+	}
+	states, ok := evt.States.([]interface{})
+	if !ok || len(states) == 0 {
+		return false
+	}
+	name, ok := states[0].(string)
+	return ok && sub.events[name]
+}
+
+func jsonRpcResult(errcode int64, result interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"error":  errcode,
+		"desc":   Err.ErrMap[errcode],
+		"result": result,
+	}
+}
@@ -68,6 +68,9 @@ type WsServer struct {
 	ActionMap    map[string]Handler   //handler functions
 	TxHashMap    map[string]string    //key: txHash   value:sessionid
 	SubscribeMap map[string]subscribe //key: sessionId   value:subscribeInfo
+
+	JsonRpcSessions map[string]int           //key: sessionId   value:subscription count of JSON-RPC session
+	Subscriptions   map[string]*subscription //key: subscription id   value:subscription of JSON-RPC session
 }
 
 //init websocket server
@@ -77,6 +80,9 @@ func InitWsServer() *WsServer {
 		SessionList:  session.NewSessionList(),
 		TxHashMap:    make(map[string]string),
 		SubscribeMap: make(map[string]subscribe),
+
+		JsonRpcSessions: make(map[string]int),
+		Subscriptions:   make(map[string]*subscription),
 	}
 	return ws
 }
@@ -183,12 +189,15 @@ func (self *WsServer) registryMethod() {
 		"getsmartcodeeventbyheight": {handler: rest.GetSmartCodeEventTxsByHeight},
 		"getcontract":               {handler: rest.GetContractState},
 		"getbalance":                {handler: rest.GetBalance},
+		"gettransactionsbyaddress":  {handler: rest.GetTransactionsByAddress},
+		"getbalancechanges":         {handler: rest.GetBalanceChanges},
 		"getconnectioncount":        {handler: rest.GetConnectionCount},
 		"getblockbyheight":          {handler: rest.GetBlockByHeight},
 		"getblockhash":              {handler: rest.GetBlockHash},
 		"getblockbyhash":            {handler: rest.GetBlockByHash},
 		"getblockheight":            {handler: rest.GetBlockHeight},
 		"gettransaction":            {handler: rest.GetTransactionByHash},
+		"gettransactionreceipt":     {handler: rest.GetTransactionReceipt},
 		"sendrawtransaction":        {handler: rest.SendRawTransaction, pushFlag: true},
 		"heartbeat":                 {handler: heartbeat},
 		"subscribe":                 {handler: subscribe},
@@ -231,15 +240,22 @@ func (self *WsServer) checkSessionsTimeout(done chan bool) {
 	for {
 		select {
 		case <-ticker.C:
-			var closeList []*session.Session
+			var timeoutList []*session.Session
 			self.SessionList.ForEachSession(func(v *session.Session) {
 				if v.SessionTimeoverCheck() {
-					resp := rest.ResponsePack(Err.SESSION_EXPIRED)
-					v.Send(marshalResp(resp))
-					closeList = append(closeList, v)
+					timeoutList = append(timeoutList, v)
 				}
 			})
-			for _, s := range closeList {
+			for _, s := range timeoutList {
+				jsonRpc, subscribed := self.getJsonRpcSession(s.GetSessionId())
+				//keep the JSON-RPC session alive while it has subscriptions
+				if subscribed {
+					continue
+				}
+				if !jsonRpc {
+					resp := rest.ResponsePack(Err.SESSION_EXPIRED)
+					s.Send(marshalResp(resp))
+				}
 				self.SessionList.CloseSession(s)
 			}
 
@@ -263,8 +279,13 @@ func (self *WsServer) webSocketHandler(w http.ResponseWriter, r *http.Request) {
 		log.Error("websocket NewSession:", err)
 		return
 	}
+	jsonRpc := r.URL.Path == JSONRPC_PATH
+	if jsonRpc {
+		self.addJsonRpcSession(nsSession.GetSessionId())
+	}
 
 	defer func() {
+		self.deleteJsonRpcSession(nsSession.GetSessionId())
 		self.deleteTxHashes(nsSession.GetSessionId())
 		self.deleteSubscribe(nsSession.GetSessionId())
 		self.SessionList.CloseSession(nsSession)
@@ -276,7 +297,10 @@ func (self *WsServer) webSocketHandler(w http.ResponseWriter, r *http.Request) {
 	for {
 		_, bysMsg, err := wsConn.ReadMessage()
 		if err == nil {
-			if self.OnDataHandle(nsSession, bysMsg, r) {
+			if jsonRpc {
+				self.OnJsonRpcDataHandle(nsSession, bysMsg)
+				nsSession.UpdateActiveTime()
+			} else if self.OnDataHandle(nsSession, bysMsg, r) {
 				nsSession.UpdateActiveTime()
 			}
 			continue
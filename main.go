@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
@@ -47,6 +48,7 @@ import (
 	hserver "github.com/ontio/ontology/http/base/actor"
 	"github.com/ontio/ontology/http/jsonrpc"
 	"github.com/ontio/ontology/http/localrpc"
+	"github.com/ontio/ontology/http/metrics"
 	"github.com/ontio/ontology/http/nodeinfo"
 	"github.com/ontio/ontology/http/restful"
 	"github.com/ontio/ontology/http/websocket"
@@ -73,6 +75,8 @@ func setupAPP() *cli.App {
 		cmd.AssetCommand,
 		cmd.ContractCommand,
 		cmd.ImportCommand,
+		cmd.RevertCommand,
+		cmd.SnapshotCommand,
 		cmd.ExportCommand,
 		cmd.TxCommond,
 		cmd.SigTxCommand,
@@ -86,7 +90,12 @@ func setupAPP() *cli.App {
 		utils.ConfigFlag,
 		utils.LogLevelFlag,
 		utils.DisableEventLogFlag,
+		utils.EnableStateArchiveFlag,
+		utils.PruneBlocksFlag,
+		utils.EnableAddressIndexFlag,
+		utils.EnableTransferIndexFlag,
 		utils.DataDirFlag,
+		utils.StoreBackendFlag,
 		//account setting
 		utils.WalletFileFlag,
 		utils.AccountAddressFlag,
@@ -96,10 +105,15 @@ func setupAPP() *cli.App {
 		utils.MaxTxInBlockFlag,
 		//txpool setting
 		utils.GasPriceFlag,
+		utils.GasPriceBumpFlag,
+		utils.TxPoolPayerQuotaFlag,
+		utils.TxPoolPeerQuotaFlag,
 		utils.GasLimitFlag,
 		utils.TxpoolPreExecDisableFlag,
 		utils.DisableSyncVerifyTxFlag,
 		utils.DisableBroadcastNetTxFlag,
+		utils.TxPoolJournalFlag,
+		utils.TxPoolRejournalFlag,
 		//p2p setting
 		utils.ReservedPeersOnlyFlag,
 		utils.ReservedPeersFileFlag,
@@ -109,6 +123,8 @@ func setupAPP() *cli.App {
 		utils.MaxConnInBoundFlag,
 		utils.MaxConnOutBoundFlag,
 		utils.MaxConnInBoundForSingleIPFlag,
+		utils.BanDurationFlag,
+		utils.EnableLinkEncryptionFlag,
 		//test mode setting
 		utils.EnableTestModeFlag,
 		utils.TestModeGenBlockTimeFlag,
@@ -117,6 +133,7 @@ func setupAPP() *cli.App {
 		utils.RPCPortFlag,
 		utils.RPCLocalEnableFlag,
 		utils.RPCLocalProtFlag,
+		utils.RPCCompatibleDisabledFlag,
 		//rest setting
 		utils.RestfulEnableFlag,
 		utils.RestfulPortFlag,
@@ -124,6 +141,9 @@ func setupAPP() *cli.App {
 		//ws setting
 		utils.WsEnabledFlag,
 		utils.WsPortFlag,
+		//metrics setting
+		utils.MetricsEnableFlag,
+		utils.MetricsPortFlag,
 	}
 	app.Before = func(context *cli.Context) error {
 		runtime.GOMAXPROCS(runtime.NumCPU())
@@ -167,7 +187,7 @@ func startOntology(ctx *cli.Context) {
 		log.Errorf("initTxPool error: %s", err)
 		return
 	}
-	p2pSvr, p2pPid, err := initP2PNode(ctx, txpool)
+	p2pSvr, p2pPid, err := initP2PNode(ctx, txpool, acc)
 	if err != nil {
 		log.Errorf("initP2PNode error: %s", err)
 		return
@@ -190,6 +210,7 @@ func startOntology(ctx *cli.Context) {
 	initRestful(ctx)
 	initWs(ctx)
 	initNodeInfo(ctx, p2pSvr)
+	initMetrics(ctx)
 
 	go logCurrBlockHeight()
 	waitToExit(ldg)
@@ -281,6 +302,16 @@ func initTxPool(ctx *cli.Context) (*proc.TXPoolServer, error) {
 	stfValidator, _ := stateful.NewValidator("stateful_validator")
 	stfValidator.Register(txPoolServer.GetPID(tc.VerifyRspActor))
 
+	journal := ctx.GlobalString(utils.GetFlagName(utils.TxPoolJournalFlag))
+	if journal != "" {
+		dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+		rejournal := time.Duration(ctx.GlobalUint(utils.GetFlagName(utils.TxPoolRejournalFlag))) * time.Second
+		err = txPoolServer.StartJournal(filepath.Join(dbDir, journal), rejournal)
+		if err != nil {
+			return nil, fmt.Errorf("Start txpool journal error: %s", err)
+		}
+	}
+
 	hserver.SetTxnPoolPid(txPoolServer.GetPID(tc.TxPoolActor))
 	hserver.SetTxPid(txPoolServer.GetPID(tc.TxActor))
 
@@ -288,7 +319,7 @@ func initTxPool(ctx *cli.Context) (*proc.TXPoolServer, error) {
 	return txPoolServer, nil
 }
 
-func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer) (*p2pserver.P2PServer, *actor.PID, error) {
+func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer, acc *account.Account) (*p2pserver.P2PServer, *actor.PID, error) {
 	if config.DefConfig.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO {
 		return nil, nil, nil
 	}
@@ -300,6 +331,9 @@ func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer) (*p2pserver.P2P
 		return nil, nil, fmt.Errorf("p2pActor init error %s", err)
 	}
 	p2p.SetPID(p2pPID)
+	if acc != nil {
+		p2p.SetIdentity(acc)
+	}
 	err = p2p.Start()
 	if err != nil {
 		return nil, nil, fmt.Errorf("p2p service start error %s", err)
@@ -408,6 +442,20 @@ func initNodeInfo(ctx *cli.Context, p2pSvr *p2pserver.P2PServer) {
 	log.Infof("Nodeinfo init success")
 }
 
+func initMetrics(ctx *cli.Context) {
+	if !config.DefConfig.Metrics.EnableMetrics {
+		return
+	}
+	go func() {
+		err := metrics.StartServer()
+		if err != nil {
+			log.Errorf("metrics server error: %s", err)
+		}
+	}()
+
+	log.Infof("Metrics init success")
+}
+
 func logCurrBlockHeight() {
 	ticker := time.NewTicker(config.DEFAULT_GEN_BLOCK_TIME * time.Second)
 	for {
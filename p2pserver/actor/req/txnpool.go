@@ -38,8 +38,8 @@ func SetTxnPoolPid(txnPid *actor.PID) {
 	txnPoolPid = txnPid
 }
 
-//add txn to txnpool
-func AddTransaction(transaction *types.Transaction) {
+//add txn to txnpool, peerId is the peer which relays it
+func AddTransaction(transaction *types.Transaction, peerId uint64) {
 	if txnPoolPid == nil {
 		log.Error("[p2p]net_server AddTransaction(): txnpool pid is nil")
 		return
@@ -48,10 +48,26 @@ func AddTransaction(transaction *types.Transaction) {
 		Tx:         transaction,
 		Sender:     tc.NetSender,
 		TxResultCh: nil,
+		PeerId:     peerId,
 	}
 	txnPoolPid.Tell(txReq)
 }
 
+//get txns in the pool matching the short ids, nil if missing or ambiguous
+func GetTxnsByShortIDs(ids []uint64) ([]*types.Transaction, error) {
+	if txnPoolPid == nil {
+		log.Warn("[p2p]net_server tx pool pid is nil")
+		return nil, errors.NewErr("[p2p]net_server tx pool pid is nil")
+	}
+	future := txnPoolPid.RequestFuture(&tc.GetTxnsByShortIDReq{IDs: ids}, txnPoolReqTimeout)
+	result, err := future.Result()
+	if err != nil {
+		log.Warnf("[p2p]net_server GetTxnsByShortIDs error: %v\n", err)
+		return nil, err
+	}
+	return result.(*tc.GetTxnsByShortIDRsp).Txs, nil
+}
+
 //get txn according to hash
 func GetTransaction(hash common.Uint256) (*types.Transaction, error) {
 	if txnPoolPid == nil {
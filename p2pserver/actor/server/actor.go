@@ -82,6 +82,12 @@ func (this *P2PActor) Receive(ctx actor.Context) {
 		this.handleGetNodeTypeReq(ctx, msg)
 	case *TransmitConsensusMsgReq:
 		this.handleTransmitConsensusMsgReq(ctx, msg)
+	case *GetBanListReq:
+		this.handleGetBanListReq(ctx, msg)
+	case *AddBanReq:
+		this.handleAddBanReq(ctx, msg)
+	case *RemoveBanReq:
+		this.handleRemoveBanReq(ctx, msg)
 	case *common.AppendPeerID:
 		this.server.OnAddNode(msg.ID)
 	case *common.RemovePeerID:
@@ -206,6 +212,36 @@ func (this *P2PActor) handleGetNodeTypeReq(ctx actor.Context, req *GetNodeTypeRe
 	}
 }
 
+//banned ips and peer ids handler
+func (this *P2PActor) handleGetBanListReq(ctx actor.Context, req *GetBanListReq) {
+	bans := this.server.GetBanList()
+	if ctx.Sender() != nil {
+		resp := &GetBanListRsp{
+			Bans: bans,
+		}
+		ctx.Sender().Request(resp, ctx.Self())
+	}
+}
+
+//ban handler
+func (this *P2PActor) handleAddBanReq(ctx actor.Context, req *AddBanReq) {
+	this.server.AddBan(req.IP, req.ID, req.Duration)
+	if ctx.Sender() != nil {
+		ctx.Sender().Request(&AddBanRsp{}, ctx.Self())
+	}
+}
+
+//remove ban handler
+func (this *P2PActor) handleRemoveBanReq(ctx actor.Context, req *RemoveBanReq) {
+	removed := this.server.RemoveBan(req.IP, req.ID)
+	if ctx.Sender() != nil {
+		resp := &RemoveBanRsp{
+			Removed: removed,
+		}
+		ctx.Sender().Request(resp, ctx.Self())
+	}
+}
+
 func (this *P2PActor) handleTransmitConsensusMsgReq(ctx actor.Context, req *TransmitConsensusMsgReq) {
 	peer := this.server.GetNetWork().GetPeer(req.Target)
 	if peer != nil {
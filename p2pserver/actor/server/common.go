@@ -19,8 +19,11 @@
 package server
 
 import (
+	"time"
+
 	types "github.com/ontio/ontology/p2pserver/common"
 	ptypes "github.com/ontio/ontology/p2pserver/message/types"
+	"github.com/ontio/ontology/p2pserver/peer"
 )
 
 //stop net server
@@ -113,3 +116,34 @@ type TransmitConsensusMsgReq struct {
 	Target uint64
 	Msg    ptypes.Message
 }
+
+//get banned ips and peer ids request
+type GetBanListReq struct {
+}
+
+//response of banned ips and peer ids
+type GetBanListRsp struct {
+	Bans []*peer.BanEntry
+}
+
+//ban an ip or a peer id request
+type AddBanReq struct {
+	IP       string
+	ID       uint64
+	Duration time.Duration
+}
+
+//response of ban request
+type AddBanRsp struct {
+}
+
+//remove the ban of an ip or a peer id request
+type RemoveBanReq struct {
+	IP string
+	ID uint64
+}
+
+//response of remove ban request
+type RemoveBanRsp struct {
+	Removed bool
+}
@@ -87,7 +87,8 @@ const (
 
 //cap flag
 const (
-	HTTP_INFO_FLAG = 0 //peer`s http info bit in cap field
+	HTTP_INFO_FLAG     = 0 //peer`s http info bit in cap field
+	COMPACT_BLOCK_FLAG = 1 //peer`s compact block relay bit in cap field
 )
 
 //actor const
@@ -102,6 +103,38 @@ const (
 	RECENT_LIMIT     = 10 //recent contact list limit
 )
 
+//address book const
+const (
+	ADDR_BOOK_FILE_NAME  = "peers.book"
+	NEW_BUCKET_COUNT     = 256     //buckets of addresses not connected yet
+	TRIED_BUCKET_COUNT   = 64      //buckets of addresses connected before
+	BUCKET_SIZE          = 64      //max addresses in a bucket
+	MAX_ADDRS_PER_SOURCE = 256     //max new addresses learned from one source group
+	ADDR_HORIZON         = 2592000 //secs an address is kept without being seen
+	ADDR_MAX_FAILURES    = 10      //max failed attempts since last success
+	ADDR_RETRY_INTERVAL  = 600     //secs before an address is attempted again
+	SEED_SRV_SERVICE     = "ontology"
+)
+
+//compact block const
+const (
+	MAX_PENDING_CMPCT_BLKS = 16 //max compact blocks waiting for missing txs
+	CMPCT_BLOCK_TIMEOUT    = 10 //secs to wait for the missing txs
+)
+
+//node identity const
+const (
+	NODE_KEY_FILE_NAME = "node.key"
+	HANDSHAKE_TIMEOUT  = 10 //secs to establish the encrypted session
+)
+
+//peer reputation const
+const (
+	BAN_FILE_NAME        = "peers.banned"
+	BAN_SCORE            = 100 //penalty score to ban a peer
+	SCORE_DECAY_INTERVAL = 60  //secs to decrease the penalty score by one
+)
+
 //PeerAddr represent peer`s net information
 type PeerAddr struct {
 	Time     int64    //latest timestamp
@@ -115,22 +148,25 @@ type PeerAddr struct {
 
 //const channel msg id and type
 const (
-	VERSION_TYPE     = "version"    //peer`s information
-	VERACK_TYPE      = "verack"     //ack msg after version recv
-	GetADDR_TYPE     = "getaddr"    //req nbr address from peer
-	ADDR_TYPE        = "addr"       //nbr address
-	PING_TYPE        = "ping"       //ping  sync height
-	PONG_TYPE        = "pong"       //pong  recv nbr height
-	GET_HEADERS_TYPE = "getheaders" //req blk hdr
-	HEADERS_TYPE     = "headers"    //blk hdr
-	INV_TYPE         = "inv"        //inv payload
-	GET_DATA_TYPE    = "getdata"    //req data from peer
-	BLOCK_TYPE       = "block"      //blk payload
-	TX_TYPE          = "tx"         //transaction
-	CONSENSUS_TYPE   = "consensus"  //consensus payload
-	GET_BLOCKS_TYPE  = "getblocks"  //req blks from peer
-	NOT_FOUND_TYPE   = "notfound"   //peer can`t find blk according to the hash
-	DISCONNECT_TYPE  = "disconnect" //peer disconnect info raise by link
+	VERSION_TYPE     = "version"     //peer`s information
+	VERACK_TYPE      = "verack"      //ack msg after version recv
+	GetADDR_TYPE     = "getaddr"     //req nbr address from peer
+	ADDR_TYPE        = "addr"        //nbr address
+	PING_TYPE        = "ping"        //ping  sync height
+	PONG_TYPE        = "pong"        //pong  recv nbr height
+	GET_HEADERS_TYPE = "getheaders"  //req blk hdr
+	HEADERS_TYPE     = "headers"     //blk hdr
+	INV_TYPE         = "inv"         //inv payload
+	GET_DATA_TYPE    = "getdata"     //req data from peer
+	BLOCK_TYPE       = "block"       //blk payload
+	TX_TYPE          = "tx"          //transaction
+	CONSENSUS_TYPE   = "consensus"   //consensus payload
+	GET_BLOCKS_TYPE  = "getblocks"   //req blks from peer
+	NOT_FOUND_TYPE   = "notfound"    //peer can`t find blk according to the hash
+	DISCONNECT_TYPE  = "disconnect"  //peer disconnect info raise by link
+	CMPCT_BLOCK_TYPE = "cmpctblock"  //blk payload with short tx ids
+	GET_BLK_TXN_TYPE = "getblocktxn" //req txs missing in compact blk
+	BLK_TXN_TYPE     = "blocktxn"    //txs missing in compact blk
 )
 
 type AppendPeerID struct {
@@ -31,15 +31,20 @@ import (
 	"github.com/ontio/ontology/p2pserver/message/types"
 )
 
+//MsgErrorHandler is called when a malformed message is received
+type MsgErrorHandler func(id uint64, addr string, err *types.MsgDecodeError)
+
 //Link used to establish
 type Link struct {
-	id        uint64
-	addr      string                 // The address of the node
-	conn      net.Conn               // Connect socket with the peer node
-	port      uint16                 // The server port of the node
-	time      time.Time              // The latest time the node activity
-	recvChan  chan *types.MsgPayload //msgpayload channel
-	reqRecord map[string]int64       //Map RequestId to Timestamp, using for rejecting duplicate request in specific time
+	id         uint64
+	addr       string                 // The address of the node
+	conn       net.Conn               // Connect socket with the peer node
+	port       uint16                 // The server port of the node
+	time       time.Time              // The latest time the node activity
+	recvChan   chan *types.MsgPayload //msgpayload channel
+	reqRecord  map[string]int64       //Map RequestId to Timestamp, using for rejecting duplicate request in specific time
+	msgErrFunc MsgErrorHandler        //handler of the malformed message
+	session    *Session               //encrypted session of the connection
 }
 
 func NewLink() *Link {
@@ -49,6 +54,22 @@ func NewLink() *Link {
 	return link
 }
 
+//SetSession set the encrypted session, which is also the connection
+func (this *Link) SetSession(session *Session) {
+	this.session = session
+	this.conn = session
+}
+
+//GetSession return the encrypted session, nil if the connection is not encrypted
+func (this *Link) GetSession() *Session {
+	return this.session
+}
+
+//SetMsgErrorHandler set the handler of the malformed message
+func (this *Link) SetMsgErrorHandler(handler MsgErrorHandler) {
+	this.msgErrFunc = handler
+}
+
 //SetID set peer id to link
 func (this *Link) SetID(id uint64) {
 	this.id = id
@@ -121,11 +142,15 @@ func (this *Link) Rx() {
 		msg, payloadSize, err := types.ReadMessage(reader)
 		if err != nil {
 			log.Infof("[p2p]error read from %s :%s", this.GetAddr(), err.Error())
+			if decodeErr, ok := err.(*types.MsgDecodeError); ok && this.msgErrFunc != nil {
+				this.msgErrFunc(this.id, this.addr, decodeErr)
+			}
 			break
 		}
 
 		t := time.Now()
 		this.UpdateRXTime(t)
+		RecvBytes.WithLabel(msg.CmdType()).Add(float64(payloadSize + common.MSG_HDR_LEN))
 
 		if !this.needSendMsg(msg) {
 			log.Debugf("skip handle msgType:%s from:%d", msg.CmdType(), this.id)
@@ -170,7 +195,11 @@ func (this *Link) Send(msg types.Message) error {
 	sink := comm.NewZeroCopySink(nil)
 	types.WriteMessage(sink, msg)
 
-	return this.SendRaw(sink.Bytes())
+	err := this.SendRaw(sink.Bytes())
+	if err == nil {
+		SentBytes.WithLabel(msg.CmdType()).Add(float64(sink.Size()))
+	}
+	return err
 }
 
 func (this *Link) SendRaw(rawPacket []byte) error {
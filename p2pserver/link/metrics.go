@@ -0,0 +1,30 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package link
+
+import (
+	"github.com/ontio/ontology/common/metrics"
+)
+
+var (
+	//RecvBytes counts the bytes received by message type
+	RecvBytes = metrics.NewCounterVec("ontology_p2p_recv_bytes_total", "Bytes received from peers by message type", "type")
+	//SentBytes counts the bytes sent by message type
+	SentBytes = metrics.NewCounterVec("ontology_p2p_sent_bytes_total", "Bytes sent to peers by message type", "type")
+)
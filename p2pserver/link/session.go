@@ -0,0 +1,237 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package link
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	comm "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/p2pserver/common"
+	"github.com/ontio/ontology/p2pserver/message/types"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	handshakeProtocol = "ontology-p2p-x25519-chachapoly-sha256"
+	handshakeCmd      = "handshake"
+	sessionKeyInfo    = "ontology-p2p-session-keys"
+	frameHeaderLen    = 4
+	maxFrameLen       = common.MAX_MSG_LEN + chacha20poly1305.Overhead
+)
+
+//Session is the encrypted transport of a link established by a Noise-style
+//handshake with ephemeral X25519 keys. The handshake hash is signed with the
+//identity keys in the version messages to authenticate both sides.
+type Session struct {
+	net.Conn
+	initiator bool
+	hash      [sha256.Size]byte
+	sendLock  sync.Mutex
+	sendAEAD  cipher.AEAD
+	sendNonce uint64
+	recvAEAD  cipher.AEAD
+	recvNonce uint64
+	recvBuf   []byte
+}
+
+//Handshake exchanges the ephemeral keys over the connection, and returns
+//the session encrypting it
+func Handshake(conn net.Conn, initiator bool) (*Session, error) {
+	var priv, pub, remote, shared [32]byte
+	if _, err := io.ReadFull(rand.Reader, priv[:]); err != nil {
+		return nil, fmt.Errorf("[p2p]generate ephemeral key error:%s", err)
+	}
+	curve25519.ScalarBaseMult(&pub, &priv)
+
+	sink := comm.NewZeroCopySink(nil)
+	sink.WriteUint32(config.DefConfig.P2PNode.NetworkMagic)
+	sink.WriteBytes(handshakePrefix())
+	sink.WriteBytes(pub[:])
+	if _, err := conn.Write(sink.Bytes()); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, len(sink.Bytes()))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	source := comm.NewZeroCopySource(buf)
+	magic, _ := source.NextUint32()
+	if magic != config.DefConfig.P2PNode.NetworkMagic {
+		return nil, fmt.Errorf("[p2p]unmatched magic number %d in handshake", magic)
+	}
+	cmd, _ := source.NextBytes(common.MSG_CMD_LEN)
+	if !bytes.Equal(cmd, handshakePrefix()) {
+		return nil, errors.New("[p2p]remote does not support encrypted handshake")
+	}
+	remoteKey, _ := source.NextBytes(uint64(len(remote)))
+	copy(remote[:], remoteKey)
+
+	curve25519.ScalarMult(&shared, &priv, &remote)
+	if shared == [32]byte{} {
+		return nil, errors.New("[p2p]invalid ephemeral key in handshake")
+	}
+
+	// the transcript is always in the order of initiator and responder
+	h := sha256.New()
+	h.Write([]byte(handshakeProtocol))
+	if initiator {
+		h.Write(pub[:])
+		h.Write(remote[:])
+	} else {
+		h.Write(remote[:])
+		h.Write(pub[:])
+	}
+	session := &Session{
+		Conn:      conn,
+		initiator: initiator,
+	}
+	copy(session.hash[:], h.Sum(nil))
+
+	keys := make([]byte, 2*chacha20poly1305.KeySize)
+	kdf := hkdf.New(sha256.New, shared[:], session.hash[:], []byte(sessionKeyInfo))
+	if _, err := io.ReadFull(kdf, keys); err != nil {
+		return nil, fmt.Errorf("[p2p]derive session keys error:%s", err)
+	}
+	initiatorAEAD, err := chacha20poly1305.New(keys[:chacha20poly1305.KeySize])
+	if err != nil {
+		return nil, err
+	}
+	responderAEAD, err := chacha20poly1305.New(keys[chacha20poly1305.KeySize:])
+	if err != nil {
+		return nil, err
+	}
+	if initiator {
+		session.sendAEAD, session.recvAEAD = initiatorAEAD, responderAEAD
+	} else {
+		session.sendAEAD, session.recvAEAD = responderAEAD, initiatorAEAD
+	}
+	return session, nil
+}
+
+//Accept reads the first bytes sent by the inbound peer, and establishes the
+//session if the peer starts the encrypted handshake. Otherwise the peer uses
+//the legacy plaintext link, and the returned connection replays the bytes read
+func Accept(conn net.Conn) (net.Conn, *Session, error) {
+	prefix := make([]byte, common.CMD_OFFSET+common.MSG_CMD_LEN)
+	if _, err := io.ReadFull(conn, prefix); err != nil {
+		return nil, nil, err
+	}
+	replay := &prefixConn{Conn: conn, prefix: prefix}
+	if !bytes.Equal(prefix[common.CMD_OFFSET:], handshakePrefix()) {
+		return replay, nil, nil
+	}
+	session, err := Handshake(replay, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, session, nil
+}
+
+//handshakePrefix returns the command field marking the encrypted handshake,
+//which is never a valid command of the legacy message header
+func handshakePrefix() []byte {
+	var cmd [common.MSG_CMD_LEN]byte
+	copy(cmd[:], handshakeCmd)
+	return cmd[:]
+}
+
+//prefixConn returns the bytes already read before reading the connection
+type prefixConn struct {
+	net.Conn
+	prefix []byte
+}
+
+func (this *prefixConn) Read(buf []byte) (int, error) {
+	if len(this.prefix) > 0 {
+		n := copy(buf, this.prefix)
+		this.prefix = this.prefix[n:]
+		return n, nil
+	}
+	return this.Conn.Read(buf)
+}
+
+//Hash returns the handshake hash of the session
+func (this *Session) Hash() []byte {
+	return this.hash[:]
+}
+
+//Initiator returns whether the local node initiates the session
+func (this *Session) Initiator() bool {
+	return this.initiator
+}
+
+func sessionNonce(n uint64) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(nonce[chacha20poly1305.NonceSize-8:], n)
+	return nonce
+}
+
+//Write encrypts the buffer in a frame and writes it to the connection
+func (this *Session) Write(buf []byte) (int, error) {
+	this.sendLock.Lock()
+	defer this.sendLock.Unlock()
+
+	frame := make([]byte, frameHeaderLen, frameHeaderLen+len(buf)+this.sendAEAD.Overhead())
+	frame = this.sendAEAD.Seal(frame, sessionNonce(this.sendNonce), buf, nil)
+	binary.BigEndian.PutUint32(frame[:frameHeaderLen], uint32(len(frame)-frameHeaderLen))
+	this.sendNonce++
+	if _, err := this.Conn.Write(frame); err != nil {
+		return 0, err
+	}
+	return len(buf), nil
+}
+
+//Read reads a frame from the connection if no decrypted data is left
+func (this *Session) Read(buf []byte) (int, error) {
+	if len(this.recvBuf) == 0 {
+		var header [frameHeaderLen]byte
+		if _, err := io.ReadFull(this.Conn, header[:]); err != nil {
+			return 0, err
+		}
+		length := binary.BigEndian.Uint32(header[:])
+		if length <= uint32(this.recvAEAD.Overhead()) || length > maxFrameLen {
+			return 0, &types.MsgDecodeError{Err: fmt.Errorf("invalid session frame length %d", length)}
+		}
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(this.Conn, frame); err != nil {
+			return 0, err
+		}
+		plain, err := this.recvAEAD.Open(frame[:0], sessionNonce(this.recvNonce), frame, nil)
+		if err != nil {
+			return 0, &types.MsgDecodeError{BadChecksum: true, Err: fmt.Errorf("session frame decryption error:%s", err)}
+		}
+		this.recvNonce++
+		this.recvBuf = plain
+	}
+	n := copy(buf, this.recvBuf)
+	this.recvBuf = this.recvBuf[n:]
+	return n, nil
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package link
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	comm "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/p2pserver/common"
+	"github.com/ontio/ontology/p2pserver/message/types"
+)
+
+func newSessionPair(t *testing.T) (*Session, *Session) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	type result struct {
+		session *Session
+		err     error
+	}
+	done := make(chan result)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			done <- result{nil, err}
+			return
+		}
+		_, session, err := Accept(conn)
+		done <- result{session, err}
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli, err := Handshake(conn, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := <-done
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	return cli, res.session
+}
+
+func TestSessionHandshake(t *testing.T) {
+	cli, srv := newSessionPair(t)
+	defer cli.Close()
+	defer srv.Close()
+
+	if !bytes.Equal(cli.Hash(), srv.Hash()) {
+		t.Fatal("session hash unmatched")
+	}
+	if !cli.Initiator() || srv.Initiator() {
+		t.Fatal("session initiator role wrong")
+	}
+
+	msgs := [][]byte{[]byte("ping"), bytes.Repeat([]byte{0x42}, 4096)}
+	for _, msg := range msgs {
+		if _, err := cli.Write(msg); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, len(msg))
+		n := 0
+		for n < len(buf) {
+			m, err := srv.Read(buf[n:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			n += m
+		}
+		if !bytes.Equal(buf, msg) {
+			t.Fatal("session message unmatched")
+		}
+	}
+}
+
+func TestSessionTamper(t *testing.T) {
+	cli, srv := newSessionPair(t)
+	defer cli.Close()
+	defer srv.Close()
+
+	// write a frame which is not sealed with the session key
+	frame := append([]byte{0, 0, 0, 32}, bytes.Repeat([]byte{0x01}, 32)...)
+	if _, err := cli.Conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+	_, err := srv.Read(make([]byte, 32))
+	decodeErr, ok := err.(*types.MsgDecodeError)
+	if !ok || !decodeErr.BadChecksum {
+		t.Fatalf("tampered frame should fail with bad checksum, got %v", err)
+	}
+}
+
+func TestAcceptLegacy(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	sink := comm.NewZeroCopySink(nil)
+	types.WriteMessage(sink, &types.VerACK{})
+	go func() {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			return
+		}
+		conn.Write(sink.Bytes())
+		conn.Close()
+	}()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	legacy, session, err := Accept(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session != nil {
+		t.Fatal("legacy peer should not establish session")
+	}
+	msg, _, err := types.ReadMessage(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.CmdType() != common.VERACK_TYPE {
+		t.Fatalf("legacy message unmatched: %s", msg.CmdType())
+	}
+}
@@ -56,6 +56,41 @@ func NewBlock(bk *ct.Block, merkleRoot common.Uint256) mt.Message {
 	return &blk
 }
 
+//compact block package
+func NewCompactBlock(bk *ct.Block, merkleRoot common.Uint256) mt.Message {
+	log.Trace()
+	var blk mt.CompactBlock
+	blk.Header = bk.Header
+	blk.MerkleRoot = merkleRoot
+	hashes := make([]common.Uint256, 0, len(bk.Transactions))
+	for _, tx := range bk.Transactions {
+		hashes = append(hashes, tx.Hash())
+	}
+	blk.ShortIDs = mt.TxShortIDs(hashes)
+
+	return &blk
+}
+
+//compact block missing txs req package
+func NewGetBlockTxn(hash common.Uint256, indexes []uint32) mt.Message {
+	log.Trace()
+	var req mt.GetBlockTxn
+	req.BlockHash = hash
+	req.Indexes = indexes
+
+	return &req
+}
+
+//compact block missing txs package
+func NewBlockTxn(hash common.Uint256, txs []*ct.Transaction) mt.Message {
+	log.Trace()
+	var blkTxn mt.BlockTxn
+	blkTxn.BlockHash = hash
+	blkTxn.Txs = txs
+
+	return &blkTxn
+}
+
 //blk hdr package
 func NewHeaders(headers []*ct.RawHeader) mt.Message {
 	log.Trace()
@@ -173,6 +208,7 @@ func NewVersion(n p2pnet.P2P, height uint32) mt.Message {
 	} else {
 		version.P.Cap[msgCommon.HTTP_INFO_FLAG] = 0x00
 	}
+	version.P.Cap[msgCommon.COMPACT_BLOCK_FLAG] = 0x01
 	return &version
 }
 
@@ -205,3 +241,13 @@ func NewConsensusDataReq(hash common.Uint256) mt.Message {
 
 	return &dataReq
 }
+
+//compact block request package
+func NewCompactBlkDataReq(hash common.Uint256) mt.Message {
+	log.Trace()
+	var dataReq mt.DataReq
+	dataReq.DataType = common.COMPACT_BLOCK
+	dataReq.Hash = hash
+
+	return &dataReq
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	comm "github.com/ontio/ontology/p2pserver/common"
+	tc "github.com/ontio/ontology/txnpool/common"
+)
+
+//CompactBlock carries the block header and the short ids of the transactions,
+//the receiver rebuilds the block with the transactions in its tx pool, which
+//indexes them by short id
+type CompactBlock struct {
+	Header     *types.Header
+	ShortIDs   []uint64
+	MerkleRoot common.Uint256
+}
+
+//TxShortIDs returns the short ids of the transaction hashes
+func TxShortIDs(hashes []common.Uint256) []uint64 {
+	ids := make([]uint64, 0, len(hashes))
+	for _, hash := range hashes {
+		ids = append(ids, tc.ShortTxID(hash))
+	}
+	return ids
+}
+
+//Serialize message payload
+func (this *CompactBlock) Serialization(sink *common.ZeroCopySink) {
+	this.Header.Serialization(sink)
+	sink.WriteVarUint(uint64(len(this.ShortIDs)))
+	var buf [8]byte
+	for _, id := range this.ShortIDs {
+		binary.LittleEndian.PutUint64(buf[:], id)
+		sink.WriteBytes(buf[:tc.SHORT_TXID_LEN])
+	}
+	sink.WriteHash(this.MerkleRoot)
+}
+
+func (this *CompactBlock) CmdType() string {
+	return comm.CMPCT_BLOCK_TYPE
+}
+
+//Deserialize message payload
+func (this *CompactBlock) Deserialization(source *common.ZeroCopySource) error {
+	this.Header = new(types.Header)
+	err := this.Header.Deserialization(source)
+	if err != nil {
+		return fmt.Errorf("read header error. err:%v", err)
+	}
+	count, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof || count > source.Len()/tc.SHORT_TXID_LEN {
+		return io.ErrUnexpectedEOF
+	}
+	this.ShortIDs = make([]uint64, 0, count)
+	var buf [8]byte
+	for i := uint64(0); i < count; i++ {
+		id, _ := source.NextBytes(tc.SHORT_TXID_LEN)
+		copy(buf[:], id)
+		this.ShortIDs = append(this.ShortIDs, binary.LittleEndian.Uint64(buf[:]))
+	}
+	this.MerkleRoot, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//GetBlockTxn requests the transactions of the compact block missing in the
+//tx pool by their indexes in the block
+type GetBlockTxn struct {
+	BlockHash common.Uint256
+	Indexes   []uint32
+}
+
+//Serialize message payload
+func (this *GetBlockTxn) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteHash(this.BlockHash)
+	sink.WriteVarUint(uint64(len(this.Indexes)))
+	for _, index := range this.Indexes {
+		sink.WriteUint32(index)
+	}
+}
+
+func (this *GetBlockTxn) CmdType() string {
+	return comm.GET_BLK_TXN_TYPE
+}
+
+//Deserialize message payload
+func (this *GetBlockTxn) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.BlockHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	count, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof || count > source.Len()/common.UINT32_SIZE {
+		return io.ErrUnexpectedEOF
+	}
+	this.Indexes = make([]uint32, 0, count)
+	for i := uint64(0); i < count; i++ {
+		index, _ := source.NextUint32()
+		this.Indexes = append(this.Indexes, index)
+	}
+	return nil
+}
+
+//BlockTxn responds the transactions requested by GetBlockTxn
+type BlockTxn struct {
+	BlockHash common.Uint256
+	Txs       []*types.Transaction
+}
+
+//Serialize message payload
+func (this *BlockTxn) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteHash(this.BlockHash)
+	sink.WriteUint32(uint32(len(this.Txs)))
+	for _, tx := range this.Txs {
+		tx.Serialization(sink)
+	}
+}
+
+func (this *BlockTxn) CmdType() string {
+	return comm.BLK_TXN_TYPE
+}
+
+//Deserialize message payload
+func (this *BlockTxn) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.BlockHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	count, eof := source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	for i := uint32(0); i < count; i++ {
+		tx := new(types.Transaction)
+		if err := tx.Deserialization(source); err != nil {
+			return fmt.Errorf("read tx error. err:%v", err)
+		}
+		this.Txs = append(this.Txs, tx)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/common"
+	ct "github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactBlockSerializationDeserialization(t *testing.T) {
+	header := &ct.Header{
+		Height:      321,
+		Bookkeepers: make([]keypair.PublicKey, 0),
+		SigData:     make([][]byte, 0),
+	}
+	hashes := []common.Uint256{{1}, {2}, {3}}
+	msg := &CompactBlock{
+		Header:     header,
+		ShortIDs:   TxShortIDs(hashes),
+		MerkleRoot: common.Uint256{4},
+	}
+	for _, id := range msg.ShortIDs {
+		assert.True(t, id < 1<<48)
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	WriteMessage(sink, msg)
+	demsg, _, err := ReadMessage(bytes.NewBuffer(sink.Bytes()))
+	assert.Nil(t, err)
+
+	cmpct := demsg.(*CompactBlock)
+	assert.Equal(t, header.Hash(), cmpct.Header.Hash())
+	assert.Equal(t, msg.ShortIDs, cmpct.ShortIDs)
+	assert.Equal(t, msg.MerkleRoot, cmpct.MerkleRoot)
+	assert.Equal(t, []uint64{1, 2, 3}, cmpct.ShortIDs)
+}
+
+func TestGetBlockTxnSerializationDeserialization(t *testing.T) {
+	msg := &GetBlockTxn{
+		BlockHash: common.Uint256{1},
+		Indexes:   []uint32{0, 3, 7},
+	}
+
+	MessageTest(t, msg)
+}
+
+func TestBlockTxnSerializationDeserialization(t *testing.T) {
+	msg := &BlockTxn{
+		BlockHash: common.Uint256{1},
+	}
+
+	MessageTest(t, msg)
+}
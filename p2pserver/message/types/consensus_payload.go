@@ -41,6 +41,7 @@ type ConsensusPayload struct {
 	Owner           keypair.PublicKey
 	Signature       []byte
 	PeerId          uint64
+	PeerPubKey      keypair.PublicKey // identity key authenticated on the link carrying the payload, nil if not
 	hash            common.Uint256
 }
 
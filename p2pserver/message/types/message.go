@@ -43,6 +43,17 @@ type MsgPayload struct {
 	Payload     Message //msg payload
 }
 
+//MsgDecodeError is returned by ReadMessage when the message received is
+//malformed or its checksum mismatches
+type MsgDecodeError struct {
+	BadChecksum bool
+	Err         error
+}
+
+func (this *MsgDecodeError) Error() string {
+	return this.Err.Error()
+}
+
 type messageHeader struct {
 	Magic    uint32
 	CMD      [common.MSG_CMD_LEN]byte // The message type
@@ -110,12 +121,12 @@ func ReadMessage(reader io.Reader) (Message, uint32, error) {
 
 	magic := config.DefConfig.P2PNode.NetworkMagic
 	if hdr.Magic != magic {
-		return nil, 0, fmt.Errorf("unmatched magic number %d, expected %d", hdr.Magic, magic)
+		return nil, 0, &MsgDecodeError{Err: fmt.Errorf("unmatched magic number %d, expected %d", hdr.Magic, magic)}
 	}
 
 	if hdr.Length > common.MAX_PAYLOAD_LEN {
-		return nil, 0, fmt.Errorf("msg payload length:%d exceed max payload size: %d",
-			hdr.Length, common.MAX_PAYLOAD_LEN)
+		return nil, 0, &MsgDecodeError{Err: fmt.Errorf("msg payload length:%d exceed max payload size: %d",
+			hdr.Length, common.MAX_PAYLOAD_LEN)}
 	}
 
 	buf := make([]byte, hdr.Length)
@@ -126,20 +137,21 @@ func ReadMessage(reader io.Reader) (Message, uint32, error) {
 
 	checksum := common.Checksum(buf)
 	if checksum != hdr.Checksum {
-		return nil, 0, fmt.Errorf("message checksum mismatch: %x != %x ", hdr.Checksum, checksum)
+		return nil, 0, &MsgDecodeError{BadChecksum: true,
+			Err: fmt.Errorf("message checksum mismatch: %x != %x ", hdr.Checksum, checksum)}
 	}
 
 	cmdType := string(bytes.TrimRight(hdr.CMD[:], string(0)))
 	msg, err := MakeEmptyMessage(cmdType)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, &MsgDecodeError{Err: err}
 	}
 
 	// the buf is referenced by msg to avoid reallocation, so can not reused
 	source := comm.NewZeroCopySource(buf)
 	err = msg.Deserialization(source)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, &MsgDecodeError{Err: err}
 	}
 
 	return msg, hdr.Length, nil
@@ -179,6 +191,12 @@ func MakeEmptyMessage(cmdType string) (Message, error) {
 		return &Disconnected{}, nil
 	case common.GET_BLOCKS_TYPE:
 		return &BlocksReq{}, nil
+	case common.CMPCT_BLOCK_TYPE:
+		return &CompactBlock{}, nil
+	case common.GET_BLK_TXN_TYPE:
+		return &GetBlockTxn{}, nil
+	case common.BLK_TXN_TYPE:
+		return &BlockTxn{}, nil
 	default:
 		return nil, errors.New("unsupported cmd type:" + cmdType)
 	}
@@ -39,6 +39,8 @@ type VersionPayload struct {
 	Relay       uint8
 	IsConsensus bool
 	SoftVersion string
+	PublicKey   []byte //identity key of the node
+	Signature   []byte //signature of the session handshake by the identity key
 }
 
 type Version struct {
@@ -59,6 +61,8 @@ func (this *Version) Serialization(sink *comm.ZeroCopySink) {
 	sink.WriteUint8(this.P.Relay)
 	sink.WriteBool(this.P.IsConsensus)
 	sink.WriteString(this.P.SoftVersion)
+	sink.WriteVarBytes(this.P.PublicKey)
+	sink.WriteVarBytes(this.P.Signature)
 }
 
 func (this *Version) CmdType() string {
@@ -92,6 +96,17 @@ func (this *Version) Deserialization(source *comm.ZeroCopySource) error {
 	this.P.SoftVersion, _, irregular, eof = source.NextString()
 	if eof || irregular {
 		this.P.SoftVersion = ""
+		return nil
+	}
+
+	this.P.PublicKey, _, irregular, eof = source.NextVarBytes()
+	if eof || irregular {
+		this.P.PublicKey = nil
+		return nil
+	}
+	this.P.Signature, _, irregular, eof = source.NextVarBytes()
+	if eof || irregular {
+		this.P.Signature = nil
 	}
 
 	return nil
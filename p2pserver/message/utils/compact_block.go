@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/types"
+	actor "github.com/ontio/ontology/p2pserver/actor/req"
+	msgCommon "github.com/ontio/ontology/p2pserver/common"
+	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
+)
+
+//pendingCompactBlock is a compact block waiting for the missing txs
+type pendingCompactBlock struct {
+	fromID     uint64
+	block      *types.Block
+	merkleRoot common.Uint256
+	missing    []uint32
+	received   time.Time
+}
+
+//pendingCompactBlocks keeps the compact blocks waiting for the missing txs
+var pendingCompactBlocks = struct {
+	sync.Mutex
+	blocks map[common.Uint256]*pendingCompactBlock
+}{blocks: make(map[common.Uint256]*pendingCompactBlock)}
+
+//addPendingCompactBlock saves the compact block waiting for the missing txs,
+//the expired ones are dropped. It returns false if too many are waiting
+func addPendingCompactBlock(hash common.Uint256, pending *pendingCompactBlock) bool {
+	pendingCompactBlocks.Lock()
+	defer pendingCompactBlocks.Unlock()
+	for h, p := range pendingCompactBlocks.blocks {
+		if time.Since(p.received) > msgCommon.CMPCT_BLOCK_TIMEOUT*time.Second {
+			delete(pendingCompactBlocks.blocks, h)
+		}
+	}
+	if len(pendingCompactBlocks.blocks) >= msgCommon.MAX_PENDING_CMPCT_BLKS {
+		return false
+	}
+	pendingCompactBlocks.blocks[hash] = pending
+	return true
+}
+
+//isPendingCompactBlock returns whether the compact block is waiting for the
+//missing txs
+func isPendingCompactBlock(hash common.Uint256) bool {
+	pendingCompactBlocks.Lock()
+	defer pendingCompactBlocks.Unlock()
+	_, ok := pendingCompactBlocks.blocks[hash]
+	return ok
+}
+
+//takePendingCompactBlock removes and returns the compact block waiting for
+//the txs from the peer
+func takePendingCompactBlock(hash common.Uint256, fromID uint64) *pendingCompactBlock {
+	pendingCompactBlocks.Lock()
+	defer pendingCompactBlocks.Unlock()
+	pending, ok := pendingCompactBlocks.blocks[hash]
+	if !ok || pending.fromID != fromID {
+		return nil
+	}
+	delete(pendingCompactBlocks.blocks, hash)
+	return pending
+}
+
+//rebuildCompactBlock fills the block with the txs in the tx pool matching
+//the short ids, and returns the indexes of the txs missing, including the
+//ones whose short id is ambiguous in the pool. It returns false if the short
+//ids collide in the block
+func rebuildCompactBlock(cmpct *msgTypes.CompactBlock) (*types.Block, []uint32, bool) {
+	ids := make(map[uint64]bool, len(cmpct.ShortIDs))
+	for _, id := range cmpct.ShortIDs {
+		if ids[id] {
+			return nil, nil, false
+		}
+		ids[id] = true
+	}
+	txs, err := actor.GetTxnsByShortIDs(cmpct.ShortIDs)
+	if err != nil || len(txs) != len(cmpct.ShortIDs) {
+		log.Debugf("[p2p]get txs by short ids for compact block fail:%v", err)
+		txs = make([]*types.Transaction, len(cmpct.ShortIDs))
+	}
+
+	block := &types.Block{
+		Header:       cmpct.Header,
+		Transactions: txs,
+	}
+	missing := make([]uint32, 0)
+	for i, tx := range txs {
+		if tx == nil {
+			missing = append(missing, uint32(i))
+		}
+	}
+	return block, missing, true
+}
+
+//checkTxsRoot returns whether the txs rebuilt match the block header
+func checkTxsRoot(block *types.Block) bool {
+	hashes := make([]common.Uint256, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		hashes = append(hashes, tx.Hash())
+	}
+	return common.ComputeMerkleRoot(hashes) == block.Header.TransactionsRoot
+}
@@ -32,12 +32,14 @@ import (
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/core/ledger"
+	scom "github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/ontology/core/types"
 	actor "github.com/ontio/ontology/p2pserver/actor/req"
 	msgCommon "github.com/ontio/ontology/p2pserver/common"
 	"github.com/ontio/ontology/p2pserver/message/msg_pack"
 	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
 	"github.com/ontio/ontology/p2pserver/net/protocol"
+	"github.com/ontio/ontology/p2pserver/peer"
 )
 
 //respCache cache for some response data
@@ -161,24 +163,180 @@ func BlockHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args
 
 	if pid != nil {
 		var block = data.Payload.(*msgTypes.Block)
-		stateHashHeight := config.GetStateHashCheckHeight(config.DefConfig.P2PNode.NetworkId)
-		if block.Blk.Header.Height >= stateHashHeight && block.MerkleRoot == common.UINT256_EMPTY {
-			log.Info("received block msg with empty merkle root")
-			remotePeer := p2p.GetPeer(data.Id)
-			if remotePeer != nil {
-				remotePeer.Close()
-			}
+		appendBlock(data, p2p, pid, block.Blk, block.MerkleRoot, data.PayloadSize)
+	}
+}
 
-			return
+//appendBlock checks the merkle root of the block received, and sends it to
+//the block sync
+func appendBlock(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, block *types.Block,
+	merkleRoot common.Uint256, blockSize uint32) {
+	stateHashHeight := config.GetStateHashCheckHeight(config.DefConfig.P2PNode.NetworkId)
+	if block.Header.Height >= stateHashHeight && merkleRoot == common.UINT256_EMPTY {
+		log.Info("received block msg with empty merkle root")
+		p2p.Penalize(data.Id, data.Addr, peer.InvalidBlock)
+		remotePeer := p2p.GetPeer(data.Id)
+		if remotePeer != nil {
+			remotePeer.Close()
 		}
 
-		input := &msgCommon.AppendBlock{
-			FromID:     data.Id,
-			BlockSize:  data.PayloadSize,
-			Block:      block.Blk,
-			MerkleRoot: block.MerkleRoot,
+		return
+	}
+
+	input := &msgCommon.AppendBlock{
+		FromID:     data.Id,
+		BlockSize:  blockSize,
+		Block:      block,
+		MerkleRoot: merkleRoot,
+	}
+	pid.Tell(input)
+}
+
+// CompactBlockHandle handles the compact block message from peer, the block
+// is rebuilt with the txs in the tx pool, and the missing txs are requested
+func CompactBlockHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	log.Trace("[p2p]receive compact block message from ", data.Addr, data.Id)
+
+	if pid == nil {
+		return
+	}
+	remotePeer := p2p.GetPeer(data.Id)
+	if remotePeer == nil {
+		log.Debug("[p2p]remotePeer invalid in CompactBlockHandle")
+		return
+	}
+	var cmpct = data.Payload.(*msgTypes.CompactBlock)
+	hash := cmpct.Header.Hash()
+	isContainBlock, err := ledger.DefLedger.IsContainBlock(hash)
+	if err != nil || isContainBlock || isPendingCompactBlock(hash) {
+		return
+	}
+	//the header must be the next block proposed and signed by the bookkeepers
+	//before looking up the tx pool, other blocks are left to the block sync
+	if cmpct.Header.Height != ledger.DefLedger.GetCurrentBlockHeight()+1 {
+		return
+	}
+	if err := ledger.DefLedger.VerifyHeader(cmpct.Header); err != nil {
+		log.Debugf("[p2p]verify compact block %s header fail:%s", hash.ToHexString(), err)
+		if scom.IsVerifyError(err) {
+			p2p.Penalize(data.Id, data.Addr, peer.InvalidHeader)
 		}
-		pid.Tell(input)
+		return
+	}
+
+	block, missing, ok := rebuildCompactBlock(cmpct)
+	if !ok {
+		requestFullBlock(p2p, remotePeer, hash)
+		return
+	}
+	if len(missing) == 0 {
+		appendCompactBlock(data, p2p, pid, remotePeer, block, cmpct.MerkleRoot)
+		return
+	}
+	pending := &pendingCompactBlock{
+		fromID:     data.Id,
+		block:      block,
+		merkleRoot: cmpct.MerkleRoot,
+		missing:    missing,
+		received:   time.Now(),
+	}
+	if !addPendingCompactBlock(hash, pending) {
+		requestFullBlock(p2p, remotePeer, hash)
+		return
+	}
+	log.Debugf("[p2p]request %d missing txs of compact block %s", len(missing), hash.ToHexString())
+	msg := msgpack.NewGetBlockTxn(hash, missing)
+	err = p2p.Send(remotePeer, msg)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+}
+
+// GetBlockTxnHandle handles the request of the txs missing in the compact block
+func GetBlockTxnHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	log.Trace("[p2p]receive get block txn message from ", data.Addr, data.Id)
+
+	remotePeer := p2p.GetPeer(data.Id)
+	if remotePeer == nil {
+		log.Debug("[p2p]remotePeer invalid in GetBlockTxnHandle")
+		return
+	}
+	var req = data.Payload.(*msgTypes.GetBlockTxn)
+	block, err := ledger.DefLedger.GetBlockByHash(req.BlockHash)
+	if err != nil || block == nil || block.Header == nil {
+		log.Debug("[p2p]can't get block by hash: ", req.BlockHash,
+			" ,send not found message")
+		msg := msgpack.NewNotFound(req.BlockHash)
+		err := p2p.Send(remotePeer, msg)
+		if err != nil {
+			log.Warn(err)
+		}
+		return
+	}
+	txs := make([]*types.Transaction, 0, len(req.Indexes))
+	for _, index := range req.Indexes {
+		if int(index) >= len(block.Transactions) {
+			log.Debugf("[p2p]invalid tx index %d of block %s", index, req.BlockHash.ToHexString())
+			p2p.Penalize(data.Id, data.Addr, peer.MalformedMsg)
+			return
+		}
+		txs = append(txs, block.Transactions[index])
+	}
+	msg := msgpack.NewBlockTxn(req.BlockHash, txs)
+	err = p2p.Send(remotePeer, msg)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+}
+
+// BlockTxnHandle handles the txs missing in the compact block from peer
+func BlockTxnHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	log.Trace("[p2p]receive block txn message from ", data.Addr, data.Id)
+
+	if pid == nil {
+		return
+	}
+	remotePeer := p2p.GetPeer(data.Id)
+	if remotePeer == nil {
+		log.Debug("[p2p]remotePeer invalid in BlockTxnHandle")
+		return
+	}
+	var blkTxn = data.Payload.(*msgTypes.BlockTxn)
+	pending := takePendingCompactBlock(blkTxn.BlockHash, data.Id)
+	if pending == nil {
+		log.Debug("[p2p]no compact block waiting for txs: ", blkTxn.BlockHash)
+		return
+	}
+	if len(blkTxn.Txs) != len(pending.missing) {
+		requestFullBlock(p2p, remotePeer, blkTxn.BlockHash)
+		return
+	}
+	for i, index := range pending.missing {
+		pending.block.Transactions[index] = blkTxn.Txs[i]
+	}
+	appendCompactBlock(data, p2p, pid, remotePeer, pending.block, pending.merkleRoot)
+}
+
+//appendCompactBlock appends the block rebuilt from the compact block, the full
+//block is requested if the txs rebuilt mismatch the header
+func appendCompactBlock(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, remotePeer *peer.Peer,
+	block *types.Block, merkleRoot common.Uint256) {
+	if !checkTxsRoot(block) {
+		log.Debug("[p2p]compact block rebuilt with mismatched txs: ", block.Hash())
+		requestFullBlock(p2p, remotePeer, block.Hash())
+		return
+	}
+	appendBlock(data, p2p, pid, block, merkleRoot, uint32(len(block.ToArray())))
+}
+
+//requestFullBlock falls back to request the full block from the peer
+func requestFullBlock(p2p p2p.P2P, remotePeer *peer.Peer, hash common.Uint256) {
+	msg := msgpack.NewBlkDataReq(hash)
+	err := p2p.Send(remotePeer, msg)
+	if err != nil {
+		log.Warn(err)
 	}
 }
 
@@ -190,9 +348,13 @@ func ConsensusHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID,
 		var consensus = data.Payload.(*msgTypes.Consensus)
 		if err := consensus.Cons.Verify(); err != nil {
 			log.Warn(err)
+			p2p.Penalize(data.Id, data.Addr, peer.ConsensusSpam)
 			return
 		}
 		consensus.Cons.PeerId = data.Id
+		if remotePeer := p2p.GetPeer(data.Id); remotePeer != nil {
+			consensus.Cons.PeerPubKey = remotePeer.GetPubKey()
+		}
 		actor.ConsensusPid.Tell(&consensus.Cons)
 	}
 }
@@ -208,7 +370,7 @@ func TransactionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID
 	log.Trace("[p2p]receive transaction message", data.Addr, data.Id)
 
 	var trn = data.Payload.(*msgTypes.Trn)
-	actor.AddTransaction(trn.Txn)
+	actor.AddTransaction(trn.Txn, data.Id)
 	log.Trace("[p2p]receive Transaction message hash", trn.Txn.Hash())
 
 }
@@ -250,6 +412,22 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 
 	}
 
+	if p2p.GetReputation().IsBanned(version.P.Nonce, data.Addr) {
+		log.Debugf("[p2p]peer %d %s is banned, close", version.P.Nonce, data.Addr)
+		remotePeer.Close()
+		return
+	}
+
+	if session := remotePeer.Link.GetSession(); session != nil {
+		pubKey, err := peer.VerifyVersion(version, session)
+		if err != nil {
+			log.Warnf("[p2p]peer %d %s identity verify fail:%s, close", version.P.Nonce, data.Addr, err)
+			remotePeer.Close()
+			return
+		}
+		remotePeer.SetPubKey(pubKey)
+	}
+
 	if version.P.Nonce == p2p.GetID() {
 		p2p.RemoveFromInConnRecord(remotePeer.GetAddr())
 		p2p.RemoveFromOutConnRecord(remotePeer.GetAddr())
@@ -308,6 +486,7 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 		remotePeer.SetHttpInfoState(false)
 	}
 	remotePeer.SetHttpInfoPort(version.P.HttpInfoPort)
+	remotePeer.SetCompactBlockState(version.P.Cap[msgCommon.COMPACT_BLOCK_FLAG] == 0x01)
 
 	remotePeer.UpdateInfo(time.Now(), version.P.Version,
 		version.P.Services, version.P.SyncPort, version.P.Nonce,
@@ -326,6 +505,11 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 	if s == msgCommon.INIT {
 		remotePeer.SetState(msgCommon.HAND_SHAKE)
 		msg = msgpack.NewVersion(p2p, ledger.DefLedger.GetCurrentBlockHeight())
+		if err := p2p.SignVersion(msg.(*msgTypes.Version), remotePeer); err != nil {
+			log.Warn(err)
+			remotePeer.Close()
+			return
+		}
 	} else if s == msgCommon.HAND {
 		remotePeer.SetState(msgCommon.HAND_SHAKED)
 		msg = msgpack.NewVerAck()
@@ -359,6 +543,13 @@ func VerAckHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, arg
 	p2p.RemoveFromConnectingList(data.Addr)
 	remotePeer.DumpInfo()
 
+	if p2p.IsAddrInOutConnRecord(data.Addr) {
+		p2p.GetAddrBook().Good(data.Addr)
+	} else if addrIp, err := msgCommon.ParseIPAddr(data.Addr); err == nil && remotePeer.GetPort() != 0 {
+		//the listening address of the inbound peer
+		p2p.GetAddrBook().AddAddress(addrIp+":"+strconv.Itoa(int(remotePeer.GetPort())), data.Addr)
+	}
+
 	if s == msgCommon.HAND_SHAKE {
 		msg := msgpack.NewVerAck()
 		p2p.Send(remotePeer, msg)
@@ -374,6 +565,7 @@ func AddrHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args
 	log.Trace("[p2p]handle addr message", data.Addr, data.Id)
 
 	var msg = data.Payload.(*msgTypes.Addr)
+	addrBook := p2p.GetAddrBook()
 	for _, v := range msg.NodeAddrs {
 		var ip net.IP
 		ip = v.IpAddr[:]
@@ -383,23 +575,14 @@ func AddrHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args
 			continue
 		}
 
-		if p2p.NodeEstablished(v.ID) {
-			continue
-		}
-
-		if ret := p2p.GetPeerFromAddr(address); ret != nil {
-			continue
-		}
-
 		if v.Port == 0 {
 			continue
 		}
-		if p2p.IsAddrFromConnecting(address) {
-			continue
+		if addrBook.AddAddress(address, data.Addr) {
+			log.Debug("[p2p]add ip address to address book:", address)
 		}
-		log.Debug("[p2p]connect ip address:", address)
-		go p2p.Connect(address)
 	}
+	p2p.ConnectAddrBook()
 }
 
 // DataReqHandle handles the data req(block/Transaction) from peer
@@ -416,7 +599,7 @@ func DataReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 	reqType := common.InventoryType(dataReq.DataType)
 	hash := dataReq.Hash
 	switch reqType {
-	case common.BLOCK:
+	case common.BLOCK, common.COMPACT_BLOCK:
 		reqID := fmt.Sprintf("%x%s", reqType, hash.ToHexString())
 		data := getRespCacheValue(reqID)
 		var msg msgTypes.Message
@@ -424,6 +607,8 @@ func DataReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 			switch data.(type) {
 			case *msgTypes.Block:
 				msg = data.(*msgTypes.Block)
+			case *msgTypes.CompactBlock:
+				msg = data.(*msgTypes.CompactBlock)
 			}
 		}
 		if msg == nil {
@@ -452,7 +637,11 @@ func DataReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 				}
 				return
 			}
-			msg = msgpack.NewBlock(block, merkleRoot)
+			if reqType == common.COMPACT_BLOCK {
+				msg = msgpack.NewCompactBlock(block, merkleRoot)
+			} else {
+				msg = msgpack.NewBlock(block, merkleRoot)
+			}
 			saveRespCache(reqID, msg)
 		}
 		err := p2p.Send(remotePeer, msg)
@@ -532,6 +721,9 @@ func InvHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args .
 				// send the block request
 				log.Infof("[p2p]inv request block hash: %x", id)
 				msg := msgpack.NewBlkDataReq(id)
+				if remotePeer.GetCompactBlockState() {
+					msg = msgpack.NewCompactBlkDataReq(id)
+				}
 				err = p2p.Send(remotePeer, msg)
 				if err != nil {
 					log.Warn(err)
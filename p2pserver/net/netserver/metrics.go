@@ -0,0 +1,34 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package netserver
+
+import (
+	"github.com/ontio/ontology/common/metrics"
+)
+
+const metricsCollector = "p2p"
+
+var peersGauge = metrics.NewGaugeVec("ontology_p2p_peers", "Count of peers by connection state", "state")
+
+//collectMetrics updates the peer count metrics
+func (this *NetServer) collectMetrics() {
+	peersGauge.WithLabel("inbound").Set(float64(this.GetInConnRecordLen()))
+	peersGauge.WithLabel("outbound").Set(float64(this.GetOutConnRecordLen()))
+	peersGauge.WithLabel("established").Set(float64(this.GetConnectionCnt()))
+}
@@ -26,10 +26,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/common/metrics"
 	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/p2pserver/common"
+	"github.com/ontio/ontology/p2pserver/link"
 	"github.com/ontio/ontology/p2pserver/message/msg_pack"
 	"github.com/ontio/ontology/p2pserver/message/types"
 	"github.com/ontio/ontology/p2pserver/net/protocol"
@@ -60,6 +63,9 @@ type NetServer struct {
 	inConnRecord  InConnectionRecord
 	outConnRecord OutConnectionRecord
 	OwnAddress    string //network`s own address(ip : sync port),which get from version check
+	reputation    *peer.Reputation
+	identity      *account.Account
+	addrBook      *peer.AddrBook
 }
 
 //InConnectionRecord include all addr connected
@@ -113,6 +119,9 @@ func (this *NetServer) init() error {
 	log.Infof("[p2p]init peer ID to %d", this.base.GetID())
 	this.Np = &peer.NbrPeers{}
 	this.Np.Init()
+	this.reputation = peer.NewReputation(common.BAN_FILE_NAME)
+	this.addrBook = peer.NewAddrBook(common.ADDR_BOOK_FILE_NAME)
+	metrics.RegisterCollector(metricsCollector, this.collectMetrics)
 
 	return nil
 }
@@ -247,6 +256,10 @@ func (this *NetServer) Connect(addr string) error {
 	if !this.AddrValid(addr) {
 		return nil
 	}
+	if this.reputation.IsBanned(0, addr) {
+		log.Debugf("[p2p]Address: %s is banned", addr)
+		return errors.New("[p2p]connect: address is banned")
+	}
 
 	this.connectLock.Lock()
 	connCount := uint(this.GetOutConnRecordLen())
@@ -267,6 +280,7 @@ func (this *NetServer) Connect(addr string) error {
 	}
 	this.connectLock.Unlock()
 
+	this.addrBook.Attempt(addr)
 	isTls := config.DefConfig.P2PNode.IsTLS
 	var conn net.Conn
 	var err error
@@ -275,6 +289,7 @@ func (this *NetServer) Connect(addr string) error {
 		conn, err = TLSDial(addr)
 		if err != nil {
 			this.RemoveFromConnectingList(addr)
+			this.addrBook.Failed(addr)
 			log.Debugf("[p2p]connect %s failed:%s", addr, err.Error())
 			return err
 		}
@@ -282,6 +297,7 @@ func (this *NetServer) Connect(addr string) error {
 		conn, err = nonTLSDial(addr)
 		if err != nil {
 			this.RemoveFromConnectingList(addr)
+			this.addrBook.Failed(addr)
 			log.Debugf("[p2p]connect %s failed:%s", addr, err.Error())
 			return err
 		}
@@ -292,16 +308,37 @@ func (this *NetServer) Connect(addr string) error {
 		conn.LocalAddr().String(), conn.RemoteAddr().String(),
 		conn.RemoteAddr().Network())
 
+	var session *link.Session
+	if this.encryptLink() {
+		session, err = handshake(conn, true)
+		if err != nil {
+			this.RemoveFromConnectingList(addr)
+			this.addrBook.Failed(addr)
+			log.Debugf("[p2p]handshake with %s failed:%s", addr, err.Error())
+			return err
+		}
+	}
+
 	this.AddOutConnRecord(addr)
 	remotePeer = peer.NewPeer()
 	this.AddPeerAddress(addr, remotePeer)
 	remotePeer.Link.SetAddr(addr)
-	remotePeer.Link.SetConn(conn)
+	if session != nil {
+		remotePeer.Link.SetSession(session)
+	} else {
+		remotePeer.Link.SetConn(conn)
+	}
+	remotePeer.Link.SetMsgErrorHandler(this.onMsgError)
 	remotePeer.AttachChan(this.NetChan)
 	go remotePeer.Link.Rx()
 	remotePeer.SetState(common.HAND)
 
 	version := msgpack.NewVersion(this, ledger.DefLedger.GetCurrentBlockHeight())
+	if err := this.SignVersion(version.(*types.Version), remotePeer); err != nil {
+		this.RemoveFromOutConnRecord(addr)
+		log.Warn(err)
+		return err
+	}
 	err = remotePeer.Send(version)
 	if err != nil {
 		this.RemoveFromOutConnRecord(addr)
@@ -313,6 +350,7 @@ func (this *NetServer) Connect(addr string) error {
 
 //Halt stop all net layer logic
 func (this *NetServer) Halt() {
+	metrics.UnregisterCollector(metricsCollector)
 	peers := this.Np.GetNeighbors()
 	for _, p := range peers {
 		p.Close()
@@ -373,6 +411,12 @@ func (this *NetServer) startNetAccept(listener net.Listener) {
 			continue
 		}
 
+		if this.reputation.IsBanned(0, conn.RemoteAddr().String()) {
+			log.Debugf("[p2p]remote %s is banned, close it ", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+
 		if this.IsAddrInInConnRecord(conn.RemoteAddr().String()) {
 			conn.Close()
 			continue
@@ -407,10 +451,45 @@ func (this *NetServer) startNetAccept(listener net.Listener) {
 		this.AddPeerAddress(addr, remotePeer)
 
 		remotePeer.Link.SetAddr(addr)
-		remotePeer.Link.SetConn(conn)
-		remotePeer.AttachChan(this.NetChan)
-		go remotePeer.Link.Rx()
+		go this.acceptSession(conn, remotePeer)
+	}
+}
+
+//acceptSession establishes the encrypted session with the inbound peer
+//starting the handshake, the legacy peer keeps the plaintext connection
+func (this *NetServer) acceptSession(conn net.Conn, remotePeer *peer.Peer) {
+	addr := conn.RemoteAddr().String()
+	conn.SetDeadline(time.Now().Add(common.HANDSHAKE_TIMEOUT * time.Second))
+	linkConn, session, err := link.Accept(conn)
+	if err != nil {
+		log.Debugf("[p2p]handshake with %s failed:%s", addr, err.Error())
+		this.RemoveFromInConnRecord(addr)
+		this.RemovePeerAddress(addr)
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	if session != nil {
+		remotePeer.Link.SetSession(session)
+	} else {
+		remotePeer.Link.SetConn(linkConn)
+	}
+	remotePeer.Link.SetMsgErrorHandler(this.onMsgError)
+	remotePeer.AttachChan(this.NetChan)
+	go remotePeer.Link.Rx()
+}
+
+//handshake establishes the encrypted session over the connection in time
+func handshake(conn net.Conn, initiator bool) (*link.Session, error) {
+	conn.SetDeadline(time.Now().Add(common.HANDSHAKE_TIMEOUT * time.Second))
+	session, err := link.Handshake(conn, initiator)
+	if err != nil {
+		conn.Close()
+		return nil, err
 	}
+	conn.SetDeadline(time.Time{})
+	return session, nil
 }
 
 //record the peer which is going to be dialed and sent version message but not in establish state
@@ -666,3 +745,88 @@ func (this *NetServer) SetOwnAddress(addr string) {
 	}
 
 }
+
+//GetReputation return the reputation of peers
+func (this *NetServer) GetReputation() *peer.Reputation {
+	return this.reputation
+}
+
+//Penalize penalize the peer for the misbehavior, and close the connection
+//if it is banned
+func (this *NetServer) Penalize(id uint64, addr string, m peer.Misbehavior) {
+	p := this.GetPeer(id)
+	if addr == "" && p != nil {
+		addr = p.GetAddr()
+	}
+	if p == nil && addr != "" {
+		p = this.GetPeerFromAddr(addr)
+	}
+	if this.reputation.Penalize(id, addr, m) && p != nil {
+		p.Close()
+	}
+}
+
+//encryptLink return whether to start the encrypted handshake with the
+//outbound peer, which is opt-in before the activation height
+func (this *NetServer) encryptLink() bool {
+	if config.DefConfig.P2PNode.EnableLinkEncryption {
+		return true
+	}
+	height := config.GetLinkEncryptionHeight(config.DefConfig.P2PNode.NetworkId)
+	return ledger.DefLedger.GetCurrentBlockHeight() >= height
+}
+
+//onMsgError penalize the peer sending the malformed message
+func (this *NetServer) onMsgError(id uint64, addr string, err *types.MsgDecodeError) {
+	if err.BadChecksum {
+		this.Penalize(id, addr, peer.BadChecksum)
+	} else {
+		this.Penalize(id, addr, peer.MalformedMsg)
+	}
+}
+
+//SetIdentity set the identity key of the node, the peer id is bound to it
+func (this *NetServer) SetIdentity(acc *account.Account) {
+	this.identity = acc
+	this.base.SetID(peer.IDFromPubKey(acc.PublicKey))
+	log.Infof("[p2p]init peer ID to %d with identity key", this.base.GetID())
+}
+
+//GetIdentity return the identity key of the node
+func (this *NetServer) GetIdentity() *account.Account {
+	return this.identity
+}
+
+//SignVersion prove the identity of the node in the version message sent to
+//the peer with encrypted session
+func (this *NetServer) SignVersion(version *types.Version, p *peer.Peer) error {
+	session := p.Link.GetSession()
+	if session == nil || this.identity == nil {
+		return nil
+	}
+	return peer.SignVersion(this.identity, version, session)
+}
+
+//GetAddrBook return the address book of the node
+func (this *NetServer) GetAddrBook() *peer.AddrBook {
+	return this.addrBook
+}
+
+//ConnectAddrBook connect the candidates picked from the address book until
+//the out connections reach the limit
+func (this *NetServer) ConnectAddrBook() {
+	free := int(config.DefConfig.P2PNode.MaxConnOutBound) - this.GetOutConnRecordLen()
+	picked := make(map[string]bool)
+	for ; free > 0; free-- {
+		addr := this.addrBook.Select(func(addr string) bool {
+			return picked[addr] || this.IsAddrInOutConnRecord(addr) || this.IsAddrFromConnecting(addr) ||
+				this.GetPeerFromAddr(addr) != nil || this.IsOwnAddress(addr) || this.reputation.IsBanned(0, addr)
+		})
+		if addr == "" {
+			return
+		}
+		picked[addr] = true
+		log.Debug("[p2p]connect address book candidate:", addr)
+		go this.Connect(addr)
+	}
+}
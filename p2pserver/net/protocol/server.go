@@ -20,6 +20,7 @@
 package p2p
 
 import (
+	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/p2pserver/common"
 	"github.com/ontio/ontology/p2pserver/message/types"
 	"github.com/ontio/ontology/p2pserver/peer"
@@ -63,4 +64,12 @@ type P2P interface {
 	SetOwnAddress(addr string)
 	IsOwnAddress(addr string) bool
 	IsAddrFromConnecting(addr string) bool
+	IsAddrInOutConnRecord(addr string) bool
+	GetReputation() *peer.Reputation
+	Penalize(id uint64, addr string, m peer.Misbehavior)
+	SetIdentity(acc *account.Account)
+	GetIdentity() *account.Account
+	SignVersion(version *types.Version, p *peer.Peer) error
+	GetAddrBook() *peer.AddrBook
+	ConnectAddrBook()
 }
@@ -32,6 +32,7 @@ import (
 	"time"
 
 	evtActor "github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/account"
 	comm "github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
@@ -92,6 +93,13 @@ func (this *P2PServer) GetConnectionCnt() uint32 {
 //Start create all services
 func (this *P2PServer) Start() error {
 	if this.network != nil {
+		if this.network.GetIdentity() == nil {
+			acc, err := peer.LoadIdentity(common.NODE_KEY_FILE_NAME)
+			if err != nil {
+				return err
+			}
+			this.network.SetIdentity(acc)
+		}
 		this.network.Start()
 	} else {
 		return errors.New("[p2p]network invalid")
@@ -110,9 +118,15 @@ func (this *P2PServer) Start() error {
 	return nil
 }
 
+//SetIdentity set the key which authenticates the node to its peers
+func (this *P2PServer) SetIdentity(acc *account.Account) {
+	this.network.SetIdentity(acc)
+}
+
 //Stop halt all service by send signal to channels
 func (this *P2PServer) Stop() {
 	this.network.Halt()
+	this.network.GetAddrBook().Save()
 	this.quitSyncRecent <- true
 	this.quitOnline <- true
 	this.quitHeartBeat <- true
@@ -184,6 +198,30 @@ func (this *P2PServer) GetID() uint64 {
 	return this.network.GetID()
 }
 
+// GetBanList returns the banned ips and peer ids
+func (this *P2PServer) GetBanList() []*peer.BanEntry {
+	return this.network.GetReputation().GetBans()
+}
+
+// AddBan bans the ip or the peer id for the duration, and closes the
+// connections of the banned peers
+func (this *P2PServer) AddBan(ip string, id uint64, duration time.Duration) {
+	reputation := this.network.GetReputation()
+	reputation.Ban(ip, id, duration, "manual")
+	for _, p := range this.network.GetNeighbors() {
+		if reputation.IsBanned(p.GetID(), p.GetAddr()) {
+			log.Infof("[p2p]close banned peer %d %s", p.GetID(), p.GetAddr())
+			p.Close()
+		}
+	}
+}
+
+// RemoveBan removes the ban of the ip or the peer id, and returns false
+// if neither is banned
+func (this *P2PServer) RemoveBan(ip string, id uint64) bool {
+	return this.network.GetReputation().Unban(ip, id)
+}
+
 // OnAddNode adds the peer id to the block sync mgr
 func (this *P2PServer) OnAddNode(id uint64) {
 	this.blockSync.OnAddNode(id)
@@ -277,15 +315,54 @@ func (this *P2PServer) WaitForPeersStart() {
 	}
 }
 
+//lookupSeedRecords resolve the peer addresses published by the seed domain
+//in SRV records of the ontology service and TXT records of ip:port lists
+func lookupSeedRecords(host string) []string {
+	addrs := make([]string, 0)
+	if net.ParseIP(host) != nil {
+		return addrs
+	}
+	_, srvs, err := net.LookupSRV(common.SEED_SRV_SERVICE, "tcp", host)
+	if err == nil {
+		for _, srv := range srvs {
+			ns, err := net.LookupHost(srv.Target)
+			if err != nil || len(ns) == 0 {
+				continue
+			}
+			addrs = append(addrs, ns[0]+":"+strconv.Itoa(int(srv.Port)))
+		}
+	}
+	txts, err := net.LookupTXT(host)
+	if err == nil {
+		for _, txt := range txts {
+			for _, addr := range strings.Fields(strings.Replace(txt, ",", " ", -1)) {
+				ip, port, err := net.SplitHostPort(addr)
+				if err != nil || net.ParseIP(ip) == nil || port == "0" {
+					continue
+				}
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs
+}
+
 //connectSeeds connect the seeds in seedlist and call for nbr list
 func (this *P2PServer) connectSeeds() {
 	seedNodes := make([]string, 0)
+	addrBook := this.network.GetAddrBook()
+	newCnt, triedCnt := addrBook.Size()
 	for _, n := range config.DefConfig.Genesis.SeedList {
 		ip, err := common.ParseIPAddr(n)
 		if err != nil {
 			log.Warnf("[p2p]seed peer %s address format is wrong", n)
 			continue
 		}
+		if newCnt+triedCnt < common.BUCKET_SIZE {
+			for _, addr := range lookupSeedRecords(ip) {
+				addrBook.AddAddress(addr, n)
+			}
+		}
 		ns, err := net.LookupHost(ip)
 		if err != nil {
 			log.Warnf("[p2p]resolve err: %s", err.Error())
@@ -466,6 +543,7 @@ func (this *P2PServer) keepOnlineService() {
 		select {
 		case <-t.C:
 			this.retryInactivePeer()
+			this.network.ConnectAddrBook()
 			t.Stop()
 			t.Reset(time.Second * common.CONN_MONITOR)
 		case <-this.quitOnline:
@@ -591,6 +669,7 @@ func (this *P2PServer) syncUpRecentPeers() {
 		select {
 		case <-t.C:
 			this.syncPeerAddr()
+			this.network.GetAddrBook().Save()
 		case <-this.quitSyncRecent:
 			t.Stop()
 			break
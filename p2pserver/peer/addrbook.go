@@ -0,0 +1,380 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package peer
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	mrand "math/rand"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	comm "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/p2pserver/common"
+)
+
+// KnownAddress is an address in the address book
+type KnownAddress struct {
+	Addr        string `json:"addr"`
+	Source      string `json:"source"`
+	LastSeen    int64  `json:"last_seen"`
+	LastAttempt int64  `json:"last_attempt"`
+	LastSuccess int64  `json:"last_success"`
+	LastFailure int64  `json:"last_failure"`
+	Attempts    int    `json:"attempts"`
+	Tried       bool   `json:"tried"`
+}
+
+// isTerrible returns whether the address is not worth keeping
+func (this *KnownAddress) isTerrible(now int64) bool {
+	if this.LastAttempt > now-60 {
+		return false
+	}
+	if this.LastSeen < now-common.ADDR_HORIZON {
+		return true
+	}
+	if this.LastSuccess == 0 && this.Attempts >= common.MAX_RETRY_COUNT {
+		return true
+	}
+	return this.Attempts >= common.ADDR_MAX_FAILURES
+}
+
+// chance returns the relative chance the address is selected
+func (this *KnownAddress) chance(now int64) float64 {
+	c := 1.0
+	if now-this.LastAttempt < common.ADDR_RETRY_INTERVAL && this.LastAttempt > this.LastSuccess {
+		c *= 0.01
+	}
+	for i := 0; i < this.Attempts && i < 8; i++ {
+		c *= 0.66
+	}
+	return c
+}
+
+// addrBookFile is the persisted content of the address book
+type addrBookFile struct {
+	NetworkMagic uint32          `json:"network_magic"`
+	Key          string          `json:"key"`
+	Addrs        []*KnownAddress `json:"addrs"`
+}
+
+// AddrBook keeps the addresses learned from seeds and peers in new buckets,
+// and moves them to tried buckets once connected. The bucket of an address
+// is decided by a secret key and the network group of the address and its
+// source, so one source can only fill a few buckets
+type AddrBook struct {
+	sync.Mutex
+	path         string
+	key          [32]byte
+	addrs        map[string]*KnownAddress
+	newBuckets   []map[string]*KnownAddress
+	triedBuckets []map[string]*KnownAddress
+	sources      map[string]int
+}
+
+// NewAddrBook returns the address book persisted in the path, an empty path
+// means the address book is not persisted
+func NewAddrBook(path string) *AddrBook {
+	this := &AddrBook{
+		path:         path,
+		addrs:        make(map[string]*KnownAddress),
+		newBuckets:   make([]map[string]*KnownAddress, common.NEW_BUCKET_COUNT),
+		triedBuckets: make([]map[string]*KnownAddress, common.TRIED_BUCKET_COUNT),
+		sources:      make(map[string]int),
+	}
+	for i := range this.newBuckets {
+		this.newBuckets[i] = make(map[string]*KnownAddress)
+	}
+	for i := range this.triedBuckets {
+		this.triedBuckets[i] = make(map[string]*KnownAddress)
+	}
+	if path != "" && comm.FileExisted(path) && this.load() {
+		return this
+	}
+	if _, err := rand.Read(this.key[:]); err != nil {
+		log.Warnf("[p2p]generate address book key fail:%s", err)
+	}
+	return this
+}
+
+// load reads the persisted address book, and returns false if nothing is loaded
+func (this *AddrBook) load() bool {
+	buf, err := ioutil.ReadFile(this.path)
+	if err != nil {
+		log.Warnf("[p2p]read %s fail:%s, address book is not loaded", this.path, err)
+		return false
+	}
+	var file addrBookFile
+	if err := json.Unmarshal(buf, &file); err != nil {
+		log.Warnf("[p2p]parse %s fail:%s, address book is not loaded", this.path, err)
+		return false
+	}
+	if file.NetworkMagic != config.DefConfig.P2PNode.NetworkMagic {
+		return false
+	}
+	key, err := hex.DecodeString(file.Key)
+	if err != nil || len(key) != len(this.key) {
+		log.Warnf("[p2p]invalid key in %s, address book is not loaded", this.path)
+		return false
+	}
+	copy(this.key[:], key)
+	for _, ka := range file.Addrs {
+		if _, ok := this.addrs[ka.Addr]; ok {
+			continue
+		}
+		if ka.Tried {
+			bucket := this.triedBuckets[this.triedBucket(ka.Addr)]
+			if len(bucket) < common.BUCKET_SIZE {
+				bucket[ka.Addr] = ka
+				this.addrs[ka.Addr] = ka
+				continue
+			}
+			ka.Tried = false
+		}
+		this.addNew(ka)
+	}
+	return true
+}
+
+// addrGroup returns the network group of the address, which is the /16 of
+// an ipv4 address or the /32 of an ipv6 address
+func addrGroup(addr string) string {
+	host := addr
+	if i := strings.LastIndex(addr, ":"); i > 0 {
+		host = addr[:i]
+	}
+	host = strings.Trim(host, "[]")
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return net.IP(ip4[:2]).String()
+	}
+	return hex.EncodeToString(ip[:4])
+}
+
+// bucketIndex maps the parts to a bucket with the secret key
+func (this *AddrBook) bucketIndex(count int, parts ...string) int {
+	h := sha256.New()
+	h.Write(this.key[:])
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return int(binary.LittleEndian.Uint64(h.Sum(nil)) % uint64(count))
+}
+
+func (this *AddrBook) newBucket(addr, source string) int {
+	return this.bucketIndex(common.NEW_BUCKET_COUNT, "new", addrGroup(source), addrGroup(addr))
+}
+
+func (this *AddrBook) triedBucket(addr string) int {
+	return this.bucketIndex(common.TRIED_BUCKET_COUNT, "tried", addrGroup(addr), addr)
+}
+
+// AddAddress adds the address learned from the source to the new buckets,
+// and returns false if the address is known or rejected
+func (this *AddrBook) AddAddress(addr, source string) bool {
+	now := time.Now().Unix()
+	this.Lock()
+	defer this.Unlock()
+	if ka, ok := this.addrs[addr]; ok {
+		ka.LastSeen = now
+		return false
+	}
+	if this.sources[addrGroup(source)] >= common.MAX_ADDRS_PER_SOURCE {
+		log.Debugf("[p2p]too many addresses from %s, %s is ignored", source, addr)
+		return false
+	}
+	return this.addNew(&KnownAddress{Addr: addr, Source: source, LastSeen: now})
+}
+
+// addNew adds the address to its new bucket, evicting the terrible or the
+// oldest address if the bucket is full, should be called with lock
+func (this *AddrBook) addNew(ka *KnownAddress) bool {
+	bucket := this.newBuckets[this.newBucket(ka.Addr, ka.Source)]
+	if len(bucket) >= common.BUCKET_SIZE {
+		now := time.Now().Unix()
+		var oldest *KnownAddress
+		for _, old := range bucket {
+			if old.isTerrible(now) {
+				this.removeNew(old)
+			} else if oldest == nil || old.LastSeen < oldest.LastSeen {
+				oldest = old
+			}
+		}
+		if len(bucket) >= common.BUCKET_SIZE {
+			this.removeNew(oldest)
+		}
+	}
+	bucket[ka.Addr] = ka
+	this.addrs[ka.Addr] = ka
+	this.sources[addrGroup(ka.Source)]++
+	return true
+}
+
+// removeNew removes the address from the new buckets, should be called with lock
+func (this *AddrBook) removeNew(ka *KnownAddress) {
+	delete(this.newBuckets[this.newBucket(ka.Addr, ka.Source)], ka.Addr)
+	delete(this.addrs, ka.Addr)
+	group := addrGroup(ka.Source)
+	this.sources[group]--
+	if this.sources[group] <= 0 {
+		delete(this.sources, group)
+	}
+}
+
+// Attempt records a connection attempt to the address
+func (this *AddrBook) Attempt(addr string) {
+	this.Lock()
+	defer this.Unlock()
+	if ka, ok := this.addrs[addr]; ok {
+		ka.LastAttempt = time.Now().Unix()
+		ka.Attempts++
+	}
+}
+
+// Failed records a failed connection to the address
+func (this *AddrBook) Failed(addr string) {
+	this.Lock()
+	defer this.Unlock()
+	if ka, ok := this.addrs[addr]; ok {
+		ka.LastFailure = time.Now().Unix()
+	}
+}
+
+// Good records a successful connection to the address, and moves it to the
+// tried buckets. The oldest address in a full tried bucket is moved back to
+// the new buckets
+func (this *AddrBook) Good(addr string) {
+	now := time.Now().Unix()
+	this.Lock()
+	defer this.Unlock()
+	ka, ok := this.addrs[addr]
+	if !ok {
+		ka = &KnownAddress{Addr: addr, Source: addr}
+	} else if !ka.Tried {
+		this.removeNew(ka)
+	}
+	ka.LastSeen = now
+	ka.LastSuccess = now
+	ka.Attempts = 0
+	if ka.Tried {
+		return
+	}
+
+	bucket := this.triedBuckets[this.triedBucket(addr)]
+	if len(bucket) >= common.BUCKET_SIZE {
+		var oldest *KnownAddress
+		for _, old := range bucket {
+			if oldest == nil || old.LastSuccess < oldest.LastSuccess {
+				oldest = old
+			}
+		}
+		delete(bucket, oldest.Addr)
+		delete(this.addrs, oldest.Addr)
+		oldest.Tried = false
+		this.addNew(oldest)
+	}
+	ka.Tried = true
+	bucket[addr] = ka
+	this.addrs[addr] = ka
+}
+
+// Select picks an address to connect from the tried or the new buckets with
+// even chance, addresses attempted many times recently are less likely to be
+// picked. It returns empty string if no address is available
+func (this *AddrBook) Select(exclude func(addr string) bool) string {
+	now := time.Now().Unix()
+	this.Lock()
+	defer this.Unlock()
+	tried := make([]*KnownAddress, 0)
+	fresh := make([]*KnownAddress, 0)
+	for _, ka := range this.addrs {
+		if exclude != nil && exclude(ka.Addr) {
+			continue
+		}
+		if ka.Tried {
+			tried = append(tried, ka)
+		} else {
+			fresh = append(fresh, ka)
+		}
+	}
+	for len(tried) > 0 || len(fresh) > 0 {
+		table := &fresh
+		if len(fresh) == 0 || (len(tried) > 0 && mrand.Intn(2) == 0) {
+			table = &tried
+		}
+		i := mrand.Intn(len(*table))
+		ka := (*table)[i]
+		if mrand.Float64() < ka.chance(now) {
+			return ka.Addr
+		}
+		//drop the address in this round to make the selection terminate
+		(*table)[i] = (*table)[len(*table)-1]
+		*table = (*table)[:len(*table)-1]
+	}
+	return ""
+}
+
+// Size returns the number of addresses in the new and the tried buckets
+func (this *AddrBook) Size() (int, int) {
+	this.Lock()
+	defer this.Unlock()
+	tried := 0
+	for _, bucket := range this.triedBuckets {
+		tried += len(bucket)
+	}
+	return len(this.addrs) - tried, tried
+}
+
+// Save persists the address book
+func (this *AddrBook) Save() {
+	if this.path == "" {
+		return
+	}
+	this.Lock()
+	file := addrBookFile{
+		NetworkMagic: config.DefConfig.P2PNode.NetworkMagic,
+		Key:          hex.EncodeToString(this.key[:]),
+		Addrs:        make([]*KnownAddress, 0, len(this.addrs)),
+	}
+	for _, ka := range this.addrs {
+		file.Addrs = append(file.Addrs, ka)
+	}
+	buf, err := json.Marshal(file)
+	this.Unlock()
+	if err != nil {
+		log.Warnf("[p2p]package address book fail:%s", err)
+		return
+	}
+	if err := ioutil.WriteFile(this.path, buf, os.ModePerm); err != nil {
+		log.Warnf("[p2p]write %s fail:%s", this.path, err)
+	}
+}
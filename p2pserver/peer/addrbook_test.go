@@ -0,0 +1,91 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package peer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology/p2pserver/common"
+)
+
+func TestAddrBookSourceLimit(t *testing.T) {
+	book := NewAddrBook("")
+	added := 0
+	for i := 0; i < 2*common.MAX_ADDRS_PER_SOURCE; i++ {
+		addr := fmt.Sprintf("%d.%d.0.1:20338", i/256+1, i%256)
+		if book.AddAddress(addr, "192.168.1.1:20338") {
+			added++
+		}
+	}
+	if added > common.MAX_ADDRS_PER_SOURCE {
+		t.Errorf("source added %d addresses over the limit", added)
+	}
+	//the same source group is limited
+	if book.AddAddress("10.200.0.1:20338", "192.168.2.2:20338") {
+		t.Errorf("address added over the source group limit")
+	}
+	if !book.AddAddress("10.200.0.1:20338", "172.16.0.1:20338") {
+		t.Errorf("address from another source rejected")
+	}
+	if book.AddAddress("10.200.0.1:20338", "172.17.0.1:20338") {
+		t.Errorf("known address added again")
+	}
+}
+
+func TestAddrBookGood(t *testing.T) {
+	dir, err := ioutil.TempDir("", "addrbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "peers.book")
+
+	book := NewAddrBook(path)
+	if book.Select(nil) != "" {
+		t.Errorf("address selected from empty book")
+	}
+	book.AddAddress("10.0.0.1:20338", "192.168.1.1:20338")
+	book.AddAddress("10.1.0.1:20338", "192.168.1.1:20338")
+	book.Attempt("10.0.0.1:20338")
+	book.Good("10.0.0.1:20338")
+	if newCnt, triedCnt := book.Size(); newCnt != 1 || triedCnt != 1 {
+		t.Errorf("expect 1 new and 1 tried, got %d and %d", newCnt, triedCnt)
+	}
+
+	addr := book.Select(func(addr string) bool {
+		return addr == "10.1.0.1:20338"
+	})
+	if addr != "10.0.0.1:20338" {
+		t.Errorf("unexpected selected address %s", addr)
+	}
+
+	//address book is persisted
+	book.Save()
+	loaded := NewAddrBook(path)
+	if newCnt, triedCnt := loaded.Size(); newCnt != 1 || triedCnt != 1 {
+		t.Errorf("expect 1 new and 1 tried loaded, got %d and %d", newCnt, triedCnt)
+	}
+	if loaded.key != book.key {
+		t.Errorf("bucket key not loaded")
+	}
+}
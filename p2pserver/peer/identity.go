@@ -0,0 +1,120 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package peer
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/ontio/ontology/account"
+	comm "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	conn "github.com/ontio/ontology/p2pserver/link"
+	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
+)
+
+//IDFromPubKey return the peer id bound to the identity key
+func IDFromPubKey(pub keypair.PublicKey) uint64 {
+	hash := sha256.Sum256(keypair.SerializePublicKey(pub))
+	return binary.LittleEndian.Uint64(hash[:8])
+}
+
+//LoadIdentity load the identity key of the node from the file, a new key
+//is generated and saved if the file does not exist
+func LoadIdentity(path string) (*account.Account, error) {
+	if !comm.FileExisted(path) {
+		acc := account.NewAccount("")
+		data := hex.EncodeToString(keypair.SerializePrivateKey(acc.PrivateKey))
+		if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+			return nil, fmt.Errorf("[p2p]write %s fail:%s", path, err)
+		}
+		log.Infof("[p2p]new identity key saved in %s", path)
+		return acc, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("[p2p]read %s fail:%s", path, err)
+	}
+	buf, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("[p2p]decode %s fail:%s", path, err)
+	}
+	pri, err := keypair.DeserializePrivateKey(buf)
+	if err != nil {
+		return nil, fmt.Errorf("[p2p]parse %s fail:%s", path, err)
+	}
+	pub := pri.Public()
+	return &account.Account{
+		PrivateKey: pri,
+		PublicKey:  pub,
+		Address:    types.AddressFromPubKey(pub),
+		SigScheme:  s.SHA256withECDSA,
+	}, nil
+}
+
+//handshakeData return the data signed by the side of the session
+func handshakeData(session *conn.Session, initiator bool, id uint64) []byte {
+	sink := comm.NewZeroCopySink(nil)
+	sink.WriteBytes(session.Hash())
+	sink.WriteBool(initiator)
+	sink.WriteUint64(id)
+	return sink.Bytes()
+}
+
+//SignVersion prove the ownership of the identity key to the remote of the
+//session in the version message
+func SignVersion(acc *account.Account, version *msgTypes.Version, session *conn.Session) error {
+	sig, err := signature.Sign(acc, handshakeData(session, session.Initiator(), version.P.Nonce))
+	if err != nil {
+		return err
+	}
+	version.P.PublicKey = keypair.SerializePublicKey(acc.PublicKey)
+	version.P.Signature = sig
+	return nil
+}
+
+//VerifyVersion verify the identity key in the version message is owned by
+//the remote of the session and bound to the peer id, and return the key
+func VerifyVersion(version *msgTypes.Version, session *conn.Session) (keypair.PublicKey, error) {
+	if len(version.P.PublicKey) == 0 || len(version.P.Signature) == 0 {
+		return nil, errors.New("no identity key in version")
+	}
+	pub, err := keypair.DeserializePublicKey(version.P.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity key:%s", err)
+	}
+	if IDFromPubKey(pub) != version.P.Nonce {
+		return nil, fmt.Errorf("peer id %d is not bound to the identity key", version.P.Nonce)
+	}
+	err = signature.Verify(pub, handshakeData(session, !session.Initiator(), version.P.Nonce), version.P.Signature)
+	if err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
@@ -26,6 +26,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/ontio/ontology-crypto/keypair"
 	comm "github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/p2pserver/common"
@@ -134,6 +135,7 @@ type Peer struct {
 	txnCnt    uint64
 	rxTxnCnt  uint64
 	connLock  sync.RWMutex
+	pubKey    keypair.PublicKey
 }
 
 //NewPeer return new peer without publickey initial
@@ -199,7 +201,11 @@ func (this *Peer) GetPort() uint16 {
 //SendTo call sync link to send buffer
 func (this *Peer) SendRaw(msgType string, msgPayload []byte) error {
 	if this.Link != nil && this.Link.Valid() {
-		return this.Link.SendRaw(msgPayload)
+		err := this.Link.SendRaw(msgPayload)
+		if err == nil {
+			conn.SentBytes.WithLabel(msgType).Add(float64(len(msgPayload)))
+		}
+		return err
 	}
 	return errors.New("[p2p]sync link invalid")
 }
@@ -293,6 +299,20 @@ func (this *Peer) GetHttpInfoState() bool {
 	return this.cap[common.HTTP_INFO_FLAG] == 1
 }
 
+//SetCompactBlockState set whether the peer relays compact blocks
+func (this *Peer) SetCompactBlockState(compact bool) {
+	if compact {
+		this.cap[common.COMPACT_BLOCK_FLAG] = 0x01
+	} else {
+		this.cap[common.COMPACT_BLOCK_FLAG] = 0x00
+	}
+}
+
+//GetCompactBlockState return whether the peer relays compact blocks
+func (this *Peer) GetCompactBlockState() bool {
+	return this.cap[common.COMPACT_BLOCK_FLAG] == 1
+}
+
 //GetHttpInfoPort return peer`s httpinfo port
 func (this *Peer) GetHttpInfoPort() uint16 {
 	return this.base.GetHttpInfoPort()
@@ -303,6 +323,16 @@ func (this *Peer) SetHttpInfoPort(port uint16) {
 	this.base.SetHttpInfoPort(port)
 }
 
+//SetPubKey set peer`s identity key authenticated in handshake
+func (this *Peer) SetPubKey(pub keypair.PublicKey) {
+	this.pubKey = pub
+}
+
+//GetPubKey return peer`s identity key, nil if not authenticated
+func (this *Peer) GetPubKey() keypair.PublicKey {
+	return this.pubKey
+}
+
 //UpdateInfo update peer`s information
 func (this *Peer) UpdateInfo(t time.Time, version uint32, services uint64,
 	syncPort uint16, nonce uint64, relay uint8, height uint64, softVer string) {
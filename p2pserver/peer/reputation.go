@@ -0,0 +1,303 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package peer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	comm "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/p2pserver/common"
+)
+
+//Misbehavior is the kind of misbehavior a peer is penalized for
+type Misbehavior uint8
+
+const (
+	MalformedMsg  Misbehavior = iota //message can not be decoded
+	BadChecksum                      //message checksum mismatch
+	InvalidHeader                    //header failed to verify by the ledger
+	InvalidBlock                     //block failed to verify by the ledger
+	ConsensusSpam                    //consensus message failed to verify
+)
+
+//penalties of the misbehaviors
+var penalties = map[Misbehavior]int{
+	MalformedMsg:  50,
+	BadChecksum:   50,
+	InvalidHeader: 50,
+	InvalidBlock:  50,
+	ConsensusSpam: 10,
+}
+
+func (this Misbehavior) String() string {
+	switch this {
+	case MalformedMsg:
+		return "malformed message"
+	case BadChecksum:
+		return "bad checksum"
+	case InvalidHeader:
+		return "invalid header"
+	case InvalidBlock:
+		return "invalid block"
+	case ConsensusSpam:
+		return "consensus spam"
+	}
+	return "unknown misbehavior"
+}
+
+//BanEntry is a banned ip or peer id
+type BanEntry struct {
+	IP     string `json:"ip,omitempty"`
+	ID     uint64 `json:"id,string,omitempty"`
+	Until  int64  `json:"until"`
+	Reason string `json:"reason"`
+}
+
+//score is the penalty score of an ip, decreased over time
+type score struct {
+	value   int
+	updated time.Time
+}
+
+//decayed returns the score decreased by the time passed since it was updated
+func (this *score) decayed(now time.Time) int {
+	value := this.value - int(now.Sub(this.updated)/(common.SCORE_DECAY_INTERVAL*time.Second))
+	if value < 0 {
+		return 0
+	}
+	return value
+}
+
+//Reputation scores the misbehaviors of peers and bans the ip and peer id
+//once the score reaches BAN_SCORE
+type Reputation struct {
+	sync.Mutex
+	path   string
+	scores map[string]*score
+	swept  time.Time //the last time the scores decayed to 0 were dropped
+	ipBans map[string]*BanEntry
+	idBans map[uint64]*BanEntry
+}
+
+//NewReputation returns the reputation with the bans persisted in the path,
+//an empty path means the bans are not persisted
+func NewReputation(path string) *Reputation {
+	this := &Reputation{
+		path:   path,
+		scores: make(map[string]*score),
+		ipBans: make(map[string]*BanEntry),
+		idBans: make(map[uint64]*BanEntry),
+	}
+	if path == "" || !comm.FileExisted(path) {
+		return this
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Warnf("[p2p]read %s fail:%s, bans are not loaded", path, err)
+		return this
+	}
+	var bans []*BanEntry
+	if err := json.Unmarshal(buf, &bans); err != nil {
+		log.Warnf("[p2p]parse %s fail:%s, bans are not loaded", path, err)
+		return this
+	}
+	for _, ban := range bans {
+		this.addBan(ban)
+	}
+	return this
+}
+
+//banIP returns the ip part of the address
+func banIP(addr string) string {
+	ip, err := common.ParseIPAddr(addr)
+	if err != nil {
+		return addr
+	}
+	return ip
+}
+
+//Penalize increases the penalty score of the peer by the misbehavior, and
+//returns true if the peer is banned
+func (this *Reputation) Penalize(id uint64, addr string, m Misbehavior) bool {
+	ip := banIP(addr)
+	now := time.Now()
+
+	this.Lock()
+	defer this.Unlock()
+	this.sweepScores(now)
+	s, ok := this.scores[ip]
+	if !ok {
+		s = &score{updated: now}
+		this.scores[ip] = s
+	}
+	s.value = s.decayed(now)
+	s.updated = now
+	s.value += penalties[m]
+	log.Infof("[p2p]peer %d %s penalized for %s, score %d", id, addr, m, s.value)
+	if s.value < common.BAN_SCORE {
+		return false
+	}
+
+	delete(this.scores, ip)
+	until := now.Add(time.Duration(config.DefConfig.P2PNode.BanDuration) * time.Second).Unix()
+	this.addBan(&BanEntry{IP: ip, ID: id, Until: until, Reason: m.String()})
+	log.Warnf("[p2p]peer %d %s banned for %s", id, addr, m)
+	this.save()
+	return true
+}
+
+//sweepScores drops the scores decayed to 0, at most once per decay interval.
+//Should be called with lock
+func (this *Reputation) sweepScores(now time.Time) {
+	if now.Sub(this.swept) < common.SCORE_DECAY_INTERVAL*time.Second {
+		return
+	}
+	this.swept = now
+	for ip, s := range this.scores {
+		if s.decayed(now) == 0 {
+			delete(this.scores, ip)
+		}
+	}
+}
+
+//IsBanned returns whether the peer id or the ip of the address is banned
+func (this *Reputation) IsBanned(id uint64, addr string) bool {
+	now := time.Now().Unix()
+	this.Lock()
+	defer this.Unlock()
+	if ban, ok := this.ipBans[banIP(addr)]; ok && ban.Until > now {
+		return true
+	}
+	if ban, ok := this.idBans[id]; ok && id != 0 && ban.Until > now {
+		return true
+	}
+	return false
+}
+
+//Ban bans the ip or the peer id for the duration
+func (this *Reputation) Ban(ip string, id uint64, duration time.Duration, reason string) {
+	ban := &BanEntry{
+		IP:     ip,
+		ID:     id,
+		Until:  time.Now().Add(duration).Unix(),
+		Reason: reason,
+	}
+	this.Lock()
+	defer this.Unlock()
+	this.addBan(ban)
+	this.save()
+}
+
+//Unban removes the ban of the ip or the peer id, and returns false if
+//neither is banned
+func (this *Reputation) Unban(ip string, id uint64) bool {
+	this.Lock()
+	defer this.Unlock()
+	ipBan, ipBanned := this.ipBans[ip]
+	idBan, idBanned := this.idBans[id]
+	if !ipBanned && !idBanned {
+		return false
+	}
+	if ipBanned {
+		this.delBan(ipBan)
+	}
+	if idBanned {
+		this.delBan(idBan)
+	}
+	this.save()
+	return true
+}
+
+//GetBans returns the bans not expired in the order of expiration
+func (this *Reputation) GetBans() []*BanEntry {
+	this.Lock()
+	defer this.Unlock()
+	bans := this.getBans()
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Until < bans[j].Until
+	})
+	return bans
+}
+
+//addBan adds the ban of the ip and the peer id, should be called with lock
+func (this *Reputation) addBan(ban *BanEntry) {
+	if ban.IP != "" {
+		this.ipBans[ban.IP] = ban
+	}
+	if ban.ID != 0 {
+		this.idBans[ban.ID] = ban
+	}
+}
+
+//delBan removes the ban of both the ip and the peer id, should be called with lock
+func (this *Reputation) delBan(ban *BanEntry) {
+	if this.ipBans[ban.IP] == ban {
+		delete(this.ipBans, ban.IP)
+	}
+	if this.idBans[ban.ID] == ban {
+		delete(this.idBans, ban.ID)
+	}
+}
+
+//getBans returns the bans not expired and removes the expired ones,
+//should be called with lock
+func (this *Reputation) getBans() []*BanEntry {
+	now := time.Now().Unix()
+	bans := make([]*BanEntry, 0, len(this.ipBans)+len(this.idBans))
+	for ip, ban := range this.ipBans {
+		if ban.Until <= now {
+			delete(this.ipBans, ip)
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	for id, ban := range this.idBans {
+		if ban.Until <= now {
+			delete(this.idBans, id)
+			continue
+		}
+		//the ban of both ip and peer id is already added
+		if ban.IP == "" || this.ipBans[ban.IP] != ban {
+			bans = append(bans, ban)
+		}
+	}
+	return bans
+}
+
+//save persists the bans, should be called with lock
+func (this *Reputation) save() {
+	if this.path == "" {
+		return
+	}
+	buf, err := json.Marshal(this.getBans())
+	if err != nil {
+		log.Warnf("[p2p]package bans fail:%s", err)
+		return
+	}
+	if err := ioutil.WriteFile(this.path, buf, os.ModePerm); err != nil {
+		log.Warnf("[p2p]write %s fail:%s", this.path, err)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package peer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology/p2pserver/common"
+)
+
+func TestReputationBan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reputation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "peers.banned")
+
+	r := NewReputation(path)
+	if r.Penalize(1, "127.0.0.1:20338", ConsensusSpam) {
+		t.Errorf("peer banned by a single consensus spam")
+	}
+	if r.Penalize(1, "127.0.0.1:20338", BadChecksum) {
+		t.Errorf("peer banned below the ban score")
+	}
+	if !r.Penalize(1, "127.0.0.1:20338", InvalidBlock) {
+		t.Errorf("peer not banned over the ban score")
+	}
+	if !r.IsBanned(0, "127.0.0.1:20339") {
+		t.Errorf("ip not banned")
+	}
+	if !r.IsBanned(1, "127.0.0.2:20338") {
+		t.Errorf("peer id not banned")
+	}
+	if r.IsBanned(2, "127.0.0.2:20338") {
+		t.Errorf("unexpected ban")
+	}
+
+	r.Ban("", 2, time.Hour, "manual")
+	if len(r.GetBans()) != 2 {
+		t.Errorf("expect 2 bans, got %d", len(r.GetBans()))
+	}
+	//both ip and peer id are banned
+	r.Ban("127.0.0.4", 4, time.Hour, "manual")
+	if !r.IsBanned(4, "") || !r.IsBanned(0, "127.0.0.4:20338") {
+		t.Errorf("ip and peer id not banned")
+	}
+	if len(r.GetBans()) != 3 {
+		t.Errorf("expect 3 bans, got %d", len(r.GetBans()))
+	}
+
+	//bans are persisted
+	loaded := NewReputation(path)
+	if !loaded.IsBanned(2, "") || !loaded.IsBanned(0, "127.0.0.1:20338") || !loaded.IsBanned(4, "") {
+		t.Errorf("bans not loaded")
+	}
+	if !loaded.Unban("127.0.0.1", 0) || loaded.Unban("127.0.0.1", 0) {
+		t.Errorf("unban ip error")
+	}
+	//the peer id banned with the ip is unbanned too
+	if loaded.IsBanned(1, "127.0.0.1:20338") {
+		t.Errorf("ip still banned")
+	}
+
+	//expired bans
+	loaded.Ban("127.0.0.3", 0, -time.Second, "manual")
+	if loaded.IsBanned(0, "127.0.0.3:20338") {
+		t.Errorf("expired ban")
+	}
+	if len(loaded.GetBans()) != 2 {
+		t.Errorf("expect 2 bans, got %d", len(loaded.GetBans()))
+	}
+}
+
+func TestReputationSweep(t *testing.T) {
+	r := NewReputation("")
+	r.Penalize(1, "127.0.0.1:20338", ConsensusSpam)
+	r.Penalize(2, "127.0.0.2:20338", InvalidBlock)
+	if len(r.scores) != 2 {
+		t.Fatalf("expect 2 scores, got %d", len(r.scores))
+	}
+
+	//the score of the first peer has decayed to 0, the second one has not
+	decay := common.SCORE_DECAY_INTERVAL * time.Second
+	r.scores["127.0.0.1"].updated = time.Now().Add(-11 * decay)
+	r.scores["127.0.0.2"].updated = time.Now().Add(-11 * decay)
+	r.swept = time.Now().Add(-decay)
+	r.Penalize(3, "127.0.0.3:20338", ConsensusSpam)
+	if _, ok := r.scores["127.0.0.1"]; ok {
+		t.Errorf("score decayed to 0 not dropped")
+	}
+	if s, ok := r.scores["127.0.0.2"]; !ok || s.decayed(time.Now()) != 39 {
+		t.Errorf("score not decayed to 0 dropped")
+	}
+	if len(r.scores) != 2 {
+		t.Errorf("expect 2 scores, got %d", len(r.scores))
+	}
+}
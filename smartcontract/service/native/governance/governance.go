@@ -31,6 +31,8 @@ import (
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/constants"
 	"github.com/ontio/ontology/common/serialization"
+	vbftconfig "github.com/ontio/ontology/consensus/vbft/config"
+	"github.com/ontio/ontology/core/signature/bls"
 	cstates "github.com/ontio/ontology/core/states"
 	"github.com/ontio/ontology/smartcontract/service/native"
 	"github.com/ontio/ontology/smartcontract/service/native/global_params"
@@ -76,6 +78,8 @@ const (
 	REDUCE_INIT_POS                  = "reduceInitPos"
 	SET_PROMISE_POS                  = "setPromisePos"
 	SET_GAS_ADDRESS                  = "setGasAddress"
+	REPORT_EQUIVOCATION              = "reportEquivocation"
+	SET_BLS_PUBKEY                   = "setBlsPubKey"
 
 	//key prefix
 	GLOBAL_PARAM      = "globalParam"
@@ -95,12 +99,15 @@ const (
 	PROMISE_POS       = "promisePos"
 	PRE_CONFIG        = "preConfig"
 	GAS_ADDRESS       = "gasAddress"
+	EQUIVOCATION      = "equivocation"
+	BLS_PUBKEY        = "blsPubKey"
 
 	//global
-	PRECISE            = 1000000
-	NEW_VERSION_VIEW   = 6
-	NEW_VERSION_BLOCK  = 414100
-	NEW_WITHDRAW_BLOCK = 2800000
+	PRECISE             = 1000000
+	NEW_VERSION_VIEW    = 6
+	NEW_VERSION_BLOCK   = 414100
+	NEW_WITHDRAW_BLOCK  = 2800000
+	EQUIVOCATION_EXPIRE = 1000 //blocks in which the evidence of equivocation can be reported
 )
 
 // candidate fee must >= 1 ONG
@@ -138,6 +145,8 @@ func RegisterGovernanceContract(native *native.NativeService) {
 	native.Register(WITHDRAW_FEE, WithdrawFee)
 	native.Register(ADD_INIT_POS, AddInitPos)
 	native.Register(REDUCE_INIT_POS, ReduceInitPos)
+	native.Register(REPORT_EQUIVOCATION, ReportEquivocation)
+	native.Register(SET_BLS_PUBKEY, SetBlsPubKey)
 
 	native.Register(INIT_CONFIG, InitConfig)
 	native.Register(APPROVE_CANDIDATE, ApproveCandidate)
@@ -619,38 +628,9 @@ func BlackNode(native *native.NativeService) ([]byte, error) {
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
 	}
-	commit := false
-	for _, peerPubkey := range params.PeerPubkeyList {
-		peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
-		if err != nil {
-			return utils.BYTE_FALSE, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
-		}
-		peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]
-		if !ok {
-			return utils.BYTE_FALSE, fmt.Errorf("blackNode, peerPubkey is not in peerPoolMap")
-		}
-
-		blackListItem := &BlackListItem{
-			PeerPubkey: peerPoolItem.PeerPubkey,
-			Address:    peerPoolItem.Address,
-			InitPos:    peerPoolItem.InitPos,
-		}
-		bf := new(bytes.Buffer)
-		if err := blackListItem.Serialize(bf); err != nil {
-			return utils.BYTE_FALSE, fmt.Errorf("serialize, serialize blackListItem error: %v", err)
-		}
-		//put peer into black list
-		native.CacheDB.Put(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix), cstates.GenRawStorageItem(bf.Bytes()))
-		//change peerPool status
-		if peerPoolItem.Status == ConsensusStatus {
-			commit = true
-		}
-		peerPoolItem.Status = BlackStatus
-		peerPoolMap.PeerPoolMap[peerPubkey] = peerPoolItem
-	}
-	err = putPeerPoolMap(native, contract, view, peerPoolMap)
+	commit, err := blackPeers(native, contract, view, peerPoolMap, params.PeerPubkeyList)
 	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("putPeerPoolMap, put peerPoolMap error: %v", err)
+		return utils.BYTE_FALSE, fmt.Errorf("blackPeers, black peers error: %v", err)
 	}
 
 	//commitDpos
@@ -663,6 +643,80 @@ func BlackNode(native *native.NativeService) ([]byte, error) {
 	return utils.BYTE_TRUE, nil
 }
 
+//Report two conflicting votes signed by a peer, the peer will be put into black list,
+//and its stake will be punished in the next commitDpos
+func ReportEquivocation(native *native.NativeService) ([]byte, error) {
+	if native.Height < config.GetVoteSigCheckHeight(config.DefConfig.P2PNode.NetworkId) {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	params := new(ReportEquivocationParam)
+	buf, err := serialization.ReadVarBytes(bytes.NewBuffer(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("serialization.ReadVarBytes, contract params deserialize error: %v", err)
+	}
+	if err := params.Deserialize(bytes.NewBuffer(buf)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, contract params deserialize error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getView, get view error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	peerPoolItem, ok := peerPoolMap.PeerPoolMap[params.PeerPubkey]
+	if !ok {
+		return utils.BYTE_FALSE, fmt.Errorf("reportEquivocation, peerPubkey is not in peerPoolMap")
+	}
+	if peerPoolItem.Status == BlackStatus {
+		return utils.BYTE_FALSE, fmt.Errorf("reportEquivocation, peer is already in black list")
+	}
+
+	//only the evidence of recent blocks is accepted
+	if params.Evidence.First == nil || params.Evidence.First.BlockNum > native.Height ||
+		params.Evidence.First.BlockNum+EQUIVOCATION_EXPIRE < native.Height {
+		return utils.BYTE_FALSE, fmt.Errorf("reportEquivocation, evidence is stale or in future")
+	}
+
+	//verify evidence
+	pubkey, err := vbftconfig.Pubkey(peerPoolItem.PeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("vbftconfig.Pubkey, peerPubkey format error: %v", err)
+	}
+	if err := params.Evidence.Verify(pubkey); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("reportEquivocation, verify evidence error: %v", err)
+	}
+
+	//one peer can only be punished once for the same round
+	key, err := equivocationKey(contract, peerPoolItem.PeerPubkey, params.Evidence.First)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("equivocationKey, get equivocation key error: %v", err)
+	}
+	reported, err := native.CacheDB.Get(key)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("native.CacheDB.Get, get equivocation error: %v", err)
+	}
+	if reported != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("reportEquivocation, equivocation is already reported")
+	}
+	bf := new(bytes.Buffer)
+	if err := params.Evidence.Serialize(bf); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("serialize, serialize evidence error: %v", err)
+	}
+	native.CacheDB.Put(key, cstates.GenRawStorageItem(bf.Bytes()))
+
+	_, err = blackPeers(native, contract, view, peerPoolMap, []string{peerPoolItem.PeerPubkey})
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("blackPeers, black peers error: %v", err)
+	}
+	return utils.BYTE_TRUE, nil
+}
+
 //Remove a node from black list, allow it to be registered
 func WhiteNode(native *native.NativeService) ([]byte, error) {
 	params := new(WhiteNodeParam)
@@ -1415,6 +1469,65 @@ func SetPeerCost(native *native.NativeService) ([]byte, error) {
 	return utils.BYTE_TRUE, nil
 }
 
+//Set bls public key of peer, which signs vbft blocks since the bls height.
+//The key takes effect from the next consensus config.
+func SetBlsPubKey(native *native.NativeService) ([]byte, error) {
+	if native.Height < config.GetBlsPubKeyHeight(config.DefConfig.P2PNode.NetworkId) {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	params := new(SetBlsPubKeyParam)
+	if err := params.Deserialize(bytes.NewBuffer(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, deserialize setBlsPubKeyParam error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("validateOwner, checkWitness error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	//check if is peer owner
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getView, get view error: %v", err)
+	}
+
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	peerPoolItem, ok := peerPoolMap.PeerPoolMap[params.PeerPubkey]
+	if !ok {
+		return utils.BYTE_FALSE, fmt.Errorf("setBlsPubKey, peerPubkey is not in peerPoolMap")
+	}
+	if peerPoolItem.Address != params.Address {
+		return utils.BYTE_FALSE, fmt.Errorf("address is not peer owner")
+	}
+
+	//check proof of possession, against rogue key of aggregated signature
+	blsPubKey, err := bls.DeserializePublicKey(params.BlsPubKey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("setBlsPubKey, blsPubKey format error: %v", err)
+	}
+	proof, err := bls.DeserializeSignature(params.Proof)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("setBlsPubKey, proof format error: %v", err)
+	}
+	if !bls.VerifyPossession(blsPubKey, proof) {
+		return utils.BYTE_FALSE, fmt.Errorf("setBlsPubKey, verify proof of possession failed")
+	}
+
+	err = putBlsPubKey(native, contract, params.PeerPubkey, params.BlsPubKey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putBlsPubKey error: %v", err)
+	}
+
+	return utils.BYTE_TRUE, nil
+}
+
 //Withdraw split fee of address
 func WithdrawFee(native *native.NativeService) ([]byte, error) {
 	if native.Height < NEW_VERSION_BLOCK {
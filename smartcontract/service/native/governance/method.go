@@ -319,6 +319,45 @@ func normalQuit(native *native.NativeService, contract common.Address, peerPoolI
 	return nil
 }
 
+//blackPeers puts the peers into black list, return whether any of them is consensus peer
+func blackPeers(native *native.NativeService, contract common.Address, view uint32, peerPoolMap *PeerPoolMap,
+	peerPubkeyList []string) (bool, error) {
+	commit := false
+	for _, peerPubkey := range peerPubkeyList {
+		peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+		if err != nil {
+			return false, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+		}
+		peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]
+		if !ok {
+			return false, fmt.Errorf("blackNode, peerPubkey is not in peerPoolMap")
+		}
+
+		blackListItem := &BlackListItem{
+			PeerPubkey: peerPoolItem.PeerPubkey,
+			Address:    peerPoolItem.Address,
+			InitPos:    peerPoolItem.InitPos,
+		}
+		bf := new(bytes.Buffer)
+		if err := blackListItem.Serialize(bf); err != nil {
+			return false, fmt.Errorf("serialize, serialize blackListItem error: %v", err)
+		}
+		//put peer into black list
+		native.CacheDB.Put(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix), cstates.GenRawStorageItem(bf.Bytes()))
+		//change peerPool status
+		if peerPoolItem.Status == ConsensusStatus {
+			commit = true
+		}
+		peerPoolItem.Status = BlackStatus
+		peerPoolMap.PeerPoolMap[peerPubkey] = peerPoolItem
+	}
+	err := putPeerPoolMap(native, contract, view, peerPoolMap)
+	if err != nil {
+		return false, fmt.Errorf("putPeerPoolMap, put peerPoolMap error: %v", err)
+	}
+	return commit, nil
+}
+
 func blackQuit(native *native.NativeService, contract common.Address, peerPoolItem *PeerPoolItem) error {
 	// ont transfer to trigger unboundong
 	err := appCallTransferOnt(native, utils.GovernanceContractAddress, utils.GovernanceContractAddress, peerPoolItem.InitPos)
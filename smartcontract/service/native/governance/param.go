@@ -25,6 +25,7 @@ import (
 
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/serialization"
+	vbftconfig "github.com/ontio/ontology/consensus/vbft/config"
 	"github.com/ontio/ontology/smartcontract/service/native/utils"
 )
 
@@ -221,6 +222,35 @@ func (this *BlackNodeParam) Deserialize(r io.Reader) error {
 	return nil
 }
 
+type ReportEquivocationParam struct {
+	PeerPubkey string
+	Evidence   *vbftconfig.Equivocation
+}
+
+func (this *ReportEquivocationParam) Serialize(w io.Writer) error {
+	if err := serialization.WriteString(w, this.PeerPubkey); err != nil {
+		return fmt.Errorf("serialization.WriteString, serialize peerPubkey error: %v", err)
+	}
+	if err := this.Evidence.Serialize(w); err != nil {
+		return fmt.Errorf("evidence.Serialize, serialize evidence error: %v", err)
+	}
+	return nil
+}
+
+func (this *ReportEquivocationParam) Deserialize(r io.Reader) error {
+	peerPubkey, err := serialization.ReadString(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize peerPubkey error: %v", err)
+	}
+	evidence := new(vbftconfig.Equivocation)
+	if err := evidence.Deserialize(r); err != nil {
+		return fmt.Errorf("evidence.Deserialize, deserialize evidence error: %v", err)
+	}
+	this.PeerPubkey = peerPubkey
+	this.Evidence = evidence
+	return nil
+}
+
 type WhiteNodeParam struct {
 	PeerPubkey string
 }
@@ -914,6 +944,53 @@ func (this *SetPeerCostParam) Deserialize(r io.Reader) error {
 	return nil
 }
 
+type SetBlsPubKeyParam struct {
+	PeerPubkey string
+	Address    common.Address
+	BlsPubKey  []byte
+	Proof      []byte // proof of possession of bls private key
+}
+
+func (this *SetBlsPubKeyParam) Serialize(w io.Writer) error {
+	if err := serialization.WriteString(w, this.PeerPubkey); err != nil {
+		return fmt.Errorf("serialization.WriteString, serialize peerPubkey error: %v", err)
+	}
+	if err := serialization.WriteVarBytes(w, this.Address[:]); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize address error: %v", err)
+	}
+	if err := serialization.WriteVarBytes(w, this.BlsPubKey); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize blsPubKey error: %v", err)
+	}
+	if err := serialization.WriteVarBytes(w, this.Proof); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize proof error: %v", err)
+	}
+	return nil
+}
+
+func (this *SetBlsPubKeyParam) Deserialize(r io.Reader) error {
+	peerPubkey, err := serialization.ReadString(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize peerPubkey error: %v", err)
+	}
+	address, err := utils.ReadAddress(r)
+	if err != nil {
+		return fmt.Errorf("utils.ReadAddress, deserialize address error: %v", err)
+	}
+	blsPubKey, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadVarBytes, deserialize blsPubKey error: %v", err)
+	}
+	proof, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadVarBytes, deserialize proof error: %v", err)
+	}
+	this.PeerPubkey = peerPubkey
+	this.Address = address
+	this.BlsPubKey = blsPubKey
+	this.Proof = proof
+	return nil
+}
+
 type WithdrawFeeParam struct {
 	Address common.Address
 }
@@ -313,6 +313,25 @@ func putGlobalParam2(native *native.NativeService, contract common.Address, glob
 	return nil
 }
 
+//equivocationKey returns the storage key of the equivocation of the peer in the round of the vote
+func equivocationKey(contract common.Address, peerPubkey string, vote *vbftconfig.Vote) ([]byte, error) {
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+	}
+	round := new(bytes.Buffer)
+	if err := serialization.WriteUint8(round, vote.Type); err != nil {
+		return nil, fmt.Errorf("serialization.WriteUint8, serialize vote type error: %v", err)
+	}
+	if err := serialization.WriteUint32(round, vote.BlockNum); err != nil {
+		return nil, fmt.Errorf("serialization.WriteUint32, serialize block num error: %v", err)
+	}
+	if err := serialization.WriteBool(round, vote.ForEmpty); err != nil {
+		return nil, fmt.Errorf("serialization.WriteBool, serialize empty flag error: %v", err)
+	}
+	return utils.ConcatKey(contract, []byte(EQUIVOCATION), peerPubkeyPrefix, round.Bytes()), nil
+}
+
 func validatePeerPubKeyFormat(pubkey string) error {
 	pk, err := vbftconfig.Pubkey(pubkey)
 	if err != nil {
@@ -761,6 +780,15 @@ func putPeerAttributes(native *native.NativeService, contract common.Address, pe
 	return nil
 }
 
+func putBlsPubKey(native *native.NativeService, contract common.Address, peerPubkey string, blsPubKey []byte) error {
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(BLS_PUBKEY), peerPubkeyPrefix), cstates.GenRawStorageItem(blsPubKey))
+	return nil
+}
+
 func getPromisePos(native *native.NativeService, contract common.Address, peerPubkey string) (*PromisePos, error) {
 	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
 	if err != nil {
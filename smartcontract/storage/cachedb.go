@@ -168,3 +168,10 @@ func (self *Iter) Key() []byte {
 	}
 	return key
 }
+
+func (self *Iter) Seek(key []byte) bool {
+	pkey := make([]byte, 1+len(key))
+	pkey[0] = byte(common.ST_STORAGE)
+	copy(pkey[1:], key)
+	return self.JoinIter.Seek(pkey)
+}
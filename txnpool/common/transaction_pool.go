@@ -20,6 +20,11 @@
 package common
 
 import (
+	"bytes"
+	"container/heap"
+	"crypto/sha256"
+	"math"
+	"math/big"
 	"sort"
 	"sync"
 
@@ -38,8 +43,34 @@ type TXAttr struct {
 }
 
 type TXEntry struct {
-	Tx    *types.Transaction // transaction which has been verified
-	Attrs []*TXAttr          // the result from each validator
+	Tx       *types.Transaction // transaction which has been verified
+	Attrs    []*TXAttr          // the result from each validator
+	Replaces common.Uint256     // the transaction with a lower gas price replaced by this one
+	PeerId   uint64             // the peer which relays the transaction, 0 if not from network
+	seq      uint64             // the order in which the transaction enters the pool
+	index    int                // the position in the eviction heap of the pool
+	key      replaceKey         // the key of the transaction to be replaced by fee
+}
+
+// replaceKey identifies the transactions which can replace each other by fee.
+// The nonces are picked by wallets at random, so the nonce alone only tells
+// apart the transactions of a payer by chance. A transaction is replaced by
+// the resubmission of the same payload with the same payer and nonce.
+type replaceKey struct {
+	payer   common.Address
+	nonce   uint32
+	payload common.Uint256
+}
+
+func getReplaceKey(tx *types.Transaction) replaceKey {
+	key := replaceKey{payer: tx.Payer, nonce: tx.Nonce}
+	if tx.Payload != nil {
+		buf := new(bytes.Buffer)
+		if err := tx.Payload.Serialize(buf); err == nil {
+			key.payload = sha256.Sum256(buf.Bytes())
+		}
+	}
+	return key
 }
 
 // TXPool contains all currently valid transactions. Transactions
@@ -48,7 +79,15 @@ type TXEntry struct {
 // in the ledger.
 type TXPool struct {
 	sync.RWMutex
-	txList map[common.Uint256]*TXEntry // Transactions which have been verified
+	txList       map[common.Uint256]*TXEntry                    // Transactions which have been verified
+	payerTxs     map[common.Address]map[common.Uint256]*TXEntry // Transactions indexed by payer
+	replaceable  map[replaceKey]*TXEntry                        // Transactions indexed by payer, nonce and payload
+	replacedBy   map[common.Uint256]common.Uint256              // Replaced transaction => the transaction replacing it
+	replacedList []common.Uint256                               // Replaced transactions in the order of replacement
+	peerTxs      map[uint64]int                                 // The number of transactions relayed by each peer
+	shortIDs     map[uint64][]*TXEntry                          // Transactions indexed by short id for compact blocks
+	evictable    evictionHeap                                   // Transactions ordered by gas price and sequence for eviction
+	seq          uint64                                         // The sequence of the latest transaction entering the pool
 }
 
 // Init creates a new transaction pool to gather.
@@ -56,26 +95,189 @@ func (tp *TXPool) Init() {
 	tp.Lock()
 	defer tp.Unlock()
 	tp.txList = make(map[common.Uint256]*TXEntry)
+	tp.payerTxs = make(map[common.Address]map[common.Uint256]*TXEntry)
+	tp.replaceable = make(map[replaceKey]*TXEntry)
+	tp.replacedBy = make(map[common.Uint256]common.Uint256)
+	tp.replacedList = make([]common.Uint256, 0)
+	tp.peerTxs = make(map[uint64]int)
+	tp.shortIDs = make(map[uint64][]*TXEntry)
+	tp.evictable = make(evictionHeap, 0)
 }
 
 // AddTxList adds a valid transaction to the transaction pool. If the
-// transaction is already in the pool, just return false. Parameter
-// txEntry includes transaction, fee, and verified information(height,
-// validator, error code).
-func (tp *TXPool) AddTxList(txEntry *TXEntry) bool {
+// transaction is already in the pool, return ErrDuplicateInput. If
+// replace-by-fee is enabled, and there is a transaction with the same
+// payer, nonce and payload in the pool, it is replaced only when the gas
+// price of the new one is higher by the configured percentage, otherwise
+// return ErrReplaceUnderpriced.
+// Otherwise the transaction is rejected with ErrPayerQuota or ErrPeerQuota
+// if its payer or source peer already has the configured number of
+// transactions in the pool. If the pool is full, the transaction with the
+// lowest gas price, and the earliest added among them, is evicted to make
+// room for one with a higher gas price and returned, otherwise return
+// ErrTxPoolFull.
+// Parameter txEntry includes transaction, fee, and verified
+// information(height, validator, error code).
+func (tp *TXPool) AddTxList(txEntry *TXEntry) (*TXEntry, errors.ErrCode) {
 	tp.Lock()
 	defer tp.Unlock()
 	txHash := txEntry.Tx.Hash()
 	if _, ok := tp.txList[txHash]; ok {
 		log.Infof("AddTxList: transaction %x is already in the pool",
 			txHash)
+		return nil, errors.ErrDuplicateInput
+	}
+
+	txEntry.key = getReplaceKey(txEntry.Tx)
+	if old := tp.replaceable[txEntry.key]; old != nil && config.DefConfig.Common.GasPriceBump > 0 {
+		if !canReplace(old.Tx.GasPrice, txEntry.Tx.GasPrice) {
+			log.Infof("AddTxList: transaction %x gas price %d is too low to replace %x with gas price %d",
+				txHash, txEntry.Tx.GasPrice, old.Tx.Hash(), old.Tx.GasPrice)
+			return nil, errors.ErrReplaceUnderpriced
+		}
+		oldHash := old.Tx.Hash()
+		tp.removeTx(oldHash)
+		tp.addReplaced(oldHash, txHash)
+		txEntry.Replaces = oldHash
+		log.Infof("AddTxList: transaction %x is replaced by %x", oldHash, txHash)
+		tp.addTx(txEntry)
+		return nil, errors.ErrNoError
+	}
+
+	payerQuota := config.DefConfig.Common.TxPoolPayerQuota
+	if payerQuota > 0 && uint(len(tp.payerTxs[txEntry.Tx.Payer])) >= payerQuota {
+		log.Infof("AddTxList: payer %s of transaction %x exceeds the quota %d",
+			txEntry.Tx.Payer.ToBase58(), txHash, payerQuota)
+		return nil, errors.ErrPayerQuota
+	}
+	peerQuota := config.DefConfig.Common.TxPoolPeerQuota
+	if txEntry.PeerId != 0 && peerQuota > 0 && uint(tp.peerTxs[txEntry.PeerId]) >= peerQuota {
+		log.Infof("AddTxList: peer %d of transaction %x exceeds the quota %d",
+			txEntry.PeerId, txHash, peerQuota)
+		return nil, errors.ErrPeerQuota
+	}
+
+	var evicted *TXEntry
+	if len(tp.txList) >= MAX_CAPACITY {
+		evicted = tp.getEvictable()
+		if evicted == nil || evicted.Tx.GasPrice >= txEntry.Tx.GasPrice {
+			log.Infof("AddTxList: transaction pool is full for transaction %x with gas price %d",
+				txHash, txEntry.Tx.GasPrice)
+			return nil, errors.ErrTxPoolFull
+		}
+		tp.removeTx(evicted.Tx.Hash())
+		log.Infof("AddTxList: transaction %x is evicted for %x", evicted.Tx.Hash(), txHash)
+	}
+	tp.addTx(txEntry)
+	return evicted, errors.ErrNoError
+}
+
+// getEvictable returns the transaction with the lowest gas price, and the
+// earliest added among them. Should be called with lock
+func (tp *TXPool) getEvictable() *TXEntry {
+	if len(tp.evictable) == 0 {
+		return nil
+	}
+	return tp.evictable[0]
+}
+
+// IsFull checks whether the pool reaches its capacity and there is no
+// transaction with a gas price lower than the given one to evict.
+func (tp *TXPool) IsFull(gasPrice uint64) bool {
+	tp.RLock()
+	defer tp.RUnlock()
+
+	if len(tp.txList) < MAX_CAPACITY {
 		return false
 	}
+	evictable := tp.getEvictable()
+	return evictable == nil || evictable.Tx.GasPrice >= gasPrice
+}
+
+// canReplace checks whether the new gas price is higher than the old one by
+// the configured percentage
+func canReplace(oldGasPrice, newGasPrice uint64) bool {
+	bump := config.DefConfig.Common.GasPriceBump
+	threshold := new(big.Int).SetUint64(oldGasPrice)
+	threshold.Mul(threshold, new(big.Int).SetUint64(100+bump))
+	price := new(big.Int).SetUint64(newGasPrice)
+	price.Mul(price, big.NewInt(100))
+	return newGasPrice > oldGasPrice && price.Cmp(threshold) >= 0
+}
+
+// addTx adds the transaction to the pool and the payer, replacement and
+// short id index, should be called with lock
+func (tp *TXPool) addTx(txEntry *TXEntry) {
+	tp.seq++
+	txEntry.seq = tp.seq
+	tp.txList[txEntry.Tx.Hash()] = txEntry
+	heap.Push(&tp.evictable, txEntry)
+	if txEntry.PeerId != 0 {
+		tp.peerTxs[txEntry.PeerId]++
+	}
+	txs, ok := tp.payerTxs[txEntry.Tx.Payer]
+	if !ok {
+		txs = make(map[common.Uint256]*TXEntry)
+		tp.payerTxs[txEntry.Tx.Payer] = txs
+	}
+	txs[txEntry.Tx.Hash()] = txEntry
+	tp.replaceable[txEntry.key] = txEntry
+	id := ShortTxID(txEntry.Tx.Hash())
+	tp.shortIDs[id] = append(tp.shortIDs[id], txEntry)
+}
 
-	tp.txList[txHash] = txEntry
+// removeTx removes the transaction from the pool and the payer, replacement
+// and short id index, should be called with lock
+func (tp *TXPool) removeTx(txHash common.Uint256) bool {
+	txEntry, ok := tp.txList[txHash]
+	if !ok {
+		return false
+	}
+	delete(tp.txList, txHash)
+	heap.Remove(&tp.evictable, txEntry.index)
+	if txEntry.PeerId != 0 {
+		tp.peerTxs[txEntry.PeerId]--
+		if tp.peerTxs[txEntry.PeerId] <= 0 {
+			delete(tp.peerTxs, txEntry.PeerId)
+		}
+	}
+	txs := tp.payerTxs[txEntry.Tx.Payer]
+	delete(txs, txHash)
+	if len(txs) == 0 {
+		delete(tp.payerTxs, txEntry.Tx.Payer)
+	}
+	if tp.replaceable[txEntry.key] == txEntry {
+		delete(tp.replaceable, txEntry.key)
+	}
+	id := ShortTxID(txHash)
+	entries := tp.shortIDs[id]
+	for i, entry := range entries {
+		if entry == txEntry {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	if len(entries) == 0 {
+		delete(tp.shortIDs, id)
+	} else {
+		tp.shortIDs[id] = entries
+	}
 	return true
 }
 
+// addReplaced records the replacement, only the latest MAX_REPLACED_RECORDS
+// replacements are kept. Should be called with lock
+func (tp *TXPool) addReplaced(oldHash, newHash common.Uint256) {
+	if _, ok := tp.replacedBy[oldHash]; !ok {
+		tp.replacedList = append(tp.replacedList, oldHash)
+	}
+	tp.replacedBy[oldHash] = newHash
+	if len(tp.replacedList) > MAX_REPLACED_RECORDS {
+		delete(tp.replacedBy, tp.replacedList[0])
+		tp.replacedList = tp.replacedList[1:]
+	}
+}
+
 // CleanTransactionList cleans the transaction list included in the ledger.
 func (tp *TXPool) CleanTransactionList(txs []*types.Transaction) error {
 	cleaned := 0
@@ -83,8 +285,7 @@ func (tp *TXPool) CleanTransactionList(txs []*types.Transaction) error {
 	tp.Lock()
 	defer tp.Unlock()
 	for _, tx := range txs {
-		if _, ok := tp.txList[tx.Hash()]; ok {
-			delete(tp.txList, tx.Hash())
+		if tp.removeTx(tx.Hash()) {
 			cleaned++
 		}
 	}
@@ -98,17 +299,12 @@ func (tp *TXPool) CleanTransactionList(txs []*types.Transaction) error {
 func (tp *TXPool) DelTxList(tx *types.Transaction) bool {
 	tp.Lock()
 	defer tp.Unlock()
-	txHash := tx.Hash()
-	if _, ok := tp.txList[txHash]; !ok {
-		return false
-	}
-	delete(tp.txList, txHash)
-	return true
+	return tp.removeTx(tx.Hash())
 }
 
 // compareTxHeight compares a verifed transaction's height with the next
 // block height from consensus. If the height is less than the next block
-// height, re-verify it.
+// height, it is waiting for the re-verification against the new block.
 func (tp *TXPool) compareTxHeight(txEntry *TXEntry, height uint32) bool {
 	for _, v := range txEntry.Attrs {
 		if v.Type == vt.Stateful &&
@@ -122,8 +318,8 @@ func (tp *TXPool) compareTxHeight(txEntry *TXEntry, height uint32) bool {
 // GetTxPool gets the transaction lists from the pool for the consensus,
 // if the byCount is marked, return the configured number at most; if the
 // the byCount is not marked, return all of the current transaction pool.
-func (tp *TXPool) GetTxPool(byCount bool, height uint32) ([]*TXEntry,
-	[]*types.Transaction) {
+// The transactions not re-verified at the height yet are skipped.
+func (tp *TXPool) GetTxPool(byCount bool, height uint32) []*TXEntry {
 	tp.RLock()
 	defer tp.RUnlock()
 
@@ -143,10 +339,8 @@ func (tp *TXPool) GetTxPool(byCount bool, height uint32) ([]*TXEntry,
 
 	var num int
 	txList := make([]*TXEntry, 0, count)
-	oldTxList := make([]*types.Transaction, 0)
 	for _, txEntry := range orderByFee {
 		if !tp.compareTxHeight(txEntry, height) {
-			oldTxList = append(oldTxList, txEntry.Tx)
 			continue
 		}
 		txList = append(txList, txEntry)
@@ -156,7 +350,7 @@ func (tp *TXPool) GetTxPool(byCount bool, height uint32) ([]*TXEntry,
 		}
 	}
 
-	return txList, oldTxList
+	return txList
 }
 
 // GetTransaction returns a transaction if it is contained in the pool
@@ -170,6 +364,33 @@ func (tp *TXPool) GetTransaction(hash common.Uint256) *types.Transaction {
 	return tp.txList[hash].Tx
 }
 
+// GetTransactions returns all the transactions in the pool
+func (tp *TXPool) GetTransactions() []*types.Transaction {
+	tp.RLock()
+	defer tp.RUnlock()
+
+	txList := make([]*types.Transaction, 0, len(tp.txList))
+	for _, txEntry := range tp.txList {
+		txList = append(txList, txEntry.Tx)
+	}
+	return txList
+}
+
+// GetTxsByShortIDs returns the transaction matching each short id, nil if
+// no transaction or more than one in the pool matches it
+func (tp *TXPool) GetTxsByShortIDs(ids []uint64) []*types.Transaction {
+	tp.RLock()
+	defer tp.RUnlock()
+
+	txList := make([]*types.Transaction, len(ids))
+	for i, id := range ids {
+		if entries := tp.shortIDs[id]; len(entries) == 1 {
+			txList[i] = entries[0].Tx
+		}
+	}
+	return txList
+}
+
 // GetTxStatus returns a transaction status if it is contained in the pool
 // and nil otherwise.
 func (tp *TXPool) GetTxStatus(hash common.Uint256) *TxStatus {
@@ -177,15 +398,46 @@ func (tp *TXPool) GetTxStatus(hash common.Uint256) *TxStatus {
 	defer tp.RUnlock()
 	txEntry, ok := tp.txList[hash]
 	if !ok {
+		if newHash, ok := tp.replacedBy[hash]; ok {
+			return &TxStatus{
+				Hash:       hash,
+				ReplacedBy: newHash,
+			}
+		}
 		return nil
 	}
 	ret := &TxStatus{
-		Hash:  hash,
-		Attrs: txEntry.Attrs,
+		Hash:     hash,
+		Attrs:    txEntry.Attrs,
+		Replaces: txEntry.Replaces,
 	}
 	return ret
 }
 
+// GetPayerFee returns the sum of gas price times gas limit of the pooled
+// transactions of the payer of tx, except tx itself and its resubmissions
+// with the same nonce and payload, as only one of them can be packed. The sum
+// saturates at math.MaxUint64
+func (tp *TXPool) GetPayerFee(tx *types.Transaction) uint64 {
+	tp.RLock()
+	defer tp.RUnlock()
+	key := getReplaceKey(tx)
+	var total uint64
+	for hash, txEntry := range tp.payerTxs[tx.Payer] {
+		if hash == tx.Hash() || txEntry.key == key {
+			continue
+		}
+		fee, overflow := common.SafeMul(txEntry.Tx.GasPrice, txEntry.Tx.GasLimit)
+		if !overflow {
+			total, overflow = common.SafeAdd(total, fee)
+		}
+		if overflow {
+			return math.MaxUint64
+		}
+	}
+	return total
+}
+
 // GetTransactionCount returns the tx number of the pool.
 func (tp *TXPool) GetTransactionCount() int {
 	tp.RLock()
@@ -214,7 +466,7 @@ func (tp *TXPool) GetUnverifiedTxs(txs []*types.Transaction,
 		}
 
 		if !tp.compareTxHeight(txEntry, height) {
-			delete(tp.txList, tx.Hash())
+			tp.removeTx(tx.Hash())
 			res.OldTxs = append(res.OldTxs, txEntry.Tx)
 			continue
 		}
@@ -236,27 +488,46 @@ func (tp *TXPool) GetUnverifiedTxs(txs []*types.Transaction,
 	return res
 }
 
-// RemoveTxsBelowGasPrice drops all transactions below the gas price
-func (tp *TXPool) RemoveTxsBelowGasPrice(gasPrice uint64) {
+// RemoveTxsBelowGasPrice drops all transactions below the gas price, and
+// returns the number of dropped transactions
+func (tp *TXPool) RemoveTxsBelowGasPrice(gasPrice uint64) int {
 	tp.Lock()
 	defer tp.Unlock()
+	removed := 0
 	for _, txEntry := range tp.txList {
 		if txEntry.Tx.GasPrice < gasPrice {
-			delete(tp.txList, txEntry.Tx.Hash())
+			tp.removeTx(txEntry.Tx.Hash())
+			removed++
 		}
 	}
+	return removed
 }
 
-// Remain returns the remaining tx list to cleanup
-func (tp *TXPool) Remain() []*types.Transaction {
+// RemoveExpiredTxs drops all transactions which can not be included in the
+// blocks higher than the height, and returns the number of dropped
+// transactions
+func (tp *TXPool) RemoveExpiredTxs(height uint32) int {
 	tp.Lock()
 	defer tp.Unlock()
-
-	txList := make([]*types.Transaction, 0, len(tp.txList))
+	removed := 0
 	for _, txEntry := range tp.txList {
-		txList = append(txList, txEntry.Tx)
-		delete(tp.txList, txEntry.Tx.Hash())
+		if validUntil, ok := txEntry.Tx.GetValidUntilHeight(); ok && validUntil <= height {
+			tp.removeTx(txEntry.Tx.Hash())
+			removed++
+		}
 	}
+	return removed
+}
 
-	return txList
+// UpdateTxAttrs refreshes the verified information of a transaction in the
+// pool after it is re-verified, and returns false if it is not in the pool.
+func (tp *TXPool) UpdateTxAttrs(txEntry *TXEntry) bool {
+	tp.Lock()
+	defer tp.Unlock()
+	old, ok := tp.txList[txEntry.Tx.Hash()]
+	if !ok {
+		return false
+	}
+	old.Attrs = txEntry.Attrs
+	return true
 }
@@ -19,12 +19,17 @@
 package common
 
 import (
+	"math"
 	"testing"
 	"time"
 
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/core/payload"
 	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/errors"
+	vt "github.com/ontio/ontology/validator/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -53,27 +58,23 @@ func TestTxPool(t *testing.T) {
 		Attrs: []*TXAttr{},
 	}
 
-	ret := txPool.AddTxList(txEntry)
-	if ret == false {
+	_, ret := txPool.AddTxList(txEntry)
+	if ret != errors.ErrNoError {
 		t.Error("Failed to add tx to the pool")
 		return
 	}
 
-	ret = txPool.AddTxList(txEntry)
-	if ret == true {
+	_, ret = txPool.AddTxList(txEntry)
+	if ret != errors.ErrDuplicateInput {
 		t.Error("Failed to add tx to the pool")
 		return
 	}
 
-	txList, oldTxList := txPool.GetTxPool(true, 0)
+	txList := txPool.GetTxPool(true, 0)
 	for _, v := range txList {
 		assert.NotNil(t, v)
 	}
 
-	for _, v := range oldTxList {
-		assert.NotNil(t, v)
-	}
-
 	entry := txPool.GetTransaction(txn.Hash())
 	if entry == nil {
 		t.Error("Failed to get the transaction")
@@ -99,3 +100,278 @@ func TestTxPool(t *testing.T) {
 		return
 	}
 }
+
+func newTestTxEntry(payer common.Address, nonce uint32, gasPrice uint64) *TXEntry {
+	mutable := &types.MutableTransaction{
+		TxType:   types.Invoke,
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Payer:    payer,
+		Payload:  &payload.InvokeCode{Code: []byte{}},
+	}
+	tx, _ := mutable.IntoImmutable()
+	return &TXEntry{Tx: tx, Attrs: []*TXAttr{}}
+}
+
+func addTestTxEntry(txPool *TXPool, txEntry *TXEntry) errors.ErrCode {
+	_, errCode := txPool.AddTxList(txEntry)
+	return errCode
+}
+
+func TestReplaceByFee(t *testing.T) {
+	bump := config.DefConfig.Common.GasPriceBump
+	payerQuota := config.DefConfig.Common.TxPoolPayerQuota
+	defer func() {
+		config.DefConfig.Common.GasPriceBump = bump
+		config.DefConfig.Common.TxPoolPayerQuota = payerQuota
+	}()
+	config.DefConfig.Common.GasPriceBump = 10
+	config.DefConfig.Common.TxPoolPayerQuota = 2
+
+	txPool := &TXPool{}
+	txPool.Init()
+
+	payer := common.Address{1}
+	old := newTestTxEntry(payer, 1, 1000)
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, old))
+	//the same nonce with other payload is not a replacement
+	mutable := &types.MutableTransaction{
+		TxType:   types.Invoke,
+		Nonce:    1,
+		GasPrice: 500,
+		Payer:    payer,
+		Payload:  &payload.InvokeCode{Code: []byte{1}},
+	}
+	tx, _ := mutable.IntoImmutable()
+	other := &TXEntry{Tx: tx, Attrs: []*TXAttr{}}
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, other))
+
+	//gas price is not high enough
+	low := newTestTxEntry(payer, 1, 1050)
+	assert.Equal(t, errors.ErrReplaceUnderpriced, addTestTxEntry(txPool, low))
+	assert.Nil(t, txPool.GetTransaction(low.Tx.Hash()))
+
+	//replacement is not limited by the quota
+	high := newTestTxEntry(payer, 1, 1100)
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, high))
+	assert.Nil(t, txPool.GetTransaction(old.Tx.Hash()))
+	assert.NotNil(t, txPool.GetTransaction(other.Tx.Hash()))
+	assert.Equal(t, 2, txPool.GetTransactionCount())
+
+	status := txPool.GetTxStatus(high.Tx.Hash())
+	assert.Equal(t, old.Tx.Hash(), status.Replaces)
+	status = txPool.GetTxStatus(old.Tx.Hash())
+	assert.Equal(t, high.Tx.Hash(), status.ReplacedBy)
+
+	//replacement is disabled
+	config.DefConfig.Common.GasPriceBump = 0
+	config.DefConfig.Common.TxPoolPayerQuota = 0
+	higher := newTestTxEntry(payer, 1, 2000)
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, higher))
+	assert.NotNil(t, txPool.GetTransaction(high.Tx.Hash()))
+	assert.Equal(t, 3, txPool.GetTransactionCount())
+}
+
+func TestGetTxPoolByPrice(t *testing.T) {
+	txPool := &TXPool{}
+	txPool.Init()
+
+	payer1 := common.Address{1}
+	payer2 := common.Address{2}
+	entries := []*TXEntry{
+		newTestTxEntry(payer1, 1, 500),
+		newTestTxEntry(payer1, 2, 3000),
+		newTestTxEntry(payer2, 1, 2000),
+		newTestTxEntry(payer2, 2, 1000),
+	}
+	for _, entry := range entries {
+		assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, entry))
+	}
+
+	txList := txPool.GetTxPool(false, 0)
+	expected := []*TXEntry{entries[1], entries[2], entries[3], entries[0]}
+	assert.Equal(t, len(expected), len(txList))
+	for i, entry := range expected {
+		assert.Equal(t, entry.Tx.Hash(), txList[i].Tx.Hash())
+	}
+}
+
+func TestGetPayerFee(t *testing.T) {
+	txPool := &TXPool{}
+	txPool.Init()
+
+	newTx := func(payer common.Address, nonce uint32, gasPrice, gasLimit uint64) *types.Transaction {
+		mutable := &types.MutableTransaction{
+			TxType:   types.Invoke,
+			Nonce:    nonce,
+			GasPrice: gasPrice,
+			GasLimit: gasLimit,
+			Payer:    payer,
+			Payload:  &payload.InvokeCode{Code: []byte{}},
+		}
+		tx, _ := mutable.IntoImmutable()
+		return tx
+	}
+	payer := common.Address{1}
+	pooled := newTx(payer, 1, 500, 20000)
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, &TXEntry{Tx: pooled}))
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, &TXEntry{Tx: newTx(payer, 2, 1000, 30000)}))
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, &TXEntry{Tx: newTx(common.Address{2}, 1, 500, 20000)}))
+
+	assert.Equal(t, uint64(500*20000+1000*30000), txPool.GetPayerFee(newTx(payer, 3, 500, 20000)))
+	//the pooled tx itself is not counted when it is re-verified
+	assert.Equal(t, uint64(1000*30000), txPool.GetPayerFee(pooled))
+	//only one of the resubmissions can be packed
+	assert.Equal(t, uint64(1000*30000), txPool.GetPayerFee(newTx(payer, 1, 600, 20000)))
+	assert.Equal(t, uint64(0), txPool.GetPayerFee(newTx(common.Address{3}, 1, 500, 20000)))
+
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, &TXEntry{Tx: newTx(payer, 4, math.MaxUint64, 2)}))
+	assert.Equal(t, uint64(math.MaxUint64), txPool.GetPayerFee(pooled))
+}
+
+func TestTxPoolQuota(t *testing.T) {
+	payerQuota := config.DefConfig.Common.TxPoolPayerQuota
+	peerQuota := config.DefConfig.Common.TxPoolPeerQuota
+	defer func() {
+		config.DefConfig.Common.TxPoolPayerQuota = payerQuota
+		config.DefConfig.Common.TxPoolPeerQuota = peerQuota
+	}()
+	config.DefConfig.Common.TxPoolPayerQuota = 2
+	config.DefConfig.Common.TxPoolPeerQuota = 3
+
+	txPool := &TXPool{}
+	txPool.Init()
+
+	payer1 := common.Address{1}
+	payer2 := common.Address{2}
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, newTestTxEntry(payer1, 1, 500)))
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, newTestTxEntry(payer1, 2, 500)))
+	assert.Equal(t, errors.ErrPayerQuota, addTestTxEntry(txPool, newTestTxEntry(payer1, 3, 500)))
+	assert.Equal(t, errors.ErrPayerQuota, addTestTxEntry(txPool, newTestTxEntry(payer1, 2, 1000)))
+
+	for i := byte(1); i <= 3; i++ {
+		entry := newTestTxEntry(common.Address{1 + i}, 1, 500)
+		entry.PeerId = uint64(i)
+		assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, entry))
+	}
+	entry := newTestTxEntry(payer2, 2, 500)
+	entry.PeerId = 1
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, entry))
+	released := newTestTxEntry(common.Address{5}, 1, 500)
+	released.PeerId = 1
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, released))
+	entry = newTestTxEntry(common.Address{6}, 1, 500)
+	entry.PeerId = 1
+	assert.Equal(t, errors.ErrPeerQuota, addTestTxEntry(txPool, entry))
+
+	//the quota is released when the transaction leaves the pool
+	txPool.DelTxList(released.Tx)
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, entry))
+}
+
+func TestTxPoolEviction(t *testing.T) {
+	payerQuota := config.DefConfig.Common.TxPoolPayerQuota
+	defer func() {
+		config.DefConfig.Common.TxPoolPayerQuota = payerQuota
+	}()
+	config.DefConfig.Common.TxPoolPayerQuota = 0
+
+	txPool := &TXPool{}
+	txPool.Init()
+
+	payer := common.Address{1}
+	for i := 0; i < MAX_CAPACITY; i++ {
+		gasPrice := uint64(1000)
+		if i%2 == 1 {
+			gasPrice = 500
+		}
+		assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, newTestTxEntry(payer, uint32(i), gasPrice)))
+	}
+	assert.True(t, txPool.IsFull(500))
+	assert.False(t, txPool.IsFull(600))
+
+	_, errCode := txPool.AddTxList(newTestTxEntry(common.Address{2}, 0, 500))
+	assert.Equal(t, errors.ErrTxPoolFull, errCode)
+
+	//the earliest added one among the lowest gas price is evicted
+	evicted, errCode := txPool.AddTxList(newTestTxEntry(common.Address{2}, 0, 600))
+	assert.Equal(t, errors.ErrNoError, errCode)
+	assert.Equal(t, uint32(1), evicted.Tx.Nonce)
+	assert.Equal(t, MAX_CAPACITY, txPool.GetTransactionCount())
+	evicted, errCode = txPool.AddTxList(newTestTxEntry(common.Address{2}, 1, 600))
+	assert.Equal(t, errors.ErrNoError, errCode)
+	assert.Equal(t, uint32(3), evicted.Tx.Nonce)
+
+	//the removed one is no longer evictable
+	assert.True(t, txPool.DelTxList(newTestTxEntry(payer, 5, 500).Tx))
+	evicted, errCode = txPool.AddTxList(newTestTxEntry(common.Address{2}, 2, 600))
+	assert.Equal(t, errors.ErrNoError, errCode)
+	assert.Nil(t, evicted)
+	evicted, errCode = txPool.AddTxList(newTestTxEntry(common.Address{2}, 3, 600))
+	assert.Equal(t, errors.ErrNoError, errCode)
+	assert.Equal(t, uint32(7), evicted.Tx.Nonce)
+}
+
+func TestRemoveExpiredTxs(t *testing.T) {
+	txPool := &TXPool{}
+	txPool.Init()
+
+	expiring := newTestTxEntry(common.Address{1}, 1, 500)
+	mutable, err := expiring.Tx.IntoMutable()
+	assert.Nil(t, err)
+	mutable.Attributes = []*types.TxAttribute{types.NewValidUntilHeightAttribute(10)}
+	expiring.Tx, err = mutable.IntoImmutable()
+	assert.Nil(t, err)
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, expiring))
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, newTestTxEntry(common.Address{2}, 1, 500)))
+
+	assert.Equal(t, 0, txPool.RemoveExpiredTxs(9))
+	assert.Equal(t, 1, txPool.RemoveExpiredTxs(10))
+	assert.Nil(t, txPool.GetTransaction(expiring.Tx.Hash()))
+	assert.Equal(t, 1, txPool.GetTransactionCount())
+}
+
+func TestUpdateTxAttrs(t *testing.T) {
+	txPool := &TXPool{}
+	txPool.Init()
+
+	entry := newTestTxEntry(common.Address{1}, 1, 500)
+	entry.Attrs = []*TXAttr{{Height: 1, Type: vt.Stateful}}
+	assert.False(t, txPool.UpdateTxAttrs(entry))
+	assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, entry))
+
+	//not re-verified at height 2 yet
+	assert.Equal(t, 0, len(txPool.GetTxPool(false, 2)))
+
+	reVerified := &TXEntry{Tx: entry.Tx, Attrs: []*TXAttr{{Height: 2, Type: vt.Stateful}}}
+	assert.True(t, txPool.UpdateTxAttrs(reVerified))
+	assert.Equal(t, 1, len(txPool.GetTxPool(false, 2)))
+}
+
+func TestGetTxsByShortIDs(t *testing.T) {
+	txPool := &TXPool{}
+	txPool.Init()
+
+	payer := common.Address{1}
+	entries := []*TXEntry{
+		newTestTxEntry(payer, 1, 500),
+		newTestTxEntry(payer, 2, 500),
+	}
+	for _, entry := range entries {
+		assert.Equal(t, errors.ErrNoError, addTestTxEntry(txPool, entry))
+	}
+
+	ids := []uint64{ShortTxID(entries[1].Tx.Hash()), ShortTxID(common.Uint256{}), ShortTxID(entries[0].Tx.Hash())}
+	txs := txPool.GetTxsByShortIDs(ids)
+	assert.Equal(t, 3, len(txs))
+	assert.Equal(t, entries[1].Tx, txs[0])
+	assert.Nil(t, txs[1])
+	assert.Equal(t, entries[0].Tx, txs[2])
+
+	// ambiguous short id
+	txPool.shortIDs[ids[0]] = append(txPool.shortIDs[ids[0]], entries[0])
+	assert.Nil(t, txPool.GetTxsByShortIDs(ids[:1])[0])
+
+	assert.True(t, txPool.DelTxList(entries[0].Tx))
+	assert.Nil(t, txPool.GetTxsByShortIDs(ids[2:])[0])
+}
@@ -19,6 +19,8 @@
 package common
 
 import (
+	"encoding/binary"
+
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/errors"
@@ -37,8 +39,19 @@ const (
 	MAX_LIMITATION   = 10000                            // The length of pending tx from net and http
 	UPDATE_FREQUENCY = 100                              // The frequency to update gas price from global params
 	MAX_TX_SIZE      = 1024 * 1024                      // The max size of a transaction to prevent DOS attacks
+	SHORT_TXID_LEN   = 6                                // The bytes of the short tx id in compact blocks
+
+	MAX_REPLACED_RECORDS = 10000 // The max number of replaced transactions kept for status query
 )
 
+// ShortTxID returns the short id of the transaction hash, which is the
+// first SHORT_TXID_LEN bytes of it
+func ShortTxID(hash common.Uint256) uint64 {
+	var buf [8]byte
+	copy(buf[:SHORT_TXID_LEN], hash[:])
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
 // ActorType enumerates the kind of actor
 type ActorType uint8
 
@@ -77,13 +90,20 @@ func (sender SenderType) Sender() string {
 type TxnStatsType uint8
 
 const (
-	_              TxnStatsType = iota
-	RcvStats                    // The count that the tx pool receive from the actor bus
-	SuccessStats                // The count that the transactions are verified successfully
-	FailureStats                // The count that the transactions are invalid
-	DuplicateStats              // The count that the transactions are duplicated input
-	SigErrStats                 // The count that the transactions' signature error
-	StateErrStats               // The count that the transactions are invalid in database
+	_                  TxnStatsType = iota
+	RcvStats                        // The count that the tx pool receive from the actor bus
+	SuccessStats                    // The count that the transactions are verified successfully
+	FailureStats                    // The count that the transactions are invalid
+	DuplicateStats                  // The count that the transactions are duplicated input
+	SigErrStats                     // The count that the transactions' signature error
+	StateErrStats                   // The count that the transactions are invalid in database
+	PoolFullStats                   // The count that the transactions are rejected as the pool is full
+	PayerQuotaStats                 // The count that the transactions are rejected as the payer exceeds its quota
+	PeerQuotaStats                  // The count that the transactions are rejected as the source peer exceeds its quota
+	EvictLowFeeStats                // The count that the transactions are evicted by ones with higher gas price as the pool is full
+	EvictReplacedStats              // The count that the transactions are replaced by ones with a higher gas price
+	EvictGasPriceStats              // The count that the transactions are evicted as the gas price threshold rises
+	EvictExpiredStats               // The count that the transactions are evicted as they expire
 
 	MaxStats
 )
@@ -99,8 +119,10 @@ type CheckBlkResult struct {
 
 // TxStatus contains the attributes of a transaction
 type TxStatus struct {
-	Hash  common.Uint256 // transaction hash
-	Attrs []*TXAttr      // transaction's status
+	Hash       common.Uint256 // transaction hash
+	Attrs      []*TXAttr      // transaction's status
+	Replaces   common.Uint256 // the transaction replaced by this one
+	ReplacedBy common.Uint256 // the transaction replacing this one, and this one is not in the pool
 }
 type TxResult struct {
 	Err  errors.ErrCode
@@ -114,6 +136,7 @@ type TxReq struct {
 	Tx         *types.Transaction
 	Sender     SenderType
 	TxResultCh chan *TxResult
+	PeerId     uint64 // The peer which relays the transaction, 0 if not from network
 }
 
 // TxRsp returns the result of submitting tx, including
@@ -157,10 +180,13 @@ type GetTxnStatusReq struct {
 }
 
 // GetTxnStatusRsp returns a transaction status for GetTxnStatusReq.
-// Output: a transaction hash and it's verified result.
+// Output: a transaction hash, it's verified result and the replace-by-fee
+// outcome.
 type GetTxnStatusRsp struct {
-	Hash     common.Uint256
-	TxStatus []*TXAttr
+	Hash       common.Uint256
+	TxStatus   []*TXAttr
+	Replaces   common.Uint256
+	ReplacedBy common.Uint256
 }
 
 // GetTxnStats specifies the api that how to get the tx statistics.
@@ -192,6 +218,18 @@ type GetPendingTxnRsp struct {
 	Txs []*types.Transaction
 }
 
+// GetTxnsByShortIDReq specifies the api that how to get the txs in the
+// pool by the short ids of a compact block.
+type GetTxnsByShortIDReq struct {
+	IDs []uint64
+}
+
+// GetTxnsByShortIDRsp returns the txs matching each short id of
+// GetTxnsByShortIDReq, nil if missing or ambiguous.
+type GetTxnsByShortIDRsp struct {
+	Txs []*types.Transaction
+}
+
 // consensus messages
 // GetTxnPoolReq specifies the api that how to get the valid transaction list.
 type GetTxnPoolReq struct {
@@ -206,8 +244,9 @@ type GetTxnPoolRsp struct {
 
 // VerifyBlockReq specifies that api that how to verify a block from consensus.
 type VerifyBlockReq struct {
-	Height uint32
-	Txs    []*types.Transaction
+	Height      uint32 // the height to verify the txs against, which may be lower for the incremental validation
+	BlockHeight uint32 // the height of the block including the txs
+	Txs         []*types.Transaction
 }
 
 // VerifyTxResult returns a single transaction's verified result.
@@ -252,3 +291,36 @@ func (n OrderByNetWorkFee) Len() int { return len(n) }
 func (n OrderByNetWorkFee) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
 
 func (n OrderByNetWorkFee) Less(i, j int) bool { return n[j].Tx.GasPrice < n[i].Tx.GasPrice }
+
+// evictionHeap is a min-heap of the transactions in the pool, the one with
+// the lowest gas price, and the earliest added among them, is on the top
+type evictionHeap []*TXEntry
+
+func (h evictionHeap) Len() int { return len(h) }
+
+func (h evictionHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h evictionHeap) Less(i, j int) bool {
+	if h[i].Tx.GasPrice != h[j].Tx.GasPrice {
+		return h[i].Tx.GasPrice < h[j].Tx.GasPrice
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h *evictionHeap) Push(x interface{}) {
+	txEntry := x.(*TXEntry)
+	txEntry.index = len(*h)
+	*h = append(*h, txEntry)
+}
+
+func (h *evictionHeap) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return last
+}
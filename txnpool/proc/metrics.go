@@ -0,0 +1,57 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proc
+
+import (
+	"github.com/ontio/ontology/common/metrics"
+	tc "github.com/ontio/ontology/txnpool/common"
+)
+
+const metricsCollector = "txnpool"
+
+var (
+	poolSizeGauge    = metrics.NewGaugeVec("ontology_txpool_size", "Count of transactions in the tx pool", "state")
+	txStatsCounter   = metrics.NewCounterVec("ontology_txpool_txs_total", "Count of transactions handled by the tx pool", "result")
+	txStatsResultTag = map[tc.TxnStatsType]string{
+		tc.RcvStats:           "received",
+		tc.SuccessStats:       "success",
+		tc.FailureStats:       "failure",
+		tc.DuplicateStats:     "duplicate",
+		tc.SigErrStats:        "sig_error",
+		tc.StateErrStats:      "state_error",
+		tc.PoolFullStats:      "pool_full",
+		tc.PayerQuotaStats:    "payer_quota",
+		tc.PeerQuotaStats:     "peer_quota",
+		tc.EvictLowFeeStats:   "evict_low_fee",
+		tc.EvictReplacedStats: "evict_replaced",
+		tc.EvictGasPriceStats: "evict_gas_price",
+		tc.EvictExpiredStats:  "evict_expired",
+	}
+)
+
+// collectMetrics updates the pool size and tx statistics metrics
+func (s *TXPoolServer) collectMetrics() {
+	poolSizeGauge.WithLabel("verified").Set(float64(s.txPool.GetTransactionCount()))
+	poolSizeGauge.WithLabel("pending").Set(float64(s.getPendingListSize()))
+	for i, v := range s.getStats() {
+		if tag, ok := txStatsResultTag[tc.TxnStatsType(i+1)]; ok {
+			txStatsCounter.WithLabel(tag).Set(float64(v))
+		}
+	}
+}
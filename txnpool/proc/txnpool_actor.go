@@ -31,8 +31,6 @@ import (
 	tx "github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/errors"
 	"github.com/ontio/ontology/events/message"
-	hComm "github.com/ontio/ontology/http/base/common"
-	"github.com/ontio/ontology/smartcontract/service/native/utils"
 	"github.com/ontio/ontology/smartcontract/service/neovm"
 	tc "github.com/ontio/ontology/txnpool/common"
 	"github.com/ontio/ontology/validator/types"
@@ -60,17 +58,6 @@ func NewVerifyRspActor(s *TXPoolServer) *VerifyRspActor {
 	return a
 }
 
-// isBalanceEnough checks if the tranactor has enough to cover gas cost
-func isBalanceEnough(address common.Address, gas uint64) bool {
-	balance, err := hComm.GetContractBalance(0, utils.OngContractAddress, address)
-	if err != nil {
-		log.Debugf("failed to get contract balance %s err %v",
-			address.ToHexString(), err)
-		return false
-	}
-	return balance >= gas
-}
-
 func replyTxResult(txResultCh chan *tc.TxResult, hash common.Uint256,
 	err errors.ErrCode, desc string) {
 	result := &tc.TxResult{
@@ -98,19 +85,6 @@ func preExecCheck(txn *tx.Transaction) (bool, string) {
 		return false, fmt.Sprintf("transaction's gasLimit %d is less than preExec gasLimit %d",
 			txn.GasLimit, result.Gas)
 	}
-	gas, overflow := common.SafeMul(txn.GasPrice, result.Gas)
-	if overflow {
-		log.Debugf("preExecCheck: gasPrice %d preExec gasLimit %d overflow",
-			txn.GasPrice, result.Gas)
-		return false, fmt.Sprintf("gasPrice %d preExec gasLimit %d overflow",
-			txn.GasPrice, result.Gas)
-	}
-	if !isBalanceEnough(txn.Payer, gas) {
-		log.Debugf("preExecCheck: transactor %s has no balance enough to cover gas cost %d",
-			txn.Payer.ToHexString(), gas)
-		return false, fmt.Sprintf("transactor %s has no balance enough to cover gas cost %d",
-			txn.Payer.ToHexString(), gas)
-	}
 	return true, ""
 }
 
@@ -121,7 +95,7 @@ type TxActor struct {
 
 // handleTransaction handles a transaction from network and http
 func (ta *TxActor) handleTransaction(sender tc.SenderType, self *actor.PID,
-	txn *tx.Transaction, txResultCh chan *tc.TxResult) {
+	txn *tx.Transaction, txResultCh chan *tc.TxResult, peerId uint64) {
 	ta.server.increaseStats(tc.RcvStats)
 	if len(txn.ToArray()) > tc.MAX_TX_SIZE {
 		log.Debugf("handleTransaction: reject a transaction due to size over 1M")
@@ -140,11 +114,12 @@ func (ta *TxActor) handleTransaction(sender tc.SenderType, self *actor.PID,
 			replyTxResult(txResultCh, txn.Hash(), errors.ErrDuplicateInput,
 				fmt.Sprintf("transaction %x is already in the tx pool", txn.Hash()))
 		}
-	} else if ta.server.getTransactionCount() >= tc.MAX_CAPACITY {
+	} else if ta.server.txPool.IsFull(txn.GasPrice) {
 		log.Debugf("handleTransaction: transaction pool is full for tx %x",
 			txn.Hash())
 
 		ta.server.increaseStats(tc.FailureStats)
+		ta.server.increaseStats(tc.PoolFullStats)
 		if sender == tc.HttpSender && txResultCh != nil {
 			replyTxResult(txResultCh, txn.Hash(), errors.ErrTxPoolFull,
 				"transaction pool is full")
@@ -196,7 +171,7 @@ func (ta *TxActor) handleTransaction(sender tc.SenderType, self *actor.PID,
 			log.Debugf("handleTransaction: preExecCheck tx %x passed", txn.Hash())
 		}
 		<-ta.server.slots
-		ta.server.assignTxToWorker(txn, sender, txResultCh)
+		ta.server.assignTxToWorker(txn, sender, peerId, txResultCh)
 	}
 }
 
@@ -217,7 +192,7 @@ func (ta *TxActor) Receive(context actor.Context) {
 
 		log.Debugf("txpool-tx actor receives tx from %v ", sender.Sender())
 
-		ta.handleTransaction(sender, context.Self(), msg.Tx, msg.TxResultCh)
+		ta.handleTransaction(sender, context.Self(), msg.Tx, msg.TxResultCh, msg.PeerId)
 
 	case *tc.GetTxnReq:
 		sender := context.Sender()
@@ -264,7 +239,8 @@ func (ta *TxActor) Receive(context actor.Context) {
 					TxStatus: nil}, context.Self())
 			} else {
 				sender.Request(&tc.GetTxnStatusRsp{Hash: res.Hash,
-					TxStatus: res.Attrs}, context.Self())
+					TxStatus: res.Attrs, Replaces: res.Replaces,
+					ReplacedBy: res.ReplacedBy}, context.Self())
 			}
 		}
 
@@ -279,6 +255,17 @@ func (ta *TxActor) Receive(context actor.Context) {
 				context.Self())
 		}
 
+	case *tc.GetTxnsByShortIDReq:
+		sender := context.Sender()
+
+		log.Debugf("txpool-tx actor receives getting txs by short id req from %v", sender)
+
+		res := ta.server.getTxsByShortIDs(msg.IDs)
+		if sender != nil {
+			sender.Request(&tc.GetTxnsByShortIDRsp{Txs: res},
+				context.Self())
+		}
+
 	default:
 		log.Debugf("txpool-tx actor: unknown msg %v type %v", msg, reflect.TypeOf(msg))
 	}
@@ -332,6 +319,13 @@ func (tpa *TxPoolActor) Receive(context actor.Context) {
 
 		tpa.server.verifyBlock(msg, sender)
 
+	case *tc.TxReq:
+		log.Debugf("txpool actor receives tx from %v", msg.Sender.Sender())
+
+		if pid := tpa.server.GetPID(tc.TxActor); pid != nil {
+			pid.Tell(msg)
+		}
+
 	case *message.SaveBlockCompleteMsg:
 		sender := context.Sender()
 
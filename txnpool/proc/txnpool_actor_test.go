@@ -171,8 +171,9 @@ func TestTxPoolActor(t *testing.T) {
 	assert.Nil(t, err)
 
 	bk := &tc.VerifyBlockReq{
-		Height: 0,
-		Txs:    []*types.Transaction{txn},
+		Height:      0,
+		BlockHeight: 1,
+		Txs:         []*types.Transaction{txn},
 	}
 	future = txPoolPid.RequestFuture(bk, 10*time.Second)
 	result, err = future.Result()
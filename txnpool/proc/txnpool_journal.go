@@ -0,0 +1,157 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	tx "github.com/ontio/ontology/core/types"
+)
+
+// txJournal is an append-only file of the transactions accepted by the
+// tx pool, which is replayed to restore the tx pool after the node restarts.
+// Each record is a transaction serialized as var bytes.
+type txJournal struct {
+	mu        sync.Mutex
+	path      string                  // The journal file path
+	writer    *os.File                // The output stream, nil if the journal is not active
+	journaled map[common.Uint256]bool // The transactions already in the journal
+	rotated   time.Time               // The last time the journal is regenerated
+}
+
+// newTxJournal creates a journal with the file path.
+func newTxJournal(path string) *txJournal {
+	return &txJournal{
+		path:      path,
+		journaled: make(map[common.Uint256]bool),
+	}
+}
+
+// load reads the transactions from the journal file. A broken record at
+// the end of the file, which is left by an interrupted write, is dropped.
+func (j *txJournal) load() ([]*tx.Transaction, error) {
+	data, err := ioutil.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read journal error %s", err)
+	}
+
+	txs := make([]*tx.Transaction, 0)
+	dropped := 0
+	source := common.NewZeroCopySource(data)
+	for source.Len() > 0 {
+		raw, _, irregular, eof := source.NextVarBytes()
+		if irregular || eof {
+			log.Warnf("tx pool journal: broken record at offset %d", source.Pos())
+			break
+		}
+		t, err := tx.TransactionFromRawBytes(raw)
+		if err != nil {
+			dropped++
+			continue
+		}
+		txs = append(txs, t)
+	}
+	log.Infof("tx pool journal: loaded %d transactions, dropped %d", len(txs), dropped)
+	return txs, nil
+}
+
+// insert appends a transaction to the journal if it is not in it yet.
+func (j *txJournal) insert(t *tx.Transaction) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.writer == nil || j.journaled[t.Hash()] {
+		return nil
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarBytes(t.Raw)
+	if _, err := j.writer.Write(sink.Bytes()); err != nil {
+		return fmt.Errorf("write journal error %s", err)
+	}
+	j.journaled[t.Hash()] = true
+	return nil
+}
+
+// rotate regenerates the journal with the transactions still in the tx
+// pool, and reopens it for appending.
+func (j *txJournal) rotate(txs []*tx.Transaction) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.writer != nil {
+		if err := j.writer.Close(); err != nil {
+			return fmt.Errorf("close journal error %s", err)
+		}
+		j.writer = nil
+	}
+
+	journaled := make(map[common.Uint256]bool, len(txs))
+	sink := common.NewZeroCopySink(nil)
+	for _, t := range txs {
+		if journaled[t.Hash()] {
+			continue
+		}
+		sink.WriteVarBytes(t.Raw)
+		journaled[t.Hash()] = true
+	}
+	if err := ioutil.WriteFile(j.path+".new", sink.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write journal error %s", err)
+	}
+	if err := os.Rename(j.path+".new", j.path); err != nil {
+		return fmt.Errorf("rename journal error %s", err)
+	}
+	writer, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("open journal error %s", err)
+	}
+	j.writer = writer
+	j.journaled = journaled
+	j.rotated = time.Now()
+	log.Infof("tx pool journal: regenerated with %d transactions", len(journaled))
+	return nil
+}
+
+// lastRotated returns the last time the journal is regenerated.
+func (j *txJournal) lastRotated() time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.rotated
+}
+
+// close closes the journal file.
+func (j *txJournal) close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.writer == nil {
+		return nil
+	}
+	err := j.writer.Close()
+	j.writer = nil
+	return err
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txpool-journal")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "txpool.journal")
+
+	mutable := &types.MutableTransaction{
+		TxType:  types.Invoke,
+		Nonce:   txn.Nonce + 1,
+		Payload: &payload.InvokeCode{Code: []byte("ont")},
+	}
+	txn2, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+
+	journal := newTxJournal(path)
+	txs, err := journal.load()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(txs))
+
+	// Not active before the first rotation
+	assert.Nil(t, journal.insert(txn))
+	assert.Nil(t, journal.rotate(nil))
+	assert.Nil(t, journal.insert(txn))
+	assert.Nil(t, journal.insert(txn))
+	assert.Nil(t, journal.insert(txn2))
+	assert.Nil(t, journal.close())
+
+	txs, err = journal.load()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(txs))
+	assert.Equal(t, txn.Hash(), txs[0].Hash())
+	assert.Equal(t, txn2.Hash(), txs[1].Hash())
+
+	// A record broken by an interrupted write is dropped
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.Nil(t, err)
+	_, err = f.Write([]byte{0xfd, 0xff})
+	assert.Nil(t, err)
+	f.Close()
+	txs, err = journal.load()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(txs))
+
+	assert.Nil(t, journal.rotate([]*types.Transaction{txn2, txn2}))
+	assert.Nil(t, journal.close())
+	txs, err = journal.load()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(txs))
+	assert.Equal(t, txn2.Hash(), txs[0].Hash())
+}
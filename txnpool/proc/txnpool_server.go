@@ -28,9 +28,13 @@ import (
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/common/metrics"
 	"github.com/ontio/ontology/core/ledger"
 	tx "github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/validation"
 	"github.com/ontio/ontology/errors"
+	"github.com/ontio/ontology/events"
+	"github.com/ontio/ontology/events/message"
 	httpcom "github.com/ontio/ontology/http/base/common"
 	params "github.com/ontio/ontology/smartcontract/service/native/global_params"
 	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
@@ -39,6 +43,7 @@ import (
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 )
 
 type txStats struct {
@@ -49,6 +54,7 @@ type txStats struct {
 type serverPendingTx struct {
 	tx     *tx.Transaction   // Pending tx
 	sender tc.SenderType     // Indicate which sender tx is from
+	peerId uint64            // The peer which relays the tx, 0 if not from network
 	ch     chan *tc.TxResult // channel to send tx result
 }
 
@@ -86,6 +92,8 @@ type TXPoolServer struct {
 	gasPrice              uint64                              // Gas price to enforce for acceptance into the pool
 	disablePreExec        bool                                // Disbale PreExecute a transaction
 	disableBroadcastNetTx bool                                // Disable broadcast tx from network
+	journal               *txJournal                          // The journal of accepted txs, nil if disabled
+	rejournal             time.Duration                       // The interval to regenerate the journal
 }
 
 // NewTxPoolServer creates a new tx pool server to schedule workers to
@@ -189,6 +197,7 @@ func (s *TXPoolServer) init(num uint8, disablePreExec, disableBroadcastNetTx boo
 		s.workers[i].init(i, s)
 		go s.workers[i].start()
 	}
+	metrics.RegisterCollector(metricsCollector, s.collectMetrics)
 }
 
 // checkPendingBlockOk checks whether a block from consensus is verified.
@@ -298,7 +307,7 @@ func (s *TXPoolServer) removePendingTx(hash common.Uint256,
 // setPendingTx adds a transaction to the pending list, if the
 // transaction is already in the pending list, just return false.
 func (s *TXPoolServer) setPendingTx(tx *tx.Transaction,
-	sender tc.SenderType, txResultCh chan *tc.TxResult) bool {
+	sender tc.SenderType, peerId uint64, txResultCh chan *tc.TxResult) bool {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -311,6 +320,7 @@ func (s *TXPoolServer) setPendingTx(tx *tx.Transaction,
 	pt := &serverPendingTx{
 		tx:     tx,
 		sender: sender,
+		peerId: peerId,
 		ch:     txResultCh,
 	}
 
@@ -320,13 +330,13 @@ func (s *TXPoolServer) setPendingTx(tx *tx.Transaction,
 
 // assignTxToWorker assigns a new transaction to a worker by LB
 func (s *TXPoolServer) assignTxToWorker(tx *tx.Transaction,
-	sender tc.SenderType, txResultCh chan *tc.TxResult) bool {
+	sender tc.SenderType, peerId uint64, txResultCh chan *tc.TxResult) bool {
 
 	if tx == nil {
 		return false
 	}
 
-	if ok := s.setPendingTx(tx, sender, txResultCh); !ok {
+	if ok := s.setPendingTx(tx, sender, peerId, txResultCh); !ok {
 		s.increaseStats(tc.DuplicateStats)
 		if sender == tc.HttpSender && txResultCh != nil {
 			replyTxResult(txResultCh, tx.Hash(), errors.ErrDuplicateInput,
@@ -473,6 +483,7 @@ func (s *TXPoolServer) getNextValidatorPID(key types.VerifyType) *actor.PID {
 
 // Stop stops server and workers.
 func (s *TXPoolServer) Stop() {
+	metrics.UnregisterCollector(metricsCollector)
 	for _, v := range s.actors {
 		v.Stop()
 	}
@@ -485,6 +496,58 @@ func (s *TXPoolServer) Stop() {
 	if s.slots != nil {
 		close(s.slots)
 	}
+
+	if journal := s.getJournal(); journal != nil {
+		if err := journal.close(); err != nil {
+			log.Warnf("close tx pool journal error %s", err)
+		}
+	}
+}
+
+// StartJournal replays the transactions in the journal file through the
+// validators, and journals the accepted transactions from then on. The
+// journal is regenerated with the txs in the pool at the first block after
+// every rejournal interval. It should be called after the validators are
+// registered and before the transactions from the network are accepted.
+func (s *TXPoolServer) StartJournal(path string, rejournal time.Duration) error {
+	journal := newTxJournal(path)
+	txs, err := journal.load()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.journal = journal
+	s.rejournal = rejournal
+	s.mu.Unlock()
+
+	for _, t := range txs {
+		s.assignTxToWorker(t, tc.NilSender, 0, nil)
+	}
+	return journal.rotate(txs)
+}
+
+// getJournal returns the tx journal, nil if it is not started
+func (s *TXPoolServer) getJournal() *txJournal {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.journal
+}
+
+// rotateJournal regenerates the journal with the txs in the pool and the
+// pending list if the rejournal interval elapses.
+func (s *TXPoolServer) rotateJournal() {
+	s.mu.RLock()
+	journal, rejournal := s.journal, s.rejournal
+	s.mu.RUnlock()
+	if journal == nil || time.Since(journal.lastRotated()) < rejournal {
+		return
+	}
+
+	txs := append(s.txPool.GetTransactions(), s.getPendingTxs(false)...)
+	if err := journal.rotate(txs); err != nil {
+		log.Warnf("rotate tx pool journal error %s", err)
+	}
 }
 
 // getTransaction returns a transaction with the transaction hash.
@@ -492,18 +555,17 @@ func (s *TXPoolServer) getTransaction(hash common.Uint256) *tx.Transaction {
 	return s.txPool.GetTransaction(hash)
 }
 
+// getPayerFee returns the gas fee of the other pooled transactions of the
+// payer of the transaction
+func (s *TXPoolServer) getPayerFee(t *tx.Transaction) uint64 {
+	return s.txPool.GetPayerFee(t)
+}
+
 // getTxPool returns a tx list for consensus.
 func (s *TXPoolServer) getTxPool(byCount bool, height uint32) []*tc.TXEntry {
 	s.setHeight(height)
 
-	avlTxList, oldTxList := s.txPool.GetTxPool(byCount, height)
-
-	for _, t := range oldTxList {
-		s.delTransaction(t)
-		s.reVerifyStateful(t, tc.NilSender)
-	}
-
-	return avlTxList
+	return s.txPool.GetTxPool(byCount, height)
 }
 
 // getTxCount returns current tx count, including pending and verified
@@ -525,9 +587,19 @@ func (s *TXPoolServer) getPendingTxs(byCount bool) []*tx.Transaction {
 	return ret
 }
 
-// cleanTransactionList cleans the txs in the block from the ledger
+// getTxsByShortIDs returns the txs in the pool matching the short ids
+func (s *TXPoolServer) getTxsByShortIDs(ids []uint64) []*tx.Transaction {
+	return s.txPool.GetTxsByShortIDs(ids)
+}
+
+// cleanTransactionList cleans the txs in the block from the ledger, and
+// the txs expired at the block height
 func (s *TXPoolServer) cleanTransactionList(txs []*tx.Transaction, height uint32) {
 	s.txPool.CleanTransactionList(txs)
+	if removed := s.txPool.RemoveExpiredTxs(height); removed > 0 {
+		log.Debugf("cleanTransactionList: %d expired transactions removed at height %d", removed, height)
+		s.addStats(tc.EvictExpiredStats, uint64(removed))
+	}
 
 	// Check whether to update the gas price and remove txs below the
 	// threshold
@@ -543,20 +615,24 @@ func (s *TXPoolServer) cleanTransactionList(txs []*tx.Transaction, height uint32
 		}
 
 		if oldGasPrice < gasPrice {
-			s.txPool.RemoveTxsBelowGasPrice(gasPrice)
+			removed := s.txPool.RemoveTxsBelowGasPrice(gasPrice)
+			s.addStats(tc.EvictGasPriceStats, uint64(removed))
 		}
 	}
-	// Cleanup tx pool
-	if !s.disablePreExec {
-		remain := s.txPool.Remain()
-		for _, t := range remain {
+	// Re-verify the remaining txs against the new block, they are kept in
+	// the pool until the stateful validator rejects them
+	for _, t := range s.txPool.GetTransactions() {
+		if !s.disablePreExec {
 			if ok, _ := preExecCheck(t); !ok {
 				log.Debugf("cleanTransactionList: preExecCheck tx %x failed", t.Hash())
+				s.delTransaction(t)
 				continue
 			}
-			s.reVerifyStateful(t, tc.NilSender)
 		}
+		s.reVerifyStateful(t, tc.NilSender, 0)
 	}
+
+	s.rotateJournal()
 }
 
 // delTransaction deletes a transaction in the tx pool.
@@ -564,20 +640,62 @@ func (s *TXPoolServer) delTransaction(t *tx.Transaction) {
 	s.txPool.DelTxList(t)
 }
 
-// addTxList adds a valid transaction to the tx pool.
-func (s *TXPoolServer) addTxList(txEntry *tc.TXEntry) bool {
-	ret := s.txPool.AddTxList(txEntry)
-	if !ret {
+// addTxList adds a valid transaction to the tx pool, and returns the
+// error code if it fails to replace the transaction with the same payer,
+// nonce and payload, or the pool has no room for it. A transaction already in
+// the pool is not an error.
+func (s *TXPoolServer) addTxList(txEntry *tc.TXEntry) errors.ErrCode {
+	// A re-verified transaction only refreshes its verified information
+	if s.txPool.UpdateTxAttrs(txEntry) {
+		return errors.ErrNoError
+	}
+
+	s.mu.RLock()
+	if pt, ok := s.allPendingTxs[txEntry.Tx.Hash()]; ok {
+		txEntry.PeerId = pt.peerId
+	}
+	s.mu.RUnlock()
+
+	evicted, errCode := s.txPool.AddTxList(txEntry)
+	switch errCode {
+	case errors.ErrNoError:
+		if evicted != nil {
+			s.increaseStats(tc.EvictLowFeeStats)
+		}
+		if txEntry.Replaces != common.UINT256_EMPTY {
+			s.increaseStats(tc.EvictReplacedStats)
+		}
+		if journal := s.getJournal(); journal != nil {
+			if err := journal.insert(txEntry.Tx); err != nil {
+				log.Warnf("addTxList: journal transaction %x error %s", txEntry.Tx.Hash(), err)
+			}
+		}
+		if events.DefActorPublisher != nil {
+			events.DefActorPublisher.Publish(message.TOPIC_NEW_TRANSACTION, &message.NewTransactionMsg{Tx: txEntry.Tx})
+		}
+	case errors.ErrDuplicateInput:
 		s.increaseStats(tc.DuplicateStats)
+		return errors.ErrNoError
+	case errors.ErrTxPoolFull:
+		s.increaseStats(tc.PoolFullStats)
+	case errors.ErrPayerQuota:
+		s.increaseStats(tc.PayerQuotaStats)
+	case errors.ErrPeerQuota:
+		s.increaseStats(tc.PeerQuotaStats)
 	}
-	return ret
+	return errCode
 }
 
 // increaseStats increases the count with the stats type
 func (s *TXPoolServer) increaseStats(v tc.TxnStatsType) {
+	s.addStats(v, 1)
+}
+
+// addStats adds the number to the count with the stats type
+func (s *TXPoolServer) addStats(v tc.TxnStatsType, n uint64) {
 	s.stats.Lock()
 	defer s.stats.Unlock()
-	s.stats.count[v-1]++
+	s.stats.count[v-1] += n
 }
 
 // getStats returns the transaction statistics
@@ -628,8 +746,8 @@ func (s *TXPoolServer) getTransactionCount() int {
 }
 
 // reVerifyStateful re-verify a transaction's stateful data.
-func (s *TXPoolServer) reVerifyStateful(tx *tx.Transaction, sender tc.SenderType) {
-	if ok := s.setPendingTx(tx, sender, nil); !ok {
+func (s *TXPoolServer) reVerifyStateful(tx *tx.Transaction, sender tc.SenderType, peerId uint64) {
+	if ok := s.setPendingTx(tx, sender, peerId, nil); !ok {
 		s.increaseStats(tc.DuplicateStats)
 		return
 	}
@@ -703,6 +821,17 @@ func (s *TXPoolServer) verifyBlock(req *tc.VerifyBlockReq, sender *actor.PID) {
 			s.sendBlkResult2Consensus()
 			return
 		}
+		// Check whether the tx expires before the block
+		if errCode := validation.VerifyTransactionHeight(t, req.BlockHeight); errCode != errors.ErrNoError {
+			entry := &tc.VerifyTxResult{
+				Height:  s.pendingBlock.height,
+				Tx:      t,
+				ErrCode: errCode,
+			}
+			s.pendingBlock.processedTxs[t.Hash()] = entry
+			s.sendBlkResult2Consensus()
+			return
+		}
 		// Check whether double spent
 		if _, ok := txs[t.Hash()]; ok {
 			entry := &tc.VerifyTxResult{
@@ -720,12 +849,12 @@ func (s *TXPoolServer) verifyBlock(req *tc.VerifyBlockReq, sender *actor.PID) {
 	checkBlkResult := s.txPool.GetUnverifiedTxs(req.Txs, req.Height)
 
 	for _, t := range checkBlkResult.UnverifiedTxs {
-		s.assignTxToWorker(t, tc.NilSender, nil)
+		s.assignTxToWorker(t, tc.NilSender, 0, nil)
 		s.pendingBlock.unProcessedTxs[t.Hash()] = t
 	}
 
 	for _, t := range checkBlkResult.OldTxs {
-		s.reVerifyStateful(t, tc.NilSender)
+		s.reVerifyStateful(t, tc.NilSender, 0)
 		s.pendingBlock.unProcessedTxs[t.Hash()] = t
 	}
 
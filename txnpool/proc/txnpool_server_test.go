@@ -79,18 +79,18 @@ func TestTxn(t *testing.T) {
 	defer s.Stop()
 
 	// Case 1: Send nil txn to the server, server should reject it
-	s.assignTxToWorker(nil, sender, nil)
+	s.assignTxToWorker(nil, sender, 0, nil)
 	/* Case 2: send non-nil txn to the server, server should assign
 	 * it to the worker
 	 */
-	s.assignTxToWorker(txn, sender, nil)
+	s.assignTxToWorker(txn, sender, 0, nil)
 
 	/* Case 3: Duplicate input the tx, server should reject the second
 	 * one
 	 */
 	time.Sleep(10 * time.Second)
-	s.assignTxToWorker(txn, sender, nil)
-	s.assignTxToWorker(txn, sender, nil)
+	s.assignTxToWorker(txn, sender, 0, nil)
+	s.assignTxToWorker(txn, sender, 0, nil)
 
 	/* Case 4: Given the tx is in the tx pool, server can get the tx
 	 * with the invalid hash
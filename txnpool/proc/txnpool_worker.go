@@ -103,6 +103,8 @@ func (worker *txPoolWorker) handleRsp(rsp *types.CheckResponse) {
 		log.Debugf("handleRsp: validator %d transaction %x invalid: %s",
 			rsp.Type, rsp.Hash, rsp.ErrCode.Error())
 		delete(worker.pendingTxList, rsp.Hash)
+		// A transaction in the pool fails the re-verification
+		worker.server.delTransaction(pt.tx)
 		worker.server.removePendingTx(rsp.Hash, rsp.ErrCode)
 		return
 	}
@@ -167,9 +169,9 @@ func (worker *txPoolWorker) putTxPool(pt *pendingTx) bool {
 		Tx:    pt.tx,
 		Attrs: pt.ret,
 	}
-	worker.server.addTxList(txEntry)
-	worker.server.removePendingTx(pt.tx.Hash(), errors.ErrNoError)
-	return true
+	errCode := worker.server.addTxList(txEntry)
+	worker.server.removePendingTx(pt.tx.Hash(), errCode)
+	return errCode == errors.ErrNoError
 }
 
 // verifyTx prepares a check request and sends it to the validators.
@@ -188,8 +190,9 @@ func (worker *txPoolWorker) verifyTx(tx *tx.Transaction) {
 	}
 	// Construct the request and send it to each validator server to verify
 	req := &types.CheckTx{
-		WorkerId: worker.workId,
-		Tx:       tx,
+		WorkerId:   worker.workId,
+		Tx:         tx,
+		PendingFee: worker.server.getPayerFee(tx),
 	}
 
 	worker.sendReq2Validator(req)
@@ -265,8 +268,9 @@ func (worker *txPoolWorker) sendReq2StatefulV(req *types.CheckTx) {
 // stateful validator
 func (worker *txPoolWorker) verifyStateful(tx *tx.Transaction) {
 	req := &types.CheckTx{
-		WorkerId: worker.workId,
-		Tx:       tx,
+		WorkerId:   worker.workId,
+		Tx:         tx,
+		PendingFee: worker.server.getPayerFee(tx),
 	}
 
 	// Construct the pending transaction
@@ -19,6 +19,8 @@
 package increment
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"sync"
 
@@ -31,10 +33,31 @@ import (
 type IncrementValidator struct {
 	mutex      sync.Mutex
 	blocks     []map[common.Uint256]bool
+	conflicts  []map[conflictKey]common.Uint256
 	baseHeight uint32
 	maxBlocks  int
 }
 
+// conflictKey identifies the transactions which are resubmissions of each
+// other, a payload signed again by the payer with the same nonce and a
+// different gas price. Only one of them can be packed
+type conflictKey struct {
+	payer   common.Address
+	nonce   uint32
+	payload common.Uint256
+}
+
+func getConflictKey(tx *types.Transaction) conflictKey {
+	key := conflictKey{payer: tx.Payer, nonce: tx.Nonce}
+	if tx.Payload != nil {
+		buf := new(bytes.Buffer)
+		if err := tx.Payload.Serialize(buf); err == nil {
+			key.payload = sha256.Sum256(buf.Bytes())
+		}
+	}
+	return key
+}
+
 func NewIncrementValidator(maxBlocks int) *IncrementValidator {
 	if maxBlocks <= 0 {
 		maxBlocks = 20
@@ -47,6 +70,7 @@ func NewIncrementValidator(maxBlocks int) *IncrementValidator {
 func (self *IncrementValidator) Clean() {
 	self.mutex.Lock()
 	self.blocks = nil
+	self.conflicts = nil
 	self.baseHeight = 0
 	self.mutex.Unlock()
 }
@@ -79,13 +103,17 @@ func (self *IncrementValidator) AddBlock(block *types.Block) {
 
 	if len(self.blocks) >= self.maxBlocks {
 		self.blocks = self.blocks[1:]
+		self.conflicts = self.conflicts[1:]
 		self.baseHeight += 1
 	}
 	txHashes := make(map[common.Uint256]bool)
+	txKeys := make(map[conflictKey]common.Uint256)
 	for _, tx := range block.Transactions {
 		txHashes[tx.Hash()] = true
+		txKeys[getConflictKey(tx)] = tx.Hash()
 	}
 	self.blocks = append(self.blocks, txHashes)
+	self.conflicts = append(self.conflicts, txKeys)
 }
 
 // Verfiy does increment check start at startHeight
@@ -104,3 +132,23 @@ func (self *IncrementValidator) Verify(tx *types.Transaction, startHeight uint32
 
 	return nil
 }
+
+// VerifyConflict checks whether a resubmission of the transaction, with the
+// same payer, nonce and payload but a different hash, has been packed since
+// startHeight
+func (self *IncrementValidator) VerifyConflict(tx *types.Transaction, startHeight uint32) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if startHeight < self.baseHeight {
+		return fmt.Errorf("can not do increment validation: startHeight %v < self.baseHeight %v", startHeight, self.baseHeight)
+	}
+
+	key := getConflictKey(tx)
+	for i := int(startHeight - self.baseHeight); i < len(self.conflicts); i++ {
+		if hash, ok := self.conflicts[i][key]; ok && hash != tx.Hash() {
+			return fmt.Errorf("nonce %d of payer %s conflicts with tx %x", tx.Nonce, tx.Payer.ToBase58(), hash)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package increment
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTx(payer common.Address, nonce uint32, gasPrice uint64) *types.Transaction {
+	return newTestInvokeTx(payer, nonce, gasPrice, []byte{})
+}
+
+func newTestInvokeTx(payer common.Address, nonce uint32, gasPrice uint64, code []byte) *types.Transaction {
+	mutable := &types.MutableTransaction{
+		TxType:   types.Invoke,
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Payer:    payer,
+		Payload:  &payload.InvokeCode{Code: code},
+	}
+	tx, _ := mutable.IntoImmutable()
+	return tx
+}
+
+func TestVerify(t *testing.T) {
+	validator := NewIncrementValidator(2)
+	packed := newTestTx(common.Address{1}, 1, 500)
+	validator.AddBlock(&types.Block{
+		Header:       &types.Header{Height: 1},
+		Transactions: []*types.Transaction{packed},
+	})
+
+	assert.NotNil(t, validator.Verify(packed, 1))
+	//nonce is not unique for a payer
+	assert.Nil(t, validator.Verify(newTestTx(common.Address{1}, 1, 600), 1))
+	assert.NotNil(t, validator.Verify(packed, 0))
+
+	// the block is out of the window
+	validator.AddBlock(&types.Block{Header: &types.Header{Height: 2}})
+	validator.AddBlock(&types.Block{Header: &types.Header{Height: 3}})
+	start, end := validator.BlockRange()
+	assert.Equal(t, uint32(2), start)
+	assert.Equal(t, uint32(4), end)
+	assert.Nil(t, validator.Verify(packed, start))
+}
+
+func TestVerifyConflict(t *testing.T) {
+	validator := NewIncrementValidator(2)
+	packed := newTestInvokeTx(common.Address{1}, 1, 500, []byte{1})
+	validator.AddBlock(&types.Block{
+		Header:       &types.Header{Height: 1},
+		Transactions: []*types.Transaction{packed},
+	})
+
+	assert.Nil(t, validator.VerifyConflict(packed, 1))
+	// the resubmission with a higher gas price conflicts with the packed one
+	assert.NotNil(t, validator.VerifyConflict(newTestInvokeTx(common.Address{1}, 1, 600, []byte{1}), 1))
+	// the nonce is picked at random, so other payloads do not conflict
+	assert.Nil(t, validator.VerifyConflict(newTestInvokeTx(common.Address{1}, 1, 600, []byte{2}), 1))
+	assert.Nil(t, validator.VerifyConflict(newTestInvokeTx(common.Address{2}, 1, 600, []byte{1}), 1))
+	assert.Nil(t, validator.VerifyConflict(newTestInvokeTx(common.Address{1}, 2, 600, []byte{1}), 1))
+	assert.NotNil(t, validator.VerifyConflict(packed, 0))
+
+	// the block is out of the window
+	validator.AddBlock(&types.Block{Header: &types.Header{Height: 2}})
+	validator.AddBlock(&types.Block{Header: &types.Header{Height: 3}})
+	start, _ := validator.BlockRange()
+	assert.Nil(t, validator.VerifyConflict(newTestInvokeTx(common.Address{1}, 1, 600, []byte{1}), start))
+}
@@ -19,16 +19,29 @@
 package stateful
 
 import (
+	"bytes"
+
 	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/common/serialization"
 	"github.com/ontio/ontology/core/ledger"
+	scom "github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/validation"
 	"github.com/ontio/ontology/errors"
+	"github.com/ontio/ontology/events"
+	"github.com/ontio/ontology/events/message"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
 	"github.com/ontio/ontology/validator/db"
+	"github.com/ontio/ontology/validator/increment"
 	vatypes "github.com/ontio/ontology/validator/types"
 	"reflect"
 )
 
+// INCREMENT_BLOCKS is the number of recent blocks kept to check nonce conflicts
+const INCREMENT_BLOCKS = 20
+
 // Validator is an interface for tx validation actor
 type Validator interface {
 	Register(poolId *actor.PID)
@@ -37,22 +50,124 @@ type Validator interface {
 }
 
 type validator struct {
-	pid       *actor.PID
-	id        string
-	bestBlock db.BestBlock
+	pid           *actor.PID
+	id            string
+	bestBlock     db.BestBlock
+	incrValidator *increment.IncrementValidator
+	sub           *events.ActorSubscriber
 }
 
 // NewValidator returns Validator for stateful check of tx
 func NewValidator(id string) (Validator, error) {
 
-	validator := &validator{id: id}
+	validator := &validator{
+		id:            id,
+		incrValidator: increment.NewIncrementValidator(INCREMENT_BLOCKS),
+	}
 	props := actor.FromProducer(func() actor.Actor {
 		return validator
 	})
 
 	pid, err := actor.SpawnNamed(props, id)
+	if err != nil {
+		return validator, err
+	}
 	validator.pid = pid
-	return validator, err
+	validator.sub = events.NewActorSubscriber(pid)
+	validator.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	return validator, nil
+}
+
+// getOngBalance reads the ONG balance of the address from the native
+// contract storage in the ledger
+func getOngBalance(address common.Address) (uint64, error) {
+	value, err := ledger.DefLedger.GetStorageItem(utils.OngContractAddress, address[:])
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(value) == 0 {
+		return 0, nil
+	}
+	return serialization.ReadUint64(bytes.NewBuffer(value))
+}
+
+// isBalanceEnough checks if the tranactor has enough to cover gas cost
+func isBalanceEnough(address common.Address, gas uint64) bool {
+	balance, err := getOngBalance(address)
+	if err != nil {
+		log.Debugf("failed to get contract balance %s err %v",
+			address.ToHexString(), err)
+		return false
+	}
+	return balance >= gas
+}
+
+// syncBlocks feeds the increment validator with the ledger blocks up to height
+func (self *validator) syncBlocks(height uint32) {
+	_, end := self.incrValidator.BlockRange()
+	if end > height {
+		return
+	}
+	if end == 0 || height-end >= INCREMENT_BLOCKS {
+		self.incrValidator.Clean()
+		end = 0
+		if height >= INCREMENT_BLOCKS {
+			end = height + 1 - INCREMENT_BLOCKS
+		}
+	}
+	for h := end; h <= height; h++ {
+		block, err := ledger.DefLedger.GetBlockByHeight(h)
+		if err != nil || block == nil {
+			log.Warnf("stateful-validator: get block %d error %v", h, err)
+			self.incrValidator.Clean()
+			return
+		}
+		self.incrValidator.AddBlock(block)
+	}
+}
+
+// checkTx does the stateful check of tx against the ledger at height, the
+// balance of the payer must also cover the pending fee of its other pooled
+// transactions
+func (self *validator) checkTx(tx *types.Transaction, pendingFee uint64, height uint32) errors.ErrCode {
+	exist, err := ledger.DefLedger.IsContainTransaction(tx.Hash())
+	if err != nil {
+		log.Warn("query db error:", err)
+		return errors.ErrUnknown
+	} else if exist {
+		return errors.ErrDuplicatedTx
+	}
+
+	self.syncBlocks(height)
+	start, _ := self.incrValidator.BlockRange()
+	if err := self.incrValidator.Verify(tx, start); err != nil {
+		log.Debugf("stateful-validator: tx %x %s", tx.Hash(), err)
+		return errors.ErrDuplicatedTx
+	}
+	if err := self.incrValidator.VerifyConflict(tx, start); err != nil {
+		log.Debugf("stateful-validator: tx %x %s", tx.Hash(), err)
+		return errors.ErrNonceConflict
+	}
+
+	if errCode := validation.VerifyTransactionHeight(tx, height+1); errCode != errors.ErrNoError {
+		return errCode
+	}
+
+	if tx.GasPrice > 0 {
+		gas, overflow := common.SafeMul(tx.GasPrice, tx.GasLimit)
+		if !overflow {
+			gas, overflow = common.SafeAdd(gas, pendingFee)
+		}
+		if overflow || !isBalanceEnough(tx.Payer, gas) {
+			log.Debugf("stateful-validator: transactor %s has no balance enough to cover gas price %d gas limit %d pending fee %d",
+				tx.Payer.ToHexString(), tx.GasPrice, tx.GasLimit, pendingFee)
+			return errors.ErrInsufficientGas
+		}
+	}
+	return errors.ErrNoError
 }
 
 func (self *validator) Receive(context actor.Context) {
@@ -67,17 +182,7 @@ func (self *validator) Receive(context actor.Context) {
 		log.Debugf("stateful-validator: receive tx %x", msg.Tx.Hash())
 		sender := context.Sender()
 		height := ledger.DefLedger.GetCurrentBlockHeight()
-
-		errCode := errors.ErrNoError
-		hash := msg.Tx.Hash()
-
-		exist, err := ledger.DefLedger.IsContainTransaction(hash)
-		if err != nil {
-			log.Warn("query db error:", err)
-			errCode = errors.ErrUnknown
-		} else if exist {
-			errCode = errors.ErrDuplicatedTx
-		}
+		errCode := self.checkTx(msg.Tx, msg.PendingFee, height)
 
 		response := &vatypes.CheckResponse{
 			WorkerId: msg.WorkerId,
@@ -89,15 +194,14 @@ func (self *validator) Receive(context actor.Context) {
 
 		sender.Tell(response)
 	case *vatypes.UnRegisterAck:
+		self.sub.Unsubscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+		self.incrValidator.Clean()
 		context.Self().Stop()
+	case *message.SaveBlockCompleteMsg:
+		self.syncBlocks(msg.Block.Header.Height)
 	case *types.Block:
-
-		//bestBlock, _ := self.db.GetBestBlock()
-		//if bestBlock.Height+1 < msg.Header.Height {
-		//	// add sync block request
-		//} else if bestBlock.Height+1 == msg.Header.Height {
-		//	self.db.PersistBlock(msg)
-		//}
+		// only blocks persisted in the ledger are trusted
+		self.syncBlocks(ledger.DefLedger.GetCurrentBlockHeight())
 
 	default:
 		log.Info("stateful-validator: unknown msg ", msg, "type", reflect.TypeOf(msg))
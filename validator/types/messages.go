@@ -43,8 +43,9 @@ type UnRegisterAck struct {
 }
 
 type CheckTx struct {
-	WorkerId uint8
-	Tx       *types.Transaction
+	WorkerId   uint8
+	Tx         *types.Transaction
+	PendingFee uint64 // the gas fee of the other transactions of the payer in the pool
 }
 
 type CheckResponse struct {